@@ -233,12 +233,31 @@ func (ruleSet) IsAtlantis(*big.Int) bool {
 	return true
 }
 
+func (ruleSet) IsAgharta(*big.Int) bool {
+	// Default true for tests
+	return true
+}
+
+func (ruleSet) IsEIP1344(*big.Int) bool { return true }
+func (ruleSet) IsEIP1884(*big.Int) bool { return true }
+func (ruleSet) IsEIP2028(*big.Int) bool { return true }
+func (ruleSet) IsEIP2200(*big.Int) bool { return true }
+func (ruleSet) IsEIP152(*big.Int) bool  { return true }
+func (ruleSet) IsEIP2929(*big.Int) bool { return false }
+func (ruleSet) IsEIP3529(*big.Int) bool { return false }
+func (ruleSet) IsEIP3541(*big.Int) bool { return false }
+func (ruleSet) IsEIP3651(*big.Int) bool { return false }
+func (ruleSet) IsEIP3855(*big.Int) bool { return true }
+func (ruleSet) IsEIP3860(*big.Int) bool { return false }
+func (ruleSet) GetChainID() *big.Int    { return new(big.Int) }
+
 func (ruleSet) GasTable(*big.Int) *vm.GasTable {
 	return &vm.GasTable{
 		ExtcodeSize:     big.NewInt(700),
 		ExtcodeCopy:     big.NewInt(700),
-		Balance:         big.NewInt(400),
-		SLoad:           big.NewInt(200),
+		ExtcodeHash:     big.NewInt(700),
+		Balance:         big.NewInt(700),
+		SLoad:           big.NewInt(800),
 		Calls:           big.NewInt(700),
 		Suicide:         big.NewInt(5000),
 		ExpByte:         big.NewInt(10),
@@ -300,3 +319,7 @@ func (self *VMEnv) StaticCall(caller vm.ContractRef, addr common.Address, data [
 func (self *VMEnv) Create(caller vm.ContractRef, data []byte, gas, price, value *big.Int) ([]byte, common.Address, error) {
 	return core.Create(self, caller, data, gas, price, value)
 }
+
+func (self *VMEnv) Create2(caller vm.ContractRef, data []byte, gas, price, salt, value *big.Int) ([]byte, common.Address, error) {
+	return core.Create2(self, caller, data, gas, price, salt, value)
+}
@@ -18,18 +18,22 @@ package main
 
 import (
 	"bufio"
+	"encoding/json"
 	"fmt"
 	"log"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"text/tabwriter"
 	"time"
 
 	"github.com/eth-classic/go-ethereum/common"
 	"github.com/eth-classic/go-ethereum/core"
 	"github.com/eth-classic/go-ethereum/core/state"
 	"github.com/eth-classic/go-ethereum/core/types"
+	"github.com/eth-classic/go-ethereum/crypto"
+	"github.com/eth-classic/go-ethereum/logger"
 	"github.com/eth-classic/go-ethereum/logger/glog"
 	"gopkg.in/urfave/cli.v1"
 )
@@ -56,6 +60,19 @@ var (
 		Name:    "upgrade-db",
 		Aliases: []string{"upgradedb"},
 		Usage:   "Upgrade chainblock database",
+		Description: `
+	Applies the pending migrations of the database key layout in place. An
+	interrupted upgrade resumes where it left off when run again.
+
+	If the database was written by an incompatible blockchain version, the
+	chain is then exported and reimported, which may take a long time.
+		`,
+		Flags: []cli.Flag{
+			cli.BoolFlag{
+				Name:  "dry-run",
+				Usage: "Report the pending migrations without applying them",
+			},
+		},
 	}
 	dumpCommand = cli.Command{
 		Action: dump,
@@ -66,6 +83,65 @@ var (
 	Use "$ geth dump 0" to dump the genesis block.
 		`,
 	}
+	exportStateCommand = cli.Command{
+		Action:  exportState,
+		Name:    "export-state",
+		Aliases: []string{"exportstate"},
+		Usage:   "Stream the state of a block to a file [REQUIRED arguments: block hash or number, filepath]",
+		Description: `
+	Export state writes the accounts of the state of the given block to a file, one
+	JSON object per line (or as an RLP stream with --rlp), ordered by the hash of
+	their address. Unlike dump, the state is never held in memory, so the state of
+	any block available in the database can be exported.
+
+	Use --start and --limit to export a range of accounts; the hash of the account
+	to resume from is printed when the limit is reached. Files written with code and
+	storage can be turned into a state or genesis by import-state.
+		`,
+		Flags: []cli.Flag{
+			cli.StringFlag{
+				Name:  "start",
+				Usage: "Address or address hash of the first account to export",
+			},
+			cli.IntFlag{
+				Name:  "limit",
+				Usage: "Maximum number of accounts to export (0 = no limit)",
+			},
+			cli.BoolFlag{
+				Name:  "nocode",
+				Usage: "Leave out contract code",
+			},
+			cli.BoolFlag{
+				Name:  "nostorage",
+				Usage: "Leave out contract storage",
+			},
+			cli.BoolFlag{
+				Name:  "rlp",
+				Usage: "Write an RLP stream instead of JSON lines",
+			},
+		},
+	}
+	importStateCommand = cli.Command{
+		Action:  importState,
+		Name:    "import-state",
+		Aliases: []string{"importstate"},
+		Usage:   "Build a state or genesis from a state export [REQUIRED argument: filepath, optional: genesis.json]",
+		Description: `
+	Import state builds the state trie of a file written by export-state in the chain
+	database and prints its root hash. The export must include code and storage.
+
+	If a second argument is given, a genesis JSON file is written to it instead,
+	allocating the exported accounts with the header settings of the configured
+	genesis block. This requires the export to include the preimages of all
+	addresses and storage keys.
+		`,
+		Flags: []cli.Flag{
+			cli.BoolFlag{
+				Name:  "rlp",
+				Usage: "Read an RLP stream instead of JSON lines",
+			},
+		},
+	}
 	dumpChainConfigCommand = cli.Command{
 		Action:  dumpChainConfig,
 		Name:    "dump-chain-config",
@@ -87,6 +163,23 @@ var (
 	Syncing will require downloading contemporary block information from the index onwards.
 		`,
 	}
+	pruneStateCommand = cli.Command{
+		Action:  pruneState,
+		Name:    "prune-state",
+		Aliases: []string{"prunestate"},
+		Usage:   "Delete stale state from the chain database [optional arguments: retained blocks, bloom size in MB]",
+		Description: `
+	Prune state marks all trie nodes and contract code reachable from the state
+	of the most recent blocks (128 by default, first argument) and deletes every
+	other trie node and contract code from the chain database. The second
+	argument sets the size of the bloom filter used for marking in megabytes
+	(2048 by default); larger filters let less stale data survive.
+
+	The node must not be running while pruning. An interrupted pruning is resumed
+	by running the command again. The state of the head block is verified after
+	pruning completes.
+		`,
+	}
 	statusCommand = cli.Command{
 		Action: status,
 		Name:   "status",
@@ -105,6 +198,21 @@ var (
 		The command will require user confirmation before any action is taken.
 		`,
 	}
+	verifyCommand = cli.Command{
+		Action: verifyChaindata,
+		Name:   "verify",
+		Usage:  "Check the integrity of the canonical chain and head state in the chain database",
+		Description: `
+	Verify walks the canonical chain from the genesis to the head block, checking
+	the header links, total difficulties, and the transaction, uncle and receipt
+	roots of every block. It then checks that every trie node and contract code
+	reachable from the state root of the head block is present and matches its hash.
+
+	Every missing or corrupted entry is reported along with the block it belongs to.
+	The command doesn't modify the database; use recover to roll back to the last
+	healthy block. The node must not be running while verifying.
+		`,
+	}
 	recoverCommand = cli.Command{
 		Action: recoverChaindata,
 		Name:   "recover",
@@ -167,11 +275,40 @@ func exportChain(ctx *cli.Context) error {
 func upgradeDB(ctx *cli.Context) error {
 	glog.Infoln("Upgrading blockchain database")
 
-	chain, chainDb := MakeChain(ctx)
+	chainDb := MakeChainDatabase(ctx)
+	dryRun := ctx.Bool("dry-run")
+
+	version := core.GetDatabaseVersion(chainDb)
+	stats, err := core.MigrateDatabase(chainDb, dryRun)
+	if len(stats) == 0 && err == nil {
+		glog.D(logger.Warn).Infof("Database version %d is up to date", version)
+	} else {
+		w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', tabwriter.AlignRight)
+		fmt.Fprintln(w, "Version\tMigration\tEntries\tSize\t")
+		for _, stat := range stats {
+			name := stat.Name
+			if stat.Resumed {
+				name += " (resumed)"
+			}
+			fmt.Fprintf(w, "%d\t%s\t%d\t%v\t\n", stat.Version, name, stat.Count, stat.Size)
+		}
+		w.Flush()
+	}
+	if err != nil {
+		chainDb.Close()
+		log.Fatal("Database migration failed: ", err)
+	}
 	bcVersion := core.GetBlockChainVersion(chainDb)
-	if bcVersion == 0 {
-		bcVersion = core.BlockChainVersion
+	chainDb.Close()
+
+	if !dryRun && len(stats) > 0 {
+		glog.D(logger.Warn).Infof("Upgraded database from version %d to %d", version, core.LatestDatabaseVersion())
 	}
+	// Reimport the chain only if written by an incompatible blockchain version
+	if dryRun || bcVersion == 0 || bcVersion == core.BlockChainVersion {
+		return nil
+	}
+	chain, chainDb := MakeChain(ctx)
 
 	// Export the current chain.
 	filename := fmt.Sprintf("blockchain_%d_%s.chain", bcVersion, time.Now().Format("20060102_150405"))
@@ -185,7 +322,8 @@ func upgradeDB(ctx *cli.Context) error {
 	// Import the chain file.
 	chain, chainDb = MakeChain(ctx)
 	core.WriteBlockChainVersion(chainDb, core.BlockChainVersion)
-	err := ImportChain(chain, exportFile)
+	core.WriteDatabaseVersion(chainDb, core.LatestDatabaseVersion())
+	err = ImportChain(chain, exportFile)
 	chainDb.Close()
 	if err != nil {
 		log.Fatalf("Import error %v (a backup is made in %s, use the import command to import it)", err, exportFile)
@@ -291,6 +429,116 @@ func dump(ctx *cli.Context) error {
 	return nil
 }
 
+// exportState streams the state of a block to a file.
+// $ geth export-state [--start address] [--limit n] [--nocode] [--nostorage] [--rlp] [blockHash|blockNum] filepath
+func exportState(ctx *cli.Context) error {
+	if ctx.NArg() != 2 {
+		glog.Fatalf("invalid arguments: use `export-state [blockHash|blockNum] filepath`")
+	}
+	conf := &state.ExportConfig{
+		SkipCode:    ctx.Bool("nocode"),
+		SkipStorage: ctx.Bool("nostorage"),
+		RLP:         ctx.Bool("rlp"),
+	}
+	if start := ctx.String("start"); start != "" {
+		switch {
+		case common.IsHexAddress(start):
+			conf.Start = crypto.Keccak256Hash(common.HexToAddress(start).Bytes())
+		case len(common.FromHex(start)) == common.HashLength:
+			conf.Start = common.HexToHash(start)
+		default:
+			glog.Fatalf("invalid --start %q: want an address or a 32 byte address hash", start)
+		}
+	}
+	if limit := ctx.Int("limit"); limit < 0 {
+		glog.Fatalf("invalid --limit %d", limit)
+	} else {
+		conf.Limit = uint64(limit)
+	}
+
+	chain, chainDb := MakeChain(ctx)
+	defer chainDb.Close()
+
+	var block *types.Block
+	if arg := ctx.Args().Get(0); hashish(arg) {
+		block = chain.GetBlock(common.HexToHash(arg))
+	} else {
+		num, _ := strconv.Atoi(arg)
+		block = chain.GetBlockByNumber(uint64(num))
+	}
+	if block == nil {
+		glog.Fatalf("block %s not found", ctx.Args().Get(0))
+	}
+	statedb, err := state.New(block.Root(), state.NewDatabase(chainDb))
+	if err != nil {
+		glog.Fatalf("state of block #%d not available: %v", block.NumberU64(), err)
+	}
+	f, err := os.Create(ctx.Args().Get(1))
+	if err != nil {
+		glog.Fatalf("could not create export file: %v", err)
+	}
+	defer f.Close()
+
+	glog.D(logger.Warn).Infof("Exporting state of block #%d [%s]", block.NumberU64(), block.Hash().Hex())
+	start := time.Now()
+	next, err := statedb.Export(f, conf)
+	if err != nil {
+		glog.Fatalf("state export failed: %v", err)
+	}
+	if next != nil {
+		glog.D(logger.Warn).Infof("Limit reached, resume the export with --start %s", next.Hex())
+	}
+	glog.D(logger.Warn).Infof("Success. Exported state in %v", time.Since(start))
+	return nil
+}
+
+// importState builds the state trie or a genesis file from a state export.
+// $ geth import-state [--rlp] filepath [genesis.json]
+func importState(ctx *cli.Context) error {
+	if ctx.NArg() < 1 || ctx.NArg() > 2 {
+		glog.Fatalf("invalid arguments: use `import-state filepath [genesis.json]`")
+	}
+	f, err := os.Open(ctx.Args().Get(0))
+	if err != nil {
+		glog.Fatalf("could not open export file: %v", err)
+	}
+	defer f.Close()
+	r := state.NewExportReader(f, ctx.Bool("rlp"))
+
+	chainDb := MakeChainDatabase(ctx)
+	defer chainDb.Close()
+
+	if path := ctx.Args().Get(1); path != "" {
+		dump, err := core.MakeGenesisDumpFrom(chainDb, r)
+		if err != nil {
+			glog.Fatalf("could not make genesis: %v", err)
+		}
+		if dump == nil {
+			glog.Fatalf("genesis block not found, start geth to initialise the chain database first")
+		}
+		out, err := os.Create(path)
+		if err != nil {
+			glog.Fatalf("could not create genesis file: %v", err)
+		}
+		defer out.Close()
+
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "    ")
+		if err := enc.Encode(dump); err != nil {
+			glog.Fatalf("could not write genesis file: %v", err)
+		}
+		glog.D(logger.Warn).Infof("Success. Wrote genesis of %d accounts to %s", len(dump.Alloc), path)
+		return nil
+	}
+	start := time.Now()
+	root, err := core.ImportState(chainDb, r)
+	if err != nil {
+		glog.Fatalf("state import failed: %v", err)
+	}
+	glog.D(logger.Warn).Infof("Success. Imported state %s in %v", root.Hex(), time.Since(start))
+	return nil
+}
+
 // hashish returns true for strings that look like hashes.
 func hashish(x string) bool {
 	_, err := strconv.Atoi(x)
@@ -33,8 +33,10 @@ import (
 
 	"github.com/eth-classic/ethash"
 	"github.com/eth-classic/go-ethereum/common"
+	"github.com/eth-classic/go-ethereum/consensus/clique"
 	"github.com/eth-classic/go-ethereum/core"
 	"github.com/eth-classic/go-ethereum/core/state"
+	"github.com/eth-classic/go-ethereum/core/state/pruner"
 	"github.com/eth-classic/go-ethereum/core/types"
 	"github.com/eth-classic/go-ethereum/eth"
 	"github.com/eth-classic/go-ethereum/event"
@@ -42,6 +44,7 @@ import (
 	"github.com/eth-classic/go-ethereum/logger/glog"
 	"github.com/eth-classic/go-ethereum/node"
 	"github.com/eth-classic/go-ethereum/pow"
+	"github.com/eth-classic/go-ethereum/pow/etchash"
 	"github.com/eth-classic/go-ethereum/rlp"
 	"gopkg.in/urfave/cli.v1"
 	"math"
@@ -393,6 +396,16 @@ func formatEthConfigPretty(ethConfig *eth.Config) (s []string) {
 	ss = append(ss, printable{0, "Pow test", ethConfig.PowTest})
 	// PowShared?
 	ss = append(ss, printable{0, "Pow shared", ethConfig.PowShared})
+	// Clique?
+	ss = append(ss, printable{0, "Clique", ethConfig.Clique})
+	// MESS disabled?
+	ss = append(ss, printable{0, "MESS disabled", ethConfig.DisableMESS})
+	// State pruning disabled?
+	ss = append(ss, printable{0, "Archive (no state pruning)", ethConfig.NoPruning})
+	// State snapshot enabled?
+	ss = append(ss, printable{0, "State snapshot", ethConfig.Snapshot})
+	// Blocks kept out of the ancient store
+	ss = append(ss, printable{0, "Ancient threshold", ethConfig.AncientThreshold})
 	// SolcPath
 	ss = append(ss, printable{0, "Solc path", ethConfig.SolcPath})
 
@@ -631,6 +644,69 @@ func rollback(ctx *cli.Context) error {
 	return nil
 }
 
+// pruneState deletes the trie nodes and contract code not reachable from the state
+// of the most recent canonical blocks.
+// $ geth prune-state [retained blocks] [bloom size MB]
+func pruneState(ctx *cli.Context) error {
+	retain, bloomSize := uint64(128), uint64(2048)
+	if arg := ctx.Args().Get(0); arg != "" {
+		n, err := strconv.ParseUint(arg, 10, 64)
+		if err != nil || n == 0 {
+			glog.Fatalf("invalid argument: use `prune-state 128`, where '128' is the number of recent blocks to retain the state of")
+		}
+		retain = n
+	}
+	if arg := ctx.Args().Get(1); arg != "" {
+		n, err := strconv.ParseUint(arg, 10, 64)
+		if err != nil || n == 0 {
+			glog.Fatalf("invalid argument: use `prune-state 128 2048`, where '2048' is the size of the state bloom in megabytes")
+		}
+		bloomSize = n
+	}
+	chainDb := MakeChainDatabase(ctx)
+	defer chainDb.Close()
+
+	genesis := core.GetBlock(chainDb, core.GetCanonicalHash(chainDb, 0))
+	if genesis == nil {
+		glog.Fatalf("genesis block not found, nothing to prune")
+	}
+	head := core.GetBlock(chainDb, core.GetHeadBlockHash(chainDb))
+	if head == nil {
+		glog.Fatalf("head block not found, start geth to repair the chain database before pruning")
+	}
+	// Retain the genesis state and the state of the most recent blocks, oldest first
+	roots := []common.Hash{genesis.Root()}
+	first := uint64(1)
+	if number := head.NumberU64(); number >= retain {
+		first = number - retain + 1
+	}
+	for number := first; number <= head.NumberU64(); number++ {
+		header := core.GetHeader(chainDb, core.GetCanonicalHash(chainDb, number))
+		if header == nil {
+			glog.Fatalf("canonical header #%d not found, start geth to repair the chain database before pruning", number)
+		}
+		roots = append(roots, header.Root)
+	}
+	if ok, _ := chainDb.Has(head.Root().Bytes()); !ok {
+		glog.Fatalf("state of head block #%d missing, start geth to repair the chain database before pruning", head.NumberU64())
+	}
+	p := pruner.NewPruner(chainDb, MustMakeChainDataDir(ctx), bloomSize*1024*1024)
+	if p.Resumable() {
+		glog.D(logger.Warn).Infoln("Resuming interrupted state pruning...")
+	}
+	glog.D(logger.Warn).Infof("Pruning state: head=#%d retained=%d", head.NumberU64(), len(roots))
+
+	start := time.Now()
+	if err := p.Prune(roots); err != nil {
+		glog.Fatalf("state pruning failed: %v", err)
+	}
+	if err := core.WriteTriePruned(chainDb); err != nil {
+		glog.Fatalf("failed to mark database pruned: %v", err)
+	}
+	glog.D(logger.Warn).Infof("Success. Pruned stale state in %v", time.Since(start))
+	return nil
+}
+
 // dumpChainConfig exports chain configuration based on context to JSON file.
 // It is not compatible with --chain flag; it is intended to move from default configs -> file,
 // and not the other way around.
@@ -759,7 +835,10 @@ func makedag(ctx *cli.Context) error {
 			}
 			glog.V(logger.Info).Infoln("making DAG, this could take awhile...")
 			glog.D(logger.Warn).Infoln("making DAG, this could take awhile...")
-			ethash.MakeDAG(blockNum, dir)
+			sconf := mustMakeSufficientChainConfig(ctx)
+			if err := etchash.MakeDAG(blockNum, dir, sconf.ChainConfig.ECIP1099Block()); err != nil {
+				glog.Fatal("Could not make DAG: ", err)
+			}
 		}
 	default:
 		wrongArgs()
@@ -887,8 +966,10 @@ func recoverChaindata(ctx *cli.Context) error {
 	defer bcdb.Close()
 
 	pow := pow.PoW(core.FakePow{})
-	if !ctx.GlobalBool(aliasableName(FakePoWFlag.Name, ctx)) {
-		pow = ethash.New()
+	if sconf.Consensus == "clique" {
+		pow = clique.New(sconf.ChainConfig.Clique, bcdb)
+	} else if !ctx.GlobalBool(aliasableName(FakePoWFlag.Name, ctx)) {
+		pow = etchash.New(ethash.New(), sconf.ChainConfig.ECIP1099Block())
 	} else {
 		glog.V(logger.Warn).Info("Consensus: fake")
 	}
@@ -940,6 +1021,28 @@ func recoverChaindata(ctx *cli.Context) error {
 	return nil
 }
 
+// verifyChaindata checks the integrity of the canonical chain and head state,
+// reporting every missing or corrupted database entry.
+// $ geth verify
+func verifyChaindata(ctx *cli.Context) error {
+	chainDb := MakeChainDatabase(ctx)
+	defer chainDb.Close()
+
+	glog.D(logger.Warn).Infoln("Verifying chain and head state...")
+	start := time.Now()
+	issues, err := core.VerifyChain(chainDb, func(issue *core.VerifyError) {
+		glog.D(logger.Error).Errorln(issue)
+	})
+	if err != nil {
+		glog.Fatalf("chain verification failed: %v", err)
+	}
+	if issues > 0 {
+		glog.Fatalf("Found %d issues in the chain database, run recover to roll back to the last healthy block", issues)
+	}
+	glog.D(logger.Warn).Infof("Success. No issues found in %v", time.Since(start))
+	return nil
+}
+
 // https://gist.github.com/r0l1/3dcbb0c8f6cfe9c66ab8008f55f8f28b
 // askForConfirmation asks the user for confirmation. A user must type in "yes" or "no" and
 // then press enter. It has fuzzy matching, so "y", "Y", "yes", "YES", and "Yes" all count as
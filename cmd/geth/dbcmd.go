@@ -0,0 +1,112 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of go-ethereum.
+//
+// go-ethereum is free software: you can redistribute it and/or modify
+// it under the terms of the GNU General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// go-ethereum is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU General Public License for more details.
+//
+// You should have received a copy of the GNU General Public License
+// along with go-ethereum. If not, see <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/eth-classic/go-ethereum/common"
+	"github.com/eth-classic/go-ethereum/core"
+	"github.com/eth-classic/go-ethereum/logger"
+	"github.com/eth-classic/go-ethereum/logger/glog"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	dbCommand = cli.Command{
+		Name:  "db",
+		Usage: "Low level chain database operations",
+		Description: `
+
+	Inspect and maintain the chain database. The node must not be running.
+
+	'$ geth db <command> --help' shows help for any subcommand.
+		`,
+		Subcommands: []cli.Command{
+			{
+				Action: dbInspect,
+				Name:   "inspect",
+				Usage:  "Show the number and size of the chain database entries of each kind",
+				Description: `
+geth db inspect
+
+	Iterates the whole chain database, grouping the entries by kind (headers,
+	bodies, receipts, total difficulties, canonical hashes, trie nodes,
+	preimages, address transaction index, MIPMap blooms, ...) and prints their
+	count and size. The tables of the ancient store are listed as well.
+				`,
+			},
+			{
+				Action: dbCompact,
+				Name:   "compact",
+				Usage:  "Compact the chain database",
+				Description: `
+geth db compact
+
+	Compacts the whole LevelDB key space of the chain database, reclaiming the
+	space of deleted and overwritten entries. This may take a long time on a
+	large database. A running node can be compacted with debug.chaindbCompact().
+				`,
+			},
+		},
+	}
+)
+
+// dbInspect prints the number and size of the chain database entries by kind.
+// $ geth db inspect
+func dbInspect(ctx *cli.Context) error {
+	db := MakeChainDatabase(ctx)
+	defer db.Close()
+
+	glog.D(logger.Warn).Infoln("Inspecting chain database...")
+	stats, err := core.InspectDatabase(db)
+	if err != nil {
+		glog.Fatalf("database inspection failed: %v", err)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "Kind\tCount\tSize\t")
+
+	var (
+		count uint64
+		size  common.StorageSize
+	)
+	for _, stat := range stats {
+		fmt.Fprintf(w, "%s\t%d\t%v\t\n", stat.Name, stat.Count, stat.Size)
+		count += stat.Count
+		size += stat.Size
+	}
+	fmt.Fprintf(w, "Total\t%d\t%v\t\n", count, size)
+	return w.Flush()
+}
+
+// dbCompact compacts the chain database.
+// $ geth db compact
+func dbCompact(ctx *cli.Context) error {
+	db := MakeChainDatabase(ctx)
+	defer db.Close()
+
+	glog.D(logger.Warn).Infoln("Compacting chain database...")
+	start := time.Now()
+	if err := core.CompactDatabase(db); err != nil {
+		glog.Fatalf("database compaction failed: %v", err)
+	}
+	glog.D(logger.Warn).Infof("Compacted chain database in %v", time.Since(start))
+	return nil
+}
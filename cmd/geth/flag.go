@@ -27,12 +27,14 @@ import (
 	"runtime"
 	"strconv"
 	"strings"
+	"time"
 
 	"errors"
 
 	"github.com/eth-classic/ethash"
 	"github.com/eth-classic/go-ethereum/accounts"
 	"github.com/eth-classic/go-ethereum/common"
+	"github.com/eth-classic/go-ethereum/consensus/clique"
 	"github.com/eth-classic/go-ethereum/core"
 	"github.com/eth-classic/go-ethereum/core/state"
 	"github.com/eth-classic/go-ethereum/core/types"
@@ -48,6 +50,7 @@ import (
 	"github.com/eth-classic/go-ethereum/p2p/discover"
 	"github.com/eth-classic/go-ethereum/p2p/nat"
 	"github.com/eth-classic/go-ethereum/pow"
+	"github.com/eth-classic/go-ethereum/pow/etchash"
 	"github.com/eth-classic/go-ethereum/whisper"
 	"gopkg.in/urfave/cli.v1"
 )
@@ -522,7 +525,7 @@ func MakeSystemNode(version string, ctx *cli.Context) *node.Node {
 // shouldAttemptDirMigration decides based on flags if
 // should attempt to migration from old (<=3.3) directory schema to new.
 func shouldAttemptDirMigration(ctx *cli.Context) bool {
-	if !ctx.GlobalIsSet(aliasableName(DataDirFlag.Name, ctx)) {
+	if !ctx.GlobalIsSet(aliasableName(DataDirFlag.Name, ctx)) && !ctx.GlobalBool(aliasableName(ReadOnlyFlag.Name, ctx)) {
 		if chainVal := mustMakeChainIdentity(ctx); core.ChainIdentitiesMain[chainVal] || core.ChainIdentitiesMorden[chainVal] {
 			return true
 		}
@@ -534,6 +537,7 @@ func mustMakeStackConf(ctx *cli.Context, name string, config *core.SufficientCha
 	// Configure the node's service container
 	stackConf = &node.Config{
 		DataDir:         MustMakeChainDataDir(ctx),
+		ReadOnly:        ctx.GlobalBool(aliasableName(ReadOnlyFlag.Name, ctx)),
 		PrivateKey:      MakeNodeKey(ctx),
 		Name:            name,
 		NoDiscovery:     ctx.GlobalBool(aliasableName(NoDiscoverFlag.Name, ctx)),
@@ -611,6 +615,23 @@ func mustMakeEthConf(ctx *cli.Context, sconf *core.SufficientChainConfig) *eth.C
 		GpobaseCorrectionFactor: ctx.GlobalInt(aliasableName(GpobaseCorrectionFactorFlag.Name, ctx)),
 		SolcPath:                ctx.GlobalString(aliasableName(SolcPathFlag.Name, ctx)),
 		AutoDAG:                 ctx.GlobalBool(aliasableName(AutoDAGFlag.Name, ctx)) || ctx.GlobalBool(aliasableName(MiningEnabledFlag.Name, ctx)),
+		DisableMESS:             ctx.GlobalBool(aliasableName(DisableMESSFlag.Name, ctx)),
+		TrieCache:               ctx.GlobalInt(aliasableName(CacheFlag.Name, ctx)) / 4,
+		TrieTimeout:             5 * time.Minute,
+		Snapshot:                ctx.GlobalBool(aliasableName(SnapshotFlag.Name, ctx)),
+	}
+	if threshold := ctx.GlobalInt(aliasableName(AncientThresholdFlag.Name, ctx)); threshold < 0 {
+		log.Fatalf("invalid %s flag value %d, want a non-negative number of blocks", aliasableName(AncientThresholdFlag.Name, ctx), threshold)
+	} else {
+		ethConf.AncientThreshold = uint64(threshold)
+	}
+
+	switch gcmode := ctx.GlobalString(aliasableName(GCModeFlag.Name, ctx)); gcmode {
+	case "full":
+	case "archive":
+		ethConf.NoPruning = true
+	default:
+		log.Fatalf("invalid %s flag value %q, want \"full\" or \"archive\"", aliasableName(GCModeFlag.Name, ctx), gcmode)
 	}
 
 	if ctx.GlobalBool(aliasableName(FastSyncFlag.Name, ctx)) {
@@ -633,6 +654,8 @@ func mustMakeEthConf(ctx *cli.Context, sconf *core.SufficientChainConfig) *eth.C
 	switch sconf.Consensus {
 	case "ethash-test":
 		ethConf.PowTest = true
+	case "clique":
+		ethConf.Clique = true
 	}
 
 	// Override any default configs in dev mode
@@ -803,7 +826,8 @@ func MustMakeChainConfigFromDefaults(ctx *cli.Context) *core.ChainConfig {
 	return c
 }
 
-// MakeChainDatabase open an LevelDB using the flags passed to the client and will hard crash if it fails.
+// MakeChainDatabase open an LevelDB, along with its ancient store, using the flags passed to the client and will hard crash if it fails.
+// With --readonly, the database is opened read-only and every write fails.
 func MakeChainDatabase(ctx *cli.Context) ethdb.Database {
 	var (
 		chaindir = MustMakeChainDataDir(ctx)
@@ -811,7 +835,12 @@ func MakeChainDatabase(ctx *cli.Context) ethdb.Database {
 		handles  = MakeDatabaseHandles()
 	)
 
-	chainDb, err := ethdb.NewLDBDatabase(filepath.Join(chaindir, "chaindata"), cache, handles)
+	dir := filepath.Join(chaindir, "chaindata")
+	open := ethdb.NewLDBDatabaseWithFreezer
+	if ctx.GlobalBool(aliasableName(ReadOnlyFlag.Name, ctx)) {
+		open = ethdb.NewReadOnlyLDBDatabaseWithFreezer
+	}
+	chainDb, err := open(dir, filepath.Join(dir, "ancient"), cache, handles)
 	if err != nil {
 		glog.Fatal("Could not open database: ", err)
 	}
@@ -825,7 +854,11 @@ func MakeIndexDatabase(ctx *cli.Context) ethdb.Database {
 		handles  = MakeDatabaseHandles()
 	)
 
-	indexesDb, err := ethdb.NewLDBDatabase(filepath.Join(chaindir, "indexes"), cache, handles)
+	open := ethdb.NewLDBDatabase
+	if ctx.GlobalBool(aliasableName(ReadOnlyFlag.Name, ctx)) {
+		open = ethdb.NewReadOnlyLDBDatabase
+	}
+	indexesDb, err := open(filepath.Join(chaindir, "indexes"), cache, handles)
 	if err != nil {
 		glog.Fatal("Could not open database: ", err)
 	}
@@ -839,14 +872,20 @@ func MakeChain(ctx *cli.Context) (chain *core.BlockChain, chainDb ethdb.Database
 	chainDb = MakeChainDatabase(ctx)
 
 	pow := pow.PoW(core.FakePow{})
-	if !ctx.GlobalBool(aliasableName(FakePoWFlag.Name, ctx)) {
-		pow = ethash.New()
+	if sconf.Consensus == "clique" {
+		pow = clique.New(sconf.ChainConfig.Clique, chainDb)
+	} else if !ctx.GlobalBool(aliasableName(FakePoWFlag.Name, ctx)) {
+		pow = etchash.New(ethash.New(), sconf.ChainConfig.ECIP1099Block())
 	} else {
 		glog.V(logger.Info).Infoln("Consensus: fake")
 		glog.D(logger.Warn).Warnln("Consensus: fake")
 	}
 
-	chain, err = core.NewBlockChain(chainDb, sconf.ChainConfig, pow, new(event.TypeMux))
+	var cacheConfig *core.CacheConfig
+	if ctx.GlobalBool(aliasableName(ReadOnlyFlag.Name, ctx)) {
+		cacheConfig = &core.CacheConfig{Disabled: true, ReadOnly: true}
+	}
+	chain, err = core.NewBlockChainWithCacheConfig(chainDb, cacheConfig, sconf.ChainConfig, pow, new(event.TypeMux))
 	if err != nil {
 		glog.Fatal("Could not start chainmanager: ", err)
 	}
@@ -98,6 +98,28 @@ var (
 		Name:  "slow",
 		Usage: "Force full sync, even if fast sync is in progress",
 	}
+	GCModeFlag = cli.StringFlag{
+		Name:  "gcmode",
+		Usage: `Blockchain garbage collection mode ("full", "archive"), archive mode doesn't restore the state dropped in full mode`,
+		Value: "full",
+	}
+	SnapshotFlag = cli.BoolFlag{
+		Name:  "snapshot",
+		Usage: "Maintain a flat state snapshot for faster state access (generated in the background)",
+	}
+	AncientThresholdFlag = cli.IntFlag{
+		Name:  "ancient-threshold",
+		Usage: "Number of recent blocks kept in the chain database, older ones being moved to the ancient store (0 = never move blocks)",
+		Value: 90000,
+	}
+	ReadOnlyFlag = cli.BoolFlag{
+		Name:  "readonly",
+		Usage: "Open the data directory read-only, without syncing or networking, to analyse a copy of another node's data directory",
+	}
+	DisableMESSFlag = cli.BoolFlag{
+		Name:  "mess-disable",
+		Usage: "Disable MESS (ECBP-1100) artificial finality, which protects against deep reorgs once synced",
+	}
 	LightKDFFlag = cli.BoolFlag{
 		Name:  "light-kdf,lightkdf",
 		Usage: "Reduce key-derivation RAM & CPU usage at some expense of KDF strength",
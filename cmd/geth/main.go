@@ -119,7 +119,12 @@ func makeCLIApp() (app *cli.App) {
 		dumpChainConfigCommand,
 		upgradedbCommand,
 		dumpCommand,
+		exportStateCommand,
+		importStateCommand,
 		rollbackCommand,
+		pruneStateCommand,
+		verifyCommand,
+		dbCommand,
 		recoverCommand,
 		resetCommand,
 		monitorCommand,
@@ -154,6 +159,11 @@ func makeCLIApp() (app *cli.App) {
 		BlockchainVersionFlag,
 		FastSyncFlag,
 		SlowSyncFlag,
+		DisableMESSFlag,
+		GCModeFlag,
+		SnapshotFlag,
+		AncientThresholdFlag,
+		ReadOnlyFlag,
 		AddrTxIndexFlag,
 		AddrTxIndexAutoBuildFlag,
 		CacheFlag,
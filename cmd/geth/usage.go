@@ -66,7 +66,12 @@ var AppHelpFlagAndCommandGroups = []flagGroup{
 			exportCommand,
 			dumpChainConfigCommand,
 			dumpCommand,
+			exportStateCommand,
+			importStateCommand,
 			rollbackCommand,
+			pruneStateCommand,
+			verifyCommand,
+			dbCommand,
 			recoverCommand,
 			resetCommand,
 		},
@@ -78,6 +83,11 @@ var AppHelpFlagAndCommandGroups = []flagGroup{
 			NodeNameFlag,
 			FastSyncFlag,
 			SlowSyncFlag,
+			DisableMESSFlag,
+			GCModeFlag,
+			SnapshotFlag,
+			AncientThresholdFlag,
+			ReadOnlyFlag,
 			CacheFlag,
 			LightKDFFlag,
 			SputnikVMFlag,
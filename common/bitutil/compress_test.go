@@ -0,0 +1,102 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package bitutil
+
+import (
+	"bytes"
+	"encoding/hex"
+	"math/rand"
+	"testing"
+)
+
+// Tests that data bitset encoding and decoding works and is bijective.
+func TestEncodingCycle(t *testing.T) {
+	tests := []string{
+		// Tests generated by go-fuzz to maximize code coverage
+		"",
+		"00",
+		"01",
+		"000001",
+		"0000000000000000000000000000000000000000000000000000000000000000",
+		"ff000000000000000000000000000000000000000000000000000000000000ff",
+		"0000000000040000000000000000000000000000000000000000000000000000ff",
+		"000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000000001",
+	}
+	for i, tt := range tests {
+		data, _ := hex.DecodeString(tt)
+
+		proc, err := bitsetDecodeBytes(bitsetEncodeBytes(data), len(data))
+		if err != nil {
+			t.Errorf("test %d: failed to decompress compressed data: %v", i, err)
+			continue
+		}
+		if !bytes.Equal(data, proc) {
+			t.Errorf("test %d: compress/decompress mismatch: have %x, want %x", i, proc, data)
+		}
+	}
+}
+
+// Tests that a known input compresses to the documented output.
+func TestEncoding(t *testing.T) {
+	data, _ := hex.DecodeString("00000400000000000000000000000000ff")
+	want, _ := hex.DecodeString("a0208004ff")
+
+	if have := CompressBytes(data); !bytes.Equal(have, want) {
+		t.Errorf("compressed data mismatch: have %x, want %x", have, want)
+	}
+}
+
+// Tests that data bitset decoding rejects malformed input.
+func TestDecodingErrors(t *testing.T) {
+	tests := []struct {
+		input string
+		size  int
+		fail  error
+	}{
+		{"0102", 1, errExceededTarget},        // longer than the target
+		{"80", 16, errMissingData},            // references a byte not given
+		{"8000", 16, errZeroContent},          // references a zero byte
+		{"80010203", 16, errUnreferencedData}, // trailing bytes
+	}
+	for i, tt := range tests {
+		data, _ := hex.DecodeString(tt.input)
+		if _, err := DecompressBytes(data, tt.size); err != tt.fail {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, tt.fail)
+		}
+	}
+}
+
+// Tests that data compression and decompression works for random sparse data.
+func TestCompression(t *testing.T) {
+	for i := 0; i < 1000; i++ {
+		data := make([]byte, 1+rand.Intn(512))
+		for j := rand.Intn(len(data)); j > 0; j-- {
+			data[rand.Intn(len(data))] = byte(rand.Intn(256))
+		}
+		comp := CompressBytes(data)
+		if len(comp) > len(data) {
+			t.Fatalf("test %d: compressed data longer than input: %d > %d", i, len(comp), len(data))
+		}
+		proc, err := DecompressBytes(comp, len(data))
+		if err != nil {
+			t.Fatalf("test %d: failed to decompress data: %v", i, err)
+		}
+		if !bytes.Equal(data, proc) {
+			t.Fatalf("test %d: compress/decompress mismatch: have %x, want %x", i, proc, data)
+		}
+	}
+}
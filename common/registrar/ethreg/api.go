@@ -93,7 +93,7 @@ func (api *PrivateRegistarAPI) SaveInfo(info *compiler.ContractInfo, filename st
 // Register registers a new content hash in the registry.
 func (api *PrivateRegistarAPI) Register(sender common.Address, addr common.Address, contentHashHex string) (bool, error) {
 	block := api.be.bc.CurrentBlock()
-	state, err := state.New(block.Root(), state.NewDatabase(api.be.chainDb))
+	state, err := api.be.bc.StateAt(block.Root())
 	if err != nil {
 		return false, err
 	}
@@ -146,6 +146,9 @@ func (m callmsg) Value() *big.Int {
 func (m callmsg) Data() []byte {
 	return m.data
 }
+func (m callmsg) AccessList() types.AccessList {
+	return nil
+}
 
 // Call forms a transaction from the given arguments and tries to execute it on
 // a private VM with a copy of the state. Any changes are therefore only temporary
@@ -173,7 +176,7 @@ func (be *registryAPIBackend) Call(fromStr, toStr, valueStr, gasStr, gasPriceStr
 	}
 
 	block := be.bc.CurrentBlock()
-	statedb, err := state.New(block.Root(), state.NewDatabase(be.chainDb))
+	statedb, err := be.bc.StateAt(block.Root())
 	if err != nil {
 		return "", "", err
 	}
@@ -217,7 +220,7 @@ func (be *registryAPIBackend) Call(fromStr, toStr, valueStr, gasStr, gasPriceStr
 // StorageAt returns the data stores in the state for the given address and location.
 func (be *registryAPIBackend) StorageAt(addr string, storageAddr string) string {
 	block := be.bc.CurrentBlock()
-	state, err := state.New(block.Root(), state.NewDatabase(be.chainDb))
+	state, err := be.bc.StateAt(block.Root())
 	if err != nil {
 		return ""
 	}
@@ -192,6 +192,17 @@ func (a Address) MarshalJSON() ([]byte, error) {
 	return json.Marshal(a.Hex())
 }
 
+// MarshalText returns the hex representation of a, allowing addresses to be
+// used as JSON object keys.
+func (a Address) MarshalText() ([]byte, error) {
+	return []byte(a.Hex()), nil
+}
+
+// UnmarshalText parses an address in hex syntax.
+func (a *Address) UnmarshalText(input []byte) error {
+	return a.UnmarshalJSON(input)
+}
+
 // Parse address from raw json data
 func (a *Address) UnmarshalJSON(data []byte) error {
 	if len(data) > 2 && data[0] == '"' && data[len(data)-1] == '"' {
@@ -17,7 +17,9 @@
 package common
 
 import (
+	"encoding/json"
 	"math/big"
+	"reflect"
 	"testing"
 )
 
@@ -85,3 +87,21 @@ func TestAddressUnmarshalJSON(t *testing.T) {
 		}
 	}
 }
+
+func TestAddressJSONMapKey(t *testing.T) {
+	in := map[Address]bool{HexToAddress("0x0000000000000000000000000000000000000010"): true}
+	blob, err := json.Marshal(in)
+	if err != nil {
+		t.Fatalf("failed to encode address keyed map: %v", err)
+	}
+	if want := `{"0x0000000000000000000000000000000000000010":true}`; string(blob) != want {
+		t.Errorf("encoding mismatch: have %s, want %s", blob, want)
+	}
+	var out map[Address]bool
+	if err := json.Unmarshal(blob, &out); err != nil {
+		t.Fatalf("failed to decode address keyed map: %v", err)
+	}
+	if !reflect.DeepEqual(in, out) {
+		t.Errorf("decoding mismatch: have %v, want %v", out, in)
+	}
+}
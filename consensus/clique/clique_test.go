@@ -0,0 +1,197 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package clique
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+	"testing"
+
+	"github.com/eth-classic/go-ethereum/common"
+	"github.com/eth-classic/go-ethereum/core"
+	"github.com/eth-classic/go-ethereum/core/types"
+	"github.com/eth-classic/go-ethereum/crypto"
+	"github.com/eth-classic/go-ethereum/ethdb"
+	"github.com/eth-classic/go-ethereum/event"
+)
+
+// newTestChain creates an in-memory block chain sealed by clique with the given
+// accounts of the pool as initial signers.
+func newTestChain(t *testing.T, accounts *testerAccountPool, signers ...string) (*core.BlockChain, *Clique) {
+	addrs := addresses(accounts, signers)
+	sort.Sort(signersAscending(addrs))
+
+	extra := make([]byte, extraVanity+common.AddressLength*len(signers)+extraSeal)
+	for i, signer := range addrs {
+		copy(extra[extraVanity+i*common.AddressLength:], signer[:])
+	}
+	var genesis core.GenesisDump
+	dump := fmt.Sprintf(`{"extraData": "0x%x", "gasLimit": "0x47e7c4", "difficulty": "0x01"}`, extra)
+	if err := json.Unmarshal([]byte(dump), &genesis); err != nil {
+		t.Fatalf("failed to parse genesis: %v", err)
+	}
+	db, _ := ethdb.NewMemDatabase()
+	if _, err := core.WriteGenesisBlock(db, &genesis); err != nil {
+		t.Fatalf("failed to write genesis: %v", err)
+	}
+	engine := New(&core.CliqueConfig{Epoch: 30000}, db)
+
+	chain, err := core.NewBlockChain(db, core.MakeChainConfig(), engine, new(event.TypeMux))
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	return chain, engine
+}
+
+// addresses resolves the addresses of the named tester accounts.
+func addresses(accounts *testerAccountPool, names []string) []common.Address {
+	addrs := make([]common.Address, len(names))
+	for i, name := range names {
+		addrs[i] = accounts.address(name)
+	}
+	return addrs
+}
+
+// makeBlock assembles an empty block on top of the current head of the chain,
+// prepared by the engine and signed by the given tester account.
+func makeBlock(t *testing.T, chain *core.BlockChain, engine *Clique, accounts *testerAccountPool, signer string) *types.Block {
+	parent := chain.CurrentBlock()
+	header := &types.Header{
+		ParentHash: parent.Hash(),
+		Number:     new(big.Int).Add(parent.Number(), common.Big1),
+		GasLimit:   core.CalcGasLimit(parent),
+		GasUsed:    new(big.Int),
+		Root:       parent.Root(),
+	}
+	engine.Authorize(accounts.address(signer), nil)
+	if err := engine.Prepare(chain, header); err != nil {
+		t.Fatalf("failed to prepare header: %v", err)
+	}
+	block := types.NewBlock(header, nil, nil, nil)
+
+	header = block.Header()
+	accounts.sign(header, signer)
+	return block.WithSeal(header)
+}
+
+// Tests that blocks sealed by authorized signers are imported into the chain,
+// and blocks sealed by anyone else are rejected.
+func TestCliqueInsertChain(t *testing.T) {
+	accounts := newTesterAccountPool()
+	chain, engine := newTestChain(t, accounts, "A")
+	defer chain.Stop()
+
+	for i := 0; i < 3; i++ {
+		block := makeBlock(t, chain, engine, accounts, "A")
+		if res := chain.InsertChain(types.Blocks{block}); res.Error != nil {
+			t.Fatalf("block %d: failed to insert: %v", i+1, res.Error)
+		}
+		if signer, err := engine.Author(block.Header()); err != nil || signer != accounts.address("A") {
+			t.Errorf("block %d: author mismatch: have %x (%v), want %x", i+1, signer, err, accounts.address("A"))
+		}
+	}
+	if head := chain.CurrentBlock().NumberU64(); head != 3 {
+		t.Fatalf("chain head mismatch: have %d, want 3", head)
+	}
+	block := makeBlock(t, chain, engine, accounts, "B")
+	if res := chain.InsertChain(types.Blocks{block}); res.Error == nil {
+		t.Fatalf("block signed by unauthorized account imported")
+	}
+	if head := chain.CurrentBlock().NumberU64(); head != 3 {
+		t.Errorf("chain head mismatch after rejected block: have %d, want 3", head)
+	}
+}
+
+// Tests that signers can be voted in and out through the proposals of the
+// signing node.
+func TestCliqueProposals(t *testing.T) {
+	accounts := newTesterAccountPool()
+	chain, engine := newTestChain(t, accounts, "A")
+	defer chain.Stop()
+
+	api := &API{chain: chain, clique: engine}
+
+	api.Propose(accounts.address("B"), true)
+	if res := chain.InsertChain(types.Blocks{makeBlock(t, chain, engine, accounts, "A")}); res.Error != nil {
+		t.Fatalf("failed to insert voting block: %v", res.Error)
+	}
+	signers, err := api.GetSigners(nil)
+	if err != nil {
+		t.Fatalf("failed to retrieve signers: %v", err)
+	}
+	if len(signers) != 2 {
+		t.Fatalf("signer count mismatch: have %d, want 2", len(signers))
+	}
+	api.Discard(accounts.address("B"))
+	if proposals := api.Proposals(); len(proposals) != 0 {
+		t.Errorf("proposals not discarded: %v", proposals)
+	}
+	// A signed the last block and may not sign again before B took its turn
+	if res := chain.InsertChain(types.Blocks{makeBlock(t, chain, engine, accounts, "A")}); res.Error == nil {
+		t.Errorf("recent signer allowed to sign again")
+	}
+	block := makeBlock(t, chain, engine, accounts, "B")
+	if res := chain.InsertChain(types.Blocks{block}); res.Error != nil {
+		t.Fatalf("failed to insert block of voted in signer: %v", res.Error)
+	}
+	if _, err := api.GetSignersAtHash(block.Hash()); err != nil {
+		t.Errorf("failed to retrieve signers at hash: %v", err)
+	}
+}
+
+// Tests that zero-period chains refuse to seal empty blocks.
+func TestCliqueSealEmptyBlock(t *testing.T) {
+	accounts := newTesterAccountPool()
+	chain, engine := newTestChain(t, accounts, "A")
+	defer chain.Stop()
+
+	block := makeBlock(t, chain, engine, accounts, "A")
+	engine.Authorize(accounts.address("A"), func(common.Address, []byte) ([]byte, error) {
+		return crypto.Sign(sigHash(block.Header()).Bytes(), accounts.accounts["A"])
+	})
+	if _, err := engine.Seal(chain, block, make(chan struct{})); err != errWaitTransactions {
+		t.Errorf("seal error mismatch: have %v, want %v", err, errWaitTransactions)
+	}
+}
+
+// Tests that Verify checks the header fields and the seal of blocks against the
+// voting snapshot of their stored parent.
+func TestCliqueVerify(t *testing.T) {
+	accounts := newTesterAccountPool()
+	chain, engine := newTestChain(t, accounts, "A")
+	defer chain.Stop()
+
+	if block := makeBlock(t, chain, engine, accounts, "A"); !engine.Verify(block) {
+		t.Errorf("block sealed by authorized signer rejected")
+	}
+	if block := makeBlock(t, chain, engine, accounts, "B"); engine.Verify(block) {
+		t.Errorf("block sealed by unauthorized signer accepted")
+	}
+	block := makeBlock(t, chain, engine, accounts, "A")
+	header := block.Header()
+	header.Nonce = types.BlockNonce{0x01}
+	if engine.Verify(block.WithSeal(header)) {
+		t.Errorf("block with invalid vote nonce accepted")
+	}
+	header = block.Header()
+	header.Extra = header.Extra[:extraVanity]
+	if engine.Verify(block.WithSeal(header)) {
+		t.Errorf("block without signature accepted")
+	}
+}
@@ -312,13 +312,6 @@ func GetAddrTxs(db ethdb.Database, address common.Address, blockStartN uint64, b
 		paginationStart = 0
 	}
 
-	// Have to cast to LevelDB to use iterator. Yuck.
-	ldb, ok := db.(*ethdb.LDBDatabase)
-	if !ok {
-		err = errWithReason(errors.New("internal interface error; please file a bug report"), "could not cast eth db to level db")
-		return nil, nil
-	}
-
 	// This will be the returnable.
 	var hashes []string
 
@@ -332,9 +325,8 @@ func GetAddrTxs(db ethdb.Database, address common.Address, blockStartN uint64, b
 		wantKindOf = kindof[0]
 	}
 
-	// Create address prefix for iteration.
-	prefix := ethdb.NewBytesPrefix(formatAddrTxIterator(address))
-	it := ldb.NewIteratorRange(prefix)
+	// Iterate the indexes of the address.
+	it := db.NewIteratorWithPrefix(formatAddrTxIterator(address))
 
 	var atxis sortableAtxis
 
@@ -404,11 +396,6 @@ func RmAddrTx(db ethdb.Database, tx *types.Transaction) error {
 		return nil
 	}
 
-	ldb, ok := db.(*ethdb.LDBDatabase)
-	if !ok {
-		return nil
-	}
-
 	txH := tx.Hash()
 	from, err := tx.From()
 	if err != nil {
@@ -418,13 +405,12 @@ func RmAddrTx(db ethdb.Database, tx *types.Transaction) error {
 	removals := [][]byte{}
 
 	// TODO: not DRY, could be refactored
-	pre := ethdb.NewBytesPrefix(formatAddrTxIterator(from))
-	it := ldb.NewIteratorRange(pre)
+	it := db.NewIteratorWithPrefix(formatAddrTxIterator(from))
 	for it.Next() {
 		key := it.Key()
 		_, _, _, _, txh := resolveAddrTxBytes(key)
 		if bytes.Compare(txH.Bytes(), txh) == 0 {
-			removals = append(removals, key)
+			removals = append(removals, common.CopyBytes(key))
 			break // because there can be only one
 		}
 	}
@@ -436,13 +422,12 @@ func RmAddrTx(db ethdb.Database, tx *types.Transaction) error {
 	to := tx.To()
 	if to != nil {
 		toRef := *to
-		pre := ethdb.NewBytesPrefix(formatAddrTxIterator(toRef))
-		it := ldb.NewIteratorRange(pre)
+		it := db.NewIteratorWithPrefix(formatAddrTxIterator(toRef))
 		for it.Next() {
 			key := it.Key()
 			_, _, _, _, txh := resolveAddrTxBytes(key)
 			if bytes.Compare(txH.Bytes(), txh) == 0 {
-				removals = append(removals, key)
+				removals = append(removals, common.CopyBytes(key))
 				break // because there can be only one
 			}
 		}
@@ -75,10 +75,12 @@ func NewBlockValidator(config *ChainConfig, blockchain *BlockChain, pow pow.PoW)
 // ValidateBlock also validates and makes sure that any previous state (or present)
 // state that might or might not be present is checked to make sure that fast
 // sync has done it's job proper. This prevents the block validator form accepting
-// false positives where a header is present but the state is not.
+// false positives where a header is present but the state is not. A block which
+// is otherwise valid, but whose parent's state is missing, is rejected with
+// ErrPrunedAncestor.
 func (v *BlockValidator) ValidateBlock(block *types.Block) error {
 	if v.bc.HasBlock(block.Hash()) {
-		if _, err := state.New(block.Root(), state.NewDatabase(v.bc.chainDb)); err == nil {
+		if _, err := state.New(block.Root(), v.bc.stateDatabase); err == nil {
 			return &KnownBlockError{block.Number(), block.Hash()}
 		}
 	}
@@ -86,13 +88,13 @@ func (v *BlockValidator) ValidateBlock(block *types.Block) error {
 	if parent == nil {
 		return ParentError(block.ParentHash())
 	}
-	if _, err := state.New(parent.Root(), state.NewDatabase(v.bc.chainDb)); err != nil {
-		return ParentError(block.ParentHash())
-	}
-
 	header := block.Header()
 	// validate the block header
-	if err := ValidateHeader(v.config, v.Pow, header, parent.Header(), false, false); err != nil {
+	if engine, ok := v.Pow.(Engine); ok {
+		if err := validateEngineHeader(engine, v.bc, header, []*types.Header{parent.Header()}); err != nil {
+			return err
+		}
+	} else if err := ValidateHeader(v.config, v.Pow, header, parent.Header(), false, false); err != nil {
 		return err
 	}
 	// verify the uncles are correctly rewarded
@@ -112,7 +114,10 @@ func (v *BlockValidator) ValidateBlock(block *types.Block) error {
 	if txSha != header.TxHash {
 		return fmt.Errorf("invalid transaction root hash. received=%x calculated=%x", header.TxHash, txSha)
 	}
-
+	// The block can only be processed on top of its parent's state
+	if _, err := state.New(parent.Root(), v.bc.stateDatabase); err != nil {
+		return ErrPrunedAncestor
+	}
 	return nil
 }
 
@@ -149,6 +154,9 @@ func (v *BlockValidator) ValidateState(block, parent *types.Block, statedb *stat
 // error if any of the included uncle headers were invalid. It returns an error
 // if the validation failed.
 func (v *BlockValidator) VerifyUncles(block, parent *types.Block) error {
+	if engine, ok := v.Pow.(Engine); ok {
+		return engine.VerifyUncles(v.bc, block)
+	}
 	// validate that there at most 2 uncles included in this block
 	if len(block.Uncles()) > 2 {
 		return validateError(fmt.Sprintf("Block can only contain maximum 2 uncles (contained %d)", len(block.Uncles())))
@@ -207,9 +215,49 @@ func (v *BlockValidator) ValidateHeader(header, parent *types.Header, checkPow b
 	if v.bc.HasHeader(header.Hash()) {
 		return nil
 	}
+	if engine, ok := v.Pow.(Engine); ok {
+		return validateEngineHeader(engine, v.bc, header, []*types.Header{parent})
+	}
 	return ValidateHeader(v.config, v.Pow, header, parent, checkPow, false)
 }
 
+// validateEngineHeader validates a header of a chain sealed by a consensus engine.
+// The block number and gas limit are checked like those of proof-of-work headers,
+// everything else is left to the engine. Parents holds the ancestors of the header
+// which may not be stored in the chain yet, oldest first.
+func validateEngineHeader(engine Engine, chain ChainReader, header *types.Header, parents []*types.Header) error {
+	var parent *types.Header
+	if len(parents) > 0 {
+		parent = parents[len(parents)-1]
+	} else {
+		parent = chain.GetHeader(header.ParentHash)
+	}
+	if parent == nil || parent.Hash() != header.ParentHash {
+		return ParentError(header.ParentHash)
+	}
+	if err := validateGasLimit(header, parent); err != nil {
+		return err
+	}
+	if new(big.Int).Sub(header.Number, parent.Number).Cmp(big.NewInt(1)) != 0 {
+		return BlockNumberErr
+	}
+	return engine.VerifyHeader(chain, header, parents)
+}
+
+// validateGasLimit checks that the gas limit of the header stays within the bounds
+// allowed by its parent.
+func validateGasLimit(header, parent *types.Header) error {
+	a := new(big.Int).Set(parent.GasLimit)
+	a = a.Sub(a, header.GasLimit)
+	a.Abs(a)
+	b := new(big.Int).Set(parent.GasLimit)
+	b = b.Div(b, GasLimitBoundDivisor)
+	if !(a.Cmp(b) < 0) || (header.GasLimit.Cmp(MinGasLimit) == -1) {
+		return fmt.Errorf("GasLimit check failed for header %v (%v > %v)", header.GasLimit, a, b)
+	}
+	return nil
+}
+
 // Validates a header. Returns an error if the header is invalid.
 //
 // See YP section 4.3.4. "Block Header Validity"
@@ -236,13 +284,8 @@ func ValidateHeader(config *ChainConfig, pow pow.PoW, header *types.Header, pare
 		return fmt.Errorf("Difficulty check failed for header %v != %v at %v", header.Difficulty, expd, header.Number)
 	}
 
-	a := new(big.Int).Set(parent.GasLimit)
-	a = a.Sub(a, header.GasLimit)
-	a.Abs(a)
-	b := new(big.Int).Set(parent.GasLimit)
-	b = b.Div(b, GasLimitBoundDivisor)
-	if !(a.Cmp(b) < 0) || (header.GasLimit.Cmp(MinGasLimit) == -1) {
-		return fmt.Errorf("GasLimit check failed for header %v (%v > %v)", header.GasLimit, a, b)
+	if err := validateGasLimit(header, parent); err != nil {
+		return err
 	}
 
 	num := new(big.Int).Set(parent.Number)
@@ -36,6 +36,7 @@ import (
 
 	"github.com/eth-classic/go-ethereum/common"
 	"github.com/eth-classic/go-ethereum/core/state"
+	"github.com/eth-classic/go-ethereum/core/state/snapshot"
 	"github.com/eth-classic/go-ethereum/core/types"
 	"github.com/eth-classic/go-ethereum/core/vm"
 	"github.com/eth-classic/go-ethereum/crypto"
@@ -47,6 +48,7 @@ import (
 	"github.com/eth-classic/go-ethereum/rlp"
 	"github.com/eth-classic/go-ethereum/trie"
 	lru "github.com/hashicorp/golang-lru"
+	"gopkg.in/karalabe/cookiejar.v2/collections/prque"
 )
 
 var (
@@ -62,11 +64,27 @@ const (
 	blockCacheLimit     = 256
 	maxFutureBlocks     = 256
 	maxTimeFutureBlocks = 30
+	triesInMemory       = 128
+	snapshotLayers      = triesInMemory - 1 // Diff layers kept above the snapshot disk layer, whose trie must stay available
 	// must be bumped when consensus algorithm is changed, this forces the upgradedb
 	// command to be run (forces the blocks to be imported again using the new algorithm)
 	BlockChainVersion = 3
 )
 
+// CacheConfig contains the configuration values for the trie node caching and
+// garbage collection of a blockchain, and for moving its old blocks into the
+// ancient store.
+type CacheConfig struct {
+	Disabled      bool               // Whether to disable trie write caching (archive node)
+	TrieNodeLimit common.StorageSize // Memory limit at which to flush cached trie nodes to disk
+	TrieTimeLimit time.Duration      // Time limit after which to flush the current in-memory trie to disk
+	Snapshot      bool               // Whether to maintain a flat state snapshot for fast state reads
+
+	AncientThreshold uint64 // Number of recent blocks kept out of the ancient store, 0 to not move any there
+
+	ReadOnly bool // Whether the database is read-only, the chain serving its content as is
+}
+
 // BlockChain represents the canonical chain given a database with a genesis
 // block. The Blockchain manages chain imports, reverts, chain reorganisations.
 //
@@ -82,7 +100,8 @@ const (
 // included in the canonical one where as GetBlockByNumber always represents the
 // canonical chain.
 type BlockChain struct {
-	config *ChainConfig // chain & network configuration
+	config      *ChainConfig // chain & network configuration
+	cacheConfig *CacheConfig // Cache configuration for pruning
 
 	hc           *HeaderChain
 	chainDb      ethdb.Database
@@ -96,6 +115,15 @@ type BlockChain struct {
 	currentBlock     *types.Block // Current head of the block chain
 	currentFastBlock *types.Block // Current head of the fast-sync chain (may be above the block chain!)
 
+	stateDatabase state.Database  // State trie and code access through the trie node cache
+	triedb        *trie.NodeCache // In-memory cache of recently committed state trie nodes
+	triegc        *prque.Prque    // Priority queue mapping block numbers to tries to gc
+	gcproc        time.Duration   // Accumulates canonical block processing for trie dumping
+	lastWrite     uint64          // Number of the block whose state was last flushed to disk
+	statePruned   bool            // Whether the state of historical blocks may be missing
+	snaps         *snapshot.Tree  // Flat state snapshot for fast account and storage access, nil if disabled
+	freezeMu      sync.Mutex      // Lock serialising moves to the ancient store with chain rewinds
+
 	stateCache   *state.StateDB // State database to reuse between imports (contains state cache)
 	bodyCache    *lru.Cache     // Cache for the most recent block bodies
 	bodyRLPCache *lru.Cache     // Cache for the most recent block bodies in RLP encoded format
@@ -105,8 +133,10 @@ type BlockChain struct {
 	quit    chan struct{} // blockchain quit channel
 	running int32         // running must be called atomically
 	// procInterrupt must be atomically called
-	procInterrupt int32          // interrupt signaler for block processing
-	wg            sync.WaitGroup // chain processing wait group for shutting down
+	procInterrupt int32 // interrupt signaler for block processing
+	// artificialFinality toggles MESS (ECBP-1100) reorg protection, must be atomically called
+	artificialFinality int32
+	wg                 sync.WaitGroup // chain processing wait group for shutting down
 
 	pow       pow.PoW
 	processor Processor // block processor interface
@@ -145,22 +175,40 @@ func (bc *BlockChain) GetBlockByHash(h common.Hash) *types.Block {
 // available in the database. It initialises the default Ethereum Validator and
 // Processor.
 func NewBlockChain(chainDb ethdb.Database, config *ChainConfig, pow pow.PoW, mux *event.TypeMux) (*BlockChain, error) {
+	return NewBlockChainWithCacheConfig(chainDb, nil, config, pow, mux)
+}
+
+// NewBlockChainWithCacheConfig returns a fully initialised block chain like
+// NewBlockChain, with state trie caching and garbage collection configured by
+// cacheConfig. A nil cacheConfig retains the state of every block (archive mode).
+func NewBlockChainWithCacheConfig(chainDb ethdb.Database, cacheConfig *CacheConfig, config *ChainConfig, pow pow.PoW, mux *event.TypeMux) (*BlockChain, error) {
+	if cacheConfig == nil {
+		cacheConfig = &CacheConfig{Disabled: true}
+	}
 	bodyCache, _ := lru.New(bodyCacheLimit)
 	bodyRLPCache, _ := lru.New(bodyCacheLimit)
 	blockCache, _ := lru.New(blockCacheLimit)
 	futureBlocks, _ := lru.New(maxFutureBlocks)
 
+	triedb := state.NewNodeCache(chainDb)
+
 	bc := &BlockChain{
-		config:       config,
-		chainDb:      chainDb,
-		eventMux:     mux,
-		quit:         make(chan struct{}),
-		bodyCache:    bodyCache,
-		bodyRLPCache: bodyRLPCache,
-		blockCache:   blockCache,
-		futureBlocks: futureBlocks,
-		pow:          pow,
-	}
+		config:        config,
+		cacheConfig:   cacheConfig,
+		chainDb:       chainDb,
+		eventMux:      mux,
+		quit:          make(chan struct{}),
+		stateDatabase: state.NewDatabaseWithCache(triedb),
+		triedb:        triedb,
+		triegc:        prque.New(),
+		bodyCache:     bodyCache,
+		bodyRLPCache:  bodyRLPCache,
+		blockCache:    blockCache,
+		futureBlocks:  futureBlocks,
+		pow:           pow,
+	}
+	bc.statePruned = GetTriePruned(chainDb)
+
 	bc.SetValidator(NewBlockValidator(config, bc, pow))
 	bc.SetProcessor(NewStateProcessor(config, bc))
 
@@ -170,18 +218,34 @@ func NewBlockChain(chainDb ethdb.Database, config *ChainConfig, pow pow.PoW, mux
 	if err != nil {
 		return nil, err
 	}
+	if engine, ok := pow.(Engine); ok {
+		bc.hc.SetEngine(engine)
+	}
 
 	bc.genesisBlock = bc.GetBlockByNumber(0)
 	if bc.genesisBlock == nil {
 		return nil, ErrNoGenesis
 	}
 
-	if err := bc.LoadLastState(false); err != nil {
+	// A read-only chain can't be repaired, it's loaded as is
+	if err := bc.LoadLastState(cacheConfig.ReadOnly); err != nil {
 		return nil, err
 	}
+	// Load the state snapshot of the head block, regenerating it if missing
+	if cacheConfig.Snapshot && !cacheConfig.ReadOnly {
+		if bc.snaps, err = snapshot.New(chainDb, triedb, bc.CurrentBlock().Root()); err != nil {
+			glog.V(logger.Warn).Warnf("State snapshot disabled: %v", err)
+		} else if bc.stateCache, err = state.NewWithSnapshot(bc.CurrentBlock().Root(), bc.stateDatabase, bc.snaps); err != nil {
+			return nil, err
+		}
+	}
 	// Check the current state of the block hashes and make sure that we do not have any of the bad blocks in our chain
 	for i := range config.BadHashes {
 		if header := bc.GetHeader(config.BadHashes[i].Hash); header != nil && header.Number.Cmp(config.BadHashes[i].Block) == 0 {
+			if cacheConfig.ReadOnly {
+				glog.V(logger.Error).Errorf("Found bad hash at block #%d [%s], not rewinding read-only chain", header.Number, header.Hash().Hex())
+				continue
+			}
 			glog.V(logger.Error).Infof("Found bad hash, rewinding chain to block #%d [%s]", header.Number, header.ParentHash.Hex())
 			bc.SetHead(header.Number.Uint64() - 1)
 			glog.V(logger.Error).Infoln("Chain rewind was successful, resuming normal operation")
@@ -189,6 +253,12 @@ func NewBlockChain(chainDb ethdb.Database, config *ChainConfig, pow pow.PoW, mux
 	}
 	// Take ownership of this particular state
 	go bc.update()
+
+	// Move the immutable part of the chain into the ancient store in the background
+	if store := ancientStore(chainDb); store != nil && cacheConfig.AncientThreshold > 0 && !cacheConfig.ReadOnly {
+		bc.wg.Add(1)
+		go bc.freeze(store)
+	}
 	return bc, nil
 }
 
@@ -198,17 +268,24 @@ func NewBlockChainDryrun(chainDb ethdb.Database, config *ChainConfig, pow pow.Po
 	blockCache, _ := lru.New(blockCacheLimit)
 	futureBlocks, _ := lru.New(maxFutureBlocks)
 
+	triedb := state.NewNodeCache(chainDb)
+
 	bc := &BlockChain{
-		config:       config,
-		chainDb:      chainDb,
-		eventMux:     mux,
-		quit:         make(chan struct{}),
-		bodyCache:    bodyCache,
-		bodyRLPCache: bodyRLPCache,
-		blockCache:   blockCache,
-		futureBlocks: futureBlocks,
-		pow:          pow,
-	}
+		config:        config,
+		cacheConfig:   &CacheConfig{Disabled: true},
+		chainDb:       chainDb,
+		eventMux:      mux,
+		quit:          make(chan struct{}),
+		stateDatabase: state.NewDatabaseWithCache(triedb),
+		triedb:        triedb,
+		triegc:        prque.New(),
+		bodyCache:     bodyCache,
+		bodyRLPCache:  bodyRLPCache,
+		blockCache:    blockCache,
+		futureBlocks:  futureBlocks,
+		pow:           pow,
+	}
+	bc.statePruned = GetTriePruned(chainDb)
 	bc.SetValidator(NewBlockValidator(config, bc, pow))
 	bc.SetProcessor(NewStateProcessor(config, bc))
 
@@ -218,6 +295,9 @@ func NewBlockChainDryrun(chainDb ethdb.Database, config *ChainConfig, pow pow.Po
 	if err != nil {
 		return nil, err
 	}
+	if engine, ok := pow.(Engine); ok {
+		bc.hc.SetEngine(engine)
+	}
 
 	bc.genesisBlock = bc.GetBlockByNumber(0)
 	if bc.genesisBlock == nil {
@@ -403,8 +483,8 @@ func (bc *BlockChain) blockIsInvalid(b *types.Block) error {
 			return ParentError(b.ParentHash())
 		}
 		// Parent does not have state; this is only a corruption if it's the not the point where fast
-		// sync was disabled and full states started to be synced.
-		if !bc.HasBlockAndState(parent.Hash()) {
+		// sync was disabled and full states started to be synced, or if state tries are not pruned.
+		if !bc.statePruned && !bc.HasBlockAndState(parent.Hash()) {
 			grandparent := bc.GetBlock(parent.ParentHash())
 			// If grandparent DOES have state, then so should parent.
 			// If grandparent doesn't have state, then assume that it was the first full block synced.
@@ -427,8 +507,8 @@ func (bc *BlockChain) blockIsInvalid(b *types.Block) error {
 		if cb.Header() == nil {
 			return fmt.Errorf("preceding nil header block=#%d, while checking block=#%d health", pi, b.NumberU64())
 		}
-		// Genesis will have state.
-		if pi > 1 {
+		// Genesis will have state. Pruned state tries leave arbitrary gaps.
+		if pi > 1 && !bc.statePruned {
 			if bc.HasBlockAndState(cb.Hash()) {
 				return fmt.Errorf("checking block=%d without state, found nonabsent state for block #%d", b.NumberU64(), pi)
 			}
@@ -621,6 +701,20 @@ func (bc *BlockChain) LoadLastState(dryrun bool) error {
 		}
 	}
 
+	// Make sure the state of the head block is available, since it may have been
+	// held in memory only when the node went down
+	if _, err := state.New(currentBlock.Root(), bc.stateDatabase); err != nil {
+		glog.V(logger.Warn).Errorf("Head state missing, repairing chain: number=%d hash=%x", currentBlock.Number(), currentBlock.Hash())
+		if err := bc.repair(&currentBlock); err != nil {
+			return err
+		}
+		if !dryrun {
+			if err := WriteHeadBlockHash(bc.chainDb, currentBlock.Hash()); err != nil {
+				return err
+			}
+		}
+	}
+
 	// Everything seems to be fine, set as the head block
 	bc.currentBlock = currentBlock
 
@@ -642,7 +736,11 @@ func (bc *BlockChain) LoadLastState(dryrun bool) error {
 		return fmt.Errorf("invalid TD=%v for currentHeader=#%d", td, currentHeader.Number)
 	}
 
-	bc.hc.SetCurrentHeader(currentHeader)
+	if bc.cacheConfig.ReadOnly {
+		bc.hc.currentHeader, bc.hc.currentHeaderHash = currentHeader, currentHeader.Hash()
+	} else {
+		bc.hc.SetCurrentHeader(currentHeader)
+	}
 
 	// Restore the last known head fast block from placeholder
 	bc.currentFastBlock = bc.currentBlock
@@ -725,7 +823,7 @@ func (bc *BlockChain) LoadLastState(dryrun bool) error {
 	}
 
 	// Initialize a statedb cache to ensure singleton account bloom filter generation
-	statedb, err := state.New(bc.currentBlock.Root(), state.NewDatabase(bc.chainDb))
+	statedb, err := state.NewWithSnapshot(bc.currentBlock.Root(), bc.stateDatabase, bc.snaps)
 	if err != nil {
 		return err
 	}
@@ -755,6 +853,28 @@ func (bc *BlockChain) LoadLastState(dryrun bool) error {
 	return nil
 }
 
+// repair tries to repair the current blockchain by rolling back the current block
+// until one with associated state is found. This is needed to fix incomplete db
+// writes caused either by crashes/power outages, or simply non-committed tries.
+//
+// This method only rolls back the current block. The current header and current
+// fast block are left intact.
+func (bc *BlockChain) repair(head **types.Block) error {
+	for {
+		// Abort if we've rewound to a head block that does have associated state
+		if _, err := state.New((*head).Root(), bc.stateDatabase); err == nil {
+			glog.V(logger.Warn).Infof("Rewound blockchain to past state: number=%d hash=%x", (*head).Number(), (*head).Hash())
+			return nil
+		}
+		// Otherwise rewind one block and recheck state availability there
+		parent := bc.GetBlock((*head).ParentHash())
+		if parent == nil {
+			return fmt.Errorf("missing block #%d [%x…] while repairing state", (*head).NumberU64()-1, (*head).ParentHash().Bytes()[:4])
+		}
+		*head = parent
+	}
+}
+
 // PurgeAbove works like SetHead, but instead of rm'ing head <-> bc.currentBlock,
 // it removes all stored blockchain data n -> *anyexistingblockdata*
 // TODO: possibly replace with kv database iterator
@@ -773,6 +893,9 @@ func (bc *BlockChain) PurgeAbove(n uint64) {
 // though, the head may be further rewound if block bodies are missing (non-archive
 // nodes after a fast sync).
 func (bc *BlockChain) SetHead(head uint64) error {
+	if bc.cacheConfig.ReadOnly {
+		return ethdb.ErrReadOnly
+	}
 	glog.V(logger.Warn).Infof("Setting blockchain head, target: %v", head)
 
 	bc.mu.Lock()
@@ -783,6 +906,16 @@ func (bc *BlockChain) SetHead(head uint64) error {
 	bc.hc.SetHead(head, delFn)
 	currentHeader := bc.hc.CurrentHeader()
 
+	// Discard the rewound blocks from the ancient store too
+	if store := ancientStore(bc.chainDb); store != nil {
+		bc.freezeMu.Lock()
+		err := truncateAncients(bc.chainDb, store, currentHeader.Number.Uint64()+1)
+		bc.freezeMu.Unlock()
+		if err != nil {
+			glog.Fatalf("failed to rewind ancient store: %v", err)
+		}
+	}
+
 	// Clear out any stale content from the caches
 	bc.bodyCache.Purge()
 	bc.bodyRLPCache.Purge()
@@ -794,7 +927,7 @@ func (bc *BlockChain) SetHead(head uint64) error {
 		bc.currentBlock = bc.GetBlock(currentHeader.Hash())
 	}
 	if bc.currentBlock != nil {
-		if _, err := state.New(bc.currentBlock.Root(), state.NewDatabase(bc.chainDb)); err != nil {
+		if _, err := state.New(bc.currentBlock.Root(), bc.stateDatabase); err != nil {
 			// Rewound state missing, rolled back to before pivot, reset to genesis
 			bc.currentBlock = nil
 		}
@@ -819,34 +952,21 @@ func (bc *BlockChain) SetHead(head uint64) error {
 	}
 
 	if bc.atxi != nil && bc.atxi.AutoMode {
-		ldb, ok := bc.atxi.Db.(*ethdb.LDBDatabase)
-		if !ok {
-			glog.Fatal("could not cast indexes db to level db")
-		}
-
-		var removals [][]byte
-		deleteRemovalsFn := func(rs [][]byte) {
-			for _, r := range rs {
-				if e := ldb.Delete(r); e != nil {
-					glog.Fatal(e)
-				}
-			}
-		}
-
-		pre := ethdb.NewBytesPrefix(txAddressIndexPrefix)
-		it := ldb.NewIteratorRange(pre)
+		batch := bc.atxi.Db.NewBatch()
+		it := bc.atxi.Db.NewIteratorWithPrefix(txAddressIndexPrefix)
 
 		for it.Next() {
 			key := it.Key()
 			_, bn, _, _, _ := resolveAddrTxBytes(key)
 			n := binary.LittleEndian.Uint64(bn)
 			if n > head {
-				removals = append(removals, key)
-				// Prevent removals from getting too massive in case it's a big rollback
-				// 100000 is a guess at a big but not-too-big memory allowance
-				if len(removals) > 100000 {
-					deleteRemovalsFn(removals)
-					removals = [][]byte{}
+				batch.Delete(key)
+				// Prevent the batch from getting too massive in case it's a big rollback
+				if batch.ValueSize() >= ethdb.IdealBatchSize {
+					if e := batch.Write(); e != nil {
+						glog.Fatal(e)
+					}
+					batch.Reset()
 				}
 			}
 		}
@@ -854,7 +974,9 @@ func (bc *BlockChain) SetHead(head uint64) error {
 		if e := it.Error(); e != nil {
 			return e
 		}
-		deleteRemovalsFn(removals)
+		if e := batch.Write(); e != nil {
+			glog.Fatal(e)
+		}
 
 		// update atxi bookmark to lower head in the case that its progress was higher than the new head
 		if bc.atxi != nil && bc.atxi.AutoMode {
@@ -873,6 +995,9 @@ func (bc *BlockChain) SetHead(head uint64) error {
 // FastSyncCommitHead sets the current head block to the one defined by the hash
 // irrelevant what the chain contents were prior.
 func (bc *BlockChain) FastSyncCommitHead(hash common.Hash) error {
+	if bc.cacheConfig.ReadOnly {
+		return ethdb.ErrReadOnly
+	}
 	// Make sure that both the block as well at its state trie exists
 	block := bc.GetBlock(hash)
 	if block == nil {
@@ -970,7 +1095,13 @@ func (bc *BlockChain) State() (*state.StateDB, error) {
 
 // StateAt returns a new mutable state based on a particular point in time.
 func (bc *BlockChain) StateAt(root common.Hash) (*state.StateDB, error) {
-	return state.New(root, state.NewDatabase(bc.chainDb))
+	return state.NewWithSnapshot(root, bc.stateDatabase, bc.snaps)
+}
+
+// StateCache returns the state database of the chain, caching the trie nodes of
+// recent states which may not have been written to the database yet.
+func (bc *BlockChain) StateCache() state.Database {
+	return bc.stateDatabase
 }
 
 // Reset purges the entire blockchain, restoring it to its genesis state.
@@ -1121,7 +1252,7 @@ func (bc *BlockChain) HasBlockAndState(hash common.Hash) bool {
 		return false
 	}
 	// Ensure the associated state is also present
-	_, err := state.New(block.Root(), state.NewDatabase(bc.chainDb))
+	_, err := state.New(block.Root(), bc.stateDatabase)
 	return err == nil
 }
 
@@ -1186,6 +1317,38 @@ func (bc *BlockChain) Stop() {
 
 	bc.wg.Wait()
 
+	// Flatten the state snapshot into the head state, so it's reusable after a restart
+	if bc.snaps != nil {
+		if err := bc.snaps.Cap(bc.CurrentBlock().Root(), 0); err != nil {
+			glog.V(logger.Error).Errorf("Failed to persist state snapshot: %v", err)
+		}
+		bc.snaps.Release()
+	}
+	// Ensure the state of a recent block is also stored to disk before exiting.
+	// We're writing three different states to catch different restart scenarios:
+	//  - HEAD:     So we don't need to reprocess any blocks in the general case
+	//  - HEAD-1:   So we don't do large reorgs if our HEAD becomes an uncle
+	//  - HEAD-127: So we have a hard limit on the number of blocks reexecuted
+	if !bc.cacheConfig.Disabled && !bc.cacheConfig.ReadOnly {
+		for _, offset := range []uint64{0, 1, triesInMemory - 1} {
+			if number := bc.CurrentBlock().NumberU64(); number > offset {
+				recent := bc.GetBlockByNumber(number - offset)
+
+				glog.V(logger.Info).Infof("Writing cached state to disk: block=%d hash=%x root=%x", recent.Number(), recent.Hash(), recent.Root())
+				if err := bc.triedb.Commit(recent.Root()); err != nil {
+					glog.V(logger.Error).Errorf("Failed to commit recent state trie: %v", err)
+				}
+			}
+		}
+		for !bc.triegc.Empty() {
+			if err := bc.dereferenceState(bc.triegc.PopItem().(common.Hash)); err != nil {
+				glog.V(logger.Error).Errorf("Failed to mark state pruned: %v", err)
+			}
+		}
+		if size := bc.triedb.Size(); size != 0 {
+			glog.V(logger.Error).Errorf("Dangling trie nodes after full cleanup: size=%v", size)
+		}
+	}
 	glog.V(logger.Info).Infoln("Chain manager stopped")
 }
 
@@ -1228,6 +1391,10 @@ func (bc *BlockChain) Rollback(chain []common.Hash) {
 // transaction and receipt data.
 func (bc *BlockChain) InsertReceiptChain(blockChain types.Blocks, receiptChain []types.Receipts) (res *ReceiptChainInsertResult) {
 	res = &ReceiptChainInsertResult{}
+	if bc.cacheConfig.ReadOnly {
+		res.Error = ethdb.ErrReadOnly
+		return
+	}
 
 	bc.wg.Add(1)
 	defer bc.wg.Done()
@@ -1441,6 +1608,9 @@ func (bc *BlockChain) WriteBlockAddrTxIndexesBatch(indexDb ethdb.Database, start
 
 // WriteBlock writes the block to the chain.
 func (bc *BlockChain) WriteBlock(block *types.Block) (status WriteStatus, err error) {
+	if bc.cacheConfig.ReadOnly {
+		return NonStatTy, ethdb.ErrReadOnly
+	}
 
 	if logger.MlogEnabled() {
 		defer func() {
@@ -1509,11 +1679,17 @@ func (bc *BlockChain) WriteBlock(block *types.Block) (status WriteStatus, err er
 	if reorg {
 		// Reorganise the chain if the parent is not the head block
 		if block.ParentHash() != bc.currentBlock.Hash() {
-			if err := bc.reorg(bc.currentBlock, block); err != nil {
+			if err := bc.reorg(bc.currentBlock, block); err == ReorgFinalityErr {
+				// Keep the block as a side chain, it may still win with more work
+				reorg = false
+			} else if err != nil {
 				return NonStatTy, err
 			}
 		}
+	}
+	if reorg {
 		bc.insert(block) // Insert the block as the new head of the chain
+		bc.capSnapshot(block.Root())
 		status = CanonStatTy
 	} else {
 		status = SideStatTy
@@ -1531,10 +1707,123 @@ func (bc *BlockChain) WriteBlock(block *types.Block) (status WriteStatus, err er
 	return
 }
 
+// WriteBlockState commits the state resulting from processing a block, such as
+// a locally mined one, the same way blocks inserted through InsertChain are.
+func (bc *BlockChain) WriteBlockState(block *types.Block, statedb *state.StateDB) error {
+	if bc.cacheConfig.ReadOnly {
+		return ethdb.ErrReadOnly
+	}
+	bc.chainmu.Lock()
+	defer bc.chainmu.Unlock()
+
+	return bc.commitState(block, statedb)
+}
+
+// commitState writes the state changes of a processed block. Archive nodes write
+// every trie node straight to disk, others go through the trie node cache, which
+// only flushes tries to disk periodically and garbage collects the rest.
+//
+// This method assumes that the chain insertion lock is held.
+func (bc *BlockChain) commitState(block *types.Block, statedb *state.StateDB) error {
+	deleteEmptyObjects := bc.config.IsAtlantis(block.Number())
+
+	// If we're running an archive node, always flush
+	if bc.cacheConfig.Disabled {
+		_, err := statedb.CommitTo(bc.chainDb, deleteEmptyObjects)
+		return err
+	}
+	root, err := statedb.CommitTo(bc.triedb, deleteEmptyObjects)
+	if err != nil {
+		return err
+	}
+
+	// Full but not archive node, do proper garbage collection
+	bc.triedb.Reference(root, common.Hash{}) // metadata reference to keep trie alive
+	bc.triegc.Push(root, -float32(block.NumberU64()))
+
+	current := block.NumberU64()
+	if current <= triesInMemory {
+		return nil
+	}
+	// If we exceeded our memory allowance, flush matured singleton nodes to disk
+	if size := bc.triedb.Size(); size > bc.cacheConfig.TrieNodeLimit {
+		if err := bc.triedb.Cap(bc.cacheConfig.TrieNodeLimit - ethdb.IdealBatchSize); err != nil {
+			return err
+		}
+	}
+	// Find the next state trie we need to commit
+	chosen := current - triesInMemory
+
+	// If we exceeded out time allowance, flush an entire trie to disk
+	if bc.gcproc > bc.cacheConfig.TrieTimeLimit {
+		if header := bc.GetHeaderByNumber(chosen); header == nil {
+			glog.V(logger.Warn).Warnf("Reorg in progress, trie commit postponed: number=%d", chosen)
+		} else {
+			// If we're exceeding limits but haven't reached a large enough memory gap,
+			// warn the user that the system is becoming unstable.
+			if chosen < bc.lastWrite+triesInMemory && bc.gcproc >= 2*bc.cacheConfig.TrieTimeLimit {
+				glog.V(logger.Info).Infof("State in memory for too long, committing: time=%v allowance=%v optimum=%.2f",
+					bc.gcproc, bc.cacheConfig.TrieTimeLimit, float64(chosen-bc.lastWrite)/triesInMemory)
+			}
+			// Flush an entire trie and restart the counters
+			if err := bc.triedb.Commit(header.Root); err != nil {
+				return err
+			}
+			bc.lastWrite = chosen
+			bc.gcproc = 0
+		}
+	}
+	// Garbage collect anything below our required write retention
+	for !bc.triegc.Empty() {
+		root, number := bc.triegc.Pop()
+		if uint64(-number) > chosen {
+			bc.triegc.Push(root, number)
+			break
+		}
+		if err := bc.dereferenceState(root.(common.Hash)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// dereferenceState releases a state trie from the trie node cache. If the trie
+// was never flushed to disk, its state is gone, and the database is marked as
+// pruned first. The marker is never cleared again: the dropped states can't be
+// recovered, so the chain stays pruned even if later run as an archive node.
+func (bc *BlockChain) dereferenceState(root common.Hash) error {
+	if !bc.statePruned {
+		if ok, _ := bc.chainDb.Has(root.Bytes()); !ok {
+			if err := WriteTriePruned(bc.chainDb); err != nil {
+				return err
+			}
+			bc.statePruned = true
+		}
+	}
+	bc.triedb.Dereference(root)
+	return nil
+}
+
+// capSnapshot flattens the state snapshot diff layers too deep below the state
+// of a new head block into the snapshot disk layer. Side chain states must not
+// be used, flattening them would drop the layers of the canonical chain.
+func (bc *BlockChain) capSnapshot(root common.Hash) {
+	if bc.snaps == nil || bc.snaps.Snapshot(root) == nil {
+		return
+	}
+	if err := bc.snaps.Cap(root, snapshotLayers); err != nil {
+		glog.V(logger.Warn).Warnf("Failed to cap state snapshot: root=%x err=%v", root, err)
+	}
+}
+
 // InsertChain inserts the given chain into the canonical chain or, otherwise, create a fork.
 // If the err return is not nil then chainIndex points to the cause in chain.
 func (bc *BlockChain) InsertChain(chain types.Blocks) (res *ChainInsertResult) {
 	res = &ChainInsertResult{} // initialize
+	if bc.cacheConfig.ReadOnly {
+		res.Error = ethdb.ErrReadOnly
+		return
+	}
 	// Do a sanity check that the provided chain is actually ordered and linked
 	for i := 1; i < len(chain); i++ {
 		if chain[i].NumberU64() != chain[i-1].NumberU64()+1 || chain[i].ParentHash() != chain[i-1].Hash() {
@@ -1554,6 +1843,14 @@ func (bc *BlockChain) InsertChain(chain types.Blocks) (res *ChainInsertResult) {
 	bc.chainmu.Lock()
 	defer bc.chainmu.Unlock()
 
+	return bc.insertChain(chain)
+}
+
+// insertChain is the internal implementation of InsertChain, which assumes that
+// the chain is contiguous and the chain insertion lock is held.
+func (bc *BlockChain) insertChain(chain types.Blocks) (res *ChainInsertResult) {
+	res = &ChainInsertResult{} // initialize
+
 	// A queued approach to delivering events. This is generally
 	// faster than direct delivery and requires much less mutex
 	// acquiring.
@@ -1600,6 +1897,33 @@ func (bc *BlockChain) InsertChain(chain types.Blocks) (res *ChainInsertResult) {
 		// Stage 1 validation of the block using the chain's validator
 		// interface.
 		err := bc.Validator().ValidateBlock(block)
+		if err == ErrPrunedAncestor {
+			// The parent's state was garbage collected. Keep a lighter side chain
+			// without state, and re-execute it once it becomes the heavier one.
+			ptd := bc.GetTd(block.ParentHash())
+			if ptd == nil {
+				res.Error = ParentError(block.ParentHash())
+				return
+			}
+			externTd := new(big.Int).Add(ptd, block.Difficulty())
+			if externTd.Cmp(bc.GetTd(bc.CurrentBlock().Hash())) > 0 {
+				res = bc.insertPrunedSideChain(chain[i:])
+				if res.Error != nil {
+					res.Index += i
+				}
+				return
+			}
+			if err := bc.writeBlockWithoutState(block, externTd); err != nil {
+				res.Error = err
+				return
+			}
+			if glog.V(logger.Detail) {
+				glog.Infof("inserted forked block #%d without state (TD=%v) [%s]\n", block.Number(), externTd, block.Hash().Hex())
+			}
+			events = append(events, ChainSideEvent{block, nil})
+			stats.processed++
+			continue
+		}
 		if err != nil {
 			if IsKnownBlockErr(err) {
 				stats.ignored++
@@ -1655,8 +1979,8 @@ func (bc *BlockChain) InsertChain(chain types.Blocks) (res *ChainInsertResult) {
 			return
 		}
 		// Write state changes to database
-		_, err = bc.stateCache.CommitTo(bc.chainDb, bc.config.IsAtlantis(block.Number()))
-		if err != nil {
+		bc.gcproc += time.Since(bstart)
+		if err := bc.commitState(block, bc.stateCache); err != nil {
 			res.Error = err
 			return
 		}
@@ -1684,6 +2008,10 @@ func (bc *BlockChain) InsertChain(chain types.Blocks) (res *ChainInsertResult) {
 			}
 			events = append(events, ChainEvent{block, block.Hash(), logs})
 
+			// Regenerate the state snapshot if the new head is not covered by it (e.g. deep reorg)
+			if bc.snaps != nil && bc.snaps.Snapshot(block.Root()) == nil {
+				bc.snaps.Rebuild(block.Root())
+			}
 			// This puts transactions in a extra db for rpc
 			if err := WriteTransactions(bc.chainDb, block); err != nil {
 				res.Error = err
@@ -1771,6 +2099,52 @@ func (bc *BlockChain) InsertChain(chain types.Blocks) (res *ChainInsertResult) {
 	return r
 }
 
+// insertPrunedSideChain imports a side chain which became heavier than the
+// canonical chain, but whose parent's state was garbage collected. The missing
+// states are regenerated by re-executing the blocks from the nearest ancestor
+// with state. The index of a failure is relative to the given chain, with the
+// failures of the ancestors reported at its first block.
+//
+// This method assumes that the chain insertion lock is held.
+func (bc *BlockChain) insertPrunedSideChain(chain types.Blocks) *ChainInsertResult {
+	var ancestors types.Blocks
+	parent := bc.GetBlock(chain[0].ParentHash())
+	for parent != nil && !bc.HasBlockAndState(parent.Hash()) {
+		ancestors = append(ancestors, parent)
+		parent = bc.GetBlock(parent.ParentHash())
+	}
+	if parent == nil {
+		return &ChainInsertResult{Error: ParentError(chain[0].ParentHash())}
+	}
+	for i, j := 0, len(ancestors)-1; i < j; i, j = i+1, j-1 {
+		ancestors[i], ancestors[j] = ancestors[j], ancestors[i]
+	}
+	glog.V(logger.Info).Infof("Re-executing pruned side chain: ancestors=%d from=#%d [%s]", len(ancestors), parent.Number(), parent.Hash().Hex())
+
+	res := bc.insertChain(append(ancestors, chain...))
+	if res.Error != nil {
+		if res.Index < len(ancestors) {
+			res.Index = 0
+		} else {
+			res.Index -= len(ancestors)
+		}
+	}
+	return res
+}
+
+// writeBlockWithoutState writes a side chain block and its total difficulty,
+// leaving its state to be computed if the side chain becomes the canonical one.
+func (bc *BlockChain) writeBlockWithoutState(block *types.Block, td *big.Int) error {
+	if err := bc.hc.WriteTd(block.Hash(), td); err != nil {
+		return err
+	}
+	if err := WriteBlock(bc.chainDb, block); err != nil {
+		return err
+	}
+	bc.futureBlocks.Remove(block.Hash())
+	return nil
+}
+
 // reorgs takes two blocks, an old chain and a new chain and will reconstruct the blocks and inserts them
 // to be part of the new canonical chain and accumulates potential missing transactions and post an
 // event about them
@@ -1841,6 +2215,13 @@ func (bc *BlockChain) reorg(oldBlock, newBlock *types.Block) error {
 		}
 	}
 
+	// Refuse reorgs not carrying enough work to override the artificial finality
+	if bc.IsArtificialFinalityEnabled() && bc.config.IsECBP1100(oldStart.Number()) {
+		if err := bc.checkArtificialFinality(commonBlock, oldStart, newStart); err != nil {
+			return err
+		}
+	}
+
 	commonHash := commonBlock.Hash()
 	if glog.V(logger.Debug) {
 		glog.Infof("Chain split detected @ [%s]. Reorganising chain from #%v %s to %s", commonHash.Hex(), numSplit, oldStart.Hash().Hex(), newStart.Hash().Hex())
@@ -1983,6 +2364,9 @@ func (bc *BlockChain) update() {
 // of the header retrieval mechanisms already need to verify nonces, as well as
 // because nonces can be verified sparsely, not needing to check each.
 func (bc *BlockChain) InsertHeaderChain(chain []*types.Header, checkFreq int) *HeaderChainInsertResult {
+	if bc.cacheConfig.ReadOnly {
+		return &HeaderChainInsertResult{Error: ethdb.ErrReadOnly}
+	}
 	// Make sure only one thread manipulates the chain at once
 	bc.chainmu.Lock()
 	defer bc.chainmu.Unlock()
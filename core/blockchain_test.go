@@ -495,12 +495,16 @@ func makeBlockChainWithDiff(genesis *types.Block, d []int, seed byte) []*types.B
 func chm(t testing.TB, genesis *types.Block, db ethdb.Database) *BlockChain {
 	var eventMux event.TypeMux
 	config := testChainConfig()
+	triedb := state.NewNodeCache(db)
 	bc := &BlockChain{
-		chainDb:      db,
-		genesisBlock: genesis,
-		eventMux:     &eventMux,
-		pow:          FakePow{},
-		config:       config,
+		chainDb:       db,
+		genesisBlock:  genesis,
+		eventMux:      &eventMux,
+		pow:           FakePow{},
+		config:        config,
+		cacheConfig:   &CacheConfig{Disabled: true},
+		stateDatabase: state.NewDatabaseWithCache(triedb),
+		triedb:        triedb,
 	}
 	valFn := func() HeaderValidator { return bc.Validator() }
 	var err error
@@ -1838,3 +1842,329 @@ func TestBlockChain_BlockIsGenesis(t *testing.T) {
 		t.Errorf("expected: is not genesis block")
 	}
 }
+
+// Tests that in full garbage collection mode only the recent state tries are
+// retained, and that the state of the head is persisted on shutdown.
+func TestTrieGarbageCollection(t *testing.T) {
+	key, _ := crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	address := crypto.PubkeyToAddress(key.PublicKey)
+	funds := big.NewInt(1000000000)
+
+	gendb, _ := ethdb.NewMemDatabase()
+	genesis := WriteGenesisBlockForTesting(gendb, GenesisAccount{address, funds})
+
+	blocks, _ := GenerateChain(testChainConfig(), genesis, gendb, 2*triesInMemory, func(i int, block *BlockGen) {
+		tx, err := types.NewTransaction(block.TxNonce(address), common.Address{byte(i), byte(i >> 8)}, big.NewInt(1), big.NewInt(21000), new(big.Int), nil).SignECDSA(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		block.AddTx(tx)
+	})
+
+	db, _ := ethdb.NewMemDatabase()
+	WriteGenesisBlockForTesting(db, GenesisAccount{address, funds})
+
+	cacheConfig := &CacheConfig{TrieNodeLimit: 256 * 1024 * 1024, TrieTimeLimit: time.Hour}
+	blockchain, err := NewBlockChainWithCacheConfig(db, cacheConfig, testChainConfig(), FakePow{}, new(event.TypeMux))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res := blockchain.InsertChain(blocks); res.Error != nil {
+		t.Fatalf("failed to insert chain: %v", res.Error)
+	}
+	if !GetTriePruned(db) {
+		t.Fatalf("database not marked as pruned")
+	}
+	for i, block := range blocks {
+		number := uint64(i + 1)
+		if has := blockchain.HasBlockAndState(block.Hash()); has != (number > triesInMemory) {
+			t.Errorf("block #%d: state availability mismatch: have %v, want %v", number, has, number > triesInMemory)
+		}
+		if ok, _ := db.Has(block.Root().Bytes()); ok {
+			t.Errorf("block #%d: state root written to disk before shutdown", number)
+		}
+	}
+	blockchain.Stop()
+
+	head := blocks[len(blocks)-1]
+	if ok, _ := db.Has(head.Root().Bytes()); !ok {
+		t.Fatalf("head state not persisted on shutdown")
+	}
+	// Reopening the chain must find the head state on disk
+	blockchain, err = NewBlockChainWithCacheConfig(db, cacheConfig, testChainConfig(), FakePow{}, new(event.TypeMux))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have := blockchain.CurrentBlock().Hash(); have != head.Hash() {
+		t.Fatalf("head block mismatch after restart: have #%d [%x…], want #%d [%x…]",
+			blockchain.CurrentBlock().NumberU64(), have[:4], head.NumberU64(), head.Hash().Bytes()[:4])
+	}
+	if _, err := blockchain.State(); err != nil {
+		t.Fatalf("head state unavailable after restart: %v", err)
+	}
+}
+
+// Tests that the database is only marked as pruned once garbage collection
+// actually drops a state, and stays marked when reopened as an archive node.
+func TestTrieGarbageCollectionMarker(t *testing.T) {
+	key, _ := crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	address := crypto.PubkeyToAddress(key.PublicKey)
+	funds := big.NewInt(1000000000)
+
+	gendb, _ := ethdb.NewMemDatabase()
+	genesis := WriteGenesisBlockForTesting(gendb, GenesisAccount{address, funds})
+
+	blocks, _ := GenerateChain(testChainConfig(), genesis, gendb, 4, func(i int, block *BlockGen) {
+		tx, err := types.NewTransaction(block.TxNonce(address), common.Address{byte(i)}, big.NewInt(1), big.NewInt(21000), new(big.Int), nil).SignECDSA(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		block.AddTx(tx)
+	})
+
+	db, _ := ethdb.NewMemDatabase()
+	WriteGenesisBlockForTesting(db, GenesisAccount{address, funds})
+
+	cacheConfig := &CacheConfig{TrieNodeLimit: 256 * 1024 * 1024, TrieTimeLimit: time.Hour}
+	blockchain, err := NewBlockChainWithCacheConfig(db, cacheConfig, testChainConfig(), FakePow{}, new(event.TypeMux))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if GetTriePruned(db) {
+		t.Fatalf("database marked as pruned on start")
+	}
+	if res := blockchain.InsertChain(blocks); res.Error != nil {
+		t.Fatalf("failed to insert chain: %v", res.Error)
+	}
+	if GetTriePruned(db) {
+		t.Fatalf("database marked as pruned with all states retained")
+	}
+	// Shutting down only persists the recent states, dropping the older ones
+	blockchain.Stop()
+	if !GetTriePruned(db) {
+		t.Fatalf("database not marked as pruned after dropping states")
+	}
+	blockchain, err = NewBlockChain(db, testChainConfig(), FakePow{}, new(event.TypeMux))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer blockchain.Stop()
+
+	if !blockchain.statePruned {
+		t.Errorf("archive chain on pruned database not treated as pruned")
+	}
+}
+
+// Tests that a side chain forking off below the retained states is imported
+// without state while lighter, and re-executed once it becomes the heavier one.
+func TestPrunedSideChain(t *testing.T) {
+	gendb, _ := ethdb.NewMemDatabase()
+	genesis := WriteGenesisBlockForTesting(gendb)
+
+	// A canonical chain past the retained states, and a side chain with short
+	// block times (i.e. a higher difficulty) forking off from a garbage collected
+	// state, cut to outweigh the canonical chain with its last block only
+	canon, _ := GenerateChain(testChainConfig(), genesis, gendb, triesInMemory+10, nil)
+	fork := canon[4]
+	side, _ := GenerateChain(testChainConfig(), fork, gendb, len(canon)-int(fork.NumberU64()), func(i int, block *BlockGen) {
+		block.SetCoinbase(common.Address{0x01})
+		block.OffsetTime(-9)
+	})
+	canonTd, sideTd := new(big.Int), new(big.Int)
+	for _, block := range canon[fork.NumberU64():] {
+		canonTd.Add(canonTd, block.Difficulty())
+	}
+	for i, block := range side {
+		if sideTd.Add(sideTd, block.Difficulty()).Cmp(canonTd) > 0 {
+			side = side[:i+1]
+			break
+		}
+	}
+	if sideTd.Cmp(canonTd) <= 0 || len(side) < 2 {
+		t.Fatalf("side chain not heavier with its last block only")
+	}
+
+	db, _ := ethdb.NewMemDatabase()
+	WriteGenesisBlockForTesting(db)
+
+	cacheConfig := &CacheConfig{TrieNodeLimit: 256 * 1024 * 1024, TrieTimeLimit: time.Hour}
+	blockchain, err := NewBlockChainWithCacheConfig(db, cacheConfig, testChainConfig(), FakePow{}, new(event.TypeMux))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer blockchain.Stop()
+
+	if res := blockchain.InsertChain(canon); res.Error != nil {
+		t.Fatalf("failed to insert canonical chain: %v", res.Error)
+	}
+	if blockchain.HasBlockAndState(fork.Hash()) {
+		t.Fatalf("fork point state not garbage collected")
+	}
+	// The lighter part of the side chain is stored without state
+	head := canon[len(canon)-1]
+	if res := blockchain.InsertChain(side[:len(side)-1]); res.Error != nil {
+		t.Fatalf("failed to insert side chain: %v", res.Error)
+	}
+	if have := blockchain.CurrentBlock().Hash(); have != head.Hash() {
+		t.Fatalf("head block mismatch: have #%d, want canonical #%d", blockchain.CurrentBlock().NumberU64(), head.NumberU64())
+	}
+	for i, block := range side[:len(side)-1] {
+		if !blockchain.HasBlock(block.Hash()) {
+			t.Fatalf("side block #%d not stored", block.NumberU64())
+		}
+		if blockchain.HasBlockAndState(block.Hash()) {
+			t.Fatalf("side block %d: state stored for lighter chain", i)
+		}
+	}
+	// The side chain's last block makes it heavier, re-executing it
+	if res := blockchain.InsertChain(side[len(side)-1:]); res.Error != nil {
+		t.Fatalf("failed to insert side chain head: %v", res.Error)
+	}
+	head = side[len(side)-1]
+	if have := blockchain.CurrentBlock().Hash(); have != head.Hash() {
+		t.Fatalf("head block mismatch: have #%d [%x…], want side #%d", blockchain.CurrentBlock().NumberU64(), have[:4], head.NumberU64())
+	}
+	if !blockchain.HasBlockAndState(head.Hash()) {
+		t.Fatalf("side chain head state missing")
+	}
+	if have := blockchain.GetBlockByNumber(fork.NumberU64() + 1).Hash(); have != side[0].Hash() {
+		t.Fatalf("canonical block #%d mismatch: have [%x…], want side [%x…]", fork.NumberU64()+1, have[:4], side[0].Hash().Bytes()[:4])
+	}
+}
+
+// Tests that a chain whose head state was lost (e.g. due to a crash with the
+// state still cached in memory) is rewound to the last block with state.
+func TestTrieGarbageCollectionRepair(t *testing.T) {
+	key, _ := crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	address := crypto.PubkeyToAddress(key.PublicKey)
+	funds := big.NewInt(1000000000)
+
+	gendb, _ := ethdb.NewMemDatabase()
+	genesis := WriteGenesisBlockForTesting(gendb, GenesisAccount{address, funds})
+
+	blocks, _ := GenerateChain(testChainConfig(), genesis, gendb, 8, func(i int, block *BlockGen) {
+		tx, err := types.NewTransaction(block.TxNonce(address), common.Address{byte(i)}, big.NewInt(1), big.NewInt(21000), new(big.Int), nil).SignECDSA(key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		block.AddTx(tx)
+	})
+
+	db, _ := ethdb.NewMemDatabase()
+	WriteGenesisBlockForTesting(db, GenesisAccount{address, funds})
+
+	cacheConfig := &CacheConfig{TrieNodeLimit: 256 * 1024 * 1024, TrieTimeLimit: time.Hour}
+	blockchain, err := NewBlockChainWithCacheConfig(db, cacheConfig, testChainConfig(), FakePow{}, new(event.TypeMux))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res := blockchain.InsertChain(blocks); res.Error != nil {
+		t.Fatalf("failed to insert chain: %v", res.Error)
+	}
+	// Simulate a crash by reopening the database without stopping the chain
+	blockchain, err = NewBlockChainWithCacheConfig(db, cacheConfig, testChainConfig(), FakePow{}, new(event.TypeMux))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have := blockchain.CurrentBlock().Hash(); have != genesis.Hash() {
+		t.Fatalf("head block mismatch after repair: have #%d, want genesis", blockchain.CurrentBlock().NumberU64())
+	}
+	// The lost blocks must be reprocessable
+	if res := blockchain.InsertChain(blocks); res.Error != nil {
+		t.Fatalf("failed to reinsert chain: %v", res.Error)
+	}
+	if have := blockchain.CurrentBlock().Hash(); have != blocks[len(blocks)-1].Hash() {
+		t.Fatalf("head block mismatch after reinsertion: have #%d, want #%d", blockchain.CurrentBlock().NumberU64(), len(blocks))
+	}
+}
+
+// Tests that importing a long side chain doesn't flatten its state snapshot
+// layers, dropping the ones of the canonical chain.
+func TestSnapshotSideChain(t *testing.T) {
+	gendb, _ := ethdb.NewMemDatabase()
+	genesis := WriteGenesisBlockForTesting(gendb)
+
+	// A canonical chain with short block times (i.e. a higher difficulty), and
+	// a lighter side chain forking off at its snapshot disk layer
+	canon, _ := GenerateChain(testChainConfig(), genesis, gendb, triesInMemory+2, func(i int, block *BlockGen) {
+		block.OffsetTime(-9)
+	})
+	fork := canon[len(canon)-snapshotLayers-1]
+	side, _ := GenerateChain(testChainConfig(), fork, gendb, snapshotLayers+1, func(i int, block *BlockGen) {
+		block.SetCoinbase(common.Address{0x01})
+	})
+
+	db, _ := ethdb.NewMemDatabase()
+	WriteGenesisBlockForTesting(db)
+
+	cacheConfig := &CacheConfig{TrieNodeLimit: 256 * 1024 * 1024, TrieTimeLimit: time.Hour, Snapshot: true}
+	blockchain, err := NewBlockChainWithCacheConfig(db, cacheConfig, testChainConfig(), FakePow{}, new(event.TypeMux))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer blockchain.Stop()
+
+	if res := blockchain.InsertChain(canon); res.Error != nil {
+		t.Fatalf("failed to insert canonical chain: %v", res.Error)
+	}
+	if res := blockchain.InsertChain(side); res.Error != nil {
+		t.Fatalf("failed to insert side chain: %v", res.Error)
+	}
+	head := canon[len(canon)-1]
+	if have := blockchain.CurrentBlock().Hash(); have != head.Hash() {
+		t.Fatalf("head block mismatch: have #%d, want #%d", blockchain.CurrentBlock().NumberU64(), head.NumberU64())
+	}
+	if blockchain.snaps.Snapshot(head.Root()) == nil {
+		t.Fatalf("head state snapshot dropped by the side chain")
+	}
+}
+
+// Tests that a chain on a read-only database serves the blocks, including the
+// ones in the ancient store, and refuses every write.
+func TestReadOnlyChain(t *testing.T) {
+	dir, err := ioutil.TempDir("", "readonly-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db := openFreezerDatabase(t, dir)
+	blocks := importFreezerChain(t, db, 8)
+	if _, err := freezeAncients(db, db.AncientStore(), 4, nil); err != nil {
+		t.Fatalf("failed to freeze blocks: %v", err)
+	}
+	db.Close()
+
+	rodb, err := ethdb.NewReadOnlyLDBDatabaseWithFreezer(filepath.Join(dir, "chaindata"), filepath.Join(dir, "chaindata", "ancient"), 0, 0)
+	if err != nil {
+		t.Fatalf("failed to open read-only database: %v", err)
+	}
+	defer rodb.Close()
+
+	chain, err := NewBlockChainWithCacheConfig(rodb, &CacheConfig{ReadOnly: true, Snapshot: true, AncientThreshold: 1}, MakeDiehardChainConfig(), FakePow{}, new(event.TypeMux))
+	if err != nil {
+		t.Fatalf("failed to create read-only chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if head := chain.CurrentBlock(); head.Hash() != blocks[len(blocks)-1].Hash() {
+		t.Errorf("read-only head mismatch: have #%d [%x], want #%d", head.Number(), head.Hash(), len(blocks))
+	}
+	for _, block := range blocks {
+		if have := chain.GetBlockByNumber(block.NumberU64()); have == nil || have.Hash() != block.Hash() {
+			t.Errorf("read-only block #%d missing", block.NumberU64())
+		}
+	}
+	if _, err := chain.State(); err != nil {
+		t.Errorf("read-only head state unavailable: %v", err)
+	}
+	if err := chain.SetHead(2); err != ethdb.ErrReadOnly {
+		t.Errorf("read-only rewind error mismatch: have %v, want %v", err, ethdb.ErrReadOnly)
+	}
+	if res := chain.InsertChain(blocks); res.Error != ethdb.ErrReadOnly {
+		t.Errorf("read-only import error mismatch: have %v, want %v", res.Error, ethdb.ErrReadOnly)
+	}
+	if head := chain.CurrentBlock(); head.Hash() != blocks[len(blocks)-1].Hash() {
+		t.Errorf("read-only head changed: have #%d [%x]", head.Number(), head.Hash())
+	}
+}
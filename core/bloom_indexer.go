@@ -0,0 +1,89 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"github.com/eth-classic/go-ethereum/common"
+	"github.com/eth-classic/go-ethereum/common/bitutil"
+	"github.com/eth-classic/go-ethereum/core/bloombits"
+	"github.com/eth-classic/go-ethereum/core/types"
+	"github.com/eth-classic/go-ethereum/ethdb"
+)
+
+const (
+	// BloomBitsBlocks is the number of blocks of a bloom bits section, the
+	// length of its bit vectors.
+	BloomBitsBlocks uint64 = 4096
+
+	// bloomConfirms is the number of blocks past a section before its bloom
+	// bits are generated, making reorgs invalidating it unlikely.
+	bloomConfirms = 256
+)
+
+// BloomIndexer is a chain indexer backend generating the rotated bloom bits of
+// the chain sections, letting log filters match a section with a few database
+// reads instead of checking the bloom of each of its blocks.
+type BloomIndexer struct {
+	size    uint64               // Number of blocks of a section
+	db      ethdb.Database       // Database to write the bloom bits into
+	gen     *bloombits.Generator // Generator rotating the blooms of the section
+	section uint64               // Section being processed
+	head    common.Hash          // Last block processed
+}
+
+// NewBloomIndexer returns a chain indexer generating the bloom bits of the
+// canonical chain sections, see GetBloomBits.
+func NewBloomIndexer(db ethdb.Database) *ChainIndexer {
+	return newBloomIndexer(db, BloomBitsBlocks, bloomConfirms)
+}
+
+// newBloomIndexer returns a bloom bits chain indexer with custom section size
+// and confirmations.
+func newBloomIndexer(db ethdb.Database, size, confirms uint64) *ChainIndexer {
+	backend := &BloomIndexer{db: db, size: size}
+	return NewChainIndexer(db, ethdb.NewTable(db, string(bloomBitsIndexPrefix)), backend, size, confirms, "bloombits")
+}
+
+// Reset implements ChainIndexerBackend, starting a new bloom bits section.
+func (b *BloomIndexer) Reset(section uint64, prevHead common.Hash) error {
+	gen, err := bloombits.NewGenerator(uint(b.size))
+	b.gen, b.section, b.head = gen, section, common.Hash{}
+	return err
+}
+
+// Process implements ChainIndexerBackend, adding the header bloom of a block
+// to the section.
+func (b *BloomIndexer) Process(header *types.Header) error {
+	b.head = header.Hash()
+	return b.gen.AddBloom(uint(header.Number.Uint64()-b.section*b.size), header.Bloom)
+}
+
+// Commit implements ChainIndexerBackend, writing the compressed bit vectors of
+// the section into the database.
+func (b *BloomIndexer) Commit() error {
+	batch := b.db.NewBatch()
+	for i := 0; i < bloombits.BloomBitLength; i++ {
+		bits, err := b.gen.Bitset(uint(i))
+		if err != nil {
+			return err
+		}
+		if err := WriteBloomBits(batch, uint(i), b.section, b.head, bitutil.CompressBytes(bits)); err != nil {
+			return err
+		}
+	}
+	return batch.Write()
+}
@@ -0,0 +1,89 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package bloombits
+
+import (
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/eth-classic/go-ethereum/core/types"
+)
+
+// Tests that batched bloom bits are correctly rotated from the input bloom
+// filters.
+func TestGenerator(t *testing.T) {
+	// Generate a full section of random input blooms
+	var input [BloomBitLength / 8]types.Bloom
+	for i := range input {
+		rand.Read(input[i][:])
+	}
+	// Crunch the input through the generator and verify the result
+	gen, err := NewGenerator(uint(len(input)))
+	if err != nil {
+		t.Fatalf("failed to create bloombit generator: %v", err)
+	}
+	if _, err := gen.Bitset(0); err == nil {
+		t.Errorf("bits retrieved before the section was generated")
+	}
+	for i, bloom := range input {
+		if err := gen.AddBloom(uint(i), bloom); err != nil {
+			t.Fatalf("bloom %d: failed to add: %v", i, err)
+		}
+	}
+	for j := 0; j < BloomBitLength; j++ {
+		bits, err := gen.Bitset(uint(j))
+		if err != nil {
+			t.Fatalf("bit %d: failed to retrieve bits: %v", j, err)
+		}
+		for i, bloom := range input {
+			want := bloom[len(bloom)-1-j/8]&(1<<byte(j%8)) != 0
+			if have := bits[i/8]&(1<<byte(7-i%8)) != 0; have != want {
+				t.Fatalf("bit %d, bloom %d: bit mismatch: have %v, want %v", j, i, have, want)
+			}
+		}
+	}
+	if _, err := gen.Bitset(BloomBitLength); err != errBloomBitOutOfBounds {
+		t.Errorf("out of bounds bit error mismatch: have %v, want %v", err, errBloomBitOutOfBounds)
+	}
+}
+
+// Tests that the rotated bits of a bloom match the bit numbering of the keys
+// added to it.
+func TestGeneratorBitNumbering(t *testing.T) {
+	var bloom types.Bloom
+	bloom.Add(new(big.Int).SetBytes([]byte("key")))
+
+	gen, _ := NewGenerator(8)
+	for i := uint(0); i < 8; i++ {
+		b := types.Bloom{}
+		if i == 3 {
+			b = bloom
+		}
+		if err := gen.AddBloom(i, b); err != nil {
+			t.Fatalf("bloom %d: failed to add: %v", i, err)
+		}
+	}
+	if err := gen.AddBloom(8, bloom); err != errSectionOutOfBounds {
+		t.Errorf("overflowing bloom error mismatch: have %v, want %v", err, errSectionOutOfBounds)
+	}
+	for _, bit := range calcBloomIndexes([]byte("key")) {
+		if bits, _ := gen.Bitset(bit); bits[0] != 1<<4 {
+			t.Errorf("bit %d: vector mismatch: have %08b, want %08b", bit, bits[0], 1<<4)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package bloombits
+
+import (
+	"fmt"
+
+	"github.com/eth-classic/go-ethereum/crypto"
+)
+
+// bloomIndexes represents the bit indexes inside the bloom filter that belong
+// to some key.
+type bloomIndexes [3]uint
+
+// calcBloomIndexes returns the bloom filter bit indexes belonging to the given
+// key, numbered the same way as the bits set by types.Bloom.Add.
+func calcBloomIndexes(b []byte) bloomIndexes {
+	b = crypto.Keccak256(b)
+
+	var idxs bloomIndexes
+	for i := 0; i < len(idxs); i++ {
+		idxs[i] = (uint(b[2*i])<<8 | uint(b[2*i+1])) & (BloomBitLength - 1)
+	}
+	return idxs
+}
+
+// Matcher matches the bloom bit vectors of a section against a filter of
+// address and topic criteria, yielding the blocks possibly containing logs
+// matching it.
+type Matcher struct {
+	sectionSize uint64 // Number of blocks of a section
+
+	filters [][]bloomIndexes // Filter the system is matching for
+}
+
+// NewMatcher creates a new matcher for the given filter. Each entry of filters
+// is a list of alternatives of which at least one has to be present in a
+// matching block, and all entries have to be matched. Empty entries and those
+// with a nil alternative match any block and are skipped.
+func NewMatcher(sectionSize uint64, filters [][][]byte) *Matcher {
+	m := &Matcher{sectionSize: sectionSize}
+	for _, filter := range filters {
+		if len(filter) == 0 {
+			continue
+		}
+		bloomBits := make([]bloomIndexes, len(filter))
+		for i, clause := range filter {
+			if clause == nil {
+				bloomBits = nil
+				break
+			}
+			bloomBits[i] = calcBloomIndexes(clause)
+		}
+		if bloomBits != nil {
+			m.filters = append(m.filters, bloomBits)
+		}
+	}
+	return m
+}
+
+// Empty returns whether the matcher has no criteria, matching any block.
+func (m *Matcher) Empty() bool {
+	return len(m.filters) == 0
+}
+
+// Match retrieves the bit vectors needed by the filter for a section using
+// fetch and returns the bitset of the section's blocks possibly matching it,
+// the first block being the most significant bit of the first byte. Each bit
+// vector is fetched at most once, and none after the match is known empty.
+func (m *Matcher) Match(fetch func(bit uint) ([]byte, error)) ([]byte, error) {
+	var (
+		size  = int(m.sectionSize / 8)
+		cache = make(map[uint][]byte)
+	)
+	result := make([]byte, size)
+	for i := range result {
+		result[i] = 0xff
+	}
+	for _, filter := range m.filters {
+		// Any of the alternatives may match, each needing all of its bits set
+		matches := make([]byte, size)
+		for _, clause := range filter {
+			and := make([]byte, size)
+			copy(and, result)
+
+			for _, bit := range clause {
+				vector, ok := cache[bit]
+				if !ok {
+					var err error
+					if vector, err = fetch(bit); err != nil {
+						return nil, err
+					}
+					if len(vector) != size {
+						return nil, fmt.Errorf("bloom bit %d vector length mismatch: have %d, want %d", bit, len(vector), size)
+					}
+					cache[bit] = vector
+				}
+				for i := range and {
+					and[i] &= vector[i]
+				}
+			}
+			for i := range matches {
+				matches[i] |= and[i]
+			}
+		}
+		result = matches
+
+		// Stop retrieving bits once no block can match anymore
+		empty := true
+		for _, b := range result {
+			if b != 0 {
+				empty = false
+				break
+			}
+		}
+		if empty {
+			break
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,104 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package bloombits
+
+import (
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/eth-classic/go-ethereum/core/types"
+)
+
+// Tests that the matcher finds the blocks of a section whose blooms contain the
+// filtered keys, retrieving each needed bit vector once.
+func TestMatcher(t *testing.T) {
+	// Generate a section with a few blocks logging some keys
+	contents := map[uint][]string{
+		3:  {"addr1", "topic1"},
+		9:  {"addr2", "topic2"},
+		12: {"addr1", "topic2"},
+	}
+	gen, _ := NewGenerator(16)
+	for i := uint(0); i < 16; i++ {
+		var bloom types.Bloom
+		for _, key := range contents[i] {
+			bloom.Add(new(big.Int).SetBytes([]byte(key)))
+		}
+		gen.AddBloom(i, bloom)
+	}
+	tests := []struct {
+		filters [][][]byte
+		blocks  []int
+	}{
+		{[][][]byte{{[]byte("addr1")}}, []int{3, 12}},
+		{[][][]byte{{[]byte("addr1"), []byte("addr2")}}, []int{3, 9, 12}},
+		{[][][]byte{{[]byte("addr1")}, {[]byte("topic2")}}, []int{12}},
+		{[][][]byte{{[]byte("addr1")}, {nil, []byte("topic3")}}, []int{3, 12}},
+		{[][][]byte{{[]byte("addr3")}, {[]byte("topic1")}}, nil},
+		{[][][]byte{nil, {[]byte("topic1")}}, []int{3}},
+	}
+	for i, tt := range tests {
+		fetched := make(map[uint]int)
+		fetch := func(bit uint) ([]byte, error) {
+			fetched[bit]++
+			return gen.Bitset(bit)
+		}
+		matches, err := NewMatcher(16, tt.filters).Match(fetch)
+		if err != nil {
+			t.Errorf("test %d: failed to match: %v", i, err)
+			continue
+		}
+		var blocks []int
+		for j := 0; j < 16; j++ {
+			if matches[j/8]&(1<<byte(7-j%8)) != 0 {
+				blocks = append(blocks, j)
+			}
+		}
+		if !reflect.DeepEqual(blocks, tt.blocks) {
+			t.Errorf("test %d: matched blocks mismatch: have %v, want %v", i, blocks, tt.blocks)
+		}
+		for bit, n := range fetched {
+			if n > 1 {
+				t.Errorf("test %d: bit %d fetched %d times", i, bit, n)
+			}
+		}
+	}
+}
+
+// Tests that a matcher without criteria matches all blocks without retrieving
+// any bits, and that retrieval failures are reported.
+func TestMatcherEmptyAndFailures(t *testing.T) {
+	failure := errors.New("missing")
+	fetch := func(bit uint) ([]byte, error) { return nil, failure }
+
+	matcher := NewMatcher(16, [][][]byte{nil, {[]byte("addr"), nil}})
+	if !matcher.Empty() {
+		t.Fatalf("wildcard matcher not empty")
+	}
+	if matches, err := matcher.Match(fetch); err != nil || !reflect.DeepEqual(matches, []byte{0xff, 0xff}) {
+		t.Errorf("empty matcher mismatch: have %x, %v, want ffff", matches, err)
+	}
+	if _, err := NewMatcher(16, [][][]byte{{[]byte("addr")}}).Match(fetch); err != failure {
+		t.Errorf("failure mismatch: have %v, want %v", err, failure)
+	}
+	short := func(bit uint) ([]byte, error) { return []byte{0xff}, nil }
+	if _, err := NewMatcher(16, [][][]byte{{[]byte("addr")}}).Match(short); err == nil {
+		t.Errorf("short bit vector accepted")
+	}
+}
@@ -0,0 +1,197 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"encoding/binary"
+	"fmt"
+	"time"
+
+	"github.com/eth-classic/go-ethereum/common"
+	"github.com/eth-classic/go-ethereum/ethdb"
+	"github.com/eth-classic/go-ethereum/logger"
+	"github.com/eth-classic/go-ethereum/logger/glog"
+	"github.com/eth-classic/go-ethereum/rlp"
+)
+
+const (
+	freezerRecheckInterval = time.Minute // Time between two checks for blocks to move to the ancient store
+	freezerBatchLimit      = 30000       // Maximum number of blocks moved to the ancient store in one go
+)
+
+// freeze periodically moves the canonical blocks older than the ancient
+// threshold out of the chain database into its ancient store.
+func (bc *BlockChain) freeze(store ethdb.AncientStore) {
+	defer bc.wg.Done()
+
+	ticker := time.NewTicker(freezerRecheckInterval)
+	defer ticker.Stop()
+
+	for {
+		for {
+			bc.freezeMu.Lock()
+			var (
+				head   = bc.CurrentBlock().NumberU64()
+				frozen int
+				err    error
+			)
+			if head > bc.cacheConfig.AncientThreshold {
+				start := time.Now()
+				if frozen, err = freezeAncients(bc.chainDb, store, head-bc.cacheConfig.AncientThreshold, bc.quit); frozen > 0 {
+					glog.V(logger.Info).Infof("Moved blocks to the ancient store: count=%d ancients=%d elapsed=%v", frozen, store.Ancients(), time.Since(start))
+				}
+			}
+			bc.freezeMu.Unlock()
+
+			if err != nil {
+				glog.V(logger.Error).Errorf("Failed to move blocks to the ancient store: %v", err)
+			}
+			if err != nil || frozen < freezerBatchLimit {
+				break
+			}
+		}
+		select {
+		case <-bc.quit:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// freezeAncients moves the canonical blocks below limit out of the database
+// into its ancient store, at most freezerBatchLimit of them or until quit is
+// closed. The blocks are synced to the ancient store before being removed from
+// the database, so they stay readable throughout. The number of blocks moved
+// is returned.
+func freezeAncients(db ethdb.Database, store ethdb.AncientStore, limit uint64, quit <-chan struct{}) (int, error) {
+	frozen := store.Ancients()
+
+	var err error
+loop:
+	for number := frozen; number < limit && number-frozen < freezerBatchLimit; number++ {
+		select {
+		case <-quit:
+			break loop
+		default:
+		}
+		hash := GetCanonicalHash(db, number)
+		if hash == (common.Hash{}) {
+			err = fmt.Errorf("canonical hash #%d missing", number)
+			break
+		}
+		header, body, td := GetHeaderRLP(db, hash), GetBodyRLP(db, hash), getTdRLP(db, hash)
+		if len(header) == 0 || len(body) == 0 || len(td) == 0 {
+			err = fmt.Errorf("block #%d [%x…] incomplete", number, hash[:4])
+			break
+		}
+		// Receipts are left out for blocks without transactions
+		receipts := getBlockReceiptsRLP(db, hash)
+		if len(receipts) == 0 {
+			receipts = rlp.EmptyList
+		}
+		if err = store.AppendAncient(number, hash[:], header, body, receipts, td); err != nil {
+			break
+		}
+	}
+	// Whatever made it into the ancient store may be removed from the database
+	if serr := store.Sync(); serr != nil {
+		return 0, serr
+	}
+	if rerr := removeAncients(db, store); rerr != nil {
+		return 0, rerr
+	}
+	return int(store.Ancients() - frozen), err
+}
+
+// removeAncients removes the blocks moved to the ancient store from the
+// database, all but the genesis block. Their numbers are kept in the database
+// to find them by hash.
+func removeAncients(db ethdb.Database, store ethdb.AncientStore) error {
+	tail, frozen := getAncientTail(db), store.Ancients()
+	if tail >= frozen {
+		return nil
+	}
+	hashes := make([]common.Hash, 0, frozen-tail)
+	for number := tail; number < frozen; number++ {
+		blob, err := store.Ancient(ethdb.AncientHashes, number)
+		if err != nil {
+			return err
+		}
+		hashes = append(hashes, common.BytesToHash(blob))
+	}
+	// Index the moved blocks by hash before removing them
+	batch := db.NewBatch()
+	for i, hash := range hashes {
+		enc := make([]byte, 8)
+		binary.BigEndian.PutUint64(enc, tail+uint64(i))
+		if err := batch.Put(append(ancientPrefix, hash[:]...), enc); err != nil {
+			return err
+		}
+		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch = db.NewBatch()
+		}
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	for i, hash := range hashes {
+		if number := tail + uint64(i); number > 0 {
+			DeleteCanonicalHash(db, number)
+			DeleteHeader(db, hash)
+			DeleteBody(db, hash)
+			DeleteTd(db, hash)
+			DeleteBlockReceipts(db, hash)
+		}
+	}
+	return writeAncientTail(db, frozen)
+}
+
+// truncateAncients discards the blocks above the given number of blocks from
+// the ancient store, when rewinding the chain below it.
+func truncateAncients(db ethdb.Database, store ethdb.AncientStore, items uint64) error {
+	if items >= store.Ancients() {
+		return nil
+	}
+	if err := store.TruncateAncients(items); err != nil {
+		return err
+	}
+	if getAncientTail(db) > items {
+		return writeAncientTail(db, items)
+	}
+	return nil
+}
+
+// getAncientTail retrieves the number of blocks moved to the ancient store and
+// removed from the database.
+func getAncientTail(db ethdb.Database) uint64 {
+	data, _ := db.Get(ancientTailKey)
+	if len(data) != 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(data)
+}
+
+// writeAncientTail stores the number of blocks moved to the ancient store and
+// removed from the database.
+func writeAncientTail(db ethdb.Database, tail uint64) error {
+	enc := make([]byte, 8)
+	binary.BigEndian.PutUint64(enc, tail)
+	return db.Put(ancientTailKey, enc)
+}
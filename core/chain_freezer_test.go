@@ -0,0 +1,173 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/eth-classic/go-ethereum/common"
+	"github.com/eth-classic/go-ethereum/core/types"
+	"github.com/eth-classic/go-ethereum/ethdb"
+	"github.com/eth-classic/go-ethereum/event"
+)
+
+// openFreezerDatabase opens a database with an ancient store in dir.
+func openFreezerDatabase(t *testing.T, dir string) *ethdb.LDBDatabase {
+	db, err := ethdb.NewLDBDatabaseWithFreezer(filepath.Join(dir, "chaindata"), filepath.Join(dir, "chaindata", "ancient"), 0, 0)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	return db
+}
+
+// freezerBlock is everything the accessors return for a canonical block.
+type freezerBlock struct {
+	Hash     common.Hash
+	Header   *types.Header
+	Body     *types.Body
+	Td       *big.Int
+	Receipts types.Receipts
+}
+
+// readFreezerBlock reads a canonical block through the accessors.
+func readFreezerBlock(db ethdb.Database, number uint64) *freezerBlock {
+	hash := GetCanonicalHash(db, number)
+	return &freezerBlock{
+		Hash:     hash,
+		Header:   GetHeader(db, hash),
+		Body:     GetBody(db, hash),
+		Td:       GetTd(db, hash),
+		Receipts: GetBlockReceipts(db, hash),
+	}
+}
+
+func TestFreezeAncients(t *testing.T) {
+	dir, err := ioutil.TempDir("", "freezer-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Import a chain with transactions into a database with an ancient store
+	db := openFreezerDatabase(t, dir)
+	var (
+		address = verifyAddress
+		funds   = big.NewInt(1000000000000000000)
+		signer  = types.NewChainIdSigner(big.NewInt(63))
+		config  = MakeDiehardChainConfig()
+	)
+	genesis := WriteGenesisBlockForTesting(db, GenesisAccount{address, funds})
+	blocks, _ := GenerateChain(config, genesis, db, 16, func(i int, block *BlockGen) {
+		if i%2 == 0 {
+			tx := types.NewTransaction(block.TxNonce(address), common.Address{0x01}, big.NewInt(1000), TxGas, new(big.Int), nil)
+			tx, err := tx.WithSigner(signer).SignECDSA(verifyKey)
+			if err != nil {
+				panic(err)
+			}
+			block.AddTx(tx)
+		}
+	})
+	chain, err := NewBlockChain(db, config, FakePow{}, new(event.TypeMux))
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	if res := chain.InsertChain(blocks); res.Error != nil {
+		t.Fatalf("failed to import block %d: %v", res.Index, res.Error)
+	}
+	chain.Stop()
+
+	want := make([]*freezerBlock, len(blocks)+1)
+	for number := range want {
+		want[number] = readFreezerBlock(db, uint64(number))
+	}
+	check := func(stage string, db ethdb.Database, frozen uint64) {
+		for number := range want {
+			if have := readFreezerBlock(db, uint64(number)); !reflect.DeepEqual(have, want[number]) {
+				t.Errorf("%s: block #%d mismatch: have %+v, want %+v", stage, number, have, want[number])
+			}
+		}
+		// Moved blocks are gone from the database itself, except for the genesis
+		for number := uint64(0); number < uint64(len(want)); number++ {
+			hash := want[number].Hash
+			_, err := db.Get(append(append(blockPrefix, hash[:]...), headerSuffix...))
+			if removed := number > 0 && number < frozen; removed != (err != nil) {
+				t.Errorf("%s: block #%d header in database: %v, want %v", stage, number, err == nil, !removed)
+			}
+		}
+	}
+	// Move the blocks in two steps, checking they remain readable
+	store := db.AncientStore()
+	for _, limit := range []uint64{6, 10} {
+		frozen := store.Ancients()
+		if n, err := freezeAncients(db, store, limit, nil); err != nil {
+			t.Fatalf("failed to freeze blocks below #%d: %v", limit, err)
+		} else if n != int(limit-frozen) {
+			t.Errorf("frozen block count mismatch: have %d, want %d", n, limit-frozen)
+		}
+		if store.Ancients() != limit {
+			t.Errorf("ancient count mismatch: have %d, want %d", store.Ancients(), limit)
+		}
+		check("frozen", db, limit)
+	}
+	// Reopen the database, the ancient store and the block numbers persisting
+	db.Close()
+	db = openFreezerDatabase(t, dir)
+	defer db.Close()
+
+	if n := db.AncientStore().Ancients(); n != 10 {
+		t.Fatalf("reopened ancient count mismatch: have %d, want 10", n)
+	}
+	check("reopened", db, 10)
+
+	// Rewind the chain into the ancient store, discarding the frozen blocks above the head
+	chain, err = NewBlockChain(db, config, FakePow{}, new(event.TypeMux))
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	defer chain.Stop()
+
+	if err := chain.SetHead(6); err != nil {
+		t.Fatalf("failed to rewind chain: %v", err)
+	}
+	if n := db.AncientStore().Ancients(); n != 7 {
+		t.Errorf("rewound ancient count mismatch: have %d, want 7", n)
+	}
+	if head := chain.CurrentBlock(); head.Hash() != want[6].Hash {
+		t.Errorf("rewound head mismatch: have #%d [%x], want #6 [%x]", head.Number(), head.Hash(), want[6].Hash)
+	}
+	for number := uint64(7); number < 10; number++ {
+		if hash := GetCanonicalHash(db, number); hash != (common.Hash{}) {
+			t.Errorf("rewound block #%d still canonical: %x", number, hash)
+		}
+		if header := GetHeader(db, want[number].Hash); header != nil {
+			t.Errorf("rewound block #%d header still present", number)
+		}
+	}
+	// The rewound blocks can be reimported and moved again
+	if res := chain.InsertChain(blocks[6:]); res.Error != nil {
+		t.Fatalf("failed to reimport block %d: %v", res.Index, res.Error)
+	}
+	if _, err := freezeAncients(db, db.AncientStore(), 12, nil); err != nil {
+		t.Fatalf("failed to freeze reimported blocks: %v", err)
+	}
+	check("reimported", db, 12)
+}
@@ -0,0 +1,349 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/eth-classic/go-ethereum/common"
+	"github.com/eth-classic/go-ethereum/core/types"
+	"github.com/eth-classic/go-ethereum/ethdb"
+	"github.com/eth-classic/go-ethereum/event"
+	"github.com/eth-classic/go-ethereum/logger"
+	"github.com/eth-classic/go-ethereum/logger/glog"
+)
+
+// chainIndexerRetryDelay is the time to wait before processing a section again
+// after a failure.
+const chainIndexerRetryDelay = 5 * time.Second
+
+// ChainIndexerBackend processes the headers of a chain section into an index.
+type ChainIndexerBackend interface {
+	// Reset starts processing a new section, following the last block of the
+	// previous one.
+	Reset(section uint64, prevHead common.Hash) error
+
+	// Process adds the next header of the section to the index.
+	Process(header *types.Header) error
+
+	// Commit writes the index of the completed section into the database.
+	Commit() error
+}
+
+// ChainIndexer builds an index over fixed size sections of the canonical chain
+// in the background. A section is processed once the chain head is confirmsReq
+// blocks past it, and is invalidated and processed again if a reorg replaces
+// any of its blocks. The progress is stored in indexDb: the number of valid
+// sections and the hash of the last block of each.
+type ChainIndexer struct {
+	chainDb ethdb.Database      // Chain database to index the headers of
+	indexDb ethdb.Database      // Database the indexing progress is stored in
+	backend ChainIndexerBackend // Processor of the section headers into an index
+	kind    string              // Name of the index, for logging
+
+	sectionSize uint64 // Number of blocks in a section
+	confirmsReq uint64 // Number of blocks past a section before processing it
+
+	storedSections uint64 // Number of sections indexed into the database
+	knownSections  uint64 // Number of sections confirmed by the chain head
+
+	update chan struct{}
+	quit   chan struct{}
+	wg     sync.WaitGroup
+	lock   sync.Mutex
+}
+
+// NewChainIndexer creates a chain indexer processing the sections of the chain
+// with backend. It does nothing until started.
+func NewChainIndexer(chainDb, indexDb ethdb.Database, backend ChainIndexerBackend, sectionSize, confirmsReq uint64, kind string) *ChainIndexer {
+	c := &ChainIndexer{
+		chainDb:     chainDb,
+		indexDb:     indexDb,
+		backend:     backend,
+		kind:        kind,
+		sectionSize: sectionSize,
+		confirmsReq: confirmsReq,
+		update:      make(chan struct{}, 1),
+		quit:        make(chan struct{}),
+	}
+	data, _ := indexDb.Get([]byte("count"))
+	if len(data) == 8 {
+		c.storedSections = binary.BigEndian.Uint64(data)
+	}
+	return c
+}
+
+// Start begins indexing the chain up to head, following the chain head events
+// posted on mux. Sections no longer canonical, e.g. after a rewind of the
+// chain, are invalidated first.
+func (c *ChainIndexer) Start(head *types.Header, mux *event.TypeMux) {
+	c.lock.Lock()
+	for c.storedSections > 0 {
+		last := c.storedSections*c.sectionSize - 1
+		if c.SectionHead(c.storedSections-1) == GetCanonicalHash(c.chainDb, last) {
+			break
+		}
+		c.setValidSections(c.storedSections - 1)
+	}
+	c.lock.Unlock()
+
+	sub := mux.Subscribe(ChainHeadEvent{})
+	c.wg.Add(2)
+	go c.eventLoop(head, sub)
+	go c.updateLoop()
+}
+
+// Close stops the indexer, waiting for the section being processed, if any.
+func (c *ChainIndexer) Close() {
+	close(c.quit)
+	c.wg.Wait()
+}
+
+// Sections returns the number of indexed sections and the hash of the last
+// block of the last one.
+func (c *ChainIndexer) Sections() (uint64, common.Hash) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.storedSections == 0 {
+		return 0, common.Hash{}
+	}
+	return c.storedSections, c.SectionHead(c.storedSections - 1)
+}
+
+// eventLoop follows the chain head, rolling back the sections replaced by
+// reorgs and signalling the newly confirmed ones to updateLoop.
+func (c *ChainIndexer) eventLoop(head *types.Header, sub event.Subscription) {
+	defer c.wg.Done()
+	defer sub.Unsubscribe()
+
+	c.newHead(head.Number.Uint64(), false)
+	prev := head
+
+	for {
+		select {
+		case <-c.quit:
+			return
+
+		case ev, ok := <-sub.Chan():
+			if !ok {
+				return
+			}
+			block := ev.Data.(ChainHeadEvent).Block
+			if block == nil {
+				continue
+			}
+			header := block.Header()
+
+			// Roll back to the common ancestor if the previous head was reorged
+			if header.ParentHash != prev.Hash() && GetCanonicalHash(c.chainDb, prev.Number.Uint64()) != prev.Hash() {
+				if ancestor := findCommonAncestor(c.chainDb, prev, header); ancestor != nil {
+					c.newHead(ancestor.Number.Uint64(), true)
+				} else {
+					glog.V(logger.Warn).Infof("%s indexer: no common ancestor of #%d [%x…] and #%d [%x…], reindexing", c.kind, prev.Number, prev.Hash().Bytes()[:4], header.Number, header.Hash().Bytes()[:4])
+					c.newHead(0, true)
+				}
+			}
+			c.newHead(header.Number.Uint64(), false)
+			prev = header
+		}
+	}
+}
+
+// newHead updates the number of sections confirmed by a new chain head. After
+// a reorg, head is the common ancestor and the sections past it are dropped.
+func (c *ChainIndexer) newHead(head uint64, reorg bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if reorg {
+		valid := (head + 1) / c.sectionSize
+		if valid < c.knownSections {
+			c.knownSections = valid
+		}
+		if valid < c.storedSections {
+			c.setValidSections(valid)
+		}
+		return
+	}
+	if head+1 < c.confirmsReq {
+		return
+	}
+	if sections := (head + 1 - c.confirmsReq) / c.sectionSize; sections > c.knownSections {
+		c.knownSections = sections
+		c.signal()
+	}
+}
+
+// signal wakes updateLoop up, if not already signalled.
+func (c *ChainIndexer) signal() {
+	select {
+	case c.update <- struct{}{}:
+	default:
+	}
+}
+
+// updateLoop processes the confirmed sections one by one, in order.
+func (c *ChainIndexer) updateLoop() {
+	defer c.wg.Done()
+
+	var (
+		start  = time.Now()
+		logged = time.Now()
+	)
+	for {
+		select {
+		case <-c.quit:
+			return
+
+		case <-c.update:
+			c.lock.Lock()
+			if c.knownSections <= c.storedSections {
+				c.lock.Unlock()
+				continue
+			}
+			section, known := c.storedSections, c.knownSections
+			var prevHead common.Hash
+			if section > 0 {
+				prevHead = c.SectionHead(section - 1)
+			}
+			c.lock.Unlock()
+
+			head, err := c.processSection(section, prevHead)
+
+			c.lock.Lock()
+			if err == nil {
+				// Store the section unless reorged meanwhile
+				if section == c.storedSections && section < c.knownSections && (section == 0 || c.SectionHead(section-1) == prevHead) {
+					c.setSectionHead(section, head)
+					c.setValidSections(section + 1)
+				}
+				if c.knownSections > c.storedSections {
+					c.signal()
+				}
+			}
+			c.lock.Unlock()
+
+			if err != nil {
+				glog.V(logger.Warn).Infof("%s indexer: section %d failed: %v", c.kind, section, err)
+				select {
+				case <-c.quit:
+					return
+				case <-time.After(chainIndexerRetryDelay):
+					c.signal()
+				}
+				continue
+			}
+			glog.V(logger.Detail).Infof("%s indexer: processed section %d [%x…]", c.kind, section, head.Bytes()[:4])
+			if time.Since(logged) > inspectLogInterval || section+1 == known {
+				glog.V(logger.Info).Infof("%s indexer: sections=%d/%d elapsed=%v", c.kind, section+1, known, time.Since(start))
+				logged = time.Now()
+			}
+		}
+	}
+}
+
+// processSection indexes the headers of a section, following the last block of
+// the previous section, and returns the hash of its last block.
+func (c *ChainIndexer) processSection(section uint64, prevHead common.Hash) (common.Hash, error) {
+	if err := c.backend.Reset(section, prevHead); err != nil {
+		return common.Hash{}, err
+	}
+	for number := section * c.sectionSize; number < (section+1)*c.sectionSize; number++ {
+		hash := GetCanonicalHash(c.chainDb, number)
+		if hash == (common.Hash{}) {
+			return common.Hash{}, fmt.Errorf("canonical block #%d unknown", number)
+		}
+		header := GetHeader(c.chainDb, hash)
+		if header == nil {
+			return common.Hash{}, fmt.Errorf("block #%d [%x…] not found", number, hash.Bytes()[:4])
+		}
+		if header.ParentHash != prevHead {
+			return common.Hash{}, fmt.Errorf("chain reorged during section processing")
+		}
+		if err := c.backend.Process(header); err != nil {
+			return common.Hash{}, err
+		}
+		prevHead = hash
+	}
+	if err := c.backend.Commit(); err != nil {
+		return common.Hash{}, err
+	}
+	return prevHead, nil
+}
+
+// SectionHead returns the hash of the last block of an indexed section.
+func (c *ChainIndexer) SectionHead(section uint64) common.Hash {
+	var key [8]byte
+	binary.BigEndian.PutUint64(key[:], section)
+
+	data, _ := c.indexDb.Get(append([]byte("shead"), key[:]...))
+	if len(data) != common.HashLength {
+		return common.Hash{}
+	}
+	return common.BytesToHash(data)
+}
+
+// setSectionHead stores the hash of the last block of an indexed section.
+func (c *ChainIndexer) setSectionHead(section uint64, hash common.Hash) {
+	var key [8]byte
+	binary.BigEndian.PutUint64(key[:], section)
+
+	c.indexDb.Put(append([]byte("shead"), key[:]...), hash.Bytes())
+}
+
+// setValidSections stores the number of indexed sections, dropping the heads
+// of the sections past it.
+func (c *ChainIndexer) setValidSections(sections uint64) {
+	var data [8]byte
+	binary.BigEndian.PutUint64(data[:], sections)
+	c.indexDb.Put([]byte("count"), data[:])
+
+	for section := sections; section < c.storedSections; section++ {
+		var key [8]byte
+		binary.BigEndian.PutUint64(key[:], section)
+		c.indexDb.Delete(append([]byte("shead"), key[:]...))
+	}
+	c.storedSections = sections
+}
+
+// findCommonAncestor returns the last block shared by the chains of the given
+// headers, nil if any of their ancestors is missing.
+func findCommonAncestor(db ethdb.Database, a, b *types.Header) *types.Header {
+	for a.Number.Cmp(b.Number) > 0 {
+		if a = GetHeader(db, a.ParentHash); a == nil {
+			return nil
+		}
+	}
+	for b.Number.Cmp(a.Number) > 0 {
+		if b = GetHeader(db, b.ParentHash); b == nil {
+			return nil
+		}
+	}
+	for a.Hash() != b.Hash() {
+		if a = GetHeader(db, a.ParentHash); a == nil {
+			return nil
+		}
+		if b = GetHeader(db, b.ParentHash); b == nil {
+			return nil
+		}
+	}
+	return a
+}
@@ -0,0 +1,208 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/eth-classic/go-ethereum/common"
+	"github.com/eth-classic/go-ethereum/common/bitutil"
+	"github.com/eth-classic/go-ethereum/core/bloombits"
+	"github.com/eth-classic/go-ethereum/core/types"
+	"github.com/eth-classic/go-ethereum/ethdb"
+	"github.com/eth-classic/go-ethereum/event"
+)
+
+// testIndexerBackend counts the sections it processed.
+type testIndexerBackend struct {
+	lock   sync.Mutex
+	resets int
+}
+
+func (b *testIndexerBackend) Reset(section uint64, prevHead common.Hash) error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	b.resets++
+	return nil
+}
+
+func (b *testIndexerBackend) Process(header *types.Header) error { return nil }
+func (b *testIndexerBackend) Commit() error                      { return nil }
+
+func (b *testIndexerBackend) processed() int {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	return b.resets
+}
+
+// writeIndexerChain stores a canonical chain of headers with the given blooms
+// after parent, returning the headers.
+func writeIndexerChain(db ethdb.Database, parent *types.Header, n int, seed byte, blooms map[uint64]types.Bloom) []*types.Header {
+	var headers []*types.Header
+	for i := 0; i < n; i++ {
+		header := &types.Header{Number: big.NewInt(0), Extra: []byte{seed}, Difficulty: big.NewInt(1)}
+		if parent != nil {
+			header.ParentHash = parent.Hash()
+			header.Number = new(big.Int).Add(parent.Number, big.NewInt(1))
+		}
+		header.Bloom = blooms[header.Number.Uint64()]
+
+		WriteHeader(db, header)
+		WriteCanonicalHash(db, header.Hash(), header.Number.Uint64())
+		headers = append(headers, header)
+		parent = header
+	}
+	return headers
+}
+
+// waitSections waits for the indexer to store the given number of sections.
+func waitSections(t *testing.T, indexer *ChainIndexer, sections uint64) {
+	for start := time.Now(); time.Since(start) < 5*time.Second; time.Sleep(10 * time.Millisecond) {
+		if stored, _ := indexer.Sections(); stored == sections {
+			return
+		}
+	}
+	stored, _ := indexer.Sections()
+	t.Fatalf("indexed sections mismatch: have %d, want %d", stored, sections)
+}
+
+func TestChainIndexer(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	headers := writeIndexerChain(db, nil, 20, 1, nil)
+
+	// Index the confirmed sections of the chain
+	var (
+		mux     = new(event.TypeMux)
+		backend = new(testIndexerBackend)
+		indexer = NewChainIndexer(db, ethdb.NewTable(db, "test-"), backend, 4, 2, "test")
+	)
+	indexer.Start(headers[19], mux)
+	waitSections(t, indexer, 4)
+
+	// Extend the chain, indexing the newly confirmed sections
+	headers = append(headers, writeIndexerChain(db, headers[19], 6, 1, nil)...)
+	mux.Post(ChainHeadEvent{types.NewBlockWithHeader(headers[25])})
+	waitSections(t, indexer, 6)
+
+	for section := uint64(0); section < 6; section++ {
+		if head := indexer.SectionHead(section); head != headers[4*section+3].Hash() {
+			t.Errorf("section %d: head mismatch: have %x, want %x", section, head, headers[4*section+3].Hash())
+		}
+	}
+	// Reorg the chain after block #9, reindexing the sections after it
+	fork := append(headers[:10:10], writeIndexerChain(db, headers[9], 18, 2, nil)...)
+	processed := backend.processed()
+
+	mux.Post(ChainHeadEvent{types.NewBlockWithHeader(fork[27])})
+	waitSections(t, indexer, 6)
+	for start := time.Now(); indexer.SectionHead(5) != fork[23].Hash() && time.Since(start) < 5*time.Second; {
+		time.Sleep(10 * time.Millisecond)
+	}
+	for section := uint64(0); section < 6; section++ {
+		if head := indexer.SectionHead(section); head != fork[4*section+3].Hash() {
+			t.Errorf("section %d: reorged head mismatch: have %x, want %x", section, head, fork[4*section+3].Hash())
+		}
+	}
+	if reindexed := backend.processed() - processed; reindexed != 4 {
+		t.Errorf("reindexed sections mismatch: have %d, want 4", reindexed)
+	}
+	indexer.Close()
+
+	// Rewind the chain, reopening the indexer drops the sections past the head
+	for number := uint64(16); number < 28; number++ {
+		DeleteCanonicalHash(db, number)
+	}
+	indexer = NewChainIndexer(db, ethdb.NewTable(db, "test-"), backend, 4, 2, "test")
+	if stored, _ := indexer.Sections(); stored != 6 {
+		t.Errorf("persisted sections mismatch: have %d, want 6", stored)
+	}
+	indexer.Start(fork[15], mux)
+	defer indexer.Close()
+
+	if stored, head := indexer.Sections(); stored != 4 || head != fork[15].Hash() {
+		t.Errorf("rewound sections mismatch: have %d [%x], want 4 [%x]", stored, head, fork[15].Hash())
+	}
+}
+
+func TestBloomIndexer(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+
+	// Create a chain with a few blocks logging some addresses
+	logged := map[uint64][]string{3: {"addr1"}, 9: {"addr1", "addr2"}, 20: {"addr2"}}
+	blooms := make(map[uint64]types.Bloom)
+	for number, addrs := range logged {
+		var bloom types.Bloom
+		for _, addr := range addrs {
+			bloom.Add(new(big.Int).SetBytes([]byte(addr)))
+		}
+		blooms[number] = bloom
+	}
+	headers := writeIndexerChain(db, nil, 34, 1, blooms)
+
+	indexer := newBloomIndexer(db, 16, 2)
+	indexer.Start(headers[33], new(event.TypeMux))
+	defer indexer.Close()
+
+	waitSections(t, indexer, 2)
+
+	// Match the sections using their bloom bits
+	tests := []struct {
+		addrs  []string
+		blocks []uint64
+	}{
+		{[]string{"addr1"}, []uint64{3, 9}},
+		{[]string{"addr2"}, []uint64{9, 20}},
+		{[]string{"addr1", "addr2"}, []uint64{3, 9, 20}},
+		{[]string{"addr3"}, nil},
+	}
+	for i, tt := range tests {
+		var filter [][]byte
+		for _, addr := range tt.addrs {
+			filter = append(filter, []byte(addr))
+		}
+		matcher := bloombits.NewMatcher(16, [][][]byte{filter})
+
+		var blocks []uint64
+		for section := uint64(0); section < 2; section++ {
+			head := GetCanonicalHash(db, 16*section+15)
+			matches, err := matcher.Match(func(bit uint) ([]byte, error) {
+				data, err := GetBloomBits(db, bit, section, head)
+				if err != nil {
+					return nil, err
+				}
+				return bitutil.DecompressBytes(data, 2)
+			})
+			if err != nil {
+				t.Fatalf("test %d, section %d: failed to match: %v", i, section, err)
+			}
+			for j := uint64(0); j < 16; j++ {
+				if matches[j/8]&(1<<byte(7-j%8)) != 0 {
+					blocks = append(blocks, 16*section+j)
+				}
+			}
+		}
+		if !reflect.DeepEqual(blocks, tt.blocks) {
+			t.Errorf("test %d: matched blocks mismatch: have %v, want %v", i, blocks, tt.blocks)
+		}
+	}
+}
@@ -0,0 +1,278 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/eth-classic/go-ethereum/common"
+	"github.com/eth-classic/go-ethereum/core/state"
+	"github.com/eth-classic/go-ethereum/core/types"
+	"github.com/eth-classic/go-ethereum/crypto"
+	"github.com/eth-classic/go-ethereum/ethdb"
+	"github.com/eth-classic/go-ethereum/logger"
+	"github.com/eth-classic/go-ethereum/logger/glog"
+	"github.com/eth-classic/go-ethereum/rlp"
+	"github.com/eth-classic/go-ethereum/trie"
+)
+
+// verifyLogInterval is the time between two progress reports of VerifyChain.
+const verifyLogInterval = 8 * time.Second
+
+// VerifyError is a missing or corrupted database entry found by VerifyChain.
+type VerifyError struct {
+	Number uint64      // Number of the canonical block the entry belongs to
+	Hash   common.Hash // Hash of the canonical block, zero if unknown
+	Msg    string      // Description of the missing or corrupted entry
+}
+
+func (e *VerifyError) Error() string {
+	return fmt.Sprintf("block #%d [%x…]: %s", e.Number, e.Hash[:4], e.Msg)
+}
+
+// chainVerifier holds the progress of a chain verification.
+type chainVerifier struct {
+	db     ethdb.Database
+	report func(*VerifyError)
+	issues int
+
+	started, logged time.Time
+}
+
+// VerifyChain walks the canonical chain from the genesis to the head block,
+// checking the header links, total difficulties, transaction, uncle and receipt
+// roots, and then the presence and integrity of every trie node and contract
+// code reachable from the state root of the head block. Every issue found is
+// passed to report; the number of issues is returned. An error is returned if
+// the head block itself can't be found.
+func VerifyChain(db ethdb.Database, report func(*VerifyError)) (int, error) {
+	head := GetHeader(db, GetHeadBlockHash(db))
+	if head == nil {
+		return 0, errors.New("head block not found")
+	}
+	v := &chainVerifier{db: db, report: report, started: time.Now(), logged: time.Now()}
+
+	var (
+		parent   *types.Header
+		parentTd *big.Int
+	)
+	for number := uint64(0); number <= head.Number.Uint64(); number++ {
+		hash := GetCanonicalHash(db, number)
+		if hash == (common.Hash{}) {
+			v.fail(number, hash, "missing canonical hash")
+			parent, parentTd = nil, nil
+			continue
+		}
+		parent, parentTd = v.verifyBlock(number, hash, parent, parentTd)
+
+		if time.Since(v.logged) > verifyLogInterval {
+			glog.V(logger.Info).Infof("Verifying chain: number=%d issues=%d elapsed=%v", number, v.issues, time.Since(v.started))
+			v.logged = time.Now()
+		}
+	}
+	v.verifyState(head.Number.Uint64(), head.Hash(), head.Root)
+
+	glog.V(logger.Info).Infof("Verified chain: head=#%d issues=%d elapsed=%v", head.Number, v.issues, time.Since(v.started))
+	return v.issues, nil
+}
+
+// fail records an issue of a canonical block.
+func (v *chainVerifier) fail(number uint64, hash common.Hash, format string, args ...interface{}) {
+	v.issues++
+	v.report(&VerifyError{Number: number, Hash: hash, Msg: fmt.Sprintf(format, args...)})
+}
+
+// verifyBlock checks the header, total difficulty, body and receipts of a
+// canonical block against its parent, if known. The header and total difficulty
+// are returned to check the next block against, nil if they are unusable.
+func (v *chainVerifier) verifyBlock(number uint64, hash common.Hash, parent *types.Header, parentTd *big.Int) (*types.Header, *big.Int) {
+	data := GetHeaderRLP(v.db, hash)
+	if len(data) == 0 {
+		v.fail(number, hash, "missing header")
+		return nil, nil
+	}
+	header := new(types.Header)
+	if err := rlp.DecodeBytes(data, header); err != nil {
+		v.fail(number, hash, "corrupted header: %v", err)
+		return nil, nil
+	}
+	if have := header.Hash(); have != hash {
+		v.fail(number, hash, "corrupted header: hash mismatch: have %x", have)
+		return nil, nil
+	}
+	if header.Number.Uint64() != number {
+		v.fail(number, hash, "header number mismatch: have %v", header.Number)
+	}
+	if parent != nil && header.ParentHash != parent.Hash() {
+		v.fail(number, hash, "parent hash mismatch: have %x, want %x", header.ParentHash, parent.Hash())
+	}
+	// Check the total difficulty, extending the parent's one
+	var td *big.Int
+	if data := getTdRLP(v.db, hash); len(data) == 0 {
+		v.fail(number, hash, "missing total difficulty")
+	} else if td = new(big.Int); rlp.DecodeBytes(data, td) != nil {
+		v.fail(number, hash, "corrupted total difficulty")
+		td = nil
+	} else {
+		var want *big.Int
+		switch {
+		case number == 0:
+			want = header.Difficulty
+		case parentTd != nil:
+			want = new(big.Int).Add(parentTd, header.Difficulty)
+		}
+		if want != nil && td.Cmp(want) != 0 {
+			v.fail(number, hash, "total difficulty mismatch: have %v, want %v", td, want)
+		}
+	}
+	// Check the body against the header roots
+	if data := GetBodyRLP(v.db, hash); len(data) == 0 {
+		v.fail(number, hash, "missing body")
+	} else {
+		body := new(types.Body)
+		if err := rlp.DecodeBytes(data, body); err != nil {
+			v.fail(number, hash, "corrupted body: %v", err)
+		} else {
+			if have := types.DeriveSha(types.Transactions(body.Transactions)); have != header.TxHash {
+				v.fail(number, hash, "transaction root mismatch: have %x, want %x", have, header.TxHash)
+			}
+			if have := types.CalcUncleHash(body.Uncles); have != header.UncleHash {
+				v.fail(number, hash, "uncle hash mismatch: have %x, want %x", have, header.UncleHash)
+			}
+		}
+	}
+	// Check the receipts against the header root, which may be left out for empty blocks
+	if data := getBlockReceiptsRLP(v.db, hash); len(data) == 0 {
+		if header.ReceiptHash != types.EmptyRootHash {
+			v.fail(number, hash, "missing receipts")
+		}
+	} else {
+		var stored []*types.ReceiptForStorage
+		if err := rlp.DecodeBytes(data, &stored); err != nil {
+			v.fail(number, hash, "corrupted receipts: %v", err)
+		} else {
+			receipts := make(types.Receipts, len(stored))
+			for i, receipt := range stored {
+				receipts[i] = (*types.Receipt)(receipt)
+			}
+			if have := types.DeriveSha(receipts); have != header.ReceiptHash {
+				v.fail(number, hash, "receipt root mismatch: have %x, want %x", have, header.ReceiptHash)
+			}
+		}
+	}
+	return header, td
+}
+
+// verifyState checks that every trie node and contract code reachable from the
+// given state root is present and matches its hash. A missing account trie node
+// ends the walk, as the accounts below it can't be reached.
+func (v *chainVerifier) verifyState(number uint64, hash, root common.Hash) {
+	reader := &verifyReader{Database: v.db, corrupted: make(map[common.Hash]common.Hash)}
+	accounts, err := trie.New(root, reader)
+	if err != nil {
+		v.fail(number, hash, "state root: %v", reader.describe(err))
+		return
+	}
+	var (
+		it      = accounts.NodeIterator(nil)
+		visited uint64
+	)
+	for it.Next(true) {
+		if !it.Leaf() {
+			continue
+		}
+		addrHash := common.BytesToHash(it.LeafKey())
+
+		var account state.Account
+		if err := rlp.DecodeBytes(it.LeafBlob(), &account); err != nil {
+			v.fail(number, hash, "account %x: corrupted account: %v", addrHash, err)
+			continue
+		}
+		if codeHash := common.BytesToHash(account.CodeHash); codeHash != emptyCodeHash {
+			if _, err := reader.Get(codeHash[:]); err != nil {
+				v.fail(number, hash, "account %x: code: %v", addrHash, reader.describe(err))
+			}
+		}
+		if account.Root != types.EmptyRootHash {
+			if err := verifyTrie(reader, account.Root); err != nil {
+				v.fail(number, hash, "account %x: storage trie: %v", addrHash, reader.describe(err))
+			}
+		}
+		if visited++; time.Since(v.logged) > verifyLogInterval {
+			glog.V(logger.Info).Infof("Verifying state: accounts=%d issues=%d elapsed=%v", visited, v.issues, time.Since(v.started))
+			v.logged = time.Now()
+		}
+	}
+	if err := it.Error(); err != nil {
+		v.fail(number, hash, "account trie: %v, state walk aborted", reader.describe(err))
+	}
+}
+
+// verifyTrie walks the trie rooted at root, returning the first issue found.
+func verifyTrie(db trie.Database, root common.Hash) error {
+	tr, err := trie.New(root, db)
+	if err != nil {
+		return err
+	}
+	it := tr.NodeIterator(nil)
+	for it.Next(true) {
+	}
+	return it.Error()
+}
+
+// errCorruptedEntry is returned by verifyReader for entries not matching their hash.
+var errCorruptedEntry = errors.New("corrupted entry")
+
+// verifyReader is a database checking the trie nodes and contract code read
+// against their hash, so corrupted entries surface as missing ones instead of
+// failing to decode.
+type verifyReader struct {
+	ethdb.Database
+	corrupted map[common.Hash]common.Hash // Corrupted entries found, mapped to their content hash
+}
+
+// Get retrieves the entry stored under the given hash, failing if it is missing
+// or its content doesn't match the hash.
+func (r *verifyReader) Get(key []byte) ([]byte, error) {
+	blob, err := r.Database.Get(key)
+	if err != nil || len(blob) == 0 {
+		return nil, fmt.Errorf("missing entry %x", key)
+	}
+	if have := crypto.Keccak256Hash(blob); !bytes.Equal(have[:], key) {
+		r.corrupted[common.BytesToHash(key)] = have
+		return nil, errCorruptedEntry
+	}
+	return blob, nil
+}
+
+// describe details the trie nodes reported missing by an error, which may have
+// been found corrupted instead.
+func (r *verifyReader) describe(err error) string {
+	if err == errCorruptedEntry {
+		return "corrupted entry"
+	}
+	if missing, ok := err.(*trie.MissingNodeError); ok {
+		if have, ok := r.corrupted[missing.NodeHash]; ok {
+			return fmt.Sprintf("corrupted trie node %x: content hash %x (path %x)", missing.NodeHash, have, missing.Path)
+		}
+	}
+	return err.Error()
+}
@@ -0,0 +1,187 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/eth-classic/go-ethereum/common"
+	"github.com/eth-classic/go-ethereum/core/state"
+	"github.com/eth-classic/go-ethereum/core/types"
+	"github.com/eth-classic/go-ethereum/crypto"
+	"github.com/eth-classic/go-ethereum/ethdb"
+	"github.com/eth-classic/go-ethereum/event"
+)
+
+var (
+	verifyKey, _   = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	verifyAddress  = crypto.PubkeyToAddress(verifyKey.PublicKey)
+	verifyContract = crypto.CreateAddress(verifyAddress, 0)
+	verifyInitCode = common.FromHex("602a60005560058060106000396000f36001600055") // stores 42 at slot 0
+	verifyCodeBlob = common.FromHex("6001600055")
+	verifyCodeHash = crypto.Keccak256(verifyCodeBlob)
+)
+
+// makeVerifyChain imports a short chain with transactions and a contract into
+// a fresh database, returning the database and the imported blocks.
+func makeVerifyChain(t *testing.T) (*ethdb.MemDatabase, []*types.Block) {
+	var (
+		key     = verifyKey
+		address = verifyAddress
+		funds   = big.NewInt(1000000000000000000)
+		signer  = types.NewChainIdSigner(big.NewInt(63))
+		config  = MakeDiehardChainConfig()
+		code    = verifyInitCode
+	)
+	gendb, _ := ethdb.NewMemDatabase()
+	genesis := GenesisBlockForTesting(gendb, address, funds)
+	blocks, _ := GenerateChain(config, genesis, gendb, 8, func(i int, block *BlockGen) {
+		var tx *types.Transaction
+		if i == 0 {
+			tx = types.NewContractCreation(block.TxNonce(address), new(big.Int), big.NewInt(200000), new(big.Int), code)
+		} else {
+			tx = types.NewTransaction(block.TxNonce(address), common.Address{0x01}, big.NewInt(1000), TxGas, new(big.Int), nil)
+		}
+		tx, err := tx.WithSigner(signer).SignECDSA(key)
+		if err != nil {
+			panic(err)
+		}
+		block.AddTx(tx)
+	})
+	db, _ := ethdb.NewMemDatabase()
+	WriteGenesisBlockForTesting(db, GenesisAccount{address, funds})
+
+	chain, err := NewBlockChain(db, config, FakePow{}, new(event.TypeMux))
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	if res := chain.InsertChain(blocks); res.Error != nil {
+		t.Fatalf("failed to import block %d: %v", res.Index, res.Error)
+	}
+	chain.Stop()
+	return db, blocks
+}
+
+// verifyIssues runs VerifyChain on the database, returning the issues found.
+func verifyIssues(t *testing.T, db ethdb.Database) []*VerifyError {
+	var issues []*VerifyError
+	n, err := VerifyChain(db, func(issue *VerifyError) { issues = append(issues, issue) })
+	if err != nil {
+		t.Fatalf("failed to verify chain: %v", err)
+	}
+	if n != len(issues) {
+		t.Errorf("issue count mismatch: have %d, reported %d", n, len(issues))
+	}
+	return issues
+}
+
+func TestVerifyChain(t *testing.T) {
+	db, _ := makeVerifyChain(t)
+	if issues := verifyIssues(t, db); len(issues) != 0 {
+		t.Fatalf("healthy chain has issues: %v", issues)
+	}
+}
+
+func TestVerifyChainIssues(t *testing.T) {
+	tests := []struct {
+		name    string
+		corrupt func(db ethdb.Database, blocks []*types.Block)
+		number  uint64
+		want    string
+	}{
+		{
+			name:    "missing body",
+			corrupt: func(db ethdb.Database, blocks []*types.Block) { DeleteBody(db, blocks[2].Hash()) },
+			number:  3,
+			want:    "missing body",
+		},
+		{
+			name: "corrupted body",
+			corrupt: func(db ethdb.Database, blocks []*types.Block) {
+				WriteBody(db, blocks[2].Hash(), &types.Body{Transactions: blocks[3].Transactions()})
+			},
+			number: 3,
+			want:   "transaction root mismatch",
+		},
+		{
+			name:    "missing receipts",
+			corrupt: func(db ethdb.Database, blocks []*types.Block) { DeleteBlockReceipts(db, blocks[4].Hash()) },
+			number:  5,
+			want:    "missing receipts",
+		},
+		{
+			name: "corrupted receipts",
+			corrupt: func(db ethdb.Database, blocks []*types.Block) {
+				WriteBlockReceipts(db, blocks[4].Hash(), GetBlockReceipts(db, blocks[5].Hash()))
+			},
+			number: 5,
+			want:   "receipt root mismatch",
+		},
+		{
+			name:    "wrong total difficulty",
+			corrupt: func(db ethdb.Database, blocks []*types.Block) { WriteTd(db, blocks[5].Hash(), big.NewInt(1)) },
+			number:  6,
+			want:    "total difficulty mismatch",
+		},
+		{
+			name: "broken header link",
+			corrupt: func(db ethdb.Database, blocks []*types.Block) {
+				genesis := GetBlock(db, blocks[0].ParentHash())
+				fork, _ := GenerateChain(MakeDiehardChainConfig(), genesis, db, 2, func(i int, block *BlockGen) {
+					block.SetCoinbase(common.Address{0x02})
+				})
+				WriteHeader(db, fork[1].Header())
+				WriteCanonicalHash(db, fork[1].Hash(), 2)
+			},
+			number: 2,
+			want:   "parent hash mismatch",
+		},
+		{
+			name: "missing code",
+			corrupt: func(db ethdb.Database, blocks []*types.Block) {
+				db.Delete(verifyCodeHash)
+			},
+			number: 8,
+			want:   "code: missing entry",
+		},
+		{
+			name: "corrupted storage node",
+			corrupt: func(db ethdb.Database, blocks []*types.Block) {
+				statedb, _ := state.New(blocks[7].Root(), state.NewDatabase(db))
+				root := statedb.StorageTrie(verifyContract).Hash()
+				db.Put(root[:], []byte{0xc0})
+			},
+			number: 8,
+			want:   "storage trie",
+		},
+	}
+	for _, tt := range tests {
+		db, blocks := makeVerifyChain(t)
+		tt.corrupt(db, blocks)
+
+		issues := verifyIssues(t, db)
+		if len(issues) == 0 {
+			t.Errorf("%s: no issues found", tt.name)
+			continue
+		}
+		if issues[0].Number != tt.number || !strings.Contains(issues[0].Msg, tt.want) {
+			t.Errorf("%s: issue mismatch: have %v, want #%d %q", tt.name, issues[0], tt.number, tt.want)
+		}
+	}
+}
@@ -98,7 +98,7 @@ type SufficientChainConfig struct {
 	Name            string           `json:"name,omitempty"`
 	State           *StateConfig     `json:"state"`     // don't omitempty for clarity of potential custom options
 	Network         int              `json:"network"`   // eth.NetworkId (mainnet=1, morden=2)
-	Consensus       string           `json:"consensus"` // pow type (ethash OR ethash-test)
+	Consensus       string           `json:"consensus"` // consensus engine (ethash, ethash-test OR clique)
 	Genesis         *GenesisDump     `json:"genesis"`
 	ChainConfig     *ChainConfig     `json:"chainConfig"`
 	Bootstrap       []string         `json:"bootstrap"`
@@ -131,8 +131,9 @@ type GenesisDump struct {
 
 // GenesisDumpAlloc is a GenesisDump.Alloc entry.
 type GenesisDumpAlloc struct {
-	Code    prefixedHex `json:"-"` // skip field for json encode
-	Storage map[hex]hex `json:"-"`
+	Code    prefixedHex `json:"code,omitempty"`
+	Storage map[hex]hex `json:"storage,omitempty"`
+	Nonce   uint64      `json:"nonce,omitempty"`
 	Balance string      `json:"balance"` // decimal string
 }
 
@@ -150,6 +151,16 @@ type ChainConfig struct {
 
 	// BadHashes holds well known blocks with consensus issues. See ErrHashKnownBad.
 	BadHashes []*BadHash `json:"badHashes"`
+
+	// Clique holds the proof-of-authority parameters of chains using the clique
+	// consensus engine.
+	Clique *CliqueConfig `json:"clique,omitempty"`
+}
+
+// CliqueConfig is the consensus engine configuration of proof-of-authority chains.
+type CliqueConfig struct {
+	Period uint64 `json:"period"` // Number of seconds between blocks to enforce
+	Epoch  uint64 `json:"epoch"`  // Epoch length to reset votes and checkpoint
 }
 
 type Fork struct {
@@ -211,7 +222,7 @@ func (c *SufficientChainConfig) IsValid() (string, bool) {
 		return "networkId", false
 	}
 
-	if c := c.Consensus; c == "" || (c != "ethash" && c != "ethash-test") {
+	if c := c.Consensus; c == "" || (c != "ethash" && c != "ethash-test" && c != "clique") {
 		return "consensus", false
 	}
 
@@ -239,6 +250,10 @@ func (c *SufficientChainConfig) IsValid() (string, bool) {
 		return "forks", false
 	}
 
+	if c.Consensus == "clique" && c.ChainConfig.Clique == nil {
+		return "chainConfig.clique", false
+	}
+
 	return "", true
 }
 
@@ -343,6 +358,118 @@ func (c *ChainConfig) IsAtlantis(num *big.Int) bool {
 	return num.Cmp(fork.Block) >= 0
 }
 
+// IsAgharta returns true if num is greater than or equal to the Agharta config block
+func (c *ChainConfig) IsAgharta(num *big.Int) bool {
+	fork := c.ForkByName("Agharta")
+	if fork.Block == nil || num == nil {
+		return false
+	}
+	return num.Cmp(fork.Block) >= 0
+}
+
+// IsEIP1344 returns true if the CHAINID instruction is enabled at num.
+func (c *ChainConfig) IsEIP1344(num *big.Int) bool {
+	_, _, configured := c.GetFeature(num, "eip1344")
+	return configured
+}
+
+// IsEIP1884 returns true if the SELFBALANCE instruction is enabled at num.
+// The accompanying repricing is configured through the "eip1884" gastable type.
+func (c *ChainConfig) IsEIP1884(num *big.Int) bool {
+	_, _, configured := c.GetFeature(num, "eip1884")
+	return configured
+}
+
+// IsEIP2028 returns true if the reduced transaction calldata cost applies at num.
+func (c *ChainConfig) IsEIP2028(num *big.Int) bool {
+	_, _, configured := c.GetFeature(num, "eip2028")
+	return configured
+}
+
+// IsEIP2200 returns true if SSTORE net gas metering applies at num.
+func (c *ChainConfig) IsEIP2200(num *big.Int) bool {
+	_, _, configured := c.GetFeature(num, "eip2200")
+	return configured
+}
+
+// IsEIP152 returns true if the BLAKE2F precompiled contract is available at num.
+func (c *ChainConfig) IsEIP152(num *big.Int) bool {
+	_, _, configured := c.GetFeature(num, "eip152")
+	return configured
+}
+
+// IsEIP2929 returns true if cold/warm state access gas pricing applies at num.
+func (c *ChainConfig) IsEIP2929(num *big.Int) bool {
+	_, _, configured := c.GetFeature(num, "eip2929")
+	return configured
+}
+
+// IsEIP3529 returns true if the reduced gas refunds apply at num.
+func (c *ChainConfig) IsEIP3529(num *big.Int) bool {
+	_, _, configured := c.GetFeature(num, "eip3529")
+	return configured
+}
+
+// IsEIP3541 returns true if new contract code starting with 0xEF is rejected at num.
+func (c *ChainConfig) IsEIP3541(num *big.Int) bool {
+	_, _, configured := c.GetFeature(num, "eip3541")
+	return configured
+}
+
+// IsEIP3651 returns true if the coinbase is warm at the start of a transaction at num.
+func (c *ChainConfig) IsEIP3651(num *big.Int) bool {
+	_, _, configured := c.GetFeature(num, "eip3651")
+	return configured
+}
+
+// IsEIP3855 returns true if the PUSH0 instruction is available at num.
+func (c *ChainConfig) IsEIP3855(num *big.Int) bool {
+	_, _, configured := c.GetFeature(num, "eip3855")
+	return configured
+}
+
+// IsEIP3860 returns true if init code is size limited and metered at num.
+func (c *ChainConfig) IsEIP3860(num *big.Int) bool {
+	_, _, configured := c.GetFeature(num, "eip3860")
+	return configured
+}
+
+// IsEIP2930 returns true if access list transactions are accepted at num.
+func (c *ChainConfig) IsEIP2930(num *big.Int) bool {
+	_, _, configured := c.GetFeature(num, "eip2930")
+	return configured
+}
+
+// ECIP1099Block returns the block from which on the ethash epoch length is doubled
+// (ECIP-1099), or nil if the configuration does not schedule it.
+func (c *ChainConfig) ECIP1099Block() *big.Int {
+	for _, f := range c.Forks {
+		if f.Block == nil {
+			continue
+		}
+		for _, ff := range f.Features {
+			if ff.ID != "ethash" {
+				continue
+			}
+			if name, ok := ff.GetString("type"); ok && name == "ecip1099" {
+				return f.Block
+			}
+		}
+	}
+	return nil
+}
+
+// IsECBP1100 returns true if MESS artificial finality (ECBP-1100) is activated at num.
+// A later fork may deactivate it again by configuring the feature with type "disabled".
+func (c *ChainConfig) IsECBP1100(num *big.Int) bool {
+	feat, _, ok := c.GetFeature(num, "ecbp1100")
+	if !ok {
+		return false
+	}
+	name, _ := feat.GetString("type")
+	return name != "disabled"
+}
+
 // ForkByName looks up a Fork by its name, assumed to be unique
 func (c *ChainConfig) ForkByName(name string) *Fork {
 	for i := range c.Forks {
@@ -353,7 +480,7 @@ func (c *ChainConfig) ForkByName(name string) *Fork {
 	return &Fork{}
 }
 
-// GetFeature looks up fork features by id, where id can (currently) be [difficulty, gastable, eip155].
+// GetFeature looks up fork features by id, where id can (currently) be [difficulty, gastable, eip155, eip1344, eip1884, eip2028, eip2200, eip152, eip2929, eip2930, eip3529, eip3541, eip3651, eip3855, eip3860, ethash, ecbp1100].
 // GetFeature returns the feature|nil, the latest fork configuring a given id, and if the given feature id was found at all
 // If queried feature is not found, returns ForkFeature{}, Fork{}, false.
 // If queried block number and/or feature is a zero-value, returns ForkFeature{}, Fork{}, false.
@@ -445,6 +572,9 @@ func (c *ChainConfig) GetSigner(blockNumber *big.Int) types.Signer {
 	feature, _, configured := c.GetFeature(blockNumber, "eip155")
 	if configured {
 		if chainId, ok := feature.GetBigInt("chainID"); ok {
+			if c.IsEIP2930(blockNumber) {
+				return types.NewAccessListSigner(chainId)
+			}
 			return types.NewChainIdSigner(chainId)
 		} else {
 			panic(fmt.Errorf("chainID is not set for EIP-155 at %v", blockNumber))
@@ -471,6 +601,10 @@ func (c *ChainConfig) GasTable(num *big.Int) *vm.GasTable {
 		return DefaultGasRepriceGasTable
 	case "eip160":
 		return DefaultDiehardGasTable
+	case "eip1884":
+		return DefaultPhoenixGasTable
+	case "eip2929":
+		return DefaultMagnetoGasTable
 	default:
 		panic(fmt.Errorf("Unsupported gastable value '%v' at block: %v", name, num))
 	}
@@ -755,6 +889,9 @@ func WriteGenesisBlock(chainDb ethdb.Database, genesis *GenesisDump) (*types.Blo
 			return nil, fmt.Errorf("malformed account %q balance %q", addrHex, account.Balance)
 		}
 		statedb.AddBalance(addr, balance)
+		if account.Nonce != 0 {
+			statedb.SetNonce(addr, account.Nonce)
+		}
 
 		code, err := account.Code.Bytes()
 		if err != nil {
@@ -839,6 +976,13 @@ func WriteGenesisBlockForTesting(db ethdb.Database, accounts ...GenesisAccount)
 
 // MakeGenesisDump makes a genesis dump
 func MakeGenesisDump(chaindb ethdb.Database) (*GenesisDump, error) {
+	return MakeGenesisDumpFrom(chaindb, nil)
+}
+
+// MakeGenesisDumpFrom makes a genesis dump with the header settings of the genesis
+// block, allocating the accounts of the given state export stream instead of the
+// genesis state if non-nil.
+func MakeGenesisDumpFrom(chaindb ethdb.Database, r *state.ExportReader) (*GenesisDump, error) {
 
 	genesis := GetBlock(chaindb, GetCanonicalHash(chaindb, 0))
 	if genesis == nil {
@@ -871,23 +1015,27 @@ func MakeGenesisDump(chaindb ethdb.Database) (*GenesisDump, error) {
 	}
 
 	// State allocations.
+	var err error
+	if r != nil {
+		if dump.Alloc, err = MakeGenesisAlloc(r); err != nil {
+			return nil, fmt.Errorf("Invalid state export: %v", err)
+		}
+		return dump, nil
+	}
 	genState, err := state.New(genesis.Root(), state.NewDatabase(chaindb))
 	if err != nil {
 		return nil, err
 	}
-	stateDump := genState.RawDump([]common.Address{})
-
-	stateAccounts := stateDump.Accounts
-	dump.Alloc = make(map[hex]*GenesisDumpAlloc, len(stateAccounts))
-
-	for address, acct := range stateAccounts {
-		if common.IsHexAddress(address) {
-			dump.Alloc[hex(address)] = &GenesisDumpAlloc{
-				Balance: acct.Balance,
-			}
-		} else {
-			return nil, fmt.Errorf("Invalid address in genesis state: %v", address)
-		}
+	// Stream the state through an export, so that code and storage are included
+	pr, pw := io.Pipe()
+	go func() {
+		_, err := genState.Export(pw, &state.ExportConfig{})
+		pw.CloseWithError(err)
+	}()
+	dump.Alloc, err = MakeGenesisAlloc(state.NewExportReader(pr, false))
+	pr.CloseWithError(err)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid genesis state: %v", err)
 	}
 	return dump, nil
 }
@@ -433,6 +433,14 @@ func TestChainConfig_GetFeature6_DefaultGasTables(t *testing.T) {
 		big.NewInt(0).Sub(DefaultConfigMainnet.ChainConfig.ForkByName("Diehard").Block, big.NewInt(1)): "eip150",
 		DefaultConfigMainnet.ChainConfig.ForkByName("Diehard").Block:                                   "eip160",
 		big.NewInt(0).Add(DefaultConfigMainnet.ChainConfig.ForkByName("Diehard").Block, big.NewInt(1)): "eip160",
+
+		big.NewInt(0).Sub(DefaultConfigMainnet.ChainConfig.ForkByName("Phoenix").Block, big.NewInt(1)): "eip160",
+		DefaultConfigMainnet.ChainConfig.ForkByName("Phoenix").Block:                                   "eip1884",
+		big.NewInt(0).Add(DefaultConfigMainnet.ChainConfig.ForkByName("Phoenix").Block, big.NewInt(1)): "eip1884",
+
+		big.NewInt(0).Sub(DefaultConfigMainnet.ChainConfig.ForkByName("Magneto").Block, big.NewInt(1)): "eip1884",
+		DefaultConfigMainnet.ChainConfig.ForkByName("Magneto").Block:                                   "eip2929",
+		big.NewInt(0).Add(DefaultConfigMainnet.ChainConfig.ForkByName("Magneto").Block, big.NewInt(1)): "eip2929",
 	}
 	for block, expected := range tables {
 		feat, fork, ok := c.GetFeature(block, "gastable")
@@ -455,6 +463,142 @@ func TestChainConfig_GetFeature6_DefaultGasTables(t *testing.T) {
 	}
 }
 
+func TestChainConfig_PhoenixFeatures(t *testing.T) {
+	c := DefaultConfigMainnet.ChainConfig
+	phoenix := c.ForkByName("Phoenix").Block
+	if phoenix == nil {
+		t.Fatal("missing Phoenix fork block")
+	}
+	before := new(big.Int).Sub(phoenix, big.NewInt(1))
+	checks := map[string]func(*big.Int) bool{
+		"eip1344": c.IsEIP1344,
+		"eip1884": c.IsEIP1884,
+		"eip2028": c.IsEIP2028,
+		"eip2200": c.IsEIP2200,
+		"eip152":  c.IsEIP152,
+	}
+	for id, check := range checks {
+		if check(before) {
+			t.Errorf("%s: unexpectedly enabled at block %v", id, before)
+		}
+		if !check(phoenix) {
+			t.Errorf("%s: expected enabled at block %v", id, phoenix)
+		}
+	}
+	if c.GasTable(phoenix) != DefaultPhoenixGasTable {
+		t.Errorf("expected Phoenix gas table at block %v", phoenix)
+	}
+	// ECIP-1088 schedules Phoenix on mainnet, Mordor and Kotti only, so Morden
+	// has no activation block and keeps the Agharta rule set.
+	if fork := DefaultConfigMorden.ChainConfig.ForkByName("Phoenix"); fork.Block != nil {
+		t.Errorf("unexpected Phoenix fork on morden at block %v", fork.Block)
+	}
+	for id, check := range map[string]func(*big.Int) bool{
+		"eip1344": DefaultConfigMorden.ChainConfig.IsEIP1344,
+		"eip2200": DefaultConfigMorden.ChainConfig.IsEIP2200,
+	} {
+		if check(big.NewInt(10500839)) {
+			t.Errorf("%s: unexpectedly enabled on morden", id)
+		}
+	}
+}
+
+func TestChainConfig_MagnetoFeatures(t *testing.T) {
+	c := DefaultConfigMainnet.ChainConfig
+	magneto := c.ForkByName("Magneto").Block
+	if magneto == nil {
+		t.Fatal("missing Magneto fork block")
+	}
+	before := new(big.Int).Sub(magneto, big.NewInt(1))
+	if c.IsEIP2929(before) || c.IsEIP2930(before) {
+		t.Errorf("Magneto features unexpectedly enabled at block %v", before)
+	}
+	if !c.IsEIP2929(magneto) || !c.IsEIP2930(magneto) {
+		t.Errorf("Magneto features expected enabled at block %v", magneto)
+	}
+	if c.GasTable(magneto) != DefaultMagnetoGasTable {
+		t.Errorf("expected Magneto gas table at block %v", magneto)
+	}
+	if _, ok := c.GetSigner(before).(types.ChainIdSigner); !ok {
+		t.Errorf("expected chain id signer at block %v", before)
+	}
+	if _, ok := c.GetSigner(magneto).(types.AccessListSigner); !ok {
+		t.Errorf("expected access list signer at block %v", magneto)
+	}
+}
+
+func TestChainConfig_MystiqueFeatures(t *testing.T) {
+	c := DefaultConfigMainnet.ChainConfig
+	mystique := c.ForkByName("Mystique").Block
+	if mystique == nil {
+		t.Fatal("missing Mystique fork block")
+	}
+	before := new(big.Int).Sub(mystique, big.NewInt(1))
+	if c.IsEIP3529(before) || c.IsEIP3541(before) {
+		t.Errorf("Mystique features unexpectedly enabled at block %v", before)
+	}
+	if !c.IsEIP3529(mystique) || !c.IsEIP3541(mystique) {
+		t.Errorf("Mystique features expected enabled at block %v", mystique)
+	}
+}
+
+func TestChainConfig_SpiralFeatures(t *testing.T) {
+	c := DefaultConfigMainnet.ChainConfig
+	spiral := c.ForkByName("Spiral").Block
+	if spiral == nil {
+		t.Fatal("missing Spiral fork block")
+	}
+	before := new(big.Int).Sub(spiral, big.NewInt(1))
+	checks := map[string]func(*big.Int) bool{
+		"eip3651": c.IsEIP3651,
+		"eip3855": c.IsEIP3855,
+		"eip3860": c.IsEIP3860,
+	}
+	for id, check := range checks {
+		if check(before) {
+			t.Errorf("%s: unexpectedly enabled at block %v", id, before)
+		}
+		if !check(spiral) {
+			t.Errorf("%s: expected enabled at block %v", id, spiral)
+		}
+	}
+}
+
+func TestChainConfig_ECIP1099Block(t *testing.T) {
+	c := DefaultConfigMainnet.ChainConfig
+	thanos := c.ForkByName("Thanos").Block
+	if thanos == nil {
+		t.Fatal("missing Thanos fork block")
+	}
+	if block := c.ECIP1099Block(); block == nil || block.Cmp(thanos) != 0 {
+		t.Errorf("ECIP-1099 block mismatch: have %v, want %v", block, thanos)
+	}
+	if block := DefaultConfigMorden.ChainConfig.ECIP1099Block(); block != nil {
+		t.Errorf("unexpected ECIP-1099 block on morden: %v", block)
+	}
+}
+
+func TestChainConfig_IsECBP1100(t *testing.T) {
+	c := DefaultConfigMainnet.ChainConfig
+	tests := []struct {
+		block int64
+		want  bool
+	}{
+		{11379999, false},
+		{11380000, true},
+		{19249999, true},
+		{19250000, false}, // deactivated by Spiral
+	}
+	for _, tt := range tests {
+		if got := c.IsECBP1100(big.NewInt(tt.block)); got != tt.want {
+			t.Errorf("IsECBP1100(%d): have %v, want %v", tt.block, got, tt.want)
+		}
+	}
+	if DefaultConfigMorden.ChainConfig.IsECBP1100(big.NewInt(11380000)) {
+		t.Error("unexpected ECBP-1100 activation on morden")
+	}
+}
+
 // TestChainConfig_GetFeature_DefaultGasTables sets that GetFeatures gets expected feature values for default fork configs.
 func TestChainConfig_GetFeature7_DefaultDifficulty(t *testing.T) {
 	c := getDefaultChainConfigSorted()
@@ -620,7 +764,10 @@ func TestChainConfig_GetSigner(t *testing.T) {
 				if !ok {
 					t.Errorf("unexpected missing eip155 chainid, block: %v", current)
 				}
-				shouldb := types.NewChainIdSigner(cid)
+				var shouldb types.Signer = types.NewChainIdSigner(cid)
+				if c.IsEIP2930(current) {
+					shouldb = types.NewAccessListSigner(cid)
+				}
 				if !signer.Equal(shouldb) {
 					t.Errorf("want: %v, got: %v", shouldb, current)
 				}
@@ -771,6 +918,14 @@ func TestSufficientChainConfig_IsValid(t *testing.T) {
 			}
 			scc.Consensus = o4
 
+			// Clique requires its engine parameters.
+			o5 := scc.Consensus
+			scc.Consensus = "clique"
+			if s, ok := scc.IsValid(); ok {
+				t.Errorf("unexpected ok: %v @ %v/%v", s, i, j)
+			}
+			scc.Consensus = o5
+
 			o := scc.Genesis
 			scc.Genesis = nil
 			if s, ok := scc.IsValid(); ok {
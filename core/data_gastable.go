@@ -27,6 +27,7 @@ import (
 var DefaultHomeSteadGasTable = &vm.GasTable{
 	ExtcodeSize:     big.NewInt(20),
 	ExtcodeCopy:     big.NewInt(20),
+	ExtcodeHash:     big.NewInt(400),
 	Balance:         big.NewInt(20),
 	SLoad:           big.NewInt(50),
 	Calls:           big.NewInt(40),
@@ -38,6 +39,7 @@ var DefaultHomeSteadGasTable = &vm.GasTable{
 var DefaultGasRepriceGasTable = &vm.GasTable{
 	ExtcodeSize:     big.NewInt(700),
 	ExtcodeCopy:     big.NewInt(700),
+	ExtcodeHash:     big.NewInt(400),
 	Balance:         big.NewInt(400),
 	SLoad:           big.NewInt(200),
 	Calls:           big.NewInt(700),
@@ -49,6 +51,7 @@ var DefaultGasRepriceGasTable = &vm.GasTable{
 var DefaultDiehardGasTable = &vm.GasTable{
 	ExtcodeSize:     big.NewInt(700),
 	ExtcodeCopy:     big.NewInt(700),
+	ExtcodeHash:     big.NewInt(400),
 	Balance:         big.NewInt(400),
 	SLoad:           big.NewInt(200),
 	Calls:           big.NewInt(700),
@@ -56,3 +59,27 @@ var DefaultDiehardGasTable = &vm.GasTable{
 	ExpByte:         big.NewInt(50),
 	CreateBySuicide: big.NewInt(25000),
 }
+
+var DefaultPhoenixGasTable = &vm.GasTable{
+	ExtcodeSize:     big.NewInt(700),
+	ExtcodeCopy:     big.NewInt(700),
+	ExtcodeHash:     big.NewInt(700),
+	Balance:         big.NewInt(700),
+	SLoad:           big.NewInt(800),
+	Calls:           big.NewInt(700),
+	Suicide:         big.NewInt(5000),
+	ExpByte:         big.NewInt(50),
+	CreateBySuicide: big.NewInt(25000),
+}
+
+var DefaultMagnetoGasTable = &vm.GasTable{
+	ExtcodeSize:     big.NewInt(100),
+	ExtcodeCopy:     big.NewInt(100),
+	ExtcodeHash:     big.NewInt(100),
+	Balance:         big.NewInt(100),
+	SLoad:           big.NewInt(100),
+	Calls:           big.NewInt(100),
+	Suicide:         big.NewInt(5000),
+	ExpByte:         big.NewInt(50),
+	CreateBySuicide: big.NewInt(25000),
+}
@@ -0,0 +1,177 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"time"
+
+	"github.com/eth-classic/go-ethereum/common"
+	"github.com/eth-classic/go-ethereum/core/state/snapshot"
+	"github.com/eth-classic/go-ethereum/ethdb"
+	"github.com/eth-classic/go-ethereum/logger"
+	"github.com/eth-classic/go-ethereum/logger/glog"
+)
+
+// inspectLogInterval is the time between two progress reports of InspectDatabase
+// and CompactDatabase.
+const inspectLogInterval = 8 * time.Second
+
+var (
+	cliqueSnapshotPrefix = []byte("clique-")                // Clique voting snapshots, see consensus/clique
+	mipmapVersionKey     = []byte("setting-mipmap-version") // Version of the MIPMap blooms, see eth/backend.go
+)
+
+// DatabaseStat is the number and total size of the database entries of a kind.
+type DatabaseStat struct {
+	Name  string
+	Count uint64
+	Size  common.StorageSize
+}
+
+// databaseCategory matches the keys of a kind of database entry.
+type databaseCategory struct {
+	name  string
+	match func(key []byte) bool
+}
+
+// hasPrefix matches the keys starting with prefix.
+func hasPrefix(prefix []byte) func([]byte) bool {
+	return func(key []byte) bool { return bytes.HasPrefix(key, prefix) }
+}
+
+// isBlockKey matches the block entry keys with the given suffix.
+func isBlockKey(suffix []byte) func([]byte) bool {
+	return func(key []byte) bool {
+		return len(key) == len(blockPrefix)+common.HashLength+len(suffix) && bytes.HasPrefix(key, blockPrefix) && bytes.HasSuffix(key, suffix)
+	}
+}
+
+// isMetadataKey matches the single entry keys tracking the database progress.
+func isMetadataKey(key []byte) bool {
+	for _, meta := range [][]byte{headHeaderKey, headBlockKey, headFastKey, triePrunedKey, ancientTailKey, blockChainVersionKey, databaseVersionKey, migrationBookmarkKey, txAddressBookmarkKey, mipmapVersionKey} {
+		if bytes.Equal(key, meta) {
+			return true
+		}
+	}
+	return snapshot.IsMetadataKey(key)
+}
+
+// databaseCategories are the kinds of database entries, matched in order.
+var databaseCategories = []databaseCategory{
+	{"Headers", isBlockKey(headerSuffix)},
+	{"Bodies", isBlockKey(bodySuffix)},
+	{"Total difficulties", isBlockKey(tdSuffix)},
+	{"Canonical hashes", hasPrefix(blockNumPrefix)},
+	{"Legacy blocks", hasPrefix(blockHashPrefix)},
+	{"Block receipts", hasPrefix(blockReceiptsPrefix)},
+	{"Transaction receipts", hasPrefix(receiptsPrefix)},
+	{"Transaction lookups", func(key []byte) bool {
+		return len(key) == len(lookupPrefix)+common.HashLength && bytes.HasPrefix(key, lookupPrefix)
+	}},
+	{"Transaction metadata", func(key []byte) bool {
+		return len(key) == common.HashLength+len(txMetaSuffix) && bytes.HasSuffix(key, txMetaSuffix)
+	}},
+	{"MIPMap blooms", hasPrefix(mipmapPre)},
+	{"Bloom bits", hasPrefix(bloomBitsPrefix)},
+	{"Bloom bits index", hasPrefix(bloomBitsIndexPrefix)},
+	{"Address transaction index", hasPrefix(txAddressIndexPrefix)},
+	{"Preimages", hasPrefix([]byte(preimagePrefix))},
+	{"Ancient block numbers", hasPrefix(ancientPrefix)},
+	{"Snapshot accounts", snapshot.IsAccountKey},
+	{"Snapshot storage", snapshot.IsStorageKey},
+	{"Clique snapshots", hasPrefix(cliqueSnapshotPrefix)},
+	{"Trie nodes, code and transactions", func(key []byte) bool { return len(key) == common.HashLength }},
+	{"Metadata", isMetadataKey},
+}
+
+// InspectDatabase iterates the whole key space of the database, grouping the
+// entries by kind. The entries of no known kind are grouped as unaccounted,
+// and the tables of the ancient store, if any, are listed last.
+func InspectDatabase(db ethdb.Database) ([]*DatabaseStat, error) {
+	stats := make([]*DatabaseStat, len(databaseCategories)+1)
+	for i, category := range databaseCategories {
+		stats[i] = &DatabaseStat{Name: category.name}
+	}
+	unaccounted := &DatabaseStat{Name: "Unaccounted"}
+	stats[len(databaseCategories)] = unaccounted
+
+	var (
+		start  = time.Now()
+		logged = time.Now()
+		count  uint64
+	)
+	it := db.NewIteratorWithPrefix(nil)
+	defer it.Release()
+
+	for it.Next() {
+		key := it.Key()
+
+		stat := unaccounted
+		for i, category := range databaseCategories {
+			if category.match(key) {
+				stat = stats[i]
+				break
+			}
+		}
+		stat.Count++
+		stat.Size += common.StorageSize(len(key) + len(it.Value()))
+
+		if count++; time.Since(logged) > inspectLogInterval {
+			glog.V(logger.Info).Infof("Inspecting database: entries=%d elapsed=%v", count, time.Since(start))
+			glog.D(logger.Warn).Infof("Inspecting database: %d entries in %v", count, time.Since(start))
+			logged = time.Now()
+		}
+	}
+	if err := it.Error(); err != nil {
+		return nil, err
+	}
+	if store := ancientStore(db); store != nil {
+		for _, kind := range ethdb.AncientKinds() {
+			size, err := store.AncientSize(kind)
+			if err != nil {
+				return nil, err
+			}
+			stats = append(stats, &DatabaseStat{Name: "Ancient " + kind, Count: store.Ancients(), Size: common.StorageSize(size)})
+		}
+	}
+	glog.V(logger.Info).Infof("Inspected database: entries=%d elapsed=%v", count, time.Since(start))
+	return stats, nil
+}
+
+// CompactDatabase compacts the whole key space of the database, range by range
+// of the first key byte, logging the progress after each range.
+func CompactDatabase(db ethdb.Compacter) error {
+	const ranges = 16
+
+	start := time.Now()
+	for i := 0; i < ranges; i++ {
+		var from, to []byte
+		if i > 0 {
+			from = []byte{byte(i * 256 / ranges)}
+		}
+		if i < ranges-1 {
+			to = []byte{byte((i + 1) * 256 / ranges)}
+		}
+		if err := db.Compact(from, to); err != nil {
+			return err
+		}
+		glog.V(logger.Info).Infof("Compacting database: progress=%d/%d elapsed=%v", i+1, ranges, time.Since(start))
+		glog.D(logger.Warn).Infof("Compacting database: %d/%d ranges done in %v", i+1, ranges, time.Since(start))
+	}
+	return nil
+}
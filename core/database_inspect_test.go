@@ -0,0 +1,125 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+
+	"github.com/eth-classic/go-ethereum/common"
+	"github.com/eth-classic/go-ethereum/core/types"
+	"github.com/eth-classic/go-ethereum/ethdb"
+	"github.com/eth-classic/go-ethereum/event"
+)
+
+// importFreezerChain imports a chain of n blocks, every other one with a
+// transaction, into the database, returning the imported blocks.
+func importFreezerChain(t *testing.T, db ethdb.Database, n int) []*types.Block {
+	var (
+		address = verifyAddress
+		funds   = big.NewInt(1000000000000000000)
+		signer  = types.NewChainIdSigner(big.NewInt(63))
+		config  = MakeDiehardChainConfig()
+	)
+	genesis := WriteGenesisBlockForTesting(db, GenesisAccount{address, funds})
+	blocks, _ := GenerateChain(config, genesis, db, n, func(i int, block *BlockGen) {
+		if i%2 == 0 {
+			tx := types.NewTransaction(block.TxNonce(address), common.Address{0x01}, big.NewInt(1000), TxGas, new(big.Int), nil)
+			tx, err := tx.WithSigner(signer).SignECDSA(verifyKey)
+			if err != nil {
+				panic(err)
+			}
+			block.AddTx(tx)
+		}
+	})
+	chain, err := NewBlockChain(db, config, FakePow{}, new(event.TypeMux))
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	if res := chain.InsertChain(blocks); res.Error != nil {
+		t.Fatalf("failed to import block %d: %v", res.Index, res.Error)
+	}
+	chain.Stop()
+	return blocks
+}
+
+func TestInspectDatabase(t *testing.T) {
+	dir, err := ioutil.TempDir("", "inspect-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db := openFreezerDatabase(t, dir)
+	defer db.Close()
+
+	importFreezerChain(t, db, 8)
+	WritePreimages(db, 8, map[common.Hash][]byte{{0x01}: {0x01}, {0x02}: {0x02}})
+
+	if _, err := freezeAncients(db, db.AncientStore(), 4, nil); err != nil {
+		t.Fatalf("failed to freeze blocks: %v", err)
+	}
+	stats, err := InspectDatabase(db)
+	if err != nil {
+		t.Fatalf("inspection failed: %v", err)
+	}
+	counts := make(map[string]uint64)
+	for _, stat := range stats {
+		if stat.Count > 0 && stat.Size == 0 {
+			t.Errorf("%s: %d entries without size", stat.Name, stat.Count)
+		}
+		counts[stat.Name] = stat.Count
+	}
+	// The genesis and blocks #4-#8 are kept in the database, the others moved
+	want := map[string]uint64{
+		"Headers":               6,
+		"Bodies":                6,
+		"Total difficulties":    6,
+		"Canonical hashes":      6,
+		"Ancient block numbers": 4,
+		"Transaction metadata":  4,
+		"Ancient headers":       4,
+		"Ancient receipts":      4,
+		"Unaccounted":           0,
+	}
+	for name, count := range want {
+		if counts[name] != count {
+			t.Errorf("%s: count mismatch: have %d, want %d", name, counts[name], count)
+		}
+	}
+	for _, name := range []string{"Trie nodes, code and transactions", "Preimages", "Metadata", "MIPMap blooms"} {
+		if counts[name] == 0 {
+			t.Errorf("%s: no entries found", name)
+		}
+	}
+	if err := CompactDatabase(db); err != nil {
+		t.Fatalf("compaction failed: %v", err)
+	}
+	if compacted, err := InspectDatabase(db); err != nil {
+		t.Fatalf("inspection after compaction failed: %v", err)
+	} else if len(compacted) != len(stats) {
+		t.Fatalf("stat count mismatch after compaction: have %d, want %d", len(compacted), len(stats))
+	} else {
+		for i, stat := range compacted {
+			if stat.Count != stats[i].Count {
+				t.Errorf("%s: count changed by compaction: have %d, want %d", stat.Name, stat.Count, stats[i].Count)
+			}
+		}
+	}
+}
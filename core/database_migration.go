@@ -0,0 +1,239 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/eth-classic/go-ethereum/common"
+	"github.com/eth-classic/go-ethereum/core/types"
+	"github.com/eth-classic/go-ethereum/ethdb"
+	"github.com/eth-classic/go-ethereum/logger"
+	"github.com/eth-classic/go-ethereum/logger/glog"
+	"github.com/eth-classic/go-ethereum/rlp"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+var (
+	databaseVersionKey   = []byte("DatabaseVersion")   // Schema version of the key layout, see databaseMigrations
+	migrationBookmarkKey = []byte("MigrationBookmark") // Progress of the running migration, see migrationBookmark
+)
+
+// DatabaseMigration is an in-place upgrade of the database key layout to a
+// schema version. It rewrites the entries of a key space one by one, queueing
+// its changes into a batch which is written along with the progress bookmark,
+// so that an interrupted migration resumes after the last written entry.
+//
+// Migrate may be called again for entries after the bookmark if the process
+// dies mid-batch, and must therefore be idempotent. The entries it writes
+// must sort outside of Prefix, or be left alone when iterated.
+type DatabaseMigration struct {
+	Version uint64 // Schema version of the database once migrated
+	Name    string // Description of the migration, for reporting
+	Prefix  []byte // Key space iterated by the migration
+
+	Migrate func(batch ethdb.Batch, key, value []byte) error
+}
+
+// databaseMigrations are the migrations of the database key layout, in order
+// of schema version. Changes of the key layout append a migration here rather
+// than bumping BlockChainVersion, which forces a reimport of the chain.
+var databaseMigrations = []*DatabaseMigration{
+	{Version: 1, Name: "Split legacy blocks", Prefix: blockHashPrefix, Migrate: migrateLegacyBlock},
+}
+
+// MigrationStat is the number and total size of the entries migrated, or to
+// be migrated, by a database migration.
+type MigrationStat struct {
+	Version uint64
+	Name    string
+	Count   uint64
+	Size    common.StorageSize
+	Resumed bool // Whether the migration resumed from a bookmark
+}
+
+// migrationBookmark is the progress of an interrupted migration, the key of
+// the last entry it wrote.
+type migrationBookmark struct {
+	Version uint64
+	Key     []byte
+}
+
+// LatestDatabaseVersion returns the schema version of the database once all
+// known migrations are applied.
+func LatestDatabaseVersion() uint64 {
+	if len(databaseMigrations) == 0 {
+		return 0
+	}
+	return databaseMigrations[len(databaseMigrations)-1].Version
+}
+
+// GetDatabaseVersion reads the schema version of the database, 0 if no
+// migration was ever applied to it.
+func GetDatabaseVersion(db ethdb.Database) uint64 {
+	var version uint64
+	enc, _ := db.Get(databaseVersionKey)
+	rlp.DecodeBytes(enc, &version)
+	return version
+}
+
+// WriteDatabaseVersion writes the schema version of the database.
+func WriteDatabaseVersion(db ethdb.Putter, version uint64) error {
+	enc, _ := rlp.EncodeToBytes(version)
+	return db.Put(databaseVersionKey, enc)
+}
+
+// getMigrationBookmark reads the progress of an interrupted migration, nil if
+// there is none.
+func getMigrationBookmark(db ethdb.Database) *migrationBookmark {
+	enc, _ := db.Get(migrationBookmarkKey)
+	if len(enc) == 0 {
+		return nil
+	}
+	bookmark := new(migrationBookmark)
+	if err := rlp.DecodeBytes(enc, bookmark); err != nil {
+		glog.V(logger.Error).Infof("invalid migration bookmark: %v", err)
+		return nil
+	}
+	return bookmark
+}
+
+// setMigrationBookmark writes the progress of a running migration.
+func setMigrationBookmark(db ethdb.Putter, bookmark *migrationBookmark) error {
+	enc, err := rlp.EncodeToBytes(bookmark)
+	if err != nil {
+		return err
+	}
+	return db.Put(migrationBookmarkKey, enc)
+}
+
+// PendingMigrations returns the migrations not yet applied to the database.
+func PendingMigrations(db ethdb.Database) []*DatabaseMigration {
+	version := GetDatabaseVersion(db)
+
+	var pending []*DatabaseMigration
+	for _, migration := range databaseMigrations {
+		if migration.Version > version {
+			pending = append(pending, migration)
+		}
+	}
+	return pending
+}
+
+// MigrateDatabase applies the pending migrations to the database in order,
+// resuming an interrupted one from its bookmark. In dry-run mode the database
+// is left untouched and the entries that would be migrated are reported.
+func MigrateDatabase(db ethdb.Database, dryRun bool) ([]*MigrationStat, error) {
+	if version, latest := GetDatabaseVersion(db), LatestDatabaseVersion(); version > latest {
+		return nil, fmt.Errorf("database version %d is newer than the latest supported %d", version, latest)
+	}
+	bookmark := getMigrationBookmark(db)
+
+	var stats []*MigrationStat
+	for _, migration := range PendingMigrations(db) {
+		stat := &MigrationStat{Version: migration.Version, Name: migration.Name}
+		stats = append(stats, stat)
+
+		var start []byte
+		if bookmark != nil && bookmark.Version == migration.Version {
+			start, stat.Resumed = append(common.CopyBytes(bookmark.Key), 0x00), true
+		}
+		if err := migrate(db, migration, start, stat, dryRun); err != nil {
+			return stats, fmt.Errorf("migration to database version %d failed: %v", migration.Version, err)
+		}
+	}
+	return stats, nil
+}
+
+// migrate applies a migration to the entries of its key space from start on.
+func migrate(db ethdb.Database, migration *DatabaseMigration, start []byte, stat *MigrationStat, dryRun bool) error {
+	r := util.BytesPrefix(migration.Prefix)
+	if start != nil {
+		r.Start = start
+	}
+	var (
+		begin  = time.Now()
+		logged = time.Now()
+		batch  = db.NewBatch()
+	)
+	it := db.NewIteratorWithRange(r.Start, r.Limit)
+	defer it.Release()
+
+	for it.Next() {
+		stat.Count++
+		stat.Size += common.StorageSize(len(it.Key()) + len(it.Value()))
+		if dryRun {
+			continue
+		}
+		if err := migration.Migrate(batch, it.Key(), it.Value()); err != nil {
+			return fmt.Errorf("entry %x: %v", it.Key(), err)
+		}
+		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			if err := setMigrationBookmark(batch, &migrationBookmark{Version: migration.Version, Key: it.Key()}); err != nil {
+				return err
+			}
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+		if time.Since(logged) > inspectLogInterval {
+			glog.V(logger.Info).Infof("Migrating database: version=%d entries=%d elapsed=%v", migration.Version, stat.Count, time.Since(begin))
+			glog.D(logger.Warn).Infof("Migrating database to version %d: %d entries in %v", migration.Version, stat.Count, time.Since(begin))
+			logged = time.Now()
+		}
+	}
+	if err := it.Error(); err != nil {
+		return err
+	}
+	if dryRun {
+		return nil
+	}
+	// Bump the version along with the last entries, dropping the bookmark
+	if err := WriteDatabaseVersion(batch, migration.Version); err != nil {
+		return err
+	}
+	if err := batch.Delete(migrationBookmarkKey); err != nil {
+		return err
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	glog.V(logger.Info).Infof("Migrated database: version=%d name=%q entries=%d elapsed=%v", migration.Version, migration.Name, stat.Count, time.Since(begin))
+	return nil
+}
+
+// migrateLegacyBlock splits a block stored in the combined format predating
+// eth/63 into its header, body and total difficulty.
+func migrateLegacyBlock(batch ethdb.Batch, key, value []byte) error {
+	var block types.StorageBlock
+	if err := rlp.DecodeBytes(value, &block); err != nil {
+		return err
+	}
+	b := (*types.Block)(&block)
+	if err := WriteTd(batch, b.Hash(), b.DeprecatedTd()); err != nil {
+		return err
+	}
+	if err := WriteBody(batch, b.Hash(), b.Body()); err != nil {
+		return err
+	}
+	if err := WriteHeader(batch, b.Header()); err != nil {
+		return err
+	}
+	return batch.Delete(key)
+}
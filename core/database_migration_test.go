@@ -0,0 +1,177 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/eth-classic/go-ethereum/core/types"
+	"github.com/eth-classic/go-ethereum/ethdb"
+	"github.com/eth-classic/go-ethereum/rlp"
+)
+
+func TestMigrateLegacyBlocks(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+
+	// Store a few blocks in the combined format predating eth/63
+	var headers []*types.Header
+	for i := 0; i < 3; i++ {
+		header := &types.Header{Number: big.NewInt(int64(i)), Difficulty: big.NewInt(int64(i + 1)), Extra: []byte("legacy")}
+		enc, err := rlp.EncodeToBytes([]interface{}{header, []*types.Transaction{}, []*types.Header{}, big.NewInt(int64(10 * i))})
+		if err != nil {
+			t.Fatalf("failed to encode legacy block: %v", err)
+		}
+		db.Put(append(blockHashPrefix, header.Hash().Bytes()...), enc)
+		headers = append(headers, header)
+	}
+	// A dry run reports the blocks without touching them
+	stats, err := MigrateDatabase(db, true)
+	if err != nil {
+		t.Fatalf("dry run failed: %v", err)
+	}
+	if len(stats) != 1 || stats[0].Version != 1 || stats[0].Count != 3 || stats[0].Size == 0 {
+		t.Fatalf("dry run report mismatch: have %+v", stats)
+	}
+	if version := GetDatabaseVersion(db); version != 0 {
+		t.Errorf("dry run bumped the version to %d", version)
+	}
+	if GetHeader(db, headers[0].Hash()) != nil {
+		t.Errorf("dry run migrated a block")
+	}
+	// A real run splits the blocks and bumps the version
+	if stats, err = MigrateDatabase(db, false); err != nil {
+		t.Fatalf("migration failed: %v", err)
+	}
+	if len(stats) != 1 || stats[0].Count != 3 || stats[0].Resumed {
+		t.Fatalf("migration report mismatch: have %+v", stats)
+	}
+	for i, header := range headers {
+		hash := header.Hash()
+		if stored := GetHeader(db, hash); stored == nil || stored.Hash() != hash {
+			t.Errorf("block %d: header mismatch: have %v", i, stored)
+		}
+		if GetBody(db, hash) == nil {
+			t.Errorf("block %d: body missing", i)
+		}
+		if td := GetTd(db, hash); td == nil || td.Int64() != int64(10*i) {
+			t.Errorf("block %d: td mismatch: have %v, want %d", i, td, 10*i)
+		}
+		if GetBlockByHashOld(db, hash) != nil {
+			t.Errorf("block %d: legacy block not deleted", i)
+		}
+	}
+	if version := GetDatabaseVersion(db); version != LatestDatabaseVersion() {
+		t.Errorf("version mismatch: have %d, want %d", version, LatestDatabaseVersion())
+	}
+	// Nothing is left to migrate
+	if stats, err = MigrateDatabase(db, false); err != nil || len(stats) != 0 {
+		t.Errorf("repeated migration mismatch: have %+v, %v", stats, err)
+	}
+}
+
+func TestMigrationResume(t *testing.T) {
+	defer func(migrations []*DatabaseMigration) { databaseMigrations = migrations }(databaseMigrations)
+
+	db, _ := ethdb.NewMemDatabase()
+
+	// Fill the key space of a test migration with a few batches worth of data
+	const entries = 100
+	value := make([]byte, ethdb.IdealBatchSize/10)
+	for i := 0; i < entries; i++ {
+		db.Put([]byte(fmt.Sprintf("old-%03d", i)), value)
+	}
+	var (
+		interrupt = 45
+		errCrash  = errors.New("crashed")
+	)
+	migrateOld := func(batch ethdb.Batch, key, value []byte) error {
+		if interrupt == 0 {
+			return errCrash
+		}
+		interrupt--
+		batch.Put(append([]byte("new-"), bytes.TrimPrefix(key, []byte("old-"))...), value)
+		return batch.Delete(key)
+	}
+	databaseMigrations = []*DatabaseMigration{
+		{Version: 1, Name: "Noop", Prefix: []byte("none-"), Migrate: migrateOld},
+		{Version: 2, Name: "Rename", Prefix: []byte("old-"), Migrate: migrateOld},
+	}
+	// Interrupt the migration, keeping the batches written before
+	if _, err := MigrateDatabase(db, false); err == nil {
+		t.Fatalf("interrupted migration succeeded")
+	}
+	if version := GetDatabaseVersion(db); version != 1 {
+		t.Fatalf("version mismatch after interruption: have %d, want 1", version)
+	}
+	bookmark := getMigrationBookmark(db)
+	if bookmark == nil || bookmark.Version != 2 {
+		t.Fatalf("bookmark mismatch after interruption: have %+v", bookmark)
+	}
+	done := iterateCount(db, []byte("new-"))
+	if done == 0 || done > 45 || iterateCount(db, []byte("old-")) != entries-done {
+		t.Fatalf("entries mismatch after interruption: %d migrated", done)
+	}
+	// A dry run reports the remaining entries only
+	stats, err := MigrateDatabase(db, true)
+	if err != nil {
+		t.Fatalf("dry run failed: %v", err)
+	}
+	if len(stats) != 1 || !stats[0].Resumed || stats[0].Count != uint64(entries-done) {
+		t.Fatalf("dry run report mismatch: have %+v, want %d entries", stats, entries-done)
+	}
+	// Resume the migration to completion
+	interrupt = entries
+	if stats, err = MigrateDatabase(db, false); err != nil {
+		t.Fatalf("resumed migration failed: %v", err)
+	}
+	if len(stats) != 1 || !stats[0].Resumed || stats[0].Count != uint64(entries-done) {
+		t.Errorf("resumed migration report mismatch: have %+v, want %d entries", stats, entries-done)
+	}
+	if n := iterateCount(db, []byte("new-")); n != entries {
+		t.Errorf("migrated entries mismatch: have %d, want %d", n, entries)
+	}
+	if n := iterateCount(db, []byte("old-")); n != 0 {
+		t.Errorf("%d entries left unmigrated", n)
+	}
+	if version := GetDatabaseVersion(db); version != 2 {
+		t.Errorf("version mismatch: have %d, want 2", version)
+	}
+	if bookmark := getMigrationBookmark(db); bookmark != nil {
+		t.Errorf("bookmark left behind: %+v", bookmark)
+	}
+	// Databases newer than the code are rejected
+	WriteDatabaseVersion(db, 3)
+	if _, err := MigrateDatabase(db, false); err == nil {
+		t.Errorf("newer database migrated")
+	}
+}
+
+// iterateCount returns the number of database entries with the given prefix.
+func iterateCount(db ethdb.Database, prefix []byte) int {
+	it := db.NewIteratorWithPrefix(prefix)
+	defer it.Release()
+
+	count := 0
+	for it.Next() {
+		count++
+	}
+	return count
+}
@@ -30,9 +30,12 @@ import (
 )
 
 var (
-	headHeaderKey = []byte("LastHeader")
-	headBlockKey  = []byte("LastBlock")
-	headFastKey   = []byte("LastFast")
+	headHeaderKey        = []byte("LastHeader")
+	headBlockKey         = []byte("LastBlock")
+	headFastKey          = []byte("LastFast")
+	triePrunedKey        = []byte("TriePruned")
+	ancientTailKey       = []byte("AncientTail") // Number of blocks moved to the ancient store and removed from the database
+	blockChainVersionKey = []byte("BlockchainVersion")
 
 	blockPrefix    = []byte("block-")
 	blockNumPrefix = []byte("block-num-")
@@ -52,6 +55,11 @@ var (
 
 	preimagePrefix = "secure-key-" // preimagePrefix + hash -> preimage
 	lookupPrefix   = []byte("l")   // lookupPrefix + hash -> transaction/receipt lookup metadata
+
+	ancientPrefix = []byte("ancient-") // ancientPrefix + hash -> number of a block moved to the ancient store
+
+	bloomBitsPrefix      = []byte("bloombits-") // bloomBitsPrefix + bit (uint16 big endian) + section (uint64 big endian) + hash -> compressed bloom bits
+	bloomBitsIndexPrefix = []byte("iB")         // bloomBitsIndexPrefix + key -> progress of the bloom bits indexer
 )
 
 // TxLookupEntry is a positional metadata to help looking up the data content of
@@ -65,12 +73,46 @@ type TxLookupEntry struct {
 // GetCanonicalHash retrieves a hash assigned to a canonical block number.
 func GetCanonicalHash(db ethdb.Database, number uint64) common.Hash {
 	data, _ := db.Get(append(blockNumPrefix, big.NewInt(int64(number)).Bytes()...))
+	if len(data) == 0 {
+		if store := ancientStore(db); store != nil && number < store.Ancients() {
+			data, _ = store.Ancient(ethdb.AncientHashes, number)
+		}
+	}
 	if len(data) == 0 {
 		return common.Hash{}
 	}
 	return common.BytesToHash(data)
 }
 
+// ancientStore returns the ancient store of the database, nil if it has none.
+func ancientStore(db ethdb.Database) ethdb.AncientStore {
+	if adb, ok := db.(ethdb.AncientDatabase); ok {
+		return adb.AncientStore()
+	}
+	return nil
+}
+
+// getAncient retrieves the data of the given kind of a block moved to the
+// ancient store, or nil if the block isn't there.
+func getAncient(db ethdb.Database, kind string, hash common.Hash) []byte {
+	store := ancientStore(db)
+	if store == nil {
+		return nil
+	}
+	data, _ := db.Get(append(ancientPrefix, hash[:]...))
+	if len(data) != 8 {
+		return nil
+	}
+	number := binary.BigEndian.Uint64(data)
+
+	// The block may have been rewound and its number refilled by another one
+	if stored, _ := store.Ancient(ethdb.AncientHashes, number); !bytes.Equal(stored, hash[:]) {
+		return nil
+	}
+	blob, _ := store.Ancient(kind, number)
+	return blob
+}
+
 // GetHeadHeaderHash retrieves the hash of the current canonical head block's
 // header. The difference between this and GetHeadBlockHash is that whereas the
 // last block hash is only updated upon a full block import, the last header
@@ -105,10 +147,20 @@ func GetHeadFastBlockHash(db ethdb.Database) common.Hash {
 	return common.BytesToHash(data)
 }
 
+// GetTriePruned reports whether state tries have ever been garbage collected in
+// the database, meaning the state of most historical blocks is missing.
+func GetTriePruned(db ethdb.Database) bool {
+	data, _ := db.Get(triePrunedKey)
+	return len(data) > 0
+}
+
 // GetHeaderRLP retrieves a block header in its raw RLP database encoding, or nil
 // if the header's not found.
 func GetHeaderRLP(db ethdb.Database, hash common.Hash) rlp.RawValue {
 	data, _ := db.Get(append(append(blockPrefix, hash[:]...), headerSuffix...))
+	if len(data) == 0 {
+		data = getAncient(db, ethdb.AncientHeaders, hash)
+	}
 	return data
 }
 
@@ -130,6 +182,9 @@ func GetHeader(db ethdb.Database, hash common.Hash) *types.Header {
 // GetBodyRLP retrieves the block body (transactions and uncles) in RLP encoding.
 func GetBodyRLP(db ethdb.Database, hash common.Hash) rlp.RawValue {
 	data, _ := db.Get(append(append(blockPrefix, hash[:]...), bodySuffix...))
+	if len(data) == 0 {
+		data = getAncient(db, ethdb.AncientBodies, hash)
+	}
 	return data
 }
 
@@ -151,7 +206,7 @@ func GetBody(db ethdb.Database, hash common.Hash) *types.Body {
 // GetTd retrieves a block's total difficulty corresponding to the hash, nil if
 // none found.
 func GetTd(db ethdb.Database, hash common.Hash) *big.Int {
-	data, _ := db.Get(append(append(blockPrefix, hash.Bytes()...), tdSuffix...))
+	data := getTdRLP(db, hash)
 	if len(data) == 0 {
 		return nil
 	}
@@ -163,6 +218,15 @@ func GetTd(db ethdb.Database, hash common.Hash) *big.Int {
 	return td
 }
 
+// getTdRLP retrieves a block's total difficulty in its raw RLP database encoding.
+func getTdRLP(db ethdb.Database, hash common.Hash) rlp.RawValue {
+	data, _ := db.Get(append(append(blockPrefix, hash.Bytes()...), tdSuffix...))
+	if len(data) == 0 {
+		data = getAncient(db, ethdb.AncientTds, hash)
+	}
+	return data
+}
+
 // GetBlock retrieves an entire block corresponding to the hash, assembling it
 // back from the stored header and body. If either the header or body could not
 // be retrieved nil is returned.
@@ -186,7 +250,7 @@ func GetBlock(db ethdb.Database, hash common.Hash) *types.Block {
 // GetBlockReceipts retrieves the receipts generated by the transactions included
 // in a block given by its hash.
 func GetBlockReceipts(db ethdb.Database, hash common.Hash) types.Receipts {
-	data, _ := db.Get(append(blockReceiptsPrefix, hash[:]...))
+	data := getBlockReceiptsRLP(db, hash)
 	if len(data) == 0 {
 		return nil
 	}
@@ -202,6 +266,16 @@ func GetBlockReceipts(db ethdb.Database, hash common.Hash) types.Receipts {
 	return receipts
 }
 
+// getBlockReceiptsRLP retrieves the receipts of a block in their raw RLP storage
+// encoding.
+func getBlockReceiptsRLP(db ethdb.Database, hash common.Hash) rlp.RawValue {
+	data, _ := db.Get(append(blockReceiptsPrefix, hash[:]...))
+	if len(data) == 0 {
+		data = getAncient(db, ethdb.AncientReceipts, hash)
+	}
+	return data
+}
+
 // GetTransaction retrieves a specific transaction from the database, along with
 // its added positional metadata.
 func GetTransaction(db ethdb.Database, hash common.Hash) (*types.Transaction, common.Hash, uint64, uint64) {
@@ -281,8 +355,17 @@ func WriteHeadFastBlockHash(db ethdb.Database, hash common.Hash) error {
 	return nil
 }
 
+// WriteTriePruned marks the database as having its state tries garbage collected.
+func WriteTriePruned(db ethdb.Database) error {
+	if err := db.Put(triePrunedKey, []byte{0x01}); err != nil {
+		glog.Fatalf("failed to store trie pruning marker into database: %v", err)
+		return err
+	}
+	return nil
+}
+
 // WriteHeader serializes a block header into the database.
-func WriteHeader(db ethdb.Database, header *types.Header) error {
+func WriteHeader(db ethdb.Putter, header *types.Header) error {
 	data, err := rlp.EncodeToBytes(header)
 	if err != nil {
 		return err
@@ -297,7 +380,7 @@ func WriteHeader(db ethdb.Database, header *types.Header) error {
 }
 
 // WriteBody serializes the body of a block into the database.
-func WriteBody(db ethdb.Database, hash common.Hash, body *types.Body) error {
+func WriteBody(db ethdb.Putter, hash common.Hash, body *types.Body) error {
 	data, err := rlp.EncodeToBytes(body)
 	if err != nil {
 		return err
@@ -312,7 +395,7 @@ func WriteBody(db ethdb.Database, hash common.Hash, body *types.Body) error {
 }
 
 // WriteTd serializes the total difficulty of a block into the database.
-func WriteTd(db ethdb.Database, hash common.Hash, td *big.Int) error {
+func WriteTd(db ethdb.Putter, hash common.Hash, td *big.Int) error {
 	data, err := rlp.EncodeToBytes(td)
 	if err != nil {
 		return err
@@ -577,10 +660,31 @@ func GetMipmapBloom(db ethdb.Database, number, level uint64) types.Bloom {
 	return types.BytesToBloom(bloomDat)
 }
 
+// bloomBitsKey returns the key of the bloom bit vector of a section, keyed by
+// the hash of the section's last block.
+func bloomBitsKey(bit uint, section uint64, head common.Hash) []byte {
+	key := append(append([]byte{}, bloomBitsPrefix...), make([]byte, 10)...)
+	binary.BigEndian.PutUint16(key[len(bloomBitsPrefix):], uint16(bit))
+	binary.BigEndian.PutUint64(key[len(bloomBitsPrefix)+2:], section)
+	return append(key, head.Bytes()...)
+}
+
+// GetBloomBits retrieves the compressed bit vector of a bloom bit for the
+// blocks of a section ending with the given head.
+func GetBloomBits(db ethdb.Database, bit uint, section uint64, head common.Hash) ([]byte, error) {
+	return db.Get(bloomBitsKey(bit, section, head))
+}
+
+// WriteBloomBits stores the compressed bit vector of a bloom bit for the blocks
+// of a section ending with the given head.
+func WriteBloomBits(db ethdb.Putter, bit uint, section uint64, head common.Hash, bits []byte) error {
+	return db.Put(bloomBitsKey(bit, section, head), bits)
+}
+
 // GetBlockChainVersion reads the version number from db.
 func GetBlockChainVersion(db ethdb.Database) int {
 	var vsn uint
-	enc, _ := db.Get([]byte("BlockchainVersion"))
+	enc, _ := db.Get(blockChainVersionKey)
 	rlp.DecodeBytes(enc, &vsn)
 	return int(vsn)
 }
@@ -588,5 +692,5 @@ func GetBlockChainVersion(db ethdb.Database) int {
 // WriteBlockChainVersion writes vsn as the version number to db.
 func WriteBlockChainVersion(db ethdb.Database, vsn int) {
 	enc, _ := rlp.EncodeToBytes(uint(vsn))
-	db.Put([]byte("BlockchainVersion"), enc)
+	db.Put(blockChainVersionKey, enc)
 }
@@ -29,6 +29,14 @@ var (
 	BlockFutureErr   = errors.New("block time is in the future")
 	BlockTSTooBigErr = errors.New("block time too big")
 	BlockEqualTSErr  = errors.New("block time stamp equal to previous")
+
+	// ReorgFinalityErr is returned when MESS artificial finality (ECBP-1100)
+	// refuses to reorganise the chain.
+	ReorgFinalityErr = errors.New("finality-enforced invalid new chain")
+
+	// ErrPrunedAncestor is returned when validating a block whose parent is known,
+	// but whose parent's state was garbage collected.
+	ErrPrunedAncestor = errors.New("pruned ancestor")
 )
 
 // Parent error. In case a parent is unknown this error will be thrown
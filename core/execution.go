@@ -17,10 +17,12 @@
 package core
 
 import (
+	"errors"
 	"fmt"
 	"math/big"
 
 	"github.com/eth-classic/go-ethereum/common"
+	"github.com/eth-classic/go-ethereum/core/state"
 	"github.com/eth-classic/go-ethereum/core/vm"
 	"github.com/eth-classic/go-ethereum/crypto"
 )
@@ -31,18 +33,22 @@ var (
 
 	maxCodeSize            = 24576
 	errMaxCodeSizeExceeded = fmt.Errorf("Max Code Size exceeded (%d)", maxCodeSize)
+
+	errContractAddressCollision = errors.New("contract address collision")
+
+	emptyCodeHash = crypto.Keccak256Hash(nil)
 )
 
 // Call executes within the given contract
 func Call(env vm.Environment, caller vm.ContractRef, addr common.Address, input []byte, gas, gasPrice, value *big.Int) (ret []byte, err error) {
-	ret, _, err = exec(env, caller, &addr, &addr, env.Db().GetCodeHash(addr), input, env.Db().GetCode(addr), gas, gasPrice, value, false)
+	ret, _, err = exec(env, caller, &addr, &addr, env.Db().GetCodeHash(addr), input, env.Db().GetCode(addr), gas, gasPrice, value, nil, false)
 	return ret, err
 }
 
 // CallCode executes the given address' code as the given contract address
 func CallCode(env vm.Environment, caller vm.ContractRef, addr common.Address, input []byte, gas, gasPrice, value *big.Int) (ret []byte, err error) {
 	callerAddr := caller.Address()
-	ret, _, err = exec(env, caller, &callerAddr, &addr, env.Db().GetCodeHash(addr), input, env.Db().GetCode(addr), gas, gasPrice, value, false)
+	ret, _, err = exec(env, caller, &callerAddr, &addr, env.Db().GetCodeHash(addr), input, env.Db().GetCode(addr), gas, gasPrice, value, nil, false)
 	return ret, err
 }
 
@@ -57,13 +63,13 @@ func DelegateCall(env vm.Environment, caller vm.ContractRef, addr common.Address
 
 // StaticCall executes within the given contract and throws exception if state is attempted to be changed
 func StaticCall(env vm.Environment, caller vm.ContractRef, addr common.Address, input []byte, gas, gasPrice *big.Int) (ret []byte, err error) {
-	ret, _, err = exec(env, caller, &addr, &addr, env.Db().GetCodeHash(addr), input, env.Db().GetCode(addr), gas, gasPrice, new(big.Int), true)
+	ret, _, err = exec(env, caller, &addr, &addr, env.Db().GetCodeHash(addr), input, env.Db().GetCode(addr), gas, gasPrice, new(big.Int), nil, true)
 	return ret, err
 }
 
 // Create creates a new contract with the given code
 func Create(env vm.Environment, caller vm.ContractRef, code []byte, gas, gasPrice, value *big.Int) (ret []byte, address common.Address, err error) {
-	ret, address, err = exec(env, caller, nil, nil, crypto.Keccak256Hash(code), nil, code, gas, gasPrice, value, false)
+	ret, address, err = exec(env, caller, nil, nil, crypto.Keccak256Hash(code), nil, code, gas, gasPrice, value, nil, false)
 	// Here we get an error if we run into maximum stack depth,
 	// See: https://github.com/ethereum/yellowpaper/pull/131
 	// and YP definitions for CREATE
@@ -75,7 +81,22 @@ func Create(env vm.Environment, caller vm.ContractRef, code []byte, gas, gasPric
 	return ret, address, err
 }
 
-func exec(env vm.Environment, caller vm.ContractRef, address, codeAddr *common.Address, codeHash common.Hash, input, code []byte, gas, gasPrice, value *big.Int, readOnly bool) (ret []byte, addr common.Address, err error) {
+// Create2 creates a new contract with the given code at an address derived
+// from the caller, the salt and the hash of the init code (EIP-1014)
+func Create2(env vm.Environment, caller vm.ContractRef, code []byte, gas, gasPrice, salt, value *big.Int) (ret []byte, address common.Address, err error) {
+	ret, address, err = exec(env, caller, nil, nil, crypto.Keccak256Hash(code), nil, code, gas, gasPrice, value, salt, false)
+
+	//if there's an error we return nothing
+	if err != nil && err != vm.ErrRevert {
+		return nil, address, err
+	}
+	return ret, address, err
+}
+
+// exec executes the given code in the context of address. A nil address
+// creates a new contract account, whose address is derived from the caller's
+// nonce or, if salt is non-nil, from the salt and code hash (CREATE2).
+func exec(env vm.Environment, caller vm.ContractRef, address, codeAddr *common.Address, codeHash common.Hash, input, code []byte, gas, gasPrice, value, salt *big.Int, readOnly bool) (ret []byte, addr common.Address, err error) {
 	evm := env.Vm()
 	// Depth check execution. Fail if we're trying to execute above the
 	// limit.
@@ -96,9 +117,27 @@ func exec(env vm.Environment, caller vm.ContractRef, address, codeAddr *common.A
 		// Create a new account on the state
 		nonce := env.Db().GetNonce(caller.Address())
 		env.Db().SetNonce(caller.Address(), nonce+1)
-		addr = crypto.CreateAddress(caller.Address(), nonce)
+		if salt != nil {
+			addr = crypto.CreateAddress2(caller.Address(), common.BigToHash(salt), codeHash.Bytes())
+		} else {
+			addr = crypto.CreateAddress(caller.Address(), nonce)
+		}
 		address = &addr
 		createAccount = true
+
+		// The address of the new contract is warm from the start (EIP-2929).
+		if env.RuleSet().IsEIP2929(env.BlockNumber()) {
+			env.Db().AddAddressToAccessList(addr)
+		}
+
+		// Ensure there's no existing contract already at the designated address.
+		// The remaining gas is consumed, as for any other failed creation.
+		if env.RuleSet().IsAgharta(env.BlockNumber()) && env.Db().Exist(addr) {
+			contractHash := env.Db().GetCodeHash(addr)
+			if env.Db().GetNonce(addr) != state.StartingNonce || (contractHash != (common.Hash{}) && contractHash != emptyCodeHash) {
+				return nil, common.Address{}, errContractAddressCollision
+			}
+		}
 	}
 
 	snapshotPreTransfer := env.SnapshotDatabase()
@@ -116,7 +155,8 @@ func exec(env vm.Environment, caller vm.ContractRef, address, codeAddr *common.A
 	} else {
 		if !env.Db().Exist(*address) {
 			//no account may change state from non-existent to existent-but-empty. Refund sender.
-			if vm.PrecompiledAtlantis[(*address).Str()] == nil && env.RuleSet().IsAtlantis(env.BlockNumber()) && value.BitLen() == 0 {
+			precompiles := vm.Precompiles(env.RuleSet(), env.BlockNumber())
+			if precompiles[(*address).Str()] == nil && env.RuleSet().IsAtlantis(env.BlockNumber()) && value.BitLen() == 0 {
 				caller.ReturnGas(gas, gasPrice)
 				return nil, common.Address{}, nil
 			}
@@ -142,6 +182,10 @@ func exec(env vm.Environment, caller vm.ContractRef, address, codeAddr *common.A
 	// calculate the gas required to store the code. If the code could not
 	// be stored due to not enough gas set an error and let it be handled
 	// by the error checking condition below.
+	if err == nil && createAccount && !maxCodeSizeExceeded && len(ret) > 0 && ret[0] == 0xEF && env.RuleSet().IsEIP3541(env.BlockNumber()) {
+		// EIP-3541 reserves the 0xEF prefix, code starting with it is rejected
+		err = vm.ErrInvalidCode
+	}
 	if err == nil && createAccount && !maxCodeSizeExceeded {
 		dataGas := big.NewInt(int64(len(ret)))
 		// create data gas
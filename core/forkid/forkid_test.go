@@ -0,0 +1,134 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package forkid
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/eth-classic/go-ethereum/common"
+	"github.com/eth-classic/go-ethereum/core"
+	"github.com/eth-classic/go-ethereum/rlp"
+)
+
+var mainnetGenesisHash = common.HexToHash("0xd4e56740f876aef8c010b86a40d5f56745a118d0906a34e69aec8c0db1cb8fa3")
+
+// Tests that the fork ID of the Ethereum Classic mainnet is calculated correctly
+// at various points of the chain, skipping the DAO checkpoint and MESS.
+func TestCreation(t *testing.T) {
+	tests := []struct {
+		head uint64
+		want ID
+	}{
+		{0, ID{Hash: checksumToBytes(0xfc64ec04), Next: 1150000}},         // Unsynced
+		{1149999, ID{Hash: checksumToBytes(0xfc64ec04), Next: 1150000}},   // Last Frontier block
+		{1150000, ID{Hash: checksumToBytes(0x97c2c34c), Next: 2500000}},   // First Homestead block
+		{1920000, ID{Hash: checksumToBytes(0x97c2c34c), Next: 2500000}},   // DAO checkpoint is no fork
+		{2500000, ID{Hash: checksumToBytes(0xdb06803f), Next: 3000000}},   // First GasReprice block
+		{3000000, ID{Hash: checksumToBytes(0xaff4bed4), Next: 5000000}},   // First Diehard block
+		{5000000, ID{Hash: checksumToBytes(0xf79a63c0), Next: 5900000}},   // First Gotham block
+		{5900000, ID{Hash: checksumToBytes(0x744899d6), Next: 8772000}},   // First Defuse Difficulty Bomb block
+		{8772000, ID{Hash: checksumToBytes(0x518b59c6), Next: 9573000}},   // First Atlantis block
+		{9573000, ID{Hash: checksumToBytes(0x7ba22882), Next: 10500839}},  // First Agharta block
+		{10500839, ID{Hash: checksumToBytes(0x9007bfcc), Next: 11700000}}, // First Phoenix block
+		{11380000, ID{Hash: checksumToBytes(0x9007bfcc), Next: 11700000}}, // MESS is no fork
+		{11700000, ID{Hash: checksumToBytes(0xdb63a1ca), Next: 13189133}}, // First Thanos block
+		{13189133, ID{Hash: checksumToBytes(0x0f6bf187), Next: 14525000}}, // First Magneto block
+		{14525000, ID{Hash: checksumToBytes(0x7fd1bb25), Next: 19250000}}, // First Mystique block
+		{19250000, ID{Hash: checksumToBytes(0xbe46d57c), Next: 0}},        // First Spiral block
+		{30000000, ID{Hash: checksumToBytes(0xbe46d57c), Next: 0}},        // Future Spiral block
+	}
+	config := core.DefaultConfigMainnet.ChainConfig
+	for i, tt := range tests {
+		if have := NewID(config, mainnetGenesisHash, tt.head); have != tt.want {
+			t.Errorf("test %d: fork ID mismatch: have %x, want %x", i, have, tt.want)
+		}
+	}
+}
+
+// Tests that remote fork IDs are properly validated against the local chain.
+func TestValidation(t *testing.T) {
+	tests := []struct {
+		head uint64
+		id   ID
+		err  error
+	}{
+		// Local is mainnet Thanos, remote announces the same. No future fork is announced.
+		{11700000, ID{Hash: checksumToBytes(0xdb63a1ca), Next: 0}, nil},
+
+		// Local is mainnet Thanos, remote announces the same. Remote also announces a next fork
+		// at block 0xffffffff, but that is uncertain.
+		{11700000, ID{Hash: checksumToBytes(0xdb63a1ca), Next: math.MaxUint32}, nil},
+
+		// Local is mainnet currently in Phoenix only (so it's aware of Thanos), remote announces
+		// also Phoenix, and it's also aware of Thanos (e.g. updated node before the fork).
+		{11000000, ID{Hash: checksumToBytes(0x9007bfcc), Next: 11700000}, nil},
+
+		// Local is mainnet Phoenix, remote announces Thanos. Local is out of sync, accept.
+		{11000000, ID{Hash: checksumToBytes(0xdb63a1ca), Next: 13189133}, nil},
+
+		// Local is mainnet Thanos, remote announces Phoenix with Thanos as next fork.
+		// Remote is simply out of sync, accept.
+		{11800000, ID{Hash: checksumToBytes(0x9007bfcc), Next: 11700000}, nil},
+
+		// Local is mainnet Magneto, remote announces Phoenix without Thanos. Remote needs
+		// software update.
+		{13189133, ID{Hash: checksumToBytes(0x9007bfcc), Next: 0}, ErrRemoteStale},
+
+		// Local is mainnet Thanos, remote announces Thanos with a next fork at a block
+		// already passed locally. The chains are incompatible.
+		{11800000, ID{Hash: checksumToBytes(0xdb63a1ca), Next: 11750000}, ErrLocalIncompatibleOrStale},
+
+		// Local is mainnet Homestead past the DAO block, remote announces the Ethereum
+		// DAO fork. The chains split, reject.
+		{2000000, ID{Hash: checksumToBytes(0x91d1f948), Next: 2463000}, ErrLocalIncompatibleOrStale},
+
+		// Local is mainnet Spiral, remote is random chain.
+		{19250000, ID{Hash: checksumToBytes(0xafec6b27), Next: 0}, ErrLocalIncompatibleOrStale},
+	}
+	config := core.DefaultConfigMainnet.ChainConfig
+	for i, tt := range tests {
+		filter := newFilter(config, mainnetGenesisHash, func() uint64 { return tt.head })
+		if err := filter(tt.id); err != tt.err {
+			t.Errorf("test %d: validation error mismatch: have %v, want %v", i, err, tt.err)
+		}
+	}
+}
+
+// Tests that IDs are properly RLP encoded (specifically important because we
+// use uint32 to store the hash, but we need to encode it as [4]byte).
+func TestEncoding(t *testing.T) {
+	tests := []struct {
+		id   ID
+		want []byte
+	}{
+		{ID{Hash: checksumToBytes(0), Next: 0}, common.Hex2Bytes("c6840000000080")},
+		{ID{Hash: checksumToBytes(0xdeadbeef), Next: 0xBADDCAFE}, common.Hex2Bytes("ca84deadbeef84baddcafe")},
+		{ID{Hash: checksumToBytes(math.MaxUint32), Next: math.MaxUint64}, common.Hex2Bytes("ce84ffffffff88ffffffffffffffff")},
+	}
+	for i, tt := range tests {
+		have, err := rlp.EncodeToBytes(tt.id)
+		if err != nil {
+			t.Errorf("test %d: failed to encode forkid: %v", i, err)
+			continue
+		}
+		if !bytes.Equal(have, tt.want) {
+			t.Errorf("test %d: RLP mismatch: have %x, want %x", i, have, tt.want)
+		}
+	}
+}
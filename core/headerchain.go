@@ -57,6 +57,7 @@ type HeaderChain struct {
 
 	rand         *mrand.Rand
 	getValidator getHeaderValidatorFn
+	engine       Engine // Consensus engine validating headers in place of proof-of-work, if any
 	eventMux     *event.TypeMux
 }
 
@@ -292,9 +293,13 @@ func (hc *HeaderChain) InsertHeaderChain(chain []*types.Header, checkFreq int, w
 			checkPow := verify[index]
 
 			var err error
-			if index == 0 {
+			switch {
+			case hc.engine != nil:
+				// Engines may need the whole not yet stored ancestry of the header
+				err = validateEngineHeader(hc.engine, hc, header, chain[:index])
+			case index == 0:
 				err = hc.getValidator().ValidateHeader(header, hc.GetHeader(header.ParentHash), checkPow)
-			} else {
+			default:
 				err = hc.getValidator().ValidateHeader(header, chain[index-1], checkPow)
 			}
 			if err != nil {
@@ -466,6 +471,15 @@ func (hc *HeaderChain) CurrentHeader() *types.Header {
 	return hc.currentHeader
 }
 
+// Config retrieves the header chain's chain configuration.
+func (hc *HeaderChain) Config() *ChainConfig { return hc.config }
+
+// SetEngine sets the consensus engine validating headers in place of the
+// proof-of-work checks of the validator.
+func (hc *HeaderChain) SetEngine(engine Engine) {
+	hc.engine = engine
+}
+
 // SetCurrentHeader sets the current head header of the canonical chain.
 func (hc *HeaderChain) SetCurrentHeader(head *types.Header) {
 	if err := WriteHeadHeaderHash(hc.chainDb, head.Hash()); err != nil {
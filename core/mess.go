@@ -0,0 +1,127 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"fmt"
+	"math/big"
+	"sync/atomic"
+
+	"github.com/eth-classic/go-ethereum/core/types"
+	"github.com/eth-classic/go-ethereum/logger"
+	"github.com/eth-classic/go-ethereum/logger/glog"
+)
+
+// MESS (Modified Exponential Subjective Scoring, ECBP-1100) antigravity curve
+// parameters. The curve is scaled by messCurveDenominator to keep the calculation
+// in integers.
+var (
+	messCurveDenominator = big.NewInt(128)
+	messXCap             = big.NewInt(25132) // floor(8000*pi), seconds
+	messAmplitude        = big.NewInt(15)
+	messHeight           = new(big.Int).Mul(new(big.Int).Mul(messCurveDenominator, messAmplitude), big2)
+)
+
+// EnableArtificialFinality toggles the enforcement of MESS artificial finality on
+// reorgs. It only has an effect on blocks where the chain configuration activates
+// ECBP-1100.
+func (bc *BlockChain) EnableArtificialFinality(enable bool) {
+	var v int32
+	if enable {
+		v = 1
+	}
+	if atomic.SwapInt32(&bc.artificialFinality, v) != v {
+		glog.V(logger.Info).Infof("MESS artificial finality enabled=%v", enable)
+	}
+}
+
+// IsArtificialFinalityEnabled returns whether MESS artificial finality is enforced.
+func (bc *BlockChain) IsArtificialFinalityEnabled() bool {
+	return atomic.LoadInt32(&bc.artificialFinality) == 1
+}
+
+// messPolynomialV is the antigravity curve of ECBP-1100, a cubic approximation of
+// a sine wave rising from 1 to 31 (times the denominator) over the first xcap
+// seconds since the common ancestor and flat afterwards:
+//
+//	denominator + (3*x^2 - 2*x^3/xcap) * height / xcap^2
+//
+// See https://github.com/ethereumclassic/ECIPs/issues/374#issuecomment-694156719
+func messPolynomialV(x *big.Int) *big.Int {
+	xc := new(big.Int).Set(x)
+	if xc.Cmp(messXCap) > 0 {
+		xc.Set(messXCap)
+	}
+	if xc.Sign() < 0 {
+		xc.SetInt64(0)
+	}
+	// 3 * x^2
+	a := new(big.Int).Mul(xc, xc)
+	a.Mul(a, big.NewInt(3))
+
+	// 2 * x^3 / xcap
+	b := new(big.Int).Exp(xc, big.NewInt(3), nil)
+	b.Mul(b, big2)
+	b.Div(b, messXCap)
+
+	a.Sub(a, b)
+	a.Mul(a, messHeight)
+	a.Div(a, new(big.Int).Mul(messXCap, messXCap))
+
+	return a.Add(a, messCurveDenominator)
+}
+
+// checkArtificialFinality implements ECBP-1100. The total difficulty the proposed
+// chain adds on top of the common ancestor has to exceed the one of the local chain
+// multiplied by an antigravity factor growing with the age of the common ancestor,
+// otherwise ReorgFinalityErr is returned.
+func (bc *BlockChain) checkArtificialFinality(commonAncestor, current, proposed *types.Block) error {
+	ancestorTd := bc.GetTd(commonAncestor.Hash())
+	localTd := bc.GetTd(current.Hash())
+	proposedParentTd := bc.GetTd(proposed.ParentHash())
+	if ancestorTd == nil || localTd == nil || proposedParentTd == nil {
+		return fmt.Errorf("missing total difficulty for MESS reorg check")
+	}
+	proposedTd := new(big.Int).Add(proposedParentTd, proposed.Difficulty())
+
+	age := new(big.Int).Sub(current.Time(), commonAncestor.Time())
+	antigravity := messPolynomialV(age)
+
+	want := new(big.Int).Mul(antigravity, new(big.Int).Sub(localTd, ancestorTd))
+	got := new(big.Int).Mul(messCurveDenominator, new(big.Int).Sub(proposedTd, ancestorTd))
+
+	if got.Cmp(want) >= 0 {
+		return nil
+	}
+	if logger.MlogEnabled() {
+		mlogBlockchainRejectReorg.AssignDetails(
+			commonAncestor.Hash().Hex(),
+			commonAncestor.Number(),
+			age,
+			current.Hash().Hex(),
+			proposed.Hash().Hex(),
+			got,
+			want,
+		).Send(mlogBlockchain)
+	}
+	glog.V(logger.Warn).Infof("MESS rejected reorg: ancestor=#%d [%s] age=%vs current=#%d [%s] proposed=#%d [%s] antigravity=%v/%v",
+		commonAncestor.Number(), commonAncestor.Hash().Hex(), age,
+		current.Number(), current.Hash().Hex(), proposed.Number(), proposed.Hash().Hex(),
+		antigravity, messCurveDenominator)
+
+	return ReorgFinalityErr
+}
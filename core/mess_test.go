@@ -0,0 +1,117 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/eth-classic/go-ethereum/common"
+	"github.com/eth-classic/go-ethereum/core/types"
+	"github.com/eth-classic/go-ethereum/ethdb"
+)
+
+func TestMESSPolynomialV(t *testing.T) {
+	tests := []struct {
+		x    int64
+		want int64
+	}{
+		{0, 128},
+		{12566, 2048},
+		{25132, 3968},
+		{100000, 3968},
+	}
+	for i, tt := range tests {
+		if got := messPolynomialV(big.NewInt(tt.x)); got.Cmp(big.NewInt(tt.want)) != 0 {
+			t.Errorf("test %d: antigravity mismatch at x=%d: have %v, want %v", i, tt.x, got, tt.want)
+		}
+	}
+}
+
+// makeBlockChainWithDiffAndTime creates a chain of blocks on top of parent with the
+// given difficulties, spaced blockTime seconds apart.
+func makeBlockChainWithDiffAndTime(parent *types.Block, d []int, blockTime int64, seed byte) []*types.Block {
+	var chain []*types.Block
+	for i, difficulty := range d {
+		header := &types.Header{
+			ParentHash:  parent.Hash(),
+			Coinbase:    common.Address{seed},
+			Number:      new(big.Int).Add(parent.Number(), common.Big1),
+			Time:        new(big.Int).Add(parent.Time(), big.NewInt(blockTime)),
+			Difficulty:  big.NewInt(int64(difficulty)),
+			UncleHash:   types.EmptyUncleHash,
+			TxHash:      types.EmptyRootHash,
+			ReceiptHash: types.EmptyRootHash,
+		}
+		block := types.NewBlockWithHeader(header)
+		chain = append(chain, block)
+		parent = chain[i]
+	}
+	return chain
+}
+
+// Tests that reorgs to chains not carrying enough work since the common ancestor
+// are rejected by MESS artificial finality, and kept as side chains.
+func TestMESSReorg(t *testing.T) {
+	tests := []struct {
+		enabled   bool
+		activated bool
+		proposed  []int
+		reorg     bool
+	}{
+		{false, true, []int{10, 10, 10, 10}, true},
+		{true, false, []int{10, 10, 10, 10}, true},
+		{true, true, []int{10, 10, 10, 10}, false},
+		{true, true, []int{30, 30, 30, 30}, true},
+	}
+	for i, tt := range tests {
+		db, _ := ethdb.NewMemDatabase()
+		genesis, err := WriteGenesisBlock(db, DefaultConfigMorden.Genesis)
+		if err != nil {
+			t.Fatal(err)
+		}
+		bc := chm(t, genesis, db)
+		if tt.activated {
+			bc.config.Forks = append(bc.config.Forks, &Fork{
+				Name:     "MESS",
+				Block:    big.NewInt(0),
+				Features: []*ForkFeature{{ID: "ecbp1100"}},
+			})
+		}
+		bc.EnableArtificialFinality(tt.enabled)
+
+		// The local chain spans 3000 seconds since the common ancestor
+		local := makeBlockChainWithDiffAndTime(genesis, []int{10, 10, 10}, 1000, 1)
+		if res := bc.InsertChain(local); res.Error != nil {
+			t.Fatalf("test %d: failed to insert local chain: %v", i, res.Error)
+		}
+		proposed := makeBlockChainWithDiffAndTime(genesis, tt.proposed, 10, 2)
+		if res := bc.InsertChain(proposed); res.Error != nil {
+			t.Fatalf("test %d: failed to insert proposed chain: %v", i, res.Error)
+		}
+		want := local[len(local)-1]
+		if tt.reorg {
+			want = proposed[len(proposed)-1]
+		}
+		if head := bc.CurrentBlock(); head.Hash() != want.Hash() {
+			t.Errorf("test %d: head mismatch: have #%d [%x], want #%d [%x]", i, head.NumberU64(), head.Hash().Bytes()[:4], want.NumberU64(), want.Hash().Bytes()[:4])
+		}
+		if bc.GetBlock(proposed[len(proposed)-1].Hash()) == nil {
+			t.Errorf("test %d: proposed chain not stored", i)
+		}
+	}
+}
@@ -14,6 +14,7 @@ var mlogTxPool = logger.MLogRegisterAvailable("txpool", mLogLinesTxPool)
 var mLogLinesBlockchain = []*logger.MLogT{
 	mlogBlockchainWriteBlock,
 	mlogBlockchainInsertBlocks,
+	mlogBlockchainRejectReorg,
 }
 
 var mLogLinesHeaderchain = []*logger.MLogT{
@@ -81,6 +82,24 @@ var mlogBlockchainReorgBlocks = &logger.MLogT{
 	},
 }
 
+var mlogBlockchainRejectReorg = &logger.MLogT{
+	Description: `Called when MESS artificial finality (ECBP-1100) rejects a chain reorganisation.
+The proposed chain is kept as a side chain. REORG.GOT and REORG.WANT are the scaled
+total difficulties of the proposed and the required chain segments since the common ancestor.`,
+	Receiver: "BLOCKCHAIN",
+	Verb:     "REJECT",
+	Subject:  "REORG",
+	Details: []logger.MLogDetailT{
+		{Owner: "REORG", Key: "LAST_COMMON_HASH", Value: "STRING"},
+		{Owner: "REORG", Key: "LAST_COMMON_NUMBER", Value: "BIGINT"},
+		{Owner: "REORG", Key: "AGE", Value: "BIGINT"},
+		{Owner: "BLOCKS", Key: "CURRENT_HASH", Value: "STRING"},
+		{Owner: "BLOCKS", Key: "PROPOSED_HASH", Value: "STRING"},
+		{Owner: "REORG", Key: "GOT", Value: "BIGINT"},
+		{Owner: "REORG", Key: "WANT", Value: "BIGINT"},
+	},
+}
+
 // Headerchain
 var mlogHeaderchainWriteHeader = &logger.MLogT{
 	Description: `Called when a single header is written to the chain header database.
@@ -22,6 +22,7 @@ import (
 
 	"github.com/eth-classic/go-ethereum/common"
 	"github.com/eth-classic/go-ethereum/ethdb"
+	"github.com/eth-classic/go-ethereum/rlp"
 	"github.com/eth-classic/go-ethereum/trie"
 	lru "github.com/hashicorp/golang-lru"
 )
@@ -61,6 +62,8 @@ type Trie interface {
 	Hash() common.Hash
 	NodeIterator(startKey []byte) trie.NodeIterator
 	GetKey([]byte) []byte // TODO(fjl): remove this when SecureTrie is removed
+	// Prove constructs a Merkle proof for key, writing the proof nodes into proofDb.
+	Prove(key []byte, fromLevel uint, proofDb trie.DatabaseWriter) error
 }
 
 // NewDatabase creates a backing store for state. The returned database is safe for
@@ -70,8 +73,29 @@ func NewDatabase(db ethdb.Database) Database {
 	return &cachingDB{db: db, codeSizeCache: csc}
 }
 
+// NewDatabaseWithCache creates a backing store for state which reads trie nodes
+// and contract code through the given trie node cache. State committed into the
+// cache is readable through the returned database before it reaches the disk.
+func NewDatabaseWithCache(cache *trie.NodeCache) Database {
+	csc, _ := lru.New(codeSizeCacheSize)
+	return &cachingDB{db: cache, codeSizeCache: csc}
+}
+
+// NewNodeCache creates a trie node cache for state tries on top of the given
+// database. The storage tries and contract code of the accounts in a cached
+// state trie are kept alive for as long as the account nodes referencing them.
+func NewNodeCache(db ethdb.Database) *trie.NodeCache {
+	return trie.NewNodeCache(db, func(leaf []byte) []common.Hash {
+		var account Account
+		if err := rlp.DecodeBytes(leaf, &account); err != nil {
+			return nil // storage slot, nothing referenced
+		}
+		return []common.Hash{account.Root, common.BytesToHash(account.CodeHash)}
+	})
+}
+
 type cachingDB struct {
-	db            ethdb.Database
+	db            trie.Database
 	mu            sync.Mutex
 	pastTries     []*trie.SecureTrie
 	codeSizeCache *lru.Cache
@@ -0,0 +1,255 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"bytes"
+	"math/big"
+
+	"github.com/eth-classic/go-ethereum/common"
+)
+
+// DiffAccount holds the values of an account at one end of a state diff.
+type DiffAccount struct {
+	Exists  bool
+	Balance *big.Int
+	Nonce   uint64
+	Code    []byte
+}
+
+// equal reports whether both account values are the same.
+func (a DiffAccount) equal(b DiffAccount) bool {
+	return a.Exists == b.Exists && a.Balance.Cmp(b.Balance) == 0 && a.Nonce == b.Nonce && bytes.Equal(a.Code, b.Code)
+}
+
+// StorageDiff holds the value of a storage slot before and after a set of state
+// changes.
+type StorageDiff struct {
+	Pre, Post common.Hash
+}
+
+// AccountDiff holds the values of an account before and after a set of state
+// changes, along with the modified storage slots.
+type AccountDiff struct {
+	Pre, Post DiffAccount
+	Storage   map[common.Hash]StorageDiff
+}
+
+// Diff is the set of accounts modified by a set of state changes.
+type Diff map[common.Address]*AccountDiff
+
+// MergeDiffs combines consecutive diffs into a single one, spanning from the pre
+// values of the first to the post values of the last. Items ending up at their
+// original value are dropped.
+func MergeDiffs(diffs ...Diff) Diff {
+	merged := make(Diff)
+	for _, diff := range diffs {
+		for addr, acc := range diff {
+			prev, ok := merged[addr]
+			if !ok {
+				prev = &AccountDiff{Pre: acc.Pre, Storage: make(map[common.Hash]StorageDiff)}
+				merged[addr] = prev
+			}
+			prev.Post = acc.Post
+			for key, slot := range acc.Storage {
+				if old, ok := prev.Storage[key]; ok {
+					slot.Pre = old.Pre
+				}
+				prev.Storage[key] = slot
+			}
+		}
+	}
+	for addr, acc := range merged {
+		for key, slot := range acc.Storage {
+			if slot.Pre == slot.Post {
+				delete(acc.Storage, key)
+			}
+		}
+		if len(acc.Storage) == 0 && acc.Pre.equal(acc.Post) {
+			delete(merged, addr)
+		}
+	}
+	return merged
+}
+
+// diffRecorder collects the original values of the state items modified since
+// the recording started, as found in the journal entries undoing them.
+type diffRecorder struct {
+	accounts     map[common.Address]*recordedAccount
+	journalIndex int // Number of journal entries already recorded
+}
+
+// recordedAccount holds the original values of the modified fields of an account.
+// The fields never modified are read from obj, which is left untouched by any
+// later recreation of the account.
+type recordedAccount struct {
+	obj     *StateObject // Account object preceding the recording, nil if it didn't exist
+	created bool         // Whether the account didn't exist when the recording started
+	reset   bool         // Whether the account was recreated, discarding obj
+
+	balance *big.Int
+	nonce   *uint64
+	code    []byte
+	codeSet bool
+	storage map[common.Hash]common.Hash
+}
+
+// StartDiff starts recording the modifications made to the state, discarding
+// any previously recorded ones. The recording is not carried over by Copy.
+func (self *StateDB) StartDiff() {
+	self.diff = &diffRecorder{
+		accounts:     make(map[common.Address]*recordedAccount),
+		journalIndex: len(self.journal),
+	}
+}
+
+// Diff returns the accounts and storage slots modified since StartDiff with
+// their values before and after. Storage slots left behind by an account that
+// was recreated are not reported. Recording continues after the call.
+func (self *StateDB) Diff() Diff {
+	if self.diff == nil {
+		return nil
+	}
+	self.recordJournal()
+
+	diff := make(Diff)
+	for addr, rec := range self.diff.accounts {
+		acc := &AccountDiff{
+			Pre:     rec.pre(self.db),
+			Storage: make(map[common.Hash]StorageDiff),
+		}
+		post := self.getStateObject(addr)
+		if post == nil {
+			acc.Post = DiffAccount{Balance: new(big.Int)}
+		} else {
+			acc.Post = DiffAccount{
+				Exists:  true,
+				Balance: new(big.Int).Set(post.Balance()),
+				Nonce:   post.Nonce(),
+				Code:    common.CopyBytes(post.Code(self.db)),
+			}
+		}
+		for key, pre := range rec.storage {
+			var value common.Hash
+			if post != nil {
+				value = post.GetState(self.db, key)
+			}
+			if value != pre {
+				acc.Storage[key] = StorageDiff{Pre: pre, Post: value}
+			}
+		}
+		if len(acc.Storage) > 0 || !acc.Pre.equal(acc.Post) {
+			diff[addr] = acc
+		}
+	}
+	return diff
+}
+
+// recordJournal records the original values found in the journal entries added
+// since the last call.
+func (self *StateDB) recordJournal() {
+	if self.diff == nil {
+		return
+	}
+	for _, entry := range self.journal[self.diff.journalIndex:] {
+		switch ch := entry.(type) {
+		case createObjectChange:
+			if _, ok := self.diff.accounts[*ch.account]; !ok {
+				self.diff.accounts[*ch.account] = &recordedAccount{created: true, storage: make(map[common.Hash]common.Hash)}
+			}
+		case resetObjectChange:
+			rec := self.recordAccount(ch.prev.address)
+			if !rec.created && !rec.reset {
+				rec.obj = ch.prev
+			}
+			rec.reset = true
+		case suicideChange:
+			if rec := self.recordAccount(*ch.account); rec.balance == nil {
+				rec.balance = new(big.Int).Set(ch.prevbalance)
+			}
+		case balanceChange:
+			if rec := self.recordAccount(*ch.account); rec.balance == nil {
+				rec.balance = new(big.Int).Set(ch.prev)
+			}
+		case nonceChange:
+			if rec := self.recordAccount(*ch.account); rec.nonce == nil {
+				nonce := ch.prev
+				rec.nonce = &nonce
+			}
+		case codeChange:
+			if rec := self.recordAccount(*ch.account); !rec.codeSet {
+				rec.code, rec.codeSet = common.CopyBytes(ch.prevcode), true
+			}
+		case storageChange:
+			rec := self.recordAccount(*ch.account)
+			if _, ok := rec.storage[ch.key]; ok {
+				continue
+			}
+			switch {
+			case rec.created:
+				rec.storage[ch.key] = common.Hash{}
+			case rec.reset:
+				// The slot wasn't modified before the recreation, original value is untouched
+				rec.storage[ch.key] = rec.obj.GetState(self.db, ch.key)
+			default:
+				rec.storage[ch.key] = ch.prevalue
+			}
+		case touchChange:
+			self.recordAccount(*ch.account)
+		}
+	}
+	self.diff.journalIndex = len(self.journal)
+}
+
+// recordAccount returns the recorded original values of an account, tracking it
+// if it wasn't modified yet. Its live object is then the one preceding the
+// recording, as a newly created account is always journalled first.
+func (self *StateDB) recordAccount(addr common.Address) *recordedAccount {
+	if rec, ok := self.diff.accounts[addr]; ok {
+		return rec
+	}
+	rec := &recordedAccount{
+		obj:     self.stateObjects[addr],
+		storage: make(map[common.Hash]common.Hash),
+	}
+	self.diff.accounts[addr] = rec
+	return rec
+}
+
+// pre assembles the original values of the recorded account.
+func (rec *recordedAccount) pre(db Database) DiffAccount {
+	if rec.created || rec.obj == nil {
+		return DiffAccount{Balance: new(big.Int)}
+	}
+	acc := DiffAccount{
+		Exists:  true,
+		Balance: rec.balance,
+		Code:    rec.code,
+	}
+	if acc.Balance == nil {
+		acc.Balance = new(big.Int).Set(rec.obj.Balance())
+	}
+	if rec.nonce != nil {
+		acc.Nonce = *rec.nonce
+	} else {
+		acc.Nonce = rec.obj.Nonce()
+	}
+	if !rec.codeSet {
+		acc.Code = common.CopyBytes(rec.obj.Code(db))
+	}
+	return acc
+}
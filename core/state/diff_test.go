@@ -0,0 +1,142 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/eth-classic/go-ethereum/common"
+	"github.com/eth-classic/go-ethereum/crypto"
+	"github.com/eth-classic/go-ethereum/ethdb"
+)
+
+func TestDiff(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	state, _ := New(common.Hash{}, NewDatabase(db))
+
+	var (
+		sender   = common.BytesToAddress([]byte{0x01})
+		contract = common.BytesToAddress([]byte{0x02})
+		doomed   = common.BytesToAddress([]byte{0x03})
+		created  = common.BytesToAddress([]byte{0x04})
+		slot1    = common.BytesToHash([]byte{0x11})
+		slot2    = common.BytesToHash([]byte{0x12})
+	)
+	state.SetBalance(sender, big.NewInt(100))
+	state.SetNonce(sender, 5)
+	state.SetCode(contract, []byte{0x60, 0x00})
+	state.SetState(contract, slot1, common.BytesToHash([]byte{0x01}))
+	state.SetState(contract, slot2, common.BytesToHash([]byte{0x02}))
+	state.SetBalance(doomed, big.NewInt(7))
+	root, _ := state.CommitTo(db, false)
+	state, _ = New(root, NewDatabase(db))
+
+	// Apply a first transaction, with a reverted part
+	state.StartDiff()
+	state.SetBalance(sender, big.NewInt(70))
+	state.SetNonce(sender, 6)
+	state.SetState(contract, slot1, common.BytesToHash([]byte{0xaa}))
+
+	rev := state.Snapshot()
+	state.SetState(contract, slot2, common.BytesToHash([]byte{0xbb}))
+	state.AddBalance(created, big.NewInt(1))
+	state.RevertToSnapshot(rev)
+	state.Finalise(true)
+
+	first := state.Diff()
+	if len(first) != 2 {
+		t.Fatalf("first diff account count mismatch: have %d, want 2", len(first))
+	}
+	acc := first[sender]
+	if acc == nil || acc.Pre.Balance.Int64() != 100 || acc.Post.Balance.Int64() != 70 || acc.Pre.Nonce != 5 || acc.Post.Nonce != 6 {
+		t.Errorf("sender diff mismatch: %+v", acc)
+	}
+	acc = first[contract]
+	if acc == nil || len(acc.Storage) != 1 || acc.Storage[slot1] != (StorageDiff{common.BytesToHash([]byte{0x01}), common.BytesToHash([]byte{0xaa})}) {
+		t.Errorf("contract diff mismatch: %+v", acc)
+	}
+	if acc != nil && (!acc.Pre.Exists || !acc.Post.Exists || !bytes.Equal(acc.Pre.Code, []byte{0x60, 0x00})) {
+		t.Errorf("contract account values mismatch: pre %+v, post %+v", acc.Pre, acc.Post)
+	}
+
+	// Apply a second transaction creating and destroying accounts
+	state.StartDiff()
+	state.SetState(contract, slot1, common.BytesToHash([]byte{0x01}))
+	state.Suicide(doomed)
+	state.AddBalance(created, big.NewInt(3))
+	state.SetCode(created, []byte{0x01})
+	state.Finalise(true)
+
+	second := state.Diff()
+	if len(second) != 3 {
+		t.Fatalf("second diff account count mismatch: have %d, want 3", len(second))
+	}
+	acc = second[doomed]
+	if acc == nil || !acc.Pre.Exists || acc.Post.Exists || acc.Pre.Balance.Int64() != 7 || acc.Post.Balance.Sign() != 0 {
+		t.Errorf("destructed account diff mismatch: %+v", acc)
+	}
+	acc = second[created]
+	if acc == nil || acc.Pre.Exists || !acc.Post.Exists || acc.Post.Balance.Int64() != 3 || !bytes.Equal(acc.Post.Code, []byte{0x01}) {
+		t.Errorf("created account diff mismatch: %+v", acc)
+	}
+
+	// Merging both transactions cancels out the storage change of the contract
+	merged := MergeDiffs(first, second)
+	if _, ok := merged[contract]; ok {
+		t.Errorf("restored contract still in merged diff: %+v", merged[contract])
+	}
+	if len(merged) != 3 {
+		t.Errorf("merged diff account count mismatch: have %d, want 3", len(merged))
+	}
+	if acc := merged[sender]; acc == nil || acc.Pre.Balance.Int64() != 100 || acc.Post.Balance.Int64() != 70 {
+		t.Errorf("merged sender diff mismatch: %+v", acc)
+	}
+}
+
+func TestDiffRecreatedAccount(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	state, _ := New(common.Hash{}, NewDatabase(db))
+
+	var (
+		addr = crypto.CreateAddress(common.Address{}, 0)
+		slot = common.BytesToHash([]byte{0x11})
+	)
+	state.SetBalance(addr, big.NewInt(10))
+	state.SetNonce(addr, 1)
+	state.SetState(addr, slot, common.BytesToHash([]byte{0x01}))
+	root, _ := state.CommitTo(db, false)
+	state, _ = New(root, NewDatabase(db))
+
+	// Recreate the account, carrying over the balance, and write the same slot
+	state.StartDiff()
+	state.CreateAccount(addr)
+	state.SetState(addr, slot, common.BytesToHash([]byte{0x02}))
+
+	diff := state.Diff()
+	acc := diff[addr]
+	if acc == nil {
+		t.Fatalf("recreated account missing from diff")
+	}
+	if !acc.Pre.Exists || acc.Pre.Nonce != 1 || acc.Post.Nonce != 0 {
+		t.Errorf("recreated account values mismatch: pre %+v, post %+v", acc.Pre, acc.Post)
+	}
+	if have := acc.Storage[slot]; have != (StorageDiff{common.BytesToHash([]byte{0x01}), common.BytesToHash([]byte{0x02})}) {
+		t.Errorf("recreated storage diff mismatch: have %+v", have)
+	}
+}
@@ -0,0 +1,168 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/eth-classic/go-ethereum/common"
+	"github.com/eth-classic/go-ethereum/common/hexutil"
+	"github.com/eth-classic/go-ethereum/rlp"
+	"github.com/eth-classic/go-ethereum/trie"
+)
+
+// ExportConfig selects the accounts and data streamed by Export.
+type ExportConfig struct {
+	Start       common.Hash // Hash of the first account to export, accounts are ordered by hash
+	Limit       uint64      // Maximum number of accounts to export, 0 for no limit
+	SkipCode    bool        // Whether to leave out contract code
+	SkipStorage bool        // Whether to leave out contract storage
+	RLP         bool        // Whether to encode the accounts as an RLP stream instead of JSON lines
+}
+
+// ExportAccount is a single account of a state export. The address and storage
+// keys are only set if their preimages are known, their hashes always are.
+type ExportAccount struct {
+	Address  hexutil.Bytes `json:"address,omitempty"`
+	Hash     common.Hash   `json:"hash"`
+	Nonce    uint64        `json:"nonce"`
+	Balance  *big.Int      `json:"balance"`
+	Root     common.Hash   `json:"root"`
+	CodeHash common.Hash   `json:"codeHash"`
+	Code     hexutil.Bytes `json:"code,omitempty"`
+	Storage  []ExportSlot  `json:"storage,omitempty"`
+}
+
+// ExportSlot is a single storage slot of an exported account.
+type ExportSlot struct {
+	Key   hexutil.Bytes `json:"key,omitempty"`
+	Hash  common.Hash   `json:"hash"`
+	Value common.Hash   `json:"value"`
+}
+
+// Export streams the accounts of the state to w one by one, without holding the
+// state in memory. If the limit was reached, the hash of the next account is
+// returned to resume the export from, nil otherwise.
+func (self *StateDB) Export(w io.Writer, conf *ExportConfig) (*common.Hash, error) {
+	out := bufio.NewWriter(w)
+	enc := json.NewEncoder(out)
+
+	var exported uint64
+	it := trie.NewIterator(self.trie.NodeIterator(conf.Start[:]))
+	for it.Next() {
+		if conf.Limit > 0 && exported == conf.Limit {
+			next := common.BytesToHash(it.Key)
+			return &next, out.Flush()
+		}
+		acc, err := self.exportAccount(it.Key, it.Value, conf)
+		if err != nil {
+			return nil, err
+		}
+		if conf.RLP {
+			err = rlp.Encode(out, acc)
+		} else {
+			err = enc.Encode(acc)
+		}
+		if err != nil {
+			return nil, err
+		}
+		exported++
+	}
+	if it.Err != nil {
+		return nil, it.Err
+	}
+	return nil, out.Flush()
+}
+
+// exportAccount assembles the export of the account stored in the account trie
+// under the given hash.
+func (self *StateDB) exportAccount(hash, blob []byte, conf *ExportConfig) (*ExportAccount, error) {
+	var data Account
+	if err := rlp.DecodeBytes(blob, &data); err != nil {
+		return nil, fmt.Errorf("invalid account %x: %v", hash, err)
+	}
+	acc := &ExportAccount{
+		Address:  self.trie.GetKey(hash),
+		Hash:     common.BytesToHash(hash),
+		Nonce:    data.Nonce,
+		Balance:  data.Balance,
+		Root:     data.Root,
+		CodeHash: common.BytesToHash(data.CodeHash),
+	}
+	if !conf.SkipCode && acc.CodeHash != common.BytesToHash(emptyCodeHash) {
+		code, err := self.db.ContractCode(acc.Hash, acc.CodeHash)
+		if err != nil {
+			return nil, fmt.Errorf("missing code of account %x: %v", hash, err)
+		}
+		acc.Code = code
+	}
+	if !conf.SkipStorage {
+		storage, err := self.db.OpenStorageTrie(acc.Hash, acc.Root)
+		if err != nil {
+			return nil, fmt.Errorf("missing storage of account %x: %v", hash, err)
+		}
+		it := trie.NewIterator(storage.NodeIterator(nil))
+		for it.Next() {
+			_, content, _, err := rlp.Split(it.Value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid storage slot %x of account %x: %v", it.Key, hash, err)
+			}
+			acc.Storage = append(acc.Storage, ExportSlot{
+				Key:   self.trie.GetKey(it.Key),
+				Hash:  common.BytesToHash(it.Key),
+				Value: common.BytesToHash(content),
+			})
+		}
+		if it.Err != nil {
+			return nil, it.Err
+		}
+	}
+	return acc, nil
+}
+
+// ExportReader decodes the accounts of a stream written by Export.
+type ExportReader struct {
+	json   *json.Decoder
+	stream *rlp.Stream
+}
+
+// NewExportReader creates a reader for an export stream in the given encoding.
+func NewExportReader(r io.Reader, isRLP bool) *ExportReader {
+	if isRLP {
+		return &ExportReader{stream: rlp.NewStream(bufio.NewReader(r), 0)}
+	}
+	return &ExportReader{json: json.NewDecoder(bufio.NewReader(r))}
+}
+
+// Next decodes the next account of the stream, returning io.EOF at its end.
+func (r *ExportReader) Next() (*ExportAccount, error) {
+	acc := new(ExportAccount)
+	if r.stream != nil {
+		if err := r.stream.Decode(acc); err != nil {
+			return nil, err
+		}
+		return acc, nil
+	}
+	if err := r.json.Decode(acc); err != nil {
+		return nil, err
+	}
+	return acc, nil
+}
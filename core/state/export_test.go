@@ -0,0 +1,152 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package state
+
+import (
+	"bytes"
+	"io"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/eth-classic/go-ethereum/common"
+	"github.com/eth-classic/go-ethereum/crypto"
+	"github.com/eth-classic/go-ethereum/ethdb"
+)
+
+// makeExportState creates a state of a few accounts, some with code and storage.
+func makeExportState(t *testing.T) *StateDB {
+	db, _ := ethdb.NewMemDatabase()
+	state, _ := New(common.Hash{}, NewDatabase(db))
+	for i := byte(0); i < 32; i++ {
+		addr := common.BytesToAddress([]byte{i})
+		state.SetBalance(addr, big.NewInt(int64(i)+1))
+		state.SetNonce(addr, uint64(i))
+		if i%4 == 0 {
+			state.SetCode(addr, []byte{i, i, i})
+			state.SetState(addr, common.BytesToHash([]byte{i}), common.BytesToHash([]byte{i + 1}))
+		}
+	}
+	root, err := state.CommitTo(db, false)
+	if err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	state, _ = New(root, NewDatabase(db))
+	return state
+}
+
+// readExport decodes all the accounts of an export stream.
+func readExport(t *testing.T, r io.Reader, isRLP bool) []*ExportAccount {
+	var accounts []*ExportAccount
+	reader := NewExportReader(r, isRLP)
+	for {
+		acc, err := reader.Next()
+		if err == io.EOF {
+			return accounts
+		}
+		if err != nil {
+			t.Fatalf("failed to decode account #%d: %v", len(accounts), err)
+		}
+		accounts = append(accounts, acc)
+	}
+}
+
+func TestExport(t *testing.T) {
+	state := makeExportState(t)
+
+	for _, isRLP := range []bool{false, true} {
+		buf := new(bytes.Buffer)
+		next, err := state.Export(buf, &ExportConfig{RLP: isRLP})
+		if err != nil {
+			t.Fatalf("rlp %v: export failed: %v", isRLP, err)
+		}
+		if next != nil {
+			t.Errorf("rlp %v: unexpected resume hash %x", isRLP, *next)
+		}
+		accounts := readExport(t, buf, isRLP)
+		if len(accounts) != 32 {
+			t.Fatalf("rlp %v: account count mismatch: have %d, want 32", isRLP, len(accounts))
+		}
+		for i, acc := range accounts {
+			if i > 0 && bytes.Compare(accounts[i-1].Hash[:], acc.Hash[:]) >= 0 {
+				t.Errorf("rlp %v: account %d out of order", isRLP, i)
+			}
+			addr := common.BytesToAddress(acc.Address)
+			if crypto.Keccak256Hash(acc.Address) != acc.Hash {
+				t.Errorf("rlp %v: account %x: address preimage mismatch", isRLP, acc.Hash)
+			}
+			if acc.Nonce != state.GetNonce(addr) || acc.Balance.Cmp(state.GetBalance(addr)) != 0 {
+				t.Errorf("rlp %v: account %x: values mismatch: nonce %d, balance %v", isRLP, addr, acc.Nonce, acc.Balance)
+			}
+			if !bytes.Equal(acc.Code, state.GetCode(addr)) {
+				t.Errorf("rlp %v: account %x: code mismatch: have %x", isRLP, addr, acc.Code)
+			}
+			if want := len(state.GetCode(addr)) > 0; want != (len(acc.Storage) == 1) {
+				t.Fatalf("rlp %v: account %x: storage mismatch: %+v", isRLP, addr, acc.Storage)
+			}
+			for _, slot := range acc.Storage {
+				key := common.BytesToHash(slot.Key)
+				if slot.Value != state.GetState(addr, key) || crypto.Keccak256Hash(slot.Key) != slot.Hash {
+					t.Errorf("rlp %v: account %x: slot mismatch: %+v", isRLP, addr, slot)
+				}
+			}
+		}
+	}
+}
+
+func TestExportRange(t *testing.T) {
+	state := makeExportState(t)
+
+	full := new(bytes.Buffer)
+	if _, err := state.Export(full, &ExportConfig{SkipCode: true, SkipStorage: true}); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	want := readExport(t, full, false)
+
+	// Export the state in chunks, resuming from the returned hashes
+	var (
+		have  []*ExportAccount
+		start common.Hash
+	)
+	for chunks := 0; ; chunks++ {
+		if chunks > 4 {
+			t.Fatalf("export didn't terminate")
+		}
+		buf := new(bytes.Buffer)
+		next, err := state.Export(buf, &ExportConfig{Start: start, Limit: 10, SkipCode: true, SkipStorage: true})
+		if err != nil {
+			t.Fatalf("chunk %d: export failed: %v", chunks, err)
+		}
+		accounts := readExport(t, buf, false)
+		if next != nil && len(accounts) != 10 {
+			t.Fatalf("chunk %d: account count mismatch: have %d, want 10", chunks, len(accounts))
+		}
+		have = append(have, accounts...)
+		if next == nil {
+			break
+		}
+		start = *next
+	}
+	if !reflect.DeepEqual(have, want) {
+		t.Errorf("chunked export mismatch: have %d accounts, want %d", len(have), len(want))
+	}
+	for _, acc := range want {
+		if len(acc.Code) > 0 || len(acc.Storage) > 0 {
+			t.Errorf("account %x: skipped code or storage exported", acc.Hash)
+		}
+	}
+}
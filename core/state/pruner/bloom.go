@@ -0,0 +1,136 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package pruner
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+
+	"github.com/eth-classic/go-ethereum/common"
+)
+
+// errBloomCorrupted is returned when a persisted state bloom can't be loaded.
+var errBloomCorrupted = errors.New("state bloom corrupted")
+
+// stateBloom is a bloom filter used during pruning to mark all the trie nodes
+// and contract code that need to be retained. False positives only make some
+// garbage survive the pruning, so the filter is safe to base deletions on.
+//
+// The keys inserted are all keccak hashes, so instead of hashing them again,
+// the bit positions are taken directly from the key.
+type stateBloom struct {
+	head common.Hash // State root of the chain head the bloom was built for
+	bits []byte      // Bit vector of the filter
+}
+
+// newStateBloom creates a new empty state bloom of the given size in bytes.
+func newStateBloom(size uint64) *stateBloom {
+	if size < common.HashLength {
+		size = common.HashLength
+	}
+	return &stateBloom{bits: make([]byte, size)}
+}
+
+// positions returns the bit positions the hash is represented by.
+func (b *stateBloom) positions(hash []byte) [4]uint64 {
+	var (
+		pos  [4]uint64
+		size = uint64(len(b.bits)) * 8
+	)
+	for i := range pos {
+		pos[i] = binary.BigEndian.Uint64(hash[i*8:]) % size
+	}
+	return pos
+}
+
+// add inserts a hash into the bloom.
+func (b *stateBloom) add(hash common.Hash) {
+	for _, pos := range b.positions(hash[:]) {
+		b.bits[pos/8] |= 1 << (pos % 8)
+	}
+}
+
+// contains reports whether a 32 byte database key might have been added to the
+// bloom. It never reports false for added keys.
+func (b *stateBloom) contains(key []byte) bool {
+	for _, pos := range b.positions(key) {
+		if b.bits[pos/8]&(1<<(pos%8)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// commit persists the bloom into the given file. The file is only moved to its
+// final location once fully written, so a present file is always complete.
+func (b *stateBloom) commit(path string) error {
+	tmp := path + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	if _, err := w.Write(b.head[:]); err != nil {
+		f.Close()
+		return err
+	}
+	if _, err := w.Write(b.bits); err != nil {
+		f.Close()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// loadStateBloom loads a state bloom previously persisted with commit.
+func loadStateBloom(path string) (*stateBloom, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if info.Size() < 2*common.HashLength {
+		return nil, errBloomCorrupted
+	}
+	b := &stateBloom{bits: make([]byte, info.Size()-common.HashLength)}
+	r := bufio.NewReader(f)
+	if _, err := io.ReadFull(r, b.head[:]); err != nil {
+		return nil, err
+	}
+	if _, err := io.ReadFull(r, b.bits); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
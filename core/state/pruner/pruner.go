@@ -0,0 +1,329 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package pruner implements offline pruning of stale state from the chain
+// database.
+package pruner
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/eth-classic/go-ethereum/common"
+	"github.com/eth-classic/go-ethereum/core/state"
+	"github.com/eth-classic/go-ethereum/crypto"
+	"github.com/eth-classic/go-ethereum/ethdb"
+	"github.com/eth-classic/go-ethereum/logger"
+	"github.com/eth-classic/go-ethereum/logger/glog"
+	"github.com/eth-classic/go-ethereum/rlp"
+	"github.com/eth-classic/go-ethereum/trie"
+)
+
+const (
+	// bloomFileName is the name of the file the state bloom is persisted into
+	// between marking and sweeping, which makes an interrupted pruning resumable.
+	bloomFileName = "statebloom.bf"
+
+	// logInterval is the time between two progress reports.
+	logInterval = 8 * time.Second
+)
+
+var (
+	// emptyRoot is the known root hash of an empty trie.
+	emptyRoot = common.HexToHash("56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421")
+
+	// emptyCode is the known hash of the empty EVM bytecode.
+	emptyCode = crypto.Keccak256Hash(nil)
+
+	// txMetaSuffix marks the transaction metadata entries, stored next to the
+	// transactions keyed by their bare hash (just like trie nodes).
+	txMetaSuffix = []byte{0x01}
+)
+
+// Pruner is an offline tool to delete the stale state of a chain database. All
+// the trie nodes and contract code reachable from a set of retained state roots
+// are marked in a bloom filter, then every other trie node and contract code is
+// swept from the database.
+//
+// The bloom filter is persisted in the data directory once marking completes,
+// so an interrupted pruning resumes with the sweeping only.
+type Pruner struct {
+	db        ethdb.Database
+	bloomPath string
+	bloomSize uint64
+
+	marked  uint64    // Number of entries marked for retention
+	logged  time.Time // Time of the last progress report
+	started time.Time // Time the current pruning phase started
+}
+
+// NewPruner creates a pruner for the given chain database, persisting its state
+// bloom of bloomSize bytes into datadir.
+func NewPruner(db ethdb.Database, datadir string, bloomSize uint64) *Pruner {
+	return &Pruner{
+		db:        db,
+		bloomPath: filepath.Join(datadir, bloomFileName),
+		bloomSize: bloomSize,
+	}
+}
+
+// Resumable reports whether an interrupted pruning was found, which Prune
+// will continue.
+func (p *Pruner) Resumable() bool {
+	_, err := os.Stat(p.bloomPath)
+	return err == nil
+}
+
+// Prune deletes every trie node and contract code from the database which is not
+// reachable from the given state roots. The roots should be ordered oldest first,
+// with the last one being the state of the chain head, which is verified to be
+// complete after pruning.
+func (p *Pruner) Prune(roots []common.Hash) error {
+	if len(roots) == 0 {
+		return fmt.Errorf("no state roots to retain")
+	}
+	head := roots[len(roots)-1]
+	if ok, _ := p.db.Has(head[:]); !ok {
+		return fmt.Errorf("head state %x missing", head)
+	}
+	// Load the bloom of an interrupted pruning, or mark the retained state anew
+	bloom, err := loadStateBloom(p.bloomPath)
+	switch {
+	case err == nil && bloom.head == head:
+		glog.V(logger.Warn).Infof("Resuming interrupted state pruning: head=%x", head)
+
+	case err == nil:
+		// The chain progressed since the interruption, the new state needs to be
+		// retained too, on top of everything marked previously
+		glog.V(logger.Warn).Infof("Chain head changed since interrupted state pruning, marking new state: old=%x new=%x", bloom.head, head)
+		if err := p.mark(bloom, roots); err != nil {
+			return err
+		}
+
+	case os.IsNotExist(err):
+		bloom = newStateBloom(p.bloomSize)
+		if err := p.mark(bloom, roots); err != nil {
+			return err
+		}
+
+	default:
+		return fmt.Errorf("failed to load state bloom %s: %v", p.bloomPath, err)
+	}
+	if err := p.sweep(bloom); err != nil {
+		return err
+	}
+	if err := p.verify(head); err != nil {
+		return fmt.Errorf("head state %x incomplete after pruning: %v", head, err)
+	}
+	glog.V(logger.Info).Infof("Verified head state after pruning: root=%x", head)
+
+	return os.Remove(p.bloomPath)
+}
+
+// mark adds all the trie nodes and contract code reachable from the given state
+// roots to the bloom, then persists it. Each root is only diffed against the
+// last successfully marked one, so shared subtries are traversed only once.
+func (p *Pruner) mark(bloom *stateBloom, roots []common.Hash) error {
+	p.started, p.logged = time.Now(), time.Now()
+
+	var prev common.Hash
+	for i, root := range roots {
+		err := p.markState(bloom, root, prev)
+		if err != nil && prev != (common.Hash{}) {
+			// The previous state may be incomplete, fall back to a full traversal
+			glog.V(logger.Debug).Infof("Failed to diff state %x against %x, marking fully: %v", root, prev, err)
+			err = p.markState(bloom, root, common.Hash{})
+		}
+		if err != nil {
+			if i == len(roots)-1 {
+				return fmt.Errorf("failed to mark head state %x: %v", root, err)
+			}
+			glog.V(logger.Warn).Infof("Skipping incomplete state: root=%x err=%v", root, err)
+			continue
+		}
+		prev = root
+		p.report("Marking retained state", fmt.Sprintf("roots=%d/%d", i+1, len(roots)))
+	}
+	bloom.head = roots[len(roots)-1]
+
+	glog.V(logger.Info).Infof("Marked retained state: roots=%d entries=%d elapsed=%v", len(roots), p.marked, time.Since(p.started))
+	return bloom.commit(p.bloomPath)
+}
+
+// markState marks the account trie of root, along with the storage tries and
+// code of its accounts, skipping everything also reachable from prev.
+func (p *Pruner) markState(bloom *stateBloom, root, prev common.Hash) error {
+	accounts, err := trie.New(root, p.db)
+	if err != nil {
+		return err
+	}
+	var (
+		prevAccounts *trie.Trie
+		it           = accounts.NodeIterator(nil)
+	)
+	if prev != (common.Hash{}) {
+		if prevAccounts, err = trie.New(prev, p.db); err != nil {
+			return err
+		}
+		it, _ = trie.NewDifferenceIterator(prevAccounts.NodeIterator(nil), it)
+	}
+	for it.Next(true) {
+		p.markNode(bloom, it.Hash())
+		if !it.Leaf() {
+			continue
+		}
+		var account state.Account
+		if err := rlp.DecodeBytes(it.LeafBlob(), &account); err != nil {
+			return err
+		}
+		if !bytes.Equal(account.CodeHash, emptyCode[:]) {
+			p.markNode(bloom, common.BytesToHash(account.CodeHash))
+		}
+		if account.Root == emptyRoot {
+			continue
+		}
+		// Only the storage changed since the previous version of the account needs marking
+		prevRoot := common.Hash{}
+		if prevAccounts != nil {
+			if blob, err := prevAccounts.TryGet(it.LeafKey()); err == nil && len(blob) > 0 {
+				var prevAccount state.Account
+				if err := rlp.DecodeBytes(blob, &prevAccount); err == nil {
+					prevRoot = prevAccount.Root
+				}
+			}
+		}
+		if err := p.markTrie(bloom, account.Root, prevRoot); err != nil {
+			return err
+		}
+	}
+	return it.Error()
+}
+
+// markTrie marks the nodes of the trie rooted at root which are not also in the
+// trie rooted at prev.
+func (p *Pruner) markTrie(bloom *stateBloom, root, prev common.Hash) error {
+	if root == prev {
+		return nil
+	}
+	tr, err := trie.New(root, p.db)
+	if err != nil {
+		return err
+	}
+	it := tr.NodeIterator(nil)
+	if prev != (common.Hash{}) && prev != emptyRoot {
+		if prevTrie, err := trie.New(prev, p.db); err == nil {
+			it, _ = trie.NewDifferenceIterator(prevTrie.NodeIterator(nil), it)
+		}
+	}
+	for it.Next(true) {
+		p.markNode(bloom, it.Hash())
+	}
+	return it.Error()
+}
+
+// markNode adds a standalone trie node or code hash to the bloom, skipping the
+// nodes embedded in their parents.
+func (p *Pruner) markNode(bloom *stateBloom, hash common.Hash) {
+	if hash == (common.Hash{}) {
+		return
+	}
+	bloom.add(hash)
+	p.marked++
+}
+
+// sweep deletes every trie node and contract code from the database which is
+// not marked in the bloom, then compacts the database to reclaim the space.
+func (p *Pruner) sweep(bloom *stateBloom) error {
+	p.started, p.logged = time.Now(), time.Now()
+
+	var (
+		deleted uint64
+		size    common.StorageSize
+		batch   = p.db.NewBatch()
+		it      = p.db.NewIteratorWithPrefix(nil)
+	)
+	defer it.Release()
+
+	for it.Next() {
+		key := it.Key()
+		if len(key) != common.HashLength || bloom.contains(key) {
+			continue
+		}
+		// Transactions are keyed by their bare hash too, leave them alone
+		if ok, _ := p.db.Has(append(common.CopyBytes(key), txMetaSuffix...)); ok {
+			continue
+		}
+		batch.Delete(key)
+		deleted++
+		size += common.StorageSize(len(key) + len(it.Value()))
+
+		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+		if time.Since(p.logged) > logInterval {
+			// Keys are uniformly distributed hashes, so their position tells the progress
+			done := float64(binary.BigEndian.Uint64(key[:8])) / math.MaxUint64
+			p.report("Pruning stale state", fmt.Sprintf("deleted=%d size=%v done=%.2f%%", deleted, size, done*100))
+		}
+	}
+	if err := it.Error(); err != nil {
+		return err
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	glog.V(logger.Info).Infof("Pruned stale state: deleted=%d size=%v elapsed=%v", deleted, size, time.Since(p.started))
+
+	// Deletions only mark the data stale, compact the database to reclaim the space
+	start := time.Now()
+	glog.V(logger.Info).Infoln("Compacting database, this may take a while")
+	if err := p.db.Compact(nil, nil); err != nil {
+		return err
+	}
+	glog.V(logger.Info).Infof("Compacted database: elapsed=%v", time.Since(start))
+	return nil
+}
+
+// verify checks that all the trie nodes and contract code of the given state
+// are present in the database.
+func (p *Pruner) verify(root common.Hash) error {
+	statedb, err := state.New(root, state.NewDatabase(p.db))
+	if err != nil {
+		return err
+	}
+	it := state.NewNodeIterator(statedb)
+	for it.Next() {
+	}
+	return it.Error
+}
+
+// report logs the progress of the current pruning phase, rate limited to one
+// message per log interval.
+func (p *Pruner) report(phase string, progress string) {
+	if time.Since(p.logged) < logInterval {
+		return
+	}
+	glog.V(logger.Info).Infof("%s: %s marked=%d elapsed=%v", phase, progress, p.marked, time.Since(p.started))
+	p.logged = time.Now()
+}
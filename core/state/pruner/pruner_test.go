@@ -0,0 +1,182 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package pruner
+
+import (
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/eth-classic/go-ethereum/common"
+	"github.com/eth-classic/go-ethereum/core/state"
+	"github.com/eth-classic/go-ethereum/crypto"
+	"github.com/eth-classic/go-ethereum/ethdb"
+)
+
+// newTestDatabase creates a temporary leveldb chain database.
+func newTestDatabase(t *testing.T) (*ethdb.LDBDatabase, string) {
+	dir, err := ioutil.TempDir("", "pruner-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := ethdb.NewLDBDatabase(filepath.Join(dir, "chaindata"), 16, 16)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	return db, dir
+}
+
+// commitState creates a state on top of parent, modifying a few accounts with
+// the given seed, and commits it into the database.
+func commitState(t *testing.T, db ethdb.Database, parent common.Hash, seed byte) common.Hash {
+	statedb, err := state.New(parent, state.NewDatabase(db))
+	if err != nil {
+		t.Fatalf("failed to open state %x: %v", parent, err)
+	}
+	for i := byte(0); i < 16; i++ {
+		addr := common.BytesToAddress([]byte{i})
+		statedb.AddBalance(addr, big.NewInt(int64(seed)+1))
+		statedb.SetState(addr, common.BytesToHash([]byte{seed}), common.BytesToHash([]byte{seed, i}))
+		if i%4 == 0 {
+			statedb.SetCode(addr, []byte{seed, i, 0x60, 0x00})
+		}
+	}
+	root, err := statedb.CommitTo(db, false)
+	if err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	return root
+}
+
+// verifyState checks that the given state is fully present in the database.
+func verifyState(db ethdb.Database, root common.Hash) error {
+	statedb, err := state.New(root, state.NewDatabase(db))
+	if err != nil {
+		return err
+	}
+	it := state.NewNodeIterator(statedb)
+	for it.Next() {
+	}
+	return it.Error
+}
+
+// Tests that pruning retains the requested states completely, deletes the stale
+// ones and leaves transactions and non-state data untouched.
+func TestPrune(t *testing.T) {
+	db, dir := newTestDatabase(t)
+	defer os.RemoveAll(dir)
+	defer db.Close()
+
+	testPrune(t, db, dir)
+}
+
+// Tests that pruning works the same on a memory database.
+func TestPruneMemory(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pruner-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, _ := ethdb.NewMemDatabase()
+	testPrune(t, db, dir)
+}
+
+func testPrune(t *testing.T, db ethdb.Database, dir string) {
+	var roots []common.Hash
+	root := common.Hash{}
+	for i := 0; i < 4; i++ {
+		root = commitState(t, db, root, byte(i))
+		roots = append(roots, root)
+	}
+	// Insert a fake transaction and an unrelated prefixed entry
+	tx := crypto.Keccak256Hash([]byte("transaction"))
+	db.Put(tx[:], []byte("tx"))
+	db.Put(append(tx.Bytes(), txMetaSuffix...), []byte("meta"))
+	db.Put([]byte("LastBlock"), []byte("head"))
+
+	if err := NewPruner(db, dir, 1024*1024).Prune(roots[2:]); err != nil {
+		t.Fatalf("failed to prune state: %v", err)
+	}
+	for i, root := range roots[2:] {
+		if err := verifyState(db, root); err != nil {
+			t.Errorf("retained state %d incomplete: %v", i+2, err)
+		}
+	}
+	for i, root := range roots[:2] {
+		if ok, _ := db.Has(root[:]); ok {
+			t.Errorf("stale state %d root not pruned", i)
+		}
+	}
+	for _, key := range [][]byte{tx[:], append(tx.Bytes(), txMetaSuffix...), []byte("LastBlock")} {
+		if ok, _ := db.Has(key); !ok {
+			t.Errorf("non-state entry %x pruned", key)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, bloomFileName)); !os.IsNotExist(err) {
+		t.Errorf("state bloom not removed after pruning: %v", err)
+	}
+}
+
+// Tests that an interrupted pruning is resumed from its persisted state bloom,
+// even if the chain progressed in the meantime.
+func TestPruneResume(t *testing.T) {
+	db, dir := newTestDatabase(t)
+	defer os.RemoveAll(dir)
+	defer db.Close()
+
+	first := commitState(t, db, common.Hash{}, 0)
+	second := commitState(t, db, first, 1)
+
+	// Simulate an interruption after marking the first state
+	pruner := NewPruner(db, dir, 1024*1024)
+	bloom := newStateBloom(pruner.bloomSize)
+	if err := pruner.mark(bloom, []common.Hash{first}); err != nil {
+		t.Fatalf("failed to mark state: %v", err)
+	}
+	if !pruner.Resumable() {
+		t.Fatalf("interrupted pruning not resumable")
+	}
+	// Resume with a progressed head, both states must be retained
+	third := commitState(t, db, second, 2)
+	if err := NewPruner(db, dir, 1024*1024).Prune([]common.Hash{third}); err != nil {
+		t.Fatalf("failed to resume pruning: %v", err)
+	}
+	for i, root := range []common.Hash{first, third} {
+		if err := verifyState(db, root); err != nil {
+			t.Errorf("retained state %d incomplete: %v", i, err)
+		}
+	}
+	if ok, _ := db.Has(second[:]); ok {
+		t.Errorf("stale state root not pruned")
+	}
+}
+
+// Tests that pruning refuses to run if the head state is missing.
+func TestPruneMissingHead(t *testing.T) {
+	db, dir := newTestDatabase(t)
+	defer os.RemoveAll(dir)
+	defer db.Close()
+
+	missing := common.HexToHash("0xdeadbeef")
+	if err := NewPruner(db, dir, 1024*1024).Prune([]common.Hash{missing}); err == nil {
+		t.Fatalf("pruning succeeded with missing head state")
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package snapshot
+
+import (
+	"bytes"
+
+	"github.com/eth-classic/go-ethereum/common"
+	"github.com/eth-classic/go-ethereum/ethdb"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+var (
+	snapshotRootKey      = []byte("SnapshotRoot")      // snapshotRootKey tracks the state root of the snapshot disk layer
+	snapshotGeneratorKey = []byte("SnapshotGenerator") // snapshotGeneratorKey tracks the progress of the snapshot generation
+
+	snapshotAccountPrefix = []byte("snapshot-account-") // snapshotAccountPrefix + account hash -> account trie value
+	snapshotStoragePrefix = []byte("snapshot-storage-") // snapshotStoragePrefix + account hash + storage hash -> storage trie value
+)
+
+// accountSnapshotKey = snapshotAccountPrefix + hash
+func accountSnapshotKey(hash common.Hash) []byte {
+	return append(append([]byte{}, snapshotAccountPrefix...), hash[:]...)
+}
+
+// storageSnapshotKey = snapshotStoragePrefix + account hash + storage hash
+func storageSnapshotKey(accountHash, storageHash common.Hash) []byte {
+	return append(append(append([]byte{}, snapshotStoragePrefix...), accountHash[:]...), storageHash[:]...)
+}
+
+// storageSnapshotsKey = snapshotStoragePrefix + account hash
+func storageSnapshotsKey(accountHash common.Hash) []byte {
+	return append(append([]byte{}, snapshotStoragePrefix...), accountHash[:]...)
+}
+
+// IsAccountKey reports whether a database key holds an account of the snapshot.
+func IsAccountKey(key []byte) bool {
+	return len(key) == len(snapshotAccountPrefix)+common.HashLength && bytes.HasPrefix(key, snapshotAccountPrefix)
+}
+
+// IsStorageKey reports whether a database key holds a storage slot of the snapshot.
+func IsStorageKey(key []byte) bool {
+	return len(key) == len(snapshotStoragePrefix)+2*common.HashLength && bytes.HasPrefix(key, snapshotStoragePrefix)
+}
+
+// IsMetadataKey reports whether a database key holds the progress of the snapshot.
+func IsMetadataKey(key []byte) bool {
+	return bytes.Equal(key, snapshotRootKey) || bytes.Equal(key, snapshotGeneratorKey)
+}
+
+// readSnapshotRoot retrieves the root of the snapshot disk layer, or the zero
+// hash if no consistent snapshot is stored.
+func readSnapshotRoot(db ethdb.Database) common.Hash {
+	data, _ := db.Get(snapshotRootKey)
+	if len(data) != common.HashLength {
+		return common.Hash{}
+	}
+	return common.BytesToHash(data)
+}
+
+// readSnapshotGenerator retrieves the last account hash covered by an unfinished
+// snapshot generation, or nil if the snapshot was fully generated.
+func readSnapshotGenerator(db ethdb.Database) []byte {
+	if ok, _ := db.Has(snapshotGeneratorKey); !ok {
+		return nil
+	}
+	marker, _ := db.Get(snapshotGeneratorKey)
+	return append([]byte{}, marker...)
+}
+
+// wipeRange deletes every entry within the given key range, returning early with
+// the abort request if one arrives meanwhile.
+func wipeRange(db ethdb.Database, r *util.Range, abort chan chan struct{}) (chan struct{}, error) {
+	it := db.NewIteratorWithRange(r.Start, r.Limit)
+	defer it.Release()
+
+	batch := db.NewBatch()
+	for it.Next() {
+		select {
+		case done := <-abort:
+			// Whatever isn't deleted yet is still beyond the marker, wiped on the next run
+			batch.Write()
+			return done, nil
+		default:
+		}
+		if err := batch.Delete(it.Key()); err != nil {
+			return nil, err
+		}
+		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return nil, err
+			}
+			batch.Reset()
+		}
+	}
+	if err := it.Error(); err != nil {
+		return nil, err
+	}
+	return nil, batch.Write()
+}
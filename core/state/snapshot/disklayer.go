@@ -0,0 +1,200 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package snapshot
+
+import (
+	"bytes"
+	"sync"
+
+	"github.com/eth-classic/go-ethereum/common"
+	"github.com/eth-classic/go-ethereum/ethdb"
+	"github.com/eth-classic/go-ethereum/trie"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// diskLayer is a low level persistent snapshot built on top of a key-value store.
+type diskLayer struct {
+	diskdb ethdb.Database // Key-value store containing the base snapshot
+	triedb trie.Database  // Trie node database to generate the snapshot from
+	root   common.Hash    // Root hash of the base snapshot
+	stale  bool           // Signals that the layer became stale (state progressed)
+
+	genMarker []byte             // Marker for the state that's indexed during initial layer generation
+	genAbort  chan chan struct{} // Notification channel to abort generating the snapshot in this layer
+
+	lock sync.RWMutex
+}
+
+// newGeneratingLayer creates an empty disk layer for the given state root, with
+// all of its data yet to be generated.
+func newGeneratingLayer(diskdb ethdb.Database, triedb trie.Database, root common.Hash) *diskLayer {
+	return &diskLayer{
+		diskdb:    diskdb,
+		triedb:    triedb,
+		root:      root,
+		genMarker: []byte{}, // Initialized but empty, nothing is covered yet
+	}
+}
+
+// Root returns root hash for which this snapshot was made.
+func (dl *diskLayer) Root() common.Hash {
+	return dl.root
+}
+
+// Parent always returns nil as there's no layer below the disk.
+func (dl *diskLayer) Parent() snapshot {
+	return nil
+}
+
+// Stale return whether this layer has become stale (was flattened across) or if
+// it's still live.
+func (dl *diskLayer) Stale() bool {
+	dl.lock.RLock()
+	defer dl.lock.RUnlock()
+
+	return dl.stale
+}
+
+// markStale flags the layer stale, failing any subsequent data access.
+func (dl *diskLayer) markStale() {
+	dl.lock.Lock()
+	defer dl.lock.Unlock()
+
+	dl.stale = true
+}
+
+// covered reports whether the data of the given account was already generated.
+//
+// This method assumes that the layer lock is held.
+func (dl *diskLayer) covered(hash common.Hash) bool {
+	return dl.genMarker == nil || bytes.Compare(hash[:], dl.genMarker) <= 0
+}
+
+// Account directly retrieves the RLP encoded account associated with a particular
+// hash in the snapshot.
+func (dl *diskLayer) Account(hash common.Hash) ([]byte, error) {
+	dl.lock.RLock()
+	defer dl.lock.RUnlock()
+
+	if dl.stale {
+		return nil, ErrSnapshotStale
+	}
+	if !dl.covered(hash) {
+		return nil, ErrNotCoveredYet
+	}
+	blob, _ := dl.diskdb.Get(accountSnapshotKey(hash))
+	if len(blob) == 0 {
+		return nil, nil
+	}
+	return blob, nil
+}
+
+// Storage directly retrieves the RLP encoded storage slot associated with a
+// particular hash within a particular account.
+func (dl *diskLayer) Storage(accountHash, storageHash common.Hash) ([]byte, error) {
+	dl.lock.RLock()
+	defer dl.lock.RUnlock()
+
+	if dl.stale {
+		return nil, ErrSnapshotStale
+	}
+	if !dl.covered(accountHash) {
+		return nil, ErrNotCoveredYet
+	}
+	blob, _ := dl.diskdb.Get(storageSnapshotKey(accountHash, storageHash))
+	if len(blob) == 0 {
+		return nil, nil
+	}
+	return blob, nil
+}
+
+// flatten writes the modifications of a diff layer, whose parent is this disk
+// layer, into the database, returning the new disk layer. Both this layer and
+// the diff become stale. Only the data already generated is written, the rest
+// is left to the generator, which must not be running.
+func (dl *diskLayer) flatten(diff *diffLayer) (*diskLayer, error) {
+	diff.markStale()
+
+	dl.lock.Lock()
+	dl.stale = true
+	dl.lock.Unlock()
+
+	// Invalidate the persisted snapshot while it's being modified, so that a crash
+	// midway results in regeneration instead of inconsistent data
+	if err := dl.diskdb.Delete(snapshotRootKey); err != nil {
+		return nil, err
+	}
+	for hash := range diff.destructSet {
+		if !dl.covered(hash) {
+			continue
+		}
+		if err := dl.diskdb.Delete(accountSnapshotKey(hash)); err != nil {
+			return nil, err
+		}
+		if _, err := wipeRange(dl.diskdb, util.BytesPrefix(storageSnapshotsKey(hash)), nil); err != nil {
+			return nil, err
+		}
+	}
+	batch := dl.diskdb.NewBatch()
+	for hash, data := range diff.accountData {
+		if !dl.covered(hash) {
+			continue
+		}
+		if len(data) == 0 {
+			if err := dl.diskdb.Delete(accountSnapshotKey(hash)); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if err := batch.Put(accountSnapshotKey(hash), data); err != nil {
+			return nil, err
+		}
+	}
+	for accountHash, storage := range diff.storageData {
+		if !dl.covered(accountHash) {
+			continue
+		}
+		for storageHash, data := range storage {
+			if len(data) == 0 {
+				if err := dl.diskdb.Delete(storageSnapshotKey(accountHash, storageHash)); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			if err := batch.Put(storageSnapshotKey(accountHash, storageHash), data); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if dl.genMarker != nil {
+		if err := batch.Put(snapshotGeneratorKey, dl.genMarker); err != nil {
+			return nil, err
+		}
+	}
+	if err := batch.Put(snapshotRootKey, diff.root[:]); err != nil {
+		return nil, err
+	}
+	if err := batch.Write(); err != nil {
+		return nil, err
+	}
+	return &diskLayer{
+		diskdb:    dl.diskdb,
+		triedb:    dl.triedb,
+		root:      diff.root,
+		genMarker: dl.genMarker,
+	}, nil
+}
@@ -0,0 +1,249 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package snapshot
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"math/big"
+	"time"
+
+	"github.com/eth-classic/go-ethereum/common"
+	"github.com/eth-classic/go-ethereum/ethdb"
+	"github.com/eth-classic/go-ethereum/logger"
+	"github.com/eth-classic/go-ethereum/logger/glog"
+	"github.com/eth-classic/go-ethereum/rlp"
+	"github.com/eth-classic/go-ethereum/trie"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+var (
+	// emptyRoot is the known root hash of an empty trie.
+	emptyRoot = common.HexToHash("56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421")
+
+	// generatorLogInterval is the time between two generation progress reports.
+	generatorLogInterval = 8 * time.Second
+)
+
+// account is the consensus representation of accounts, only decoded by the
+// generator to find the storage trie belonging to them.
+type account struct {
+	Nonce    uint64
+	Balance  *big.Int
+	Root     common.Hash
+	CodeHash []byte
+}
+
+// startGeneration starts generating the snapshot data of the layer in the
+// background, if it's not complete yet.
+//
+// This method assumes that the tree lock is held.
+func (dl *diskLayer) startGeneration() {
+	if dl.genMarker == nil || dl.genAbort != nil {
+		return
+	}
+	dl.genAbort = make(chan chan struct{})
+	go dl.generate(dl.genAbort)
+}
+
+// stopGeneration aborts a running generation, waiting for it to persist its
+// progress.
+//
+// This method assumes that the tree lock is held.
+func (dl *diskLayer) stopGeneration() {
+	if dl.genAbort == nil {
+		return
+	}
+	done := make(chan struct{})
+	dl.genAbort <- done
+	<-done
+	dl.genAbort = nil
+}
+
+// generate is a background thread that iterates over the state trie of the
+// layer, writing each account and storage slot into the snapshot, from the
+// generation marker onwards. Once done, or if the state is not available, it
+// waits for the abort signal.
+func (dl *diskLayer) generate(abort chan chan struct{}) {
+	dl.lock.RLock()
+	marker := dl.genMarker
+	dl.lock.RUnlock()
+
+	var (
+		start  = time.Now()
+		logged = time.Now()
+		batch  = dl.diskdb.NewBatch()
+
+		accounts, slots uint64
+	)
+	// The persisted snapshot is about to be generated for this root from the marker
+	// on, anything beyond the marker is leftover from an earlier, aborted run
+	batch.Put(snapshotRootKey, dl.root[:])
+	batch.Put(snapshotGeneratorKey, marker)
+	if err := batch.Write(); err != nil {
+		glog.V(logger.Error).Errorf("Failed to start snapshot generation: %v", err)
+		close(<-abort)
+		return
+	}
+	batch = dl.diskdb.NewBatch()
+
+	if done, err := dl.wipeUncovered(marker, abort); done != nil || err != nil {
+		if err != nil {
+			glog.V(logger.Error).Errorf("Failed to wipe stale snapshot data: %v", err)
+			done = <-abort
+		}
+		close(done)
+		return
+	}
+	// flush writes the generated data, advancing the marker to the given account
+	flush := func(next []byte) error {
+		if next != nil {
+			batch.Put(snapshotGeneratorKey, next)
+		}
+		if err := batch.Write(); err != nil {
+			return err
+		}
+		batch = dl.diskdb.NewBatch()
+		if next != nil {
+			dl.lock.Lock()
+			dl.genMarker = next
+			dl.lock.Unlock()
+		}
+		return nil
+	}
+	// pause persists the progress and waits for the generation to be aborted
+	pause := func(done chan struct{}, err error) {
+		if err != nil {
+			glog.V(logger.Warn).Warnf("State snapshot generation paused: root=%x at=%x err=%v", dl.root, marker, err)
+		}
+		if err := batch.Write(); err != nil {
+			glog.V(logger.Error).Errorf("Failed to persist snapshot generation progress: %v", err)
+		}
+		if done == nil {
+			done = <-abort
+		}
+		close(done)
+	}
+	accTrie, err := trie.NewSecure(dl.root, dl.triedb, 0)
+	if err != nil {
+		pause(nil, err)
+		return
+	}
+	it := trie.NewIterator(accTrie.NodeIterator(marker))
+	for it.Next() {
+		select {
+		case done := <-abort:
+			pause(done, nil)
+			return
+		default:
+		}
+		accountHash := common.BytesToHash(it.Key)
+		if err := batch.Put(accountSnapshotKey(accountHash), it.Value); err != nil {
+			pause(nil, err)
+			return
+		}
+		accounts++
+
+		var acc account
+		if err := rlp.DecodeBytes(it.Value, &acc); err != nil {
+			pause(nil, err)
+			return
+		}
+		if acc.Root != emptyRoot {
+			storeTrie, err := trie.New(acc.Root, dl.triedb)
+			if err != nil {
+				pause(nil, err)
+				return
+			}
+			storeIt := trie.NewIterator(storeTrie.NodeIterator(nil))
+			for storeIt.Next() {
+				if err := batch.Put(storageSnapshotKey(accountHash, common.BytesToHash(storeIt.Key)), storeIt.Value); err != nil {
+					pause(nil, err)
+					return
+				}
+				slots++
+
+				// Large contracts are flushed in chunks, but only covered once complete
+				if batch.ValueSize() > ethdb.IdealBatchSize {
+					if err := flush(nil); err != nil {
+						pause(nil, err)
+						return
+					}
+					select {
+					case done := <-abort:
+						pause(done, nil)
+						return
+					default:
+					}
+				}
+			}
+			if storeIt.Err != nil {
+				pause(nil, storeIt.Err)
+				return
+			}
+		}
+		if batch.ValueSize() > ethdb.IdealBatchSize {
+			marker = accountHash.Bytes()
+			if err := flush(marker); err != nil {
+				pause(nil, err)
+				return
+			}
+		}
+		if time.Since(logged) > generatorLogInterval {
+			// Account hashes are uniformly distributed, so their position tells the progress
+			done := float64(binary.BigEndian.Uint64(accountHash[:8])) / math.MaxUint64
+			glog.V(logger.Info).Infof("Generating state snapshot: root=%x accounts=%d slots=%d done=%.2f%% elapsed=%v", dl.root, accounts, slots, done*100, time.Since(start))
+			logged = time.Now()
+		}
+	}
+	if it.Err != nil {
+		pause(nil, it.Err)
+		return
+	}
+	// Generation complete, persist everything and mark the snapshot fully covered
+	if err := batch.Write(); err != nil {
+		pause(nil, err)
+		return
+	}
+	if err := dl.diskdb.Delete(snapshotGeneratorKey); err != nil {
+		pause(nil, err)
+		return
+	}
+	dl.lock.Lock()
+	dl.genMarker = nil
+	dl.lock.Unlock()
+
+	glog.V(logger.Info).Infof("Generated state snapshot: root=%x accounts=%d slots=%d elapsed=%v", dl.root, accounts, slots, time.Since(start))
+	close(<-abort)
+}
+
+// wipeUncovered deletes all the snapshot data beyond the generation marker,
+// which may be stale leftovers of an earlier snapshot or generation run.
+func (dl *diskLayer) wipeUncovered(marker []byte, abort chan chan struct{}) (chan struct{}, error) {
+	accounts := util.BytesPrefix(snapshotAccountPrefix)
+	storage := util.BytesPrefix(snapshotStoragePrefix)
+	if len(marker) > 0 {
+		// Skip the marker account itself along with all of its storage slots
+		accounts.Start = append(append(common.CopyBytes(snapshotAccountPrefix), marker...), 0x00)
+		storage.Start = append(append(common.CopyBytes(snapshotStoragePrefix), marker...), bytes.Repeat([]byte{0xff}, common.HashLength+1)...)
+	}
+	if done, err := wipeRange(dl.diskdb, accounts, abort); done != nil || err != nil {
+		return done, err
+	}
+	return wipeRange(dl.diskdb, storage, abort)
+}
@@ -0,0 +1,271 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package snapshot implements a flat key-value snapshot of the state, allowing
+// accounts and storage slots to be read without walking the state tries.
+//
+// The snapshot consists of a persistent disk layer holding the state of some
+// older block, with an in-memory diff layer per more recent block on top of it.
+// Diff layers below a configured depth are flattened into the disk layer as the
+// chain progresses.
+package snapshot
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/eth-classic/go-ethereum/common"
+	"github.com/eth-classic/go-ethereum/ethdb"
+	"github.com/eth-classic/go-ethereum/logger"
+	"github.com/eth-classic/go-ethereum/logger/glog"
+	"github.com/eth-classic/go-ethereum/trie"
+)
+
+var (
+	// ErrSnapshotStale is returned from data accessors if the underlying snapshot
+	// layer had been invalidated due to the chain progressing forward far enough
+	// to not maintain the layer's original state.
+	ErrSnapshotStale = errors.New("snapshot stale")
+
+	// ErrNotCoveredYet is returned from data accessors if the underlying snapshot
+	// is being generated currently and the requested data item is not yet in the
+	// range of accounts covered.
+	ErrNotCoveredYet = errors.New("not covered yet")
+
+	// errSnapshotCycle is returned if a snapshot is attempted to be inserted
+	// that forms a cycle in the snapshot tree.
+	errSnapshotCycle = errors.New("snapshot cycle")
+)
+
+// Snapshot represents the functionality supported by a snapshot storage layer.
+// Accounts and storage slots are keyed by the hash of their address and slot,
+// the same way they are in the secure state tries, and returned in their trie
+// leaf encoding. A nil blob denotes a missing account or slot.
+type Snapshot interface {
+	// Root returns the state root for which this snapshot was made.
+	Root() common.Hash
+
+	// Account directly retrieves the RLP encoded account associated with a
+	// particular hash in the snapshot.
+	Account(hash common.Hash) ([]byte, error)
+
+	// Storage directly retrieves the RLP encoded storage slot associated with a
+	// particular hash within a particular account.
+	Storage(accountHash, storageHash common.Hash) ([]byte, error)
+}
+
+// snapshot is the internal version of the snapshot data layer that supports some
+// additional methods compared to the public API.
+type snapshot interface {
+	Snapshot
+
+	// Parent returns the subsequent layer of a snapshot, or nil if the base was
+	// reached.
+	Parent() snapshot
+
+	// Stale returns whether this layer has become stale (was flattened across)
+	// or if it's still live.
+	Stale() bool
+}
+
+// Tree is an Ethereum state snapshot tree. It consists of one persistent base
+// layer backed by a key-value store, on top of which arbitrarily many in-memory
+// diff layers are topped. The memory diffs can form a tree with branching, but
+// the disk layer is singleton and common to all. If a reorg goes deeper than the
+// disk layer, everything needs to be regenerated.
+//
+// The goal of a state snapshot is twofold: to allow direct access to account and
+// storage data to avoid expensive multi-level trie lookups; and to allow sorted,
+// cheap iteration of the account/storage tries for sync aid.
+type Tree struct {
+	diskdb ethdb.Database           // Persistent database to store the snapshot
+	triedb trie.Database            // In-memory cache to access the trie through
+	layers map[common.Hash]snapshot // Collection of all known layers
+	lock   sync.RWMutex
+}
+
+// New attempts to load an already existing snapshot from a persistent key-value
+// store, ensuring that the head of the snapshot matches the expected one.
+//
+// If the snapshot is missing or inconsistent, the entirety is deleted and will
+// be reconstructed from scratch based on the tries in the trie database. This
+// happens in a background thread, the snapshot only serving the generated part
+// of the state until done.
+func New(diskdb ethdb.Database, triedb trie.Database, root common.Hash) (*Tree, error) {
+	snap := &Tree{
+		diskdb: diskdb,
+		triedb: triedb,
+		layers: make(map[common.Hash]snapshot),
+	}
+	var disk *diskLayer
+	if readSnapshotRoot(diskdb) == root {
+		disk = &diskLayer{
+			diskdb:    diskdb,
+			triedb:    triedb,
+			root:      root,
+			genMarker: readSnapshotGenerator(diskdb),
+		}
+		if disk.genMarker == nil {
+			glog.V(logger.Info).Infof("Loaded state snapshot: root=%x", root)
+		} else {
+			glog.V(logger.Info).Infof("Resuming state snapshot generation: root=%x at=%x", root, disk.genMarker)
+		}
+	} else {
+		glog.V(logger.Warn).Infof("State snapshot missing or outdated, regenerating: root=%x", root)
+		disk = newGeneratingLayer(diskdb, triedb, root)
+	}
+	disk.startGeneration()
+	snap.layers[root] = disk
+	return snap, nil
+}
+
+// Snapshot retrieves a snapshot belonging to the given block root, or nil if no
+// snapshot is maintained for that block.
+func (t *Tree) Snapshot(blockRoot common.Hash) Snapshot {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	if snap, ok := t.layers[blockRoot]; ok {
+		return snap
+	}
+	return nil
+}
+
+// Update adds a new snapshot into the tree, if that can be linked to an existing
+// old parent. It is disallowed to insert a disk layer (the origin of all).
+func (t *Tree) Update(blockRoot common.Hash, parentRoot common.Hash, destructs map[common.Hash]struct{}, accounts map[common.Hash][]byte, storage map[common.Hash]map[common.Hash][]byte) error {
+	// Reject noop updates to avoid self-loops in the snapshot tree
+	if blockRoot == parentRoot {
+		return errSnapshotCycle
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	parent, ok := t.layers[parentRoot]
+	if !ok {
+		return fmt.Errorf("parent [%x] snapshot missing", parentRoot)
+	}
+	if _, ok := t.layers[blockRoot]; ok {
+		// The same state was already reached through a different block
+		return nil
+	}
+	t.layers[blockRoot] = newDiffLayer(parent, blockRoot, destructs, accounts, storage)
+	return nil
+}
+
+// Cap traverses downwards the snapshot tree from a head block hash until the
+// number of allowed layers are crossed. All layers beyond the permitted number
+// are flattened downwards into the disk layer. Layers not descending from the
+// new disk layer are dropped.
+func (t *Tree) Cap(root common.Hash, layers int) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	snap, ok := t.layers[root]
+	if !ok {
+		return fmt.Errorf("snapshot [%x] missing", root)
+	}
+	// Gather the diff layers from the requested one down to the disk layer
+	var chain []*diffLayer
+	for {
+		diff, ok := snap.(*diffLayer)
+		if !ok {
+			break
+		}
+		chain = append(chain, diff)
+		snap = diff.Parent()
+	}
+	if len(chain) <= layers {
+		return nil
+	}
+	disk, ok := snap.(*diskLayer)
+	if !ok || disk.Stale() {
+		return fmt.Errorf("snapshot [%x] is not linked to the disk layer", root)
+	}
+	// Stop any running generation, flatten the layers beyond the limit, oldest
+	// first, and restart generating on top of the new disk layer
+	disk.stopGeneration()
+	for i := len(chain) - 1; i >= layers; i-- {
+		var err error
+		if disk, err = disk.flatten(chain[i]); err != nil {
+			return err
+		}
+	}
+	disk.startGeneration()
+
+	if layers > 0 {
+		chain[layers-1].setParent(disk)
+	}
+	t.layers[disk.root] = disk
+	t.dropStale()
+	return nil
+}
+
+// dropStale removes all the layers from the tree which were flattened into the
+// disk layer, or descend from a layer which was.
+//
+// This method assumes that the tree lock is held.
+func (t *Tree) dropStale() {
+	for root, snap := range t.layers {
+		for layer := snap; layer != nil; layer = layer.Parent() {
+			if layer.Stale() {
+				if diff, ok := snap.(*diffLayer); ok {
+					diff.markStale()
+				}
+				delete(t.layers, root)
+				break
+			}
+		}
+	}
+}
+
+// Rebuild wipes all available snapshot data from the persistent database and
+// discards all caches and diff layers. Afterwards, it starts a new snapshot
+// generator with the given root hash.
+func (t *Tree) Rebuild(root common.Hash) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	// Invalidate all the layers, stopping any running generation
+	for _, snap := range t.layers {
+		switch layer := snap.(type) {
+		case *diskLayer:
+			layer.stopGeneration()
+			layer.markStale()
+		case *diffLayer:
+			layer.markStale()
+		}
+	}
+	glog.V(logger.Warn).Infof("Rebuilding state snapshot: root=%x", root)
+
+	disk := newGeneratingLayer(t.diskdb, t.triedb, root)
+	disk.startGeneration()
+	t.layers = map[common.Hash]snapshot{root: disk}
+}
+
+// Release stops any running snapshot generation, persisting its progress. The
+// tree must not be used afterwards.
+func (t *Tree) Release() {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	for _, snap := range t.layers {
+		if disk, ok := snap.(*diskLayer); ok {
+			disk.stopGeneration()
+		}
+	}
+}
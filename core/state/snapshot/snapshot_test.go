@@ -0,0 +1,325 @@
+// Copyright 2019 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package snapshot
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/eth-classic/go-ethereum/common"
+	"github.com/eth-classic/go-ethereum/crypto"
+	"github.com/eth-classic/go-ethereum/ethdb"
+	"github.com/eth-classic/go-ethereum/rlp"
+	"github.com/eth-classic/go-ethereum/trie"
+)
+
+// newTestDatabase creates a temporary leveldb database.
+func newTestDatabase(t *testing.T) (*ethdb.LDBDatabase, func()) {
+	dir, err := ioutil.TempDir("", "snapshot-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := ethdb.NewLDBDatabase(dir, 16, 16)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	return db, func() {
+		db.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+// testAccount is the content of a generated test account.
+type testAccount struct {
+	nonce   uint64
+	storage map[common.Hash][]byte
+}
+
+// makeState commits a state trie with the given accounts into the database,
+// returning its root along with the leaves of the account trie.
+func makeState(t *testing.T, db ethdb.Database, accounts map[common.Hash]testAccount) (common.Hash, map[common.Hash][]byte) {
+	accTrie, _ := trie.New(common.Hash{}, db)
+	leaves := make(map[common.Hash][]byte)
+	for hash, acc := range accounts {
+		storeTrie, _ := trie.New(common.Hash{}, db)
+		for slot, value := range acc.storage {
+			storeTrie.Update(slot[:], value)
+		}
+		storeRoot, err := storeTrie.CommitTo(db)
+		if err != nil {
+			t.Fatalf("failed to commit storage trie: %v", err)
+		}
+		blob, _ := rlp.EncodeToBytes(account{Nonce: acc.nonce, Balance: big.NewInt(1), Root: storeRoot, CodeHash: crypto.Keccak256(nil)})
+		accTrie.Update(hash[:], blob)
+		leaves[hash] = blob
+	}
+	root, err := accTrie.CommitTo(db)
+	if err != nil {
+		t.Fatalf("failed to commit account trie: %v", err)
+	}
+	return root, leaves
+}
+
+// waitGeneration blocks until the disk layer of the tree finished generating.
+func waitGeneration(t *testing.T, snaps *Tree, root common.Hash) {
+	disk := snaps.Snapshot(root).(*diskLayer)
+	for i := 0; i < 500; i++ {
+		disk.lock.RLock()
+		done := disk.genMarker == nil
+		disk.lock.RUnlock()
+		if done {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("snapshot generation of %x timed out", root)
+}
+
+func TestGeneration(t *testing.T) {
+	db, cleanup := newTestDatabase(t)
+	defer cleanup()
+
+	var (
+		acc1  = common.HexToHash("0x01")
+		acc2  = common.HexToHash("0x02")
+		slot1 = common.HexToHash("0x11")
+		slot2 = common.HexToHash("0x12")
+	)
+	root, leaves := makeState(t, db, map[common.Hash]testAccount{
+		acc1: {nonce: 1},
+		acc2: {nonce: 2, storage: map[common.Hash][]byte{slot1: {0x01}, slot2: {0x02}}},
+	})
+	snaps, err := New(db, db, root)
+	if err != nil {
+		t.Fatalf("failed to create snapshot: %v", err)
+	}
+	defer snaps.Release()
+	waitGeneration(t, snaps, root)
+
+	snap := snaps.Snapshot(root)
+	for hash, leaf := range leaves {
+		if blob, err := snap.Account(hash); err != nil || !bytes.Equal(blob, leaf) {
+			t.Errorf("account %x mismatch: have %x, %v; want %x", hash, blob, err, leaf)
+		}
+	}
+	if blob, err := snap.Account(common.HexToHash("0x03")); err != nil || blob != nil {
+		t.Errorf("missing account mismatch: have %x, %v; want nil", blob, err)
+	}
+	if blob, err := snap.Storage(acc2, slot2); err != nil || !bytes.Equal(blob, []byte{0x02}) {
+		t.Errorf("storage slot mismatch: have %x, %v; want 02", blob, err)
+	}
+	if blob, err := snap.Storage(acc1, slot1); err != nil || blob != nil {
+		t.Errorf("missing storage slot mismatch: have %x, %v; want nil", blob, err)
+	}
+	if have := readSnapshotRoot(db); have != root {
+		t.Errorf("persisted root mismatch: have %x, want %x", have, root)
+	}
+	if marker := readSnapshotGenerator(db); marker != nil {
+		t.Errorf("generator marker left after generation: %x", marker)
+	}
+}
+
+func TestDiffLayers(t *testing.T) {
+	db, cleanup := newTestDatabase(t)
+	defer cleanup()
+
+	var (
+		acc1 = common.HexToHash("0x01")
+		acc2 = common.HexToHash("0x02")
+		slot = common.HexToHash("0x11")
+	)
+	root, leaves := makeState(t, db, map[common.Hash]testAccount{
+		acc1: {nonce: 1},
+		acc2: {nonce: 2, storage: map[common.Hash][]byte{slot: {0x01}}},
+	})
+	snaps, err := New(db, db, root)
+	if err != nil {
+		t.Fatalf("failed to create snapshot: %v", err)
+	}
+	defer snaps.Release()
+	waitGeneration(t, snaps, root)
+
+	// Modify an account in the first layer, destruct the contract in the second
+	root1, root2 := common.HexToHash("0xa1"), common.HexToHash("0xa2")
+	if err := snaps.Update(root1, root, nil, map[common.Hash][]byte{acc1: {0xaa}}, nil); err != nil {
+		t.Fatalf("failed to add first layer: %v", err)
+	}
+	if err := snaps.Update(root2, root1, map[common.Hash]struct{}{acc2: {}}, nil, nil); err != nil {
+		t.Fatalf("failed to add second layer: %v", err)
+	}
+	if err := snaps.Update(root2, root2, nil, nil, nil); err != errSnapshotCycle {
+		t.Errorf("self-referencing layer error mismatch: have %v, want %v", err, errSnapshotCycle)
+	}
+	if err := snaps.Update(common.HexToHash("0xa3"), common.HexToHash("0xff"), nil, nil, nil); err == nil {
+		t.Errorf("layer on missing parent accepted")
+	}
+	snap1, snap2 := snaps.Snapshot(root1), snaps.Snapshot(root2)
+
+	if blob, _ := snap1.Account(acc1); !bytes.Equal(blob, []byte{0xaa}) {
+		t.Errorf("modified account mismatch: have %x, want aa", blob)
+	}
+	if blob, _ := snap1.Account(acc2); !bytes.Equal(blob, leaves[acc2]) {
+		t.Errorf("parent account mismatch: have %x, want %x", blob, leaves[acc2])
+	}
+	if blob, _ := snap2.Account(acc1); !bytes.Equal(blob, []byte{0xaa}) {
+		t.Errorf("inherited account mismatch: have %x, want aa", blob)
+	}
+	if blob, err := snap2.Account(acc2); err != nil || blob != nil {
+		t.Errorf("destructed account mismatch: have %x, %v; want nil", blob, err)
+	}
+	if blob, err := snap2.Storage(acc2, slot); err != nil || blob != nil {
+		t.Errorf("destructed storage mismatch: have %x, %v; want nil", blob, err)
+	}
+	if blob, _ := snap1.Storage(acc2, slot); !bytes.Equal(blob, []byte{0x01}) {
+		t.Errorf("parent storage mismatch: have %x, want 01", blob)
+	}
+}
+
+func TestCap(t *testing.T) {
+	db, cleanup := newTestDatabase(t)
+	defer cleanup()
+
+	var (
+		acc1 = common.HexToHash("0x01")
+		acc2 = common.HexToHash("0x02")
+		slot = common.HexToHash("0x11")
+	)
+	root, _ := makeState(t, db, map[common.Hash]testAccount{
+		acc1: {nonce: 1},
+		acc2: {nonce: 2, storage: map[common.Hash][]byte{slot: {0x01}}},
+	})
+	snaps, err := New(db, db, root)
+	if err != nil {
+		t.Fatalf("failed to create snapshot: %v", err)
+	}
+	waitGeneration(t, snaps, root)
+
+	// Build a chain of three layers along with a side layer forking off the first
+	root1, root2, root3 := common.HexToHash("0xa1"), common.HexToHash("0xa2"), common.HexToHash("0xa3")
+	side := common.HexToHash("0xb2")
+
+	snaps.Update(root1, root, map[common.Hash]struct{}{acc2: {}}, nil, nil)
+	snaps.Update(root2, root1, nil, map[common.Hash][]byte{acc1: {0xaa}}, map[common.Hash]map[common.Hash][]byte{acc2: {slot: {0x02}}})
+	snaps.Update(root3, root2, nil, map[common.Hash][]byte{acc1: {0xbb}}, nil)
+	snaps.Update(side, root1, nil, map[common.Hash][]byte{acc1: {0xcc}}, nil)
+
+	base, snap1, snapSide := snaps.Snapshot(root), snaps.Snapshot(root1), snaps.Snapshot(side)
+
+	// Keep a single diff layer, flattening the first two into the disk
+	if err := snaps.Cap(root3, 1); err != nil {
+		t.Fatalf("failed to cap snapshot: %v", err)
+	}
+	for _, snap := range []Snapshot{base, snap1, snapSide} {
+		if _, err := snap.Account(acc1); err != ErrSnapshotStale {
+			t.Errorf("flattened layer %x error mismatch: have %v, want %v", snap.Root(), err, ErrSnapshotStale)
+		}
+	}
+	for _, root := range []common.Hash{root, root1, side} {
+		if snaps.Snapshot(root) != nil {
+			t.Errorf("flattened layer %x still in the tree", root)
+		}
+	}
+	if _, ok := snaps.Snapshot(root2).(*diskLayer); !ok {
+		t.Fatalf("disk layer not moved to the capped root")
+	}
+	snap3 := snaps.Snapshot(root3)
+	if blob, _ := snap3.Account(acc1); !bytes.Equal(blob, []byte{0xbb}) {
+		t.Errorf("top account mismatch: have %x, want bb", blob)
+	}
+	if blob, _ := snap3.Storage(acc2, slot); !bytes.Equal(blob, []byte{0x02}) {
+		t.Errorf("flattened storage mismatch: have %x, want 02", blob)
+	}
+	// Flatten everything, then reload the persisted snapshot
+	if err := snaps.Cap(root3, 0); err != nil {
+		t.Fatalf("failed to flatten snapshot: %v", err)
+	}
+	snaps.Release()
+
+	if have := readSnapshotRoot(db); have != root3 {
+		t.Fatalf("persisted root mismatch: have %x, want %x", have, root3)
+	}
+	snaps, err = New(db, db, root3)
+	if err != nil {
+		t.Fatalf("failed to reload snapshot: %v", err)
+	}
+	defer snaps.Release()
+
+	snap := snaps.Snapshot(root3)
+	if blob, err := snap.Account(acc1); err != nil || !bytes.Equal(blob, []byte{0xbb}) {
+		t.Errorf("reloaded account mismatch: have %x, %v; want bb", blob, err)
+	}
+	if blob, err := snap.Account(acc2); err != nil || blob != nil {
+		t.Errorf("reloaded destructed account mismatch: have %x, %v; want nil", blob, err)
+	}
+	if blob, err := snap.Storage(acc2, slot); err != nil || !bytes.Equal(blob, []byte{0x02}) {
+		t.Errorf("reloaded storage mismatch: have %x, %v; want 02", blob, err)
+	}
+}
+
+func TestRebuild(t *testing.T) {
+	db, cleanup := newTestDatabase(t)
+	defer cleanup()
+
+	var (
+		acc1  = common.HexToHash("0x01")
+		acc2  = common.HexToHash("0x02")
+		slot1 = common.HexToHash("0x11")
+		slot2 = common.HexToHash("0x12")
+	)
+	root, _ := makeState(t, db, map[common.Hash]testAccount{
+		acc1: {nonce: 1, storage: map[common.Hash][]byte{slot1: {0x01}}},
+		acc2: {nonce: 2},
+	})
+	snaps, err := New(db, db, root)
+	if err != nil {
+		t.Fatalf("failed to create snapshot: %v", err)
+	}
+	defer snaps.Release()
+	waitGeneration(t, snaps, root)
+
+	snaps.Update(common.HexToHash("0xa1"), root, nil, map[common.Hash][]byte{acc2: {0xaa}}, nil)
+	old := snaps.Snapshot(common.HexToHash("0xa1"))
+
+	// Rebuild onto an unrelated state, as after a reorg deeper than the disk layer
+	newRoot, leaves := makeState(t, db, map[common.Hash]testAccount{
+		acc1: {nonce: 3, storage: map[common.Hash][]byte{slot2: {0x02}}},
+	})
+	snaps.Rebuild(newRoot)
+	waitGeneration(t, snaps, newRoot)
+
+	if _, err := old.Account(acc2); err != ErrSnapshotStale {
+		t.Errorf("old layer error mismatch: have %v, want %v", err, ErrSnapshotStale)
+	}
+	snap := snaps.Snapshot(newRoot)
+	if blob, err := snap.Account(acc1); err != nil || !bytes.Equal(blob, leaves[acc1]) {
+		t.Errorf("regenerated account mismatch: have %x, %v; want %x", blob, err, leaves[acc1])
+	}
+	if blob, err := snap.Account(acc2); err != nil || blob != nil {
+		t.Errorf("stale account not wiped: have %x, %v", blob, err)
+	}
+	if blob, err := snap.Storage(acc1, slot1); err != nil || blob != nil {
+		t.Errorf("stale storage not wiped: have %x, %v", blob, err)
+	}
+	if blob, err := snap.Storage(acc1, slot2); err != nil || !bytes.Equal(blob, []byte{0x02}) {
+		t.Errorf("regenerated storage mismatch: have %x, %v; want 02", blob, err)
+	}
+}
@@ -171,6 +171,27 @@ func (c *StateObject) getTrie(db Database) Trie {
 	return c.trie
 }
 
+// loadStorage retrieves the encoded value of a storage slot from the flat state
+// snapshot if available, falling back to the storage trie otherwise. Slots
+// written by the earlier transactions of the block are taken from the pending
+// snapshot changes, the snapshot itself holding the parent block's values.
+func (self *StateObject) loadStorage(db Database, key common.Hash) ([]byte, error) {
+	if self.db.snap != nil {
+		keyHash := crypto.Keccak256Hash(key[:])
+		if enc, ok := self.db.snapStorage[self.addrHash][keyHash]; ok {
+			return enc, nil
+		}
+		// The storage of destructed accounts in the snapshot belongs to a previous incarnation
+		if _, destructed := self.db.snapDestructs[self.addrHash]; destructed {
+			return nil, nil
+		}
+		if enc, err := self.db.snap.Storage(self.addrHash, keyHash); err == nil {
+			return enc, nil
+		}
+	}
+	return self.getTrie(db).TryGet(key[:])
+}
+
 // GetState returns a value in account storage.
 func (self *StateObject) GetState(db Database, key common.Hash) common.Hash {
 	value, exists := self.cachedStorage[key]
@@ -178,7 +199,7 @@ func (self *StateObject) GetState(db Database, key common.Hash) common.Hash {
 		return value
 	}
 	// Load from DB in case it is missing.
-	enc, err := self.getTrie(db).TryGet(key[:])
+	enc, err := self.loadStorage(db, key)
 	if err != nil {
 		self.setError(err)
 		return common.Hash{}
@@ -196,6 +217,24 @@ func (self *StateObject) GetState(db Database, key common.Hash) common.Hash {
 	return value
 }
 
+// GetCommittedState retrieves a value from the committed account storage trie.
+func (self *StateObject) GetCommittedState(db Database, key common.Hash) common.Hash {
+	var value common.Hash
+	enc, err := self.loadStorage(db, key)
+	if err != nil {
+		self.setError(err)
+		return common.Hash{}
+	}
+	if len(enc) > 0 {
+		_, content, _, err := rlp.Split(enc)
+		if err != nil {
+			self.setError(err)
+		}
+		value.SetBytes(content)
+	}
+	return value
+}
+
 // SetState updates a value in account storage.
 func (self *StateObject) SetState(db Database, key, value common.Hash) {
 	self.db.journal = append(self.db.journal, storageChange{
@@ -219,15 +258,29 @@ func (self *StateObject) setState(key, value common.Hash) {
 // updateTrie writes cached storage modifications into the object's storage trie.
 func (self *StateObject) updateTrie(db Database) Trie {
 	tr := self.getTrie(db)
+
+	// Collect the storage changes for the state snapshot too
+	var storage map[common.Hash][]byte
+	if self.db.snap != nil && len(self.dirtyStorage) > 0 {
+		if storage = self.db.snapStorage[self.addrHash]; storage == nil {
+			storage = make(map[common.Hash][]byte)
+			self.db.snapStorage[self.addrHash] = storage
+		}
+	}
 	for key, value := range self.dirtyStorage {
 		delete(self.dirtyStorage, key)
+
+		var v []byte
 		if (value == common.Hash{}) {
 			self.setError(tr.TryDelete(key[:]))
-			continue
+		} else {
+			// Encoding []byte cannot fail, ok to ignore the error.
+			v, _ = rlp.EncodeToBytes(bytes.TrimLeft(value[:], "\x00"))
+			self.setError(tr.TryUpdate(key[:], v))
+		}
+		if storage != nil {
+			storage[crypto.Keccak256Hash(key[:])] = v
 		}
-		// Encoding []byte cannot fail, ok to ignore the error.
-		v, _ := rlp.EncodeToBytes(bytes.TrimLeft(value[:], "\x00"))
-		self.setError(tr.TryUpdate(key[:], v))
 	}
 	return tr
 }
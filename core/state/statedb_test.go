@@ -27,13 +27,91 @@ import (
 	"strings"
 	"testing"
 	"testing/quick"
+	"time"
 
 	"github.com/eth-classic/go-ethereum/common"
+	"github.com/eth-classic/go-ethereum/core/state/snapshot"
 	"github.com/eth-classic/go-ethereum/core/vm"
+	"github.com/eth-classic/go-ethereum/crypto"
 	"github.com/eth-classic/go-ethereum/ethdb"
+	"github.com/eth-classic/go-ethereum/rlp"
+	"github.com/eth-classic/go-ethereum/trie"
 	"gopkg.in/check.v1"
 )
 
+// proofDatabase loads a list of proof nodes into a database keyed by their hash,
+// as expected by trie.VerifyProof.
+func proofDatabase(proof [][]byte) *ethdb.MemDatabase {
+	db, _ := ethdb.NewMemDatabase()
+	for _, node := range proof {
+		db.Put(crypto.Keccak256(node), node)
+	}
+	return db
+}
+
+// Tests that account and storage proofs verify against the state and storage
+// roots, both for existing and missing entries.
+func TestGetProof(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	state, _ := New(common.Hash{}, NewDatabase(db))
+
+	for i := byte(0); i < 64; i++ {
+		addr := common.BytesToAddress([]byte{i})
+		state.AddBalance(addr, big.NewInt(int64(i)+1))
+		state.SetState(addr, common.BytesToHash([]byte{i}), common.BytesToHash([]byte{i, i}))
+	}
+	root, _ := state.CommitTo(db, false)
+	state, _ = New(root, NewDatabase(db))
+
+	addr := common.BytesToAddress([]byte{42})
+	proof, err := state.GetProof(addr)
+	if err != nil {
+		t.Fatalf("failed to prove account: %v", err)
+	}
+	blob, err, _ := trie.VerifyProof(root, crypto.Keccak256(addr[:]), proofDatabase(proof))
+	if err != nil {
+		t.Fatalf("account proof invalid: %v", err)
+	}
+	var account Account
+	if err := rlp.DecodeBytes(blob, &account); err != nil {
+		t.Fatalf("failed to decode proven account: %v", err)
+	}
+	if account.Balance.Cmp(big.NewInt(43)) != 0 {
+		t.Errorf("proven balance mismatch: have %v, want 43", account.Balance)
+	}
+	// Prove an existing and a missing storage slot
+	for _, slot := range []common.Hash{common.BytesToHash([]byte{42}), common.BytesToHash([]byte{43})} {
+		proof, err := state.GetStorageProof(addr, slot)
+		if err != nil {
+			t.Fatalf("failed to prove slot %x: %v", slot, err)
+		}
+		blob, err, _ := trie.VerifyProof(account.Root, crypto.Keccak256(slot[:]), proofDatabase(proof))
+		if err != nil {
+			t.Fatalf("storage proof of slot %x invalid: %v", slot, err)
+		}
+		var value []byte
+		if len(blob) > 0 {
+			if err := rlp.DecodeBytes(blob, &value); err != nil {
+				t.Fatalf("failed to decode proven slot: %v", err)
+			}
+		}
+		if have, want := common.BytesToHash(value), state.GetState(addr, slot); have != want {
+			t.Errorf("proven slot %x mismatch: have %x, want %x", slot, have, want)
+		}
+	}
+	// Prove the absence of an account
+	missing := common.BytesToAddress([]byte{0xff, 0xff})
+	if proof, err = state.GetProof(missing); err != nil {
+		t.Fatalf("failed to prove missing account: %v", err)
+	}
+	if blob, err, _ := trie.VerifyProof(root, crypto.Keccak256(missing[:]), proofDatabase(proof)); err != nil || blob != nil {
+		t.Fatalf("absence proof invalid: blob %x, err %v", blob, err)
+	}
+	if _, err := state.GetStorageProof(missing, common.Hash{}); err == nil {
+		t.Fatalf("storage proof of missing account succeeded")
+	}
+}
+
 // Tests that updating a state trie does not leak any database writes prior to
 // actually committing the state.
 func TestUpdateLeaks(t *testing.T) {
@@ -422,3 +500,148 @@ func (s *StateSuite) TestTouchDelete(c *check.C) {
 		c.Fatal("expected no dirty state object")
 	}
 }
+
+// TestAccessList checks that access list additions are tracked and undone by
+// snapshot reverts.
+func TestAccessList(t *testing.T) {
+	mem, _ := ethdb.NewMemDatabase()
+	state, _ := New(common.Hash{}, NewDatabase(mem))
+
+	var (
+		sender = common.HexToAddress("0x01")
+		dst    = common.HexToAddress("0x02")
+		pre    = common.HexToAddress("0x09")
+		other  = common.HexToAddress("0xff")
+		slot   = common.HexToHash("0x01")
+	)
+	state.PrepareAccessList(sender, &dst, []common.Address{pre})
+	state.AddSlotToAccessList(other, slot)
+	for _, addr := range []common.Address{sender, dst, pre, other} {
+		if !state.AddressInAccessList(addr) {
+			t.Errorf("address %x missing from access list", addr)
+		}
+	}
+	if _, ok := state.SlotInAccessList(other, slot); !ok {
+		t.Error("slot missing from access list")
+	}
+
+	snapshot := state.Snapshot()
+	fresh := common.HexToAddress("0xaa")
+	state.AddSlotToAccessList(fresh, slot)
+	state.AddSlotToAccessList(other, common.HexToHash("0x02"))
+	if addrOk, slotOk := state.SlotInAccessList(fresh, slot); !addrOk || !slotOk {
+		t.Error("added slot missing from access list")
+	}
+	state.RevertToSnapshot(snapshot)
+
+	if state.AddressInAccessList(fresh) {
+		t.Error("reverted address still in access list")
+	}
+	if _, ok := state.SlotInAccessList(other, common.HexToHash("0x02")); ok {
+		t.Error("reverted slot still in access list")
+	}
+	if _, ok := state.SlotInAccessList(other, slot); !ok {
+		t.Error("slot prior to snapshot was reverted")
+	}
+
+	// Copies must not share the access list.
+	cpy := state.Copy()
+	cpy.AddAddressToAccessList(fresh)
+	if state.AddressInAccessList(fresh) {
+		t.Error("copy modified the original access list")
+	}
+}
+
+// Benchmarks hashing and committing the changes of a block-like workload: a
+// number of modified accounts, a tenth of them with a few storage slots each.
+func BenchmarkIntermediateRoot(b *testing.B) {
+	for _, size := range []int{100, 1000, 10000} {
+		b.Run(fmt.Sprintf("%d", size), func(b *testing.B) {
+			benchmarkStateCommit(b, size, false)
+		})
+	}
+}
+
+func BenchmarkCommit(b *testing.B) {
+	for _, size := range []int{100, 1000, 10000} {
+		b.Run(fmt.Sprintf("%d", size), func(b *testing.B) {
+			benchmarkStateCommit(b, size, true)
+		})
+	}
+}
+
+func benchmarkStateCommit(b *testing.B, accounts int, commit bool) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		db, _ := ethdb.NewMemDatabase()
+		state, _ := New(common.Hash{}, NewDatabase(db))
+		for j := 0; j < accounts; j++ {
+			addr := common.BytesToAddress(crypto.Keccak256([]byte{byte(j), byte(j >> 8)}))
+			state.AddBalance(addr, big.NewInt(int64(j+1)))
+			if j%10 == 0 {
+				for k := 0; k < 10; k++ {
+					state.SetState(addr, common.BytesToHash([]byte{byte(k)}), common.BytesToHash([]byte{byte(j), byte(k + 1)}))
+				}
+			}
+		}
+		b.StartTimer()
+
+		if commit {
+			state.CommitTo(db, false)
+		} else {
+			state.IntermediateRoot(false)
+		}
+	}
+}
+
+// Tests that with a state snapshot attached, the committed value of a slot is
+// the one written by an earlier transaction of the block, not the parent's.
+func TestSnapshotCommittedState(t *testing.T) {
+	var (
+		db, _ = ethdb.NewMemDatabase()
+		addr  = common.Address{0x01}
+		key   = common.Hash{0x02}
+	)
+	state, _ := New(common.Hash{}, NewDatabase(db))
+	state.SetState(addr, key, common.Hash{31: 0x01})
+	root, err := state.CommitTo(db, false)
+	if err != nil {
+		t.Fatalf("failed to commit state: %v", err)
+	}
+	cache := NewNodeCache(db)
+	snaps, err := snapshot.New(db, cache, root)
+	if err != nil {
+		t.Fatalf("failed to create snapshot: %v", err)
+	}
+	defer snaps.Release()
+
+	// Wait for the snapshot to cover the slot
+	for i := 0; ; i++ {
+		if _, err := snaps.Snapshot(root).Storage(crypto.Keccak256Hash(addr[:]), crypto.Keccak256Hash(key[:])); err == nil {
+			break
+		}
+		if i == 500 {
+			t.Fatalf("snapshot generation timed out")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	state, err = NewWithSnapshot(root, NewDatabaseWithCache(cache), snaps)
+	if err != nil {
+		t.Fatalf("failed to open state: %v", err)
+	}
+	// The first transaction overwrites the slot, the second one reads it back
+	state.SetState(addr, key, common.Hash{31: 0x02})
+	state.Finalise(false)
+
+	if have, want := state.GetCommittedState(addr, key), (common.Hash{31: 0x02}); have != want {
+		t.Errorf("committed value mismatch: have %x, want %x", have, want)
+	}
+	// A slot cleared by an earlier transaction reads back empty
+	state.SetState(addr, key, common.Hash{})
+	state.Finalise(false)
+
+	if have := state.GetCommittedState(addr, key); have != (common.Hash{}) {
+		t.Errorf("committed value of cleared slot mismatch: have %x, want zero", have)
+	}
+}
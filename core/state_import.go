@@ -0,0 +1,162 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/eth-classic/go-ethereum/common"
+	"github.com/eth-classic/go-ethereum/core/state"
+	"github.com/eth-classic/go-ethereum/core/types"
+	"github.com/eth-classic/go-ethereum/crypto"
+	"github.com/eth-classic/go-ethereum/ethdb"
+	"github.com/eth-classic/go-ethereum/logger"
+	"github.com/eth-classic/go-ethereum/logger/glog"
+	"github.com/eth-classic/go-ethereum/rlp"
+	"github.com/eth-classic/go-ethereum/trie"
+)
+
+// importCommitInterval is the number of accounts imported between two commits of
+// the account trie, bounding the memory used by an import.
+const importCommitInterval = 10000
+
+// ImportState builds the state trie of an export stream in the database, along
+// with the contract code and the known preimages, returning its root hash. The
+// export must include the code and storage of every account.
+func ImportState(db ethdb.Database, r *state.ExportReader) (common.Hash, error) {
+	accTrie, err := trie.New(common.Hash{}, db)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	var (
+		preimages = make(map[common.Hash][]byte)
+		imported  int
+	)
+	flush := func() (common.Hash, error) {
+		root, err := accTrie.CommitTo(db)
+		if err != nil {
+			return common.Hash{}, err
+		}
+		if err := WritePreimages(db, 0, preimages); err != nil {
+			return common.Hash{}, err
+		}
+		preimages = make(map[common.Hash][]byte)
+		return root, nil
+	}
+	for {
+		acc, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return common.Hash{}, fmt.Errorf("account #%d: %v", imported, err)
+		}
+		storage, err := trie.New(common.Hash{}, db)
+		if err != nil {
+			return common.Hash{}, err
+		}
+		for _, slot := range acc.Storage {
+			value, _ := rlp.EncodeToBytes(bytes.TrimLeft(slot.Value[:], "\x00"))
+			if err := storage.TryUpdate(slot.Hash[:], value); err != nil {
+				return common.Hash{}, err
+			}
+			if len(slot.Key) > 0 {
+				preimages[slot.Hash] = slot.Key
+			}
+		}
+		root, err := storage.CommitTo(db)
+		if err != nil {
+			return common.Hash{}, err
+		}
+		if root != acc.Root {
+			return common.Hash{}, fmt.Errorf("account %x: storage root mismatch: have %x, want %x (storage skipped from export?)", acc.Hash, root, acc.Root)
+		}
+		if acc.CodeHash != emptyCodeHash {
+			if crypto.Keccak256Hash(acc.Code) != acc.CodeHash {
+				return common.Hash{}, fmt.Errorf("account %x: code hash mismatch (code skipped from export?)", acc.Hash)
+			}
+			if err := db.Put(acc.CodeHash[:], acc.Code); err != nil {
+				return common.Hash{}, err
+			}
+		}
+		blob, err := rlp.EncodeToBytes(state.Account{
+			Nonce:    acc.Nonce,
+			Balance:  acc.Balance,
+			Root:     acc.Root,
+			CodeHash: acc.CodeHash[:],
+		})
+		if err != nil {
+			return common.Hash{}, err
+		}
+		if err := accTrie.TryUpdate(acc.Hash[:], blob); err != nil {
+			return common.Hash{}, err
+		}
+		if len(acc.Address) > 0 {
+			preimages[acc.Hash] = acc.Address
+		}
+		if imported++; imported%importCommitInterval == 0 {
+			if _, err := flush(); err != nil {
+				return common.Hash{}, err
+			}
+			glog.V(logger.Info).Infof("Imported %d accounts", imported)
+		}
+	}
+	return flush()
+}
+
+// MakeGenesisAlloc builds the genesis allocation of the accounts of an export
+// stream. The export must include the code and storage of every account, and
+// the preimages of all addresses and storage keys.
+func MakeGenesisAlloc(r *state.ExportReader) (map[hex]*GenesisDumpAlloc, error) {
+	alloc := make(map[hex]*GenesisDumpAlloc)
+	for {
+		acc, err := r.Next()
+		if err == io.EOF {
+			return alloc, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("account #%d: %v", len(alloc), err)
+		}
+		if len(acc.Address) != common.AddressLength {
+			return nil, fmt.Errorf("account %x: missing address preimage", acc.Hash)
+		}
+		if acc.CodeHash != emptyCodeHash && crypto.Keccak256Hash(acc.Code) != acc.CodeHash {
+			return nil, fmt.Errorf("account %x: code hash mismatch (code skipped from export?)", acc.Hash)
+		}
+		account := &GenesisDumpAlloc{
+			Nonce:   acc.Nonce,
+			Balance: acc.Balance.String(),
+		}
+		if len(acc.Code) > 0 {
+			account.Code = prefixedHex(common.ToHex(acc.Code))
+		}
+		if len(acc.Storage) > 0 {
+			account.Storage = make(map[hex]hex, len(acc.Storage))
+			for _, slot := range acc.Storage {
+				if len(slot.Key) != common.HashLength {
+					return nil, fmt.Errorf("account %x: missing preimage of storage slot %x", acc.Hash, slot.Hash)
+				}
+				account.Storage[hex(common.Bytes2Hex(slot.Key))] = hex(common.Bytes2Hex(slot.Value[:]))
+			}
+		} else if acc.Root != types.EmptyRootHash {
+			return nil, fmt.Errorf("account %x: missing storage (storage skipped from export?)", acc.Hash)
+		}
+		alloc[hex(common.Bytes2Hex(acc.Address))] = account
+	}
+}
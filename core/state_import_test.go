@@ -0,0 +1,136 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/eth-classic/go-ethereum/core/state"
+	"github.com/eth-classic/go-ethereum/ethdb"
+)
+
+// importTestGenesis is a genesis allocating accounts with code, storage and nonces.
+var importTestGenesis = &GenesisDump{
+	Nonce:      "0x0000000000000042",
+	Timestamp:  "0x0000000000000000000000000000000000000000000000000000000000000000",
+	Coinbase:   "0x0000000000000000000000000000000000000000",
+	Difficulty: "0x0000000000000000000000000000000000000000000000000000000400000000",
+	GasLimit:   "0x0000000000000000000000000000000000000000000000000000000000001388",
+	Mixhash:    "0x0000000000000000000000000000000000000000000000000000000000000000",
+	ParentHash: "0x0000000000000000000000000000000000000000000000000000000000000000",
+	Alloc: map[hex]*GenesisDumpAlloc{
+		"000d836201318ec6899a67540690382780743280": {Balance: "200000000000000000000"},
+		"001762430ea9c3a26e5749afdb70da5f78ddbb8c": {Balance: "1", Nonce: 7},
+		"001d14804b399c6ef80e64576f657660804fec0b": {
+			Balance: "0",
+			Nonce:   1,
+			Code:    "0x6000",
+			Storage: map[hex]hex{
+				"0000000000000000000000000000000000000000000000000000000000000001": "00000000000000000000000000000000000000000000000000000000000000aa",
+				"0000000000000000000000000000000000000000000000000000000000000002": "00000000000000000000000000000000000000000000000000000000000000bb",
+			},
+		},
+	},
+}
+
+func TestImportState(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	genesis, err := WriteGenesisBlock(db, importTestGenesis)
+	if err != nil {
+		t.Fatalf("failed to write genesis: %v", err)
+	}
+	statedb, _ := state.New(genesis.Root(), state.NewDatabase(db))
+
+	for _, isRLP := range []bool{false, true} {
+		buf := new(bytes.Buffer)
+		if _, err := statedb.Export(buf, &state.ExportConfig{RLP: isRLP}); err != nil {
+			t.Fatalf("rlp %v: export failed: %v", isRLP, err)
+		}
+		imported, _ := ethdb.NewMemDatabase()
+		root, err := ImportState(imported, state.NewExportReader(buf, isRLP))
+		if err != nil {
+			t.Fatalf("rlp %v: import failed: %v", isRLP, err)
+		}
+		if root != genesis.Root() {
+			t.Fatalf("rlp %v: imported root mismatch: have %x, want %x", isRLP, root, genesis.Root())
+		}
+		importedState, err := state.New(root, state.NewDatabase(imported))
+		if err != nil {
+			t.Fatalf("rlp %v: imported state not available: %v", isRLP, err)
+		}
+		for addrHex, account := range importTestGenesis.Alloc {
+			var addr [20]byte
+			addrHex.Decode(addr[:])
+			code, _ := account.Code.Bytes()
+			if have := importedState.GetCode(addr); !bytes.Equal(have, code) {
+				t.Errorf("rlp %v: account %s: code mismatch: have %x, want %x", isRLP, addrHex, have, code)
+			}
+			if have := importedState.GetNonce(addr); have != account.Nonce {
+				t.Errorf("rlp %v: account %s: nonce mismatch: have %d, want %d", isRLP, addrHex, have, account.Nonce)
+			}
+		}
+	}
+
+	// Exports without storage can't be imported
+	buf := new(bytes.Buffer)
+	if _, err := statedb.Export(buf, &state.ExportConfig{SkipStorage: true}); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	imported, _ := ethdb.NewMemDatabase()
+	if _, err := ImportState(imported, state.NewExportReader(buf, false)); err == nil {
+		t.Errorf("import of export without storage succeeded")
+	}
+}
+
+func TestMakeGenesisDumpFrom(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+	genesis, err := WriteGenesisBlock(db, importTestGenesis)
+	if err != nil {
+		t.Fatalf("failed to write genesis: %v", err)
+	}
+	statedb, _ := state.New(genesis.Root(), state.NewDatabase(db))
+
+	buf := new(bytes.Buffer)
+	if _, err := statedb.Export(buf, &state.ExportConfig{RLP: true}); err != nil {
+		t.Fatalf("export failed: %v", err)
+	}
+	dump, err := MakeGenesisDumpFrom(db, state.NewExportReader(buf, true))
+	if err != nil {
+		t.Fatalf("failed to make genesis dump: %v", err)
+	}
+	// The genesis made from the export recreates the exported state
+	other, _ := ethdb.NewMemDatabase()
+	block, err := WriteGenesisBlock(other, dump)
+	if err != nil {
+		t.Fatalf("failed to write genesis dump: %v", err)
+	}
+	if block.Hash() != genesis.Hash() {
+		t.Errorf("genesis hash mismatch: have %x, want %x", block.Hash(), genesis.Hash())
+	}
+	// The genesis dump itself carries code and storage along too
+	if dump, err = MakeGenesisDump(db); err != nil {
+		t.Fatalf("failed to make genesis dump: %v", err)
+	}
+	other, _ = ethdb.NewMemDatabase()
+	if block, err = WriteGenesisBlock(other, dump); err != nil {
+		t.Fatalf("failed to write genesis dump: %v", err)
+	}
+	if block.Hash() != genesis.Hash() {
+		t.Errorf("genesis dump hash mismatch: have %x, want %x", block.Hash(), genesis.Hash())
+	}
+}
@@ -64,6 +64,28 @@ func NewStateProcessor(config *ChainConfig, bc *BlockChain) *StateProcessor {
 // returns the amount of gas that was used in the process. If any of the
 // transactions failed to execute due to insufficient gas it will return an error.
 func (p *StateProcessor) Process(block *types.Block, statedb *state.StateDB) (types.Receipts, vm.Logs, *big.Int, error) {
+	return p.process(block, statedb, nil)
+}
+
+// ProcessDiffs processes the block like Process, returning the state changes
+// made by each of its transactions, followed by the ones made by the rewards.
+func (p *StateProcessor) ProcessDiffs(block *types.Block, statedb *state.StateDB) ([]state.Diff, state.Diff, error) {
+	var diffs []state.Diff
+
+	statedb.StartDiff()
+	_, _, _, err := p.process(block, statedb, func() {
+		diffs = append(diffs, statedb.Diff())
+		statedb.StartDiff()
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return diffs, statedb.Diff(), nil
+}
+
+// process executes the transactions of the block along with the rewards, calling
+// txDone, if set, after each transaction.
+func (p *StateProcessor) process(block *types.Block, statedb *state.StateDB, txDone func()) (types.Receipts, vm.Logs, *big.Int, error) {
 	var (
 		receipts     types.Receipts
 		totalUsedGas = big.NewInt(0)
@@ -91,6 +113,9 @@ func (p *StateProcessor) Process(block *types.Block, statedb *state.StateDB) (ty
 			}
 			receipts = append(receipts, receipt)
 			allLogs = append(allLogs, logs...)
+			if txDone != nil {
+				txDone()
+			}
 			continue
 		}
 		receipt, logs, _, err := ApplyMultiVmTransaction(p.config, p.bc, gp, statedb, header, tx, totalUsedGas)
@@ -99,8 +124,15 @@ func (p *StateProcessor) Process(block *types.Block, statedb *state.StateDB) (ty
 		}
 		receipts = append(receipts, receipt)
 		allLogs = append(allLogs, logs...)
+		if txDone != nil {
+			txDone()
+		}
+	}
+	if engine, ok := p.bc.pow.(Engine); ok {
+		engine.Finalize(p.bc, header, statedb, block.Uncles())
+	} else {
+		AccumulateRewards(p.config, statedb, header, block.Uncles())
 	}
-	AccumulateRewards(p.config, statedb, header, block.Uncles())
 
 	return receipts, allLogs, totalUsedGas, err
 }
@@ -128,6 +160,7 @@ func ApplyTransaction(config *ChainConfig, bc *BlockChain, gp *GasPool, statedb
 
 	usedGas.Add(usedGas, gas)
 	receipt := types.NewReceipt(root, usedGas)
+	receipt.Type = tx.Type()
 	receipt.TxHash = tx.Hash()
 	receipt.GasUsed = new(big.Int).Set(gas)
 	if MessageCreatesContract(tx) {
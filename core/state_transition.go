@@ -21,16 +21,24 @@ import (
 	"math/big"
 
 	"github.com/eth-classic/go-ethereum/common"
+	"github.com/eth-classic/go-ethereum/core/types"
 	"github.com/eth-classic/go-ethereum/core/vm"
 	"github.com/eth-classic/go-ethereum/logger"
 	"github.com/eth-classic/go-ethereum/logger/glog"
 )
 
 var (
-	TxGas                 = big.NewInt(21000) // Per transaction not creating a contract. NOTE: Not payable on data of calls between transactions.
-	TxGasContractCreation = big.NewInt(53000) // Per transaction that creates a contract. NOTE: Not payable on data of calls between transactions.
-	TxDataZeroGas         = big.NewInt(4)     // Per byte of data attached to a transaction that equals zero. NOTE: Not payable on data of calls between transactions.
-	TxDataNonZeroGas      = big.NewInt(68)    // Per byte of data attached to a transaction that is not equal to zero. NOTE: Not payable on data of calls between transactions.
+	TxGas                   = big.NewInt(21000) // Per transaction not creating a contract. NOTE: Not payable on data of calls between transactions.
+	TxGasContractCreation   = big.NewInt(53000) // Per transaction that creates a contract. NOTE: Not payable on data of calls between transactions.
+	TxDataZeroGas           = big.NewInt(4)     // Per byte of data attached to a transaction that equals zero. NOTE: Not payable on data of calls between transactions.
+	TxDataNonZeroGas        = big.NewInt(68)    // Per byte of data attached to a transaction that is not equal to zero. NOTE: Not payable on data of calls between transactions.
+	TxDataNonZeroGasEIP2028 = big.NewInt(16)    // Per byte of non zero data attached to a transaction after EIP 2028 (part in Phoenix)
+
+	TxAccessListAddressGas    = big.NewInt(2400) // Per address specified in an EIP 2930 access list
+	TxAccessListStorageKeyGas = big.NewInt(1900) // Per storage key specified in an EIP 2930 access list
+
+	RefundQuotient        = big.NewInt(2) // Maximum refund quotient; max gas refund is gasUsed / RefundQuotient
+	RefundQuotientEIP3529 = big.NewInt(5) // Maximum refund quotient after EIP 3529 (part in Mystique)
 )
 
 /*
@@ -44,8 +52,10 @@ The state transitioning model does all all the necessary work to work out a vali
 3) Create a new state object if the recipient is \0*32
 4) Value transfer
 == If contract creation ==
-  4a) Attempt to run transaction data
-  4b) If valid, use result as code for the new state object
+
+	4a) Attempt to run transaction data
+	4b) If valid, use result as code for the new state object
+
 == end ==
 5) Run Script section
 6) Derive new state root
@@ -73,6 +83,7 @@ type Message interface {
 
 	Nonce() uint64
 	Data() []byte
+	AccessList() types.AccessList
 }
 
 func MessageCreatesContract(msg Message) bool {
@@ -80,8 +91,8 @@ func MessageCreatesContract(msg Message) bool {
 }
 
 // IntrinsicGas computes the 'intrinsic gas' for a message
-// with the given data.
-func IntrinsicGas(data []byte, contractCreation, homestead bool) *big.Int {
+// with the given data and access list.
+func IntrinsicGas(data []byte, accessList types.AccessList, contractCreation, homestead, eip2028, eip3860 bool) *big.Int {
 	igas := new(big.Int)
 	if contractCreation && homestead {
 		igas.Set(TxGasContractCreation)
@@ -96,11 +107,24 @@ func IntrinsicGas(data []byte, contractCreation, homestead bool) *big.Int {
 			}
 		}
 		m := big.NewInt(nz)
-		m.Mul(m, TxDataNonZeroGas)
+		if eip2028 {
+			m.Mul(m, TxDataNonZeroGasEIP2028)
+		} else {
+			m.Mul(m, TxDataNonZeroGas)
+		}
 		igas.Add(igas, m)
 		m.SetInt64(int64(len(data)) - nz)
 		m.Mul(m, TxDataZeroGas)
 		igas.Add(igas, m)
+
+		if contractCreation && eip3860 {
+			words := big.NewInt(int64((len(data) + 31) / 32))
+			igas.Add(igas, words.Mul(words, vm.InitCodeWordGasEIP3860))
+		}
+	}
+	if accessList != nil {
+		igas.Add(igas, new(big.Int).Mul(big.NewInt(int64(len(accessList))), TxAccessListAddressGas))
+		igas.Add(igas, new(big.Int).Mul(big.NewInt(int64(accessList.StorageKeys())), TxAccessListStorageKeyGas))
 	}
 	return igas
 }
@@ -229,12 +253,33 @@ func (self *StateTransition) TransitionDb() (ret []byte, gas *big.Int, failed bo
 	sender, _ := self.from() // err checked in preCheck
 
 	homestead := self.env.RuleSet().IsHomestead(self.env.BlockNumber())
+	eip2028 := self.env.RuleSet().IsEIP2028(self.env.BlockNumber())
+	eip3860 := self.env.RuleSet().IsEIP3860(self.env.BlockNumber())
 	contractCreation := MessageCreatesContract(msg)
+	if contractCreation && eip3860 && len(self.data) > vm.MaxInitCodeSizeEIP3860 {
+		return nil, nil, false, InvalidTxError(vm.ErrMaxInitCodeSize)
+	}
 	// Pay intrinsic gas
-	if err = self.useGas(IntrinsicGas(self.data, contractCreation, homestead)); err != nil {
+	if err = self.useGas(IntrinsicGas(self.data, msg.AccessList(), contractCreation, homestead, eip2028, eip3860)); err != nil {
 		return nil, nil, false, InvalidTxError(err)
 	}
 
+	// Warm up the sender, recipient, precompiles and the transaction's access list
+	if self.env.RuleSet().IsEIP2929(self.env.BlockNumber()) {
+		precompiles := vm.ActivePrecompiles(self.env.RuleSet(), self.env.BlockNumber())
+		self.state.PrepareAccessList(sender.Address(), msg.To(), precompiles)
+		for _, tuple := range msg.AccessList() {
+			self.state.AddAddressToAccessList(tuple.Address)
+			for _, key := range tuple.StorageKeys {
+				self.state.AddSlotToAccessList(tuple.Address, key)
+			}
+		}
+		// EIP-3651 also warms up the coinbase
+		if self.env.RuleSet().IsEIP3651(self.env.BlockNumber()) {
+			self.state.AddAddressToAccessList(self.env.Coinbase())
+		}
+	}
+
 	vmenv := self.env
 	//var addr common.Address
 	var vmerr error
@@ -275,9 +320,13 @@ func (self *StateTransition) refundGas() {
 	remaining := new(big.Int).Mul(self.gas, self.gasPrice)
 	sender.AddBalance(remaining)
 
-	// Apply refund counter, capped to half of the used gas.
-	uhalf := remaining.Div(self.gasUsed(), common.Big2)
-	refund := common.BigMin(uhalf, self.state.GetRefund())
+	// Apply refund counter, capped to half of the used gas (a fifth after EIP-3529).
+	quotient := RefundQuotient
+	if self.env.RuleSet().IsEIP3529(self.env.BlockNumber()) {
+		quotient = RefundQuotientEIP3529
+	}
+	maxRefund := remaining.Div(self.gasUsed(), quotient)
+	refund := common.BigMin(maxRefund, self.state.GetRefund())
 	self.gas.Add(self.gas, refund)
 	self.state.AddBalance(sender.Address(), refund.Mul(refund, self.gasPrice))
 	// Also return remaining gas to the block gas counter so it is
@@ -27,6 +27,7 @@ import (
 	"github.com/eth-classic/go-ethereum/common"
 	"github.com/eth-classic/go-ethereum/core/state"
 	"github.com/eth-classic/go-ethereum/core/types"
+	"github.com/eth-classic/go-ethereum/core/vm"
 	"github.com/eth-classic/go-ethereum/event"
 	"github.com/eth-classic/go-ethereum/logger"
 	"github.com/eth-classic/go-ethereum/logger/glog"
@@ -75,6 +76,9 @@ type TxPool struct {
 	wg sync.WaitGroup // for shutdown sync
 
 	homestead bool
+	eip2028   bool
+	eip2930   bool
+	eip3860   bool
 }
 
 func NewTxPool(config *ChainConfig, eventMux *event.TypeMux, currentStateFn stateFn, gasLimitFn func() *big.Int) *TxPool {
@@ -111,6 +115,16 @@ func (pool *TxPool) eventLoop() {
 			if ev.Block != nil && pool.config.IsHomestead(ev.Block.Number()) {
 				pool.homestead = true
 			}
+			if ev.Block != nil && pool.config.IsEIP2028(ev.Block.Number()) {
+				pool.eip2028 = true
+			}
+			if ev.Block != nil && pool.config.IsEIP3860(ev.Block.Number()) {
+				pool.eip3860 = true
+			}
+			if ev.Block != nil && !pool.eip2930 && pool.config.IsEIP2930(ev.Block.Number()) {
+				pool.eip2930 = true
+				pool.signer = types.NewAccessListSigner(pool.config.GetChainID())
+			}
 
 			pool.resetState()
 			pool.mu.Unlock()
@@ -237,6 +251,11 @@ func (pool *TxPool) validateTx(tx *types.Transaction) (e error) {
 			e,
 		).Send(mlogTxPool)
 	}()
+	// Reject typed transactions until EIP-2930 is activated
+	if !pool.eip2930 && tx.Type() != types.LegacyTxType {
+		e = types.ErrTxTypeNotSupported
+		return
+	}
 	// Drop transactions under our own minimal accepted gas price
 	if !local && pool.minGasPrice.Cmp(tx.GasPrice()) > 0 {
 		e = ErrCheap
@@ -290,7 +309,13 @@ func (pool *TxPool) validateTx(tx *types.Transaction) (e error) {
 		return
 	}
 
-	intrGas := IntrinsicGas(tx.Data(), MessageCreatesContract(tx), pool.homestead)
+	// Reject contract creations with oversized init code after EIP-3860
+	if pool.eip3860 && MessageCreatesContract(tx) && len(tx.Data()) > vm.MaxInitCodeSizeEIP3860 {
+		e = vm.ErrMaxInitCodeSize
+		return
+	}
+
+	intrGas := IntrinsicGas(tx.Data(), tx.AccessList(), MessageCreatesContract(tx), pool.homestead, pool.eip2028, pool.eip3860)
 	if tx.Gas().Cmp(intrGas) < 0 {
 		e = ErrIntrinsicGas
 		return
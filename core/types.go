@@ -23,11 +23,13 @@ import (
 	"math/big"
 
 	"github.com/eth-classic/go-ethereum/accounts"
+	"github.com/eth-classic/go-ethereum/common"
 	"github.com/eth-classic/go-ethereum/core/state"
 	"github.com/eth-classic/go-ethereum/core/types"
 	"github.com/eth-classic/go-ethereum/core/vm"
 	"github.com/eth-classic/go-ethereum/ethdb"
 	"github.com/eth-classic/go-ethereum/event"
+	"github.com/eth-classic/go-ethereum/pow"
 )
 
 // Validator is an interface which defines the standard for block validation.
@@ -58,6 +60,51 @@ type HeaderValidator interface {
 	ValidateHeader(header, parent *types.Header, checkPow bool) error
 }
 
+// ChainReader defines the small collection of methods needed to access the local
+// chain during header verification and block sealing.
+type ChainReader interface {
+	// Config retrieves the chain configuration.
+	Config() *ChainConfig
+
+	// CurrentHeader retrieves the current head header of the local chain.
+	CurrentHeader() *types.Header
+
+	// GetHeader retrieves a block header from the database by hash.
+	GetHeader(hash common.Hash) *types.Header
+
+	// GetHeaderByNumber retrieves a canonical block header from the database by number.
+	GetHeaderByNumber(number uint64) *types.Header
+}
+
+// Engine is a consensus engine used in place of proof-of-work, e.g. clique
+// proof-of-authority. Engines are handed to the block chain and the miner as a
+// pow.PoW; wherever the proof-of-work implements Engine, its methods replace
+// the difficulty, proof-of-work, uncle and reward rules of ethash chains.
+//
+// VerifyHeader checks whether a header conforms to the consensus rules of the
+// engine. Parents holds the ancestors of the header which may not be stored in
+// the chain yet, oldest first. The block number and gas limit are validated
+// by the caller.
+//
+// VerifyUncles verifies that the uncles of the block conform to the rules of
+// the engine.
+//
+// Prepare initializes the consensus fields of a block header to be sealed.
+//
+// Finalize applies the post-transaction state modifications (e.g. rewards) of
+// the block.
+//
+// Seal generates a sealed version of the block, returning a nil block if stop
+// was closed before sealing finished.
+type Engine interface {
+	pow.PoW
+	VerifyHeader(chain ChainReader, header *types.Header, parents []*types.Header) error
+	VerifyUncles(chain ChainReader, block *types.Block) error
+	Prepare(chain ChainReader, header *types.Header) error
+	Finalize(chain ChainReader, header *types.Header, state *state.StateDB, uncles []*types.Header)
+	Seal(chain ChainReader, block *types.Block, stop <-chan struct{}) (*types.Block, error)
+}
+
 // Processor is an interface for processing blocks using a given initial state.
 //
 // Process takes the block to be processed and the statedb upon which the
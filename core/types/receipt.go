@@ -53,6 +53,7 @@ type Receipt struct {
 	ContractAddress common.Address
 	GasUsed         *big.Int
 	Status          ReceiptStatus
+	Type            uint8
 }
 
 // storedReceiptRLP is the storage encoding of a receipt.
@@ -86,12 +87,56 @@ func NewReceipt(root []byte, cumulativeGasUsed *big.Int) *Receipt {
 // EncodeRLP implements rlp.Encoder, and flattens the consensus fields of a receipt
 // into an RLP stream.
 func (r *Receipt) EncodeRLP(w io.Writer) error {
-	return rlp.Encode(w, []interface{}{r.statusEncoding(), r.CumulativeGasUsed, r.Bloom, r.Logs})
+	if r.Type == LegacyTxType {
+		return rlp.Encode(w, r.consensusFields())
+	}
+	enc, err := r.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	return rlp.Encode(w, enc)
+}
+
+func (r *Receipt) consensusFields() []interface{} {
+	return []interface{}{r.statusEncoding(), r.CumulativeGasUsed, r.Bloom, r.Logs}
+}
+
+// MarshalBinary returns the consensus encoding of the receipt: the RLP list for
+// legacy receipts and type || rlp(fields) for typed ones.
+func (r *Receipt) MarshalBinary() ([]byte, error) {
+	payload, err := rlp.EncodeToBytes(r.consensusFields())
+	if err != nil || r.Type == LegacyTxType {
+		return payload, err
+	}
+	return append([]byte{r.Type}, payload...), nil
 }
 
 // DecodeRLP implements rlp.Decoder, and loads the consensus fields of a receipt
 // from an RLP stream.
 func (r *Receipt) DecodeRLP(s *rlp.Stream) error {
+	kind, _, err := s.Kind()
+	if err != nil {
+		return err
+	}
+	if kind == rlp.List {
+		r.Type = LegacyTxType
+		return r.decodeConsensusFields(s)
+	}
+	b, err := s.Bytes()
+	if err != nil {
+		return err
+	}
+	if len(b) == 0 {
+		return errEmptyTypedTx
+	}
+	if b[0] != AccessListTxType {
+		return ErrTxTypeNotSupported
+	}
+	r.Type = b[0]
+	return r.decodeConsensusFields(rlp.NewStream(bytes.NewReader(b[1:]), uint64(len(b)-1)))
+}
+
+func (r *Receipt) decodeConsensusFields(s *rlp.Stream) error {
 	var receipt struct {
 		PostStateOrStatus []byte
 		CumulativeGasUsed *big.Int
@@ -168,16 +213,40 @@ func (r *ReceiptForStorage) EncodeRLP(w io.Writer) error {
 		ContractAddress:   r.ContractAddress,
 		GasUsed:           r.GasUsed,
 	}
-	return rlp.Encode(w, receiptToStore)
+	if r.Type == LegacyTxType {
+		return rlp.Encode(w, receiptToStore)
+	}
+	// Typed receipts are stored as an RLP string holding type || rlp(receipt).
+	payload, err := rlp.EncodeToBytes(receiptToStore)
+	if err != nil {
+		return err
+	}
+	return rlp.Encode(w, append([]byte{r.Type}, payload...))
 }
 
 // DecodeRLP implements rlp.Decoder, and loads both consensus and implementation
 // fields of a receipt from an RLP stream.
 func (r *ReceiptForStorage) DecodeRLP(s *rlp.Stream) error {
-	raw, err := s.Raw()
+	kind, _, err := s.Kind()
 	if err != nil {
 		return err
 	}
+	var raw []byte
+	if kind == rlp.List {
+		if raw, err = s.Raw(); err != nil {
+			return err
+		}
+		r.Type = LegacyTxType
+	} else {
+		if raw, err = s.Bytes(); err != nil {
+			return err
+		}
+		if len(raw) == 0 {
+			return errEmptyTypedTx
+		}
+		r.Type, raw = raw[0], raw[1:]
+		return decodeStoredReceiptRLP(r, raw)
+	}
 
 	// Try decoding the receipt without Status first
 	if err := decodeStoredReceiptRLP(r, raw); err == nil {
@@ -243,7 +312,7 @@ func (r Receipts) Len() int { return len(r) }
 
 // GetRlp returns the RLP encoding of one receipt from the list.
 func (r Receipts) GetRlp(i int) []byte {
-	bytes, err := rlp.EncodeToBytes(r[i])
+	bytes, err := r[i].MarshalBinary()
 	if err != nil {
 		panic(err)
 	}
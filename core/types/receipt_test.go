@@ -0,0 +1,82 @@
+// Copyright 2015 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package types
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/eth-classic/go-ethereum/common"
+	"github.com/eth-classic/go-ethereum/rlp"
+)
+
+func TestTypedReceiptEncoding(t *testing.T) {
+	receipt := NewReceipt(nil, big.NewInt(21000))
+	receipt.Status = TxSuccess
+	receipt.Type = AccessListTxType
+	receipt.TxHash = common.Hash{1}
+	receipt.GasUsed = big.NewInt(21000)
+
+	// The consensus encoding is prefixed with the transaction type.
+	bin, err := receipt.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bin[0] != AccessListTxType {
+		t.Fatalf("missing type prefix: %x", bin)
+	}
+	if !bytes.Equal(Receipts{receipt}.GetRlp(0), bin) {
+		t.Errorf("derivable encoding mismatch")
+	}
+
+	enc, err := rlp.EncodeToBytes(receipt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dec := new(Receipt)
+	if err := rlp.DecodeBytes(enc, dec); err != nil {
+		t.Fatal(err)
+	}
+	if dec.Type != AccessListTxType || dec.Status != TxSuccess || dec.CumulativeGasUsed.Cmp(receipt.CumulativeGasUsed) != 0 {
+		t.Errorf("consensus decoding mismatch: %v", dec)
+	}
+
+	// Storage encoding must preserve the type and implementation fields.
+	enc, err = rlp.EncodeToBytes((*ReceiptForStorage)(receipt))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored := new(ReceiptForStorage)
+	if err := rlp.DecodeBytes(enc, stored); err != nil {
+		t.Fatal(err)
+	}
+	if stored.Type != AccessListTxType || stored.TxHash != receipt.TxHash || stored.GasUsed.Cmp(receipt.GasUsed) != 0 {
+		t.Errorf("storage decoding mismatch: %v", (*Receipt)(stored))
+	}
+
+	// Legacy receipts keep their plain list encoding.
+	receipt.Type = LegacyTxType
+	enc, _ = rlp.EncodeToBytes((*ReceiptForStorage)(receipt))
+	stored = new(ReceiptForStorage)
+	if err := rlp.DecodeBytes(enc, stored); err != nil {
+		t.Fatal(err)
+	}
+	if stored.Type != LegacyTxType || stored.TxHash != receipt.TxHash {
+		t.Errorf("legacy storage decoding mismatch: %v", (*Receipt)(stored))
+	}
+}
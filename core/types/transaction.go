@@ -30,11 +30,27 @@ import (
 	"github.com/eth-classic/go-ethereum/rlp"
 )
 
-var ErrInvalidSig = errors.New("invalid v, r, s values")
+var (
+	ErrInvalidSig         = errors.New("invalid v, r, s values")
+	ErrTxTypeNotSupported = errors.New("transaction type not supported")
+	errEmptyTypedTx       = errors.New("empty typed transaction bytes")
+)
+
+// Transaction types, as defined by EIP-2718.
+const (
+	LegacyTxType     = 0x00
+	AccessListTxType = 0x01
+)
 
 type Transaction struct {
 	signer Signer
+	typ    byte
 	data   txdata
+
+	// Fields of typed (EIP-2718) transactions only
+	chainId    *big.Int
+	accessList AccessList
+
 	// caches
 	hash atomic.Value
 	size atomic.Value
@@ -50,6 +66,19 @@ type txdata struct {
 	V, R, S         *big.Int // signature
 }
 
+// accessListTxdata is the RLP payload of an EIP-2930 access list transaction.
+// Its V value is the signature's y-parity (0 or 1) rather than 27/28.
+type accessListTxdata struct {
+	ChainID         *big.Int
+	AccountNonce    uint64
+	Price, GasLimit *big.Int
+	Recipient       *common.Address `rlp:"nil"` // nil means contract creation
+	Amount          *big.Int
+	Payload         []byte
+	AccessList      AccessList
+	V, R, S         *big.Int // signature
+}
+
 func NewContractCreation(nonce uint64, amount, gasLimit, gasPrice *big.Int, data []byte) *Transaction {
 	if len(data) > 0 {
 		data = common.CopyBytes(data)
@@ -98,22 +127,151 @@ func NewTransaction(nonce uint64, to common.Address, amount, gasLimit, gasPrice
 	return &Transaction{signer: BasicSigner{}, data: d}
 }
 
+// NewAccessListTransaction creates an unsigned EIP-2930 transaction. A nil
+// recipient means contract creation.
+func NewAccessListTransaction(chainId *big.Int, nonce uint64, to *common.Address, amount, gasLimit, gasPrice *big.Int, data []byte, accessList AccessList) *Transaction {
+	var tx *Transaction
+	if to == nil {
+		tx = NewContractCreation(nonce, amount, gasLimit, gasPrice, data)
+	} else {
+		tx = NewTransaction(nonce, *to, amount, gasLimit, gasPrice, data)
+	}
+	tx.typ = AccessListTxType
+	tx.chainId = new(big.Int).Set(chainId)
+	tx.accessList = accessList
+	tx.signer = NewAccessListSigner(chainId)
+	return tx
+}
+
 func (tx *Transaction) SetSigner(s Signer) {
 	tx.signer = s
 }
 
+// Type returns the EIP-2718 transaction type.
+func (tx *Transaction) Type() uint8 {
+	return tx.typ
+}
+
+// AccessList returns the EIP-2930 access list of the transaction, nil for legacy transactions.
+func (tx *Transaction) AccessList() AccessList {
+	return tx.accessList
+}
+
 // ChainId returns which chain id this transaction was signed for (if at all)
 func (tx *Transaction) ChainId() *big.Int {
+	if tx.typ != LegacyTxType {
+		return new(big.Int).Set(tx.chainId)
+	}
 	return deriveChainId(tx.data.V)
 }
 
 // Protected returns whether the transaction is protected from replay protection
 func (tx *Transaction) Protected() bool {
+	if tx.typ != LegacyTxType {
+		return true
+	}
 	return isProtectedV(tx.data.V)
 }
 
+// shallowCopy returns a copy of tx sharing the signer and payload values but
+// none of the caches.
+func (tx *Transaction) shallowCopy() *Transaction {
+	return &Transaction{
+		signer:     tx.signer,
+		typ:        tx.typ,
+		data:       tx.data,
+		chainId:    tx.chainId,
+		accessList: tx.accessList,
+	}
+}
+
+// EncodeRLP implements rlp.Encoder. Legacy transactions are encoded as an RLP
+// list, typed transactions as an RLP string holding the EIP-2718 envelope.
 func (tx *Transaction) EncodeRLP(w io.Writer) error {
-	return rlp.Encode(w, &tx.data)
+	if tx.typ == LegacyTxType {
+		return rlp.Encode(w, &tx.data)
+	}
+	enc, err := tx.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	return rlp.Encode(w, enc)
+}
+
+// MarshalBinary returns the canonical encoding of the transaction: the RLP
+// list for legacy transactions and type || rlp(payload) for typed ones.
+func (tx *Transaction) MarshalBinary() ([]byte, error) {
+	if tx.typ == LegacyTxType {
+		return rlp.EncodeToBytes(&tx.data)
+	}
+	payload, err := rlp.EncodeToBytes(tx.accessListData())
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte{tx.typ}, payload...), nil
+}
+
+// accessListData returns the RLP payload of a typed transaction.
+func (tx *Transaction) accessListData() *accessListTxdata {
+	return &accessListTxdata{
+		ChainID:      tx.chainId,
+		AccountNonce: tx.data.AccountNonce,
+		Price:        tx.data.Price,
+		GasLimit:     tx.data.GasLimit,
+		Recipient:    tx.data.Recipient,
+		Amount:       tx.data.Amount,
+		Payload:      tx.data.Payload,
+		AccessList:   tx.accessList,
+		V:            tx.data.V,
+		R:            tx.data.R,
+		S:            tx.data.S,
+	}
+}
+
+// UnmarshalBinary decodes the canonical encoding of a transaction, as
+// produced by MarshalBinary.
+func (tx *Transaction) UnmarshalBinary(b []byte) error {
+	if len(b) > 0 && b[0] > 0x7f {
+		// It's a legacy transaction.
+		return rlp.DecodeBytes(b, tx)
+	}
+	if err := tx.decodeTyped(b); err != nil {
+		return err
+	}
+	tx.size.Store(common.StorageSize(len(b)))
+	return nil
+}
+
+// decodeTyped decodes an EIP-2718 envelope.
+func (tx *Transaction) decodeTyped(b []byte) error {
+	if len(b) == 0 {
+		return errEmptyTypedTx
+	}
+	switch b[0] {
+	case AccessListTxType:
+		var d accessListTxdata
+		if err := rlp.DecodeBytes(b[1:], &d); err != nil {
+			return err
+		}
+		tx.typ = AccessListTxType
+		tx.chainId = d.ChainID
+		tx.accessList = d.AccessList
+		tx.data = txdata{
+			AccountNonce: d.AccountNonce,
+			Price:        d.Price,
+			GasLimit:     d.GasLimit,
+			Recipient:    d.Recipient,
+			Amount:       d.Amount,
+			Payload:      d.Payload,
+			V:            d.V,
+			R:            d.R,
+			S:            d.S,
+		}
+		tx.signer = NewAccessListSigner(d.ChainID)
+		return nil
+	default:
+		return ErrTxTypeNotSupported
+	}
 }
 
 // DeriveSigner makes a *best* guess about which signer to use.
@@ -126,8 +284,23 @@ func deriveSigner(V *big.Int) Signer {
 }
 
 func (tx *Transaction) DecodeRLP(s *rlp.Stream) error {
-	_, size, _ := s.Kind()
-	err := s.Decode(&tx.data)
+	kind, size, err := s.Kind()
+	if err != nil {
+		return err
+	}
+	if kind == rlp.String {
+		// It's an EIP-2718 typed transaction envelope.
+		b, err := s.Bytes()
+		if err != nil {
+			return err
+		}
+		if err := tx.decodeTyped(b); err != nil {
+			return err
+		}
+		tx.size.Store(common.StorageSize(len(b)))
+		return nil
+	}
+	err = s.Decode(&tx.data)
 	if err == nil {
 		tx.size.Store(common.StorageSize(rlp.ListSize(size)))
 	}
@@ -154,13 +327,18 @@ func (tx *Transaction) To() *common.Address {
 	}
 }
 
-// Hash hashes the RLP encoding of tx.
-// It uniquely identifies the transaction.
+// Hash hashes the RLP encoding of tx, or the EIP-2718 envelope of typed
+// transactions. It uniquely identifies the transaction.
 func (tx *Transaction) Hash() common.Hash {
 	if hash := tx.hash.Load(); hash != nil {
 		return hash.(common.Hash)
 	}
-	v := rlpHash(tx)
+	var v common.Hash
+	if tx.typ == LegacyTxType {
+		v = rlpHash(tx)
+	} else {
+		v = prefixedRlpHash(tx.typ, tx.accessListData())
+	}
 	tx.hash.Store(v)
 	return v
 }
@@ -175,8 +353,15 @@ func (tx *Transaction) Size() common.StorageSize {
 	if size := tx.size.Load(); size != nil {
 		return size.(common.StorageSize)
 	}
+	// Typed transactions are measured by their envelope, without the RLP string
+	// header wrapping them in blocks.
 	c := writeCounter(0)
-	rlp.Encode(&c, &tx.data)
+	if tx.typ == LegacyTxType {
+		rlp.Encode(&c, &tx.data)
+	} else {
+		c = 1
+		rlp.Encode(&c, tx.accessListData())
+	}
 	tx.size.Store(common.StorageSize(c))
 	return common.StorageSize(c)
 }
@@ -226,9 +411,10 @@ func (tx *Transaction) String() string {
 	} else {
 		to = fmt.Sprintf("%x", tx.data.Recipient[:])
 	}
-	enc, _ := rlp.EncodeToBytes(&tx.data)
+	enc, _ := tx.MarshalBinary()
 	return fmt.Sprintf(`
 	TX(%x)
+	Type:     %d
 	Contract: %v
 	From:     %s
 	To:       %s
@@ -243,6 +429,7 @@ func (tx *Transaction) String() string {
 	Hex:      %x
 `,
 		tx.Hash(),
+		tx.typ,
 		len(tx.data.Recipient.Bytes()) == 0,
 		from,
 		to,
@@ -267,9 +454,10 @@ func (s Transactions) Len() int { return len(s) }
 // Swap swaps the i'th and the j'th element in s
 func (s Transactions) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
 
-// GetRlp implements Rlpable and returns the i'th element of s in rlp
+// GetRlp implements Rlpable and returns the i'th element of s in rlp.
+// Typed transactions are returned as their EIP-2718 envelope.
 func (s Transactions) GetRlp(i int) []byte {
-	enc, _ := rlp.EncodeToBytes(s[i])
+	enc, _ := s[i].MarshalBinary()
 	return enc
 }
 
@@ -39,6 +39,9 @@ func isProtectedV(V *big.Int) bool {
 
 // normaliseV returns the Ethereum version of the V parameter
 func normaliseV(s Signer, v *big.Int) byte {
+	if s, ok := s.(AccessListSigner); ok {
+		return normaliseV(s.ChainIdSigner, v)
+	}
 	if s, ok := s.(ChainIdSigner); ok {
 		stdV := v.BitLen() <= 8 && (v.Uint64() == 27 || v.Uint64() == 28)
 		if s.chainId.BitLen() > 0 && !stdV {
@@ -101,6 +104,10 @@ func Sender(signer Signer, tx *Transaction) (common.Address, error) {
 
 // SignatureValues returns the ECDSA signature values contained in the transaction.
 func SignatureValues(signer Signer, tx *Transaction) (v byte, r *big.Int, s *big.Int) {
+	if tx.typ != LegacyTxType {
+		// Typed transactions carry the y-parity (0 or 1) instead of V.
+		return byte(tx.data.V.Uint64()), new(big.Int).Set(tx.data.R), new(big.Int).Set(tx.data.S)
+	}
 	return normaliseV(signer, tx.data.V), new(big.Int).Set(tx.data.R), new(big.Int).Set(tx.data.S)
 }
 
@@ -154,6 +161,9 @@ func (s ChainIdSigner) SignECDSA(tx *Transaction, prv *ecdsa.PrivateKey) (*Trans
 }
 
 func (s ChainIdSigner) PublicKey(tx *Transaction) ([]byte, error) {
+	if tx.typ != LegacyTxType {
+		return nil, ErrTxTypeNotSupported
+	}
 	// if the transaction is not protected fall back to homestead signer
 	if !tx.Protected() {
 		return (BasicSigner{}).PublicKey(tx)
@@ -194,7 +204,7 @@ func (s ChainIdSigner) WithSignature(tx *Transaction, sig []byte) (*Transaction,
 		panic(fmt.Sprintf("wrong size for snature: got %d, want 65", len(sig)))
 	}
 
-	cpy := &Transaction{signer: tx.signer, data: tx.data}
+	cpy := tx.shallowCopy()
 	cpy.data.R = new(big.Int).SetBytes(sig[:32])
 	cpy.data.S = new(big.Int).SetBytes(sig[32:64])
 	cpy.data.V = new(big.Int).SetBytes([]byte{sig[64]})
@@ -228,6 +238,110 @@ func (s ChainIdSigner) SigECDSA(tx *Transaction, prv *ecdsa.PrivateKey) (*Transa
 	return s.WithSignature(tx, sig)
 }
 
+// AccessListSigner implements Signer for EIP-2930 access list transactions,
+// falling back to ChainIdSigner for legacy transactions.
+type AccessListSigner struct {
+	ChainIdSigner
+}
+
+func NewAccessListSigner(chainId *big.Int) AccessListSigner {
+	return AccessListSigner{NewChainIdSigner(chainId)}
+}
+
+func (s AccessListSigner) Equal(s2 Signer) bool {
+	other, ok := s2.(AccessListSigner)
+	if !ok {
+		return false
+	}
+	return s.ChainIdSigner.Equal(other.ChainIdSigner)
+}
+
+func (s AccessListSigner) SignECDSA(tx *Transaction, prv *ecdsa.PrivateKey) (*Transaction, error) {
+	h := s.Hash(tx)
+	sig, err := crypto.Sign(h[:], prv)
+	if err != nil {
+		return nil, err
+	}
+	return s.WithSignature(tx, sig)
+}
+
+func (s AccessListSigner) PublicKey(tx *Transaction) ([]byte, error) {
+	switch tx.typ {
+	case LegacyTxType:
+		return s.ChainIdSigner.PublicKey(tx)
+	case AccessListTxType:
+	default:
+		return nil, ErrTxTypeNotSupported
+	}
+	if tx.chainId == nil || s.chainId == nil || tx.chainId.Cmp(s.chainId) != 0 {
+		return nil, ErrInvalidChainId
+	}
+	// The V value of typed transactions is the y-parity of the signature
+	if tx.data.V.BitLen() > 1 {
+		return nil, ErrInvalidSig
+	}
+	V := byte(tx.data.V.Uint64()) + 27
+	if !crypto.ValidateSignatureValues(V, tx.data.R, tx.data.S, true) {
+		return nil, ErrInvalidSig
+	}
+
+	// encode the signature in uncompressed format
+	R, S := tx.data.R.Bytes(), tx.data.S.Bytes()
+	sig := make([]byte, 65)
+	copy(sig[32-len(R):32], R)
+	copy(sig[64-len(S):64], S)
+	sig[64] = V - 27
+
+	// recover the public key from the signature
+	hash := s.Hash(tx)
+	pub, err := crypto.Ecrecover(hash[:], sig)
+	if err != nil {
+		return nil, err
+	}
+	if len(pub) == 0 || pub[0] != 4 {
+		return nil, errors.New("invalid public key")
+	}
+	return pub, nil
+}
+
+// WithSignature returns a new transaction with the given signature. For typed
+// transactions the last signature byte is the raw y-parity (0 or 1), which is
+// stored as V unchanged. Legacy transactions are handled by the ChainIdSigner.
+func (s AccessListSigner) WithSignature(tx *Transaction, sig []byte) (*Transaction, error) {
+	if tx.typ == LegacyTxType {
+		return s.ChainIdSigner.WithSignature(tx, sig)
+	}
+	if len(sig) != 65 {
+		panic(fmt.Sprintf("wrong size for signature: got %d, want 65", len(sig)))
+	}
+	if tx.chainId.Sign() != 0 && tx.chainId.Cmp(s.chainId) != 0 {
+		return nil, ErrInvalidChainId
+	}
+	cpy := tx.shallowCopy()
+	cpy.data.R = new(big.Int).SetBytes(sig[:32])
+	cpy.data.S = new(big.Int).SetBytes(sig[32:64])
+	cpy.data.V = new(big.Int).SetBytes([]byte{sig[64]})
+	return cpy, nil
+}
+
+// Hash returns the hash to be signed by the sender.
+// It does not uniquely identify the transaction.
+func (s AccessListSigner) Hash(tx *Transaction) common.Hash {
+	if tx.typ == LegacyTxType {
+		return s.ChainIdSigner.Hash(tx)
+	}
+	return prefixedRlpHash(tx.typ, []interface{}{
+		s.chainId,
+		tx.data.AccountNonce,
+		tx.data.Price,
+		tx.data.GasLimit,
+		tx.data.Recipient,
+		tx.data.Amount,
+		tx.data.Payload,
+		tx.accessList,
+	})
+}
+
 type BasicSigner struct{}
 
 func (s BasicSigner) Equal(s2 Signer) bool {
@@ -241,7 +355,7 @@ func (fs BasicSigner) WithSignature(tx *Transaction, sig []byte) (*Transaction,
 	if len(sig) != 65 {
 		panic(fmt.Sprintf("wrong size for snature: got %d, want 65", len(sig)))
 	}
-	cpy := &Transaction{signer: tx.signer, data: tx.data}
+	cpy := tx.shallowCopy()
 	cpy.data.R = new(big.Int).SetBytes(sig[:32])
 	cpy.data.S = new(big.Int).SetBytes(sig[32:64])
 	cpy.data.V = new(big.Int).SetBytes([]byte{sig[64] + 27})
@@ -271,6 +385,9 @@ func (fs BasicSigner) Hash(tx *Transaction) common.Hash {
 }
 
 func (fs BasicSigner) PublicKey(tx *Transaction) ([]byte, error) {
+	if tx.typ != LegacyTxType {
+		return nil, ErrTxTypeNotSupported
+	}
 	if tx.data.V.BitLen() > 8 {
 		return nil, ErrInvalidSig
 	}
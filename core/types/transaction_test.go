@@ -70,6 +70,121 @@ func TestTransactionEncode(t *testing.T) {
 	}
 }
 
+var (
+	testAccessListAddr = common.HexToAddress("b94f5374fce5edbc8e2a8697c15331677e6ebf0b")
+
+	emptyAccessListTx = NewAccessListTransaction(
+		big.NewInt(1),
+		3,
+		&testAccessListAddr,
+		big.NewInt(10),
+		big.NewInt(25000),
+		big.NewInt(1),
+		common.FromHex("5544"),
+		nil,
+	)
+
+	signedAccessListTx, _ = emptyAccessListTx.WithSignature(
+		common.Hex2Bytes("c9519f4f2b30335884581971573fadf60c6204f59a911df35ee8a540456b266032f1e8e2c5dd761f9e4f88f41c8310aeaba26a8bfcdacfedfa12ec3862d3752101"),
+	)
+)
+
+func TestAccessListTransactionSigHash(t *testing.T) {
+	s := NewAccessListSigner(big.NewInt(1))
+	want := common.HexToHash("49b486f0ec0a60dfbbca2d30cb07c9e8ffb2a2ff41f29a1ab6737475f6ff69f3")
+	if h := s.Hash(emptyAccessListTx); h != want {
+		t.Errorf("empty access list tx hash mismatch, got %x", h)
+	}
+	if h := s.Hash(signedAccessListTx); h != want {
+		t.Errorf("signed access list tx hash mismatch, got %x", h)
+	}
+}
+
+func TestAccessListTransactionEncode(t *testing.T) {
+	have, err := signedAccessListTx.MarshalBinary()
+	if err != nil {
+		t.Fatalf("encode error: %v", err)
+	}
+	want := common.FromHex("01f8630103018261a894b94f5374fce5edbc8e2a8697c15331677e6ebf0b0a825544c001a0c9519f4f2b30335884581971573fadf60c6204f59a911df35ee8a540456b2660a032f1e8e2c5dd761f9e4f88f41c8310aeaba26a8bfcdacfedfa12ec3862d37521")
+	if !bytes.Equal(have, want) {
+		t.Errorf("encoded binary mismatch, got %x", have)
+	}
+
+	// Within RLP streams the typed encoding is wrapped in a string.
+	have, err = rlp.EncodeToBytes(signedAccessListTx)
+	if err != nil {
+		t.Fatalf("encode error: %v", err)
+	}
+	want = append(common.FromHex("b866"), want...)
+	if !bytes.Equal(have, want) {
+		t.Errorf("encoded RLP mismatch, got %x", have)
+	}
+}
+
+func TestAccessListTransactionDecode(t *testing.T) {
+	key, addr := defaultTestKey()
+	to := common.HexToAddress("095e7baea6a6c7c4c2dfeb977efac326af552d87")
+	list := AccessList{{Address: to, StorageKeys: []common.Hash{{1}, {2}}}}
+	tx, err := NewAccessListTransaction(big.NewInt(61), 1, &to, big.NewInt(1), big.NewInt(50000), big.NewInt(1), nil, list).SignECDSA(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	enc, err := tx.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Decode from both the binary and the RLP-wrapped encodings.
+	binTx := new(Transaction)
+	if err := binTx.UnmarshalBinary(enc); err != nil {
+		t.Fatal(err)
+	}
+	rlpEnc, _ := rlp.EncodeToBytes(tx)
+	rlpTx, err := decodeTx(rlpEnc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size := tx.Size(); int(size) != len(enc) {
+		t.Errorf("size mismatch: have %v, want %d", size, len(enc))
+	}
+	for _, dec := range []*Transaction{binTx, rlpTx} {
+		if dec.Hash() != tx.Hash() {
+			t.Errorf("hash mismatch: have %x, want %x", dec.Hash(), tx.Hash())
+		}
+		if dec.Type() != AccessListTxType {
+			t.Errorf("type mismatch: have %d, want %d", dec.Type(), AccessListTxType)
+		}
+		if dec.ChainId().Cmp(big.NewInt(61)) != 0 {
+			t.Errorf("chain id mismatch: have %v, want 61", dec.ChainId())
+		}
+		if dec.AccessList().StorageKeys() != 2 {
+			t.Errorf("access list mismatch: have %v", dec.AccessList())
+		}
+		if size := dec.Size(); int(size) != len(enc) {
+			t.Errorf("size mismatch: have %v, want %d", size, len(enc))
+		}
+		if v, _, _ := dec.SignatureValues(); v > 1 {
+			t.Errorf("signature y-parity out of range: %d", v)
+		}
+		from, err := dec.From()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if from != addr {
+			t.Errorf("sender mismatch: have %x, want %x", from, addr)
+		}
+	}
+
+	// Legacy signers must refuse typed transactions.
+	if _, err := Sender(NewChainIdSigner(big.NewInt(61)), tx); err != ErrTxTypeNotSupported {
+		t.Errorf("expected %v, got %v", ErrTxTypeNotSupported, err)
+	}
+	// The access list signer must verify the chain id.
+	if _, err := Sender(NewAccessListSigner(big.NewInt(1)), tx); err != ErrInvalidChainId {
+		t.Errorf("expected %v, got %v", ErrInvalidChainId, err)
+	}
+}
+
 func decodeTx(data []byte) (*Transaction, error) {
 	var tx Transaction
 	return &tx, rlp.Decode(bytes.NewReader(data), &tx)
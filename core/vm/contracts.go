@@ -17,11 +17,13 @@
 package vm
 
 import (
+	"encoding/binary"
 	"errors"
 	"math/big"
 
 	"github.com/eth-classic/go-ethereum/common"
 	"github.com/eth-classic/go-ethereum/crypto"
+	"github.com/eth-classic/go-ethereum/crypto/blake2b"
 	"github.com/eth-classic/go-ethereum/crypto/bn256"
 	"github.com/eth-classic/go-ethereum/logger"
 	"github.com/eth-classic/go-ethereum/logger/glog"
@@ -82,6 +84,39 @@ var PrecompiledAtlantis = func() map[string]*PrecompiledAccount {
 	}
 	return precompiles
 }()
+var PrecompiledPhoenix = func() map[string]*PrecompiledAccount {
+	precompiles := make(map[string]*PrecompiledAccount)
+	for k, c := range PrecompiledAtlantis {
+		precompiles[k] = c
+	}
+	for k, c := range PrecompiledContractsPhoenix() {
+		precompiles[k] = c
+	}
+	return precompiles
+}()
+
+// Precompiles returns the set of precompiled contracts active at the given block.
+func Precompiles(ruleset RuleSet, num *big.Int) map[string]*PrecompiledAccount {
+	switch {
+	case ruleset.IsEIP152(num):
+		return PrecompiledPhoenix
+	case ruleset.IsAtlantis(num):
+		return PrecompiledAtlantis
+	default:
+		return PrecompiledPreAtlantis
+	}
+}
+
+// ActivePrecompiles returns the addresses of the precompiled contracts active
+// at the given block.
+func ActivePrecompiles(ruleset RuleSet, num *big.Int) []common.Address {
+	precompiles := Precompiles(ruleset, num)
+	addrs := make([]common.Address, 0, len(precompiles))
+	for k := range precompiles {
+		addrs = append(addrs, common.BytesToAddress([]byte(k)))
+	}
+	return addrs
+}
 
 // PrecompiledContractsPreAtlantis returns the default set of precompiled ethereum
 // contracts defined by the ethereum yellow paper pre-Atlantis.
@@ -202,6 +237,21 @@ func PrecompiledContractsAtlantis() map[string]*PrecompiledAccount {
 	}
 }
 
+// PrecompiledContractsPhoenix returns the set of precompiled contracts introduced in Phoenix
+func PrecompiledContractsPhoenix() map[string]*PrecompiledAccount {
+	return map[string]*PrecompiledAccount{
+		// blake2F
+		string(common.LeftPadBytes([]byte{9}, 20)): {func(in []byte) *big.Int {
+			// If the input is malformed, we can't calculate the gas, return 0 and let the
+			// actual call choke and fault.
+			if len(in) != blake2FInputLength {
+				return new(big.Int)
+			}
+			return new(big.Int).SetUint64(uint64(binary.BigEndian.Uint32(in[0:4])))
+		}, blake2F},
+	}
+}
+
 func sha256Func(in []byte) ([]byte, error) {
 	return crypto.Sha256(in), nil
 }
@@ -276,6 +326,56 @@ func bigModExp(in []byte) ([]byte, error) {
 	return common.LeftPadBytes(base.Exp(base, exp, mod).Bytes(), int(modLen.Int64())), nil
 }
 
+const (
+	blake2FInputLength        = 213
+	blake2FFinalBlockBytes    = byte(1)
+	blake2FNonFinalBlockBytes = byte(0)
+)
+
+var (
+	errBlake2FInvalidInputLength = errors.New("invalid input length")
+	errBlake2FInvalidFinalFlag   = errors.New("invalid final flag")
+)
+
+func blake2F(input []byte) ([]byte, error) {
+	// Make sure the input is valid (correct length and final flag)
+	if len(input) != blake2FInputLength {
+		return nil, errBlake2FInvalidInputLength
+	}
+	if input[212] != blake2FNonFinalBlockBytes && input[212] != blake2FFinalBlockBytes {
+		return nil, errBlake2FInvalidFinalFlag
+	}
+	// Parse the input into the Blake2b call parameters
+	var (
+		rounds = binary.BigEndian.Uint32(input[0:4])
+		final  = (input[212] == blake2FFinalBlockBytes)
+
+		h [8]uint64
+		m [16]uint64
+		t [2]uint64
+	)
+	for i := 0; i < 8; i++ {
+		offset := 4 + i*8
+		h[i] = binary.LittleEndian.Uint64(input[offset : offset+8])
+	}
+	for i := 0; i < 16; i++ {
+		offset := 68 + i*8
+		m[i] = binary.LittleEndian.Uint64(input[offset : offset+8])
+	}
+	t[0] = binary.LittleEndian.Uint64(input[196:204])
+	t[1] = binary.LittleEndian.Uint64(input[204:212])
+
+	// Execute the compression function, extract and return the result
+	blake2b.F(&h, m, t, final, rounds)
+
+	output := make([]byte, 64)
+	for i := 0; i < 8; i++ {
+		offset := i * 8
+		binary.LittleEndian.PutUint64(output[offset:offset+8], h[i])
+	}
+	return output, nil
+}
+
 var (
 	// true32Byte is returned if the bn256 pairing check succeeds.
 	true32Byte = []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1}
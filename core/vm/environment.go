@@ -27,6 +27,20 @@ import (
 type RuleSet interface {
 	IsHomestead(*big.Int) bool
 	IsAtlantis(*big.Int) bool
+	IsAgharta(*big.Int) bool
+	IsEIP1344(*big.Int) bool
+	IsEIP1884(*big.Int) bool
+	IsEIP2028(*big.Int) bool
+	IsEIP2200(*big.Int) bool
+	IsEIP152(*big.Int) bool
+	IsEIP2929(*big.Int) bool
+	IsEIP3529(*big.Int) bool
+	IsEIP3541(*big.Int) bool
+	IsEIP3651(*big.Int) bool
+	IsEIP3855(*big.Int) bool
+	IsEIP3860(*big.Int) bool
+	// GetChainID returns the chain id exposed by the CHAINID instruction.
+	GetChainID() *big.Int
 	// GasTable returns the gas prices for this phase, which is based on
 	// block number passed in.
 	GasTable(*big.Int) *GasTable
@@ -83,6 +97,8 @@ type Environment interface {
 	StaticCall(me ContractRef, addr common.Address, data []byte, gas, price *big.Int) ([]byte, error)
 	// Create a new contract
 	Create(me ContractRef, data []byte, gas, price, value *big.Int) ([]byte, common.Address, error)
+	// Create a new contract at an address derived from the given salt and init code
+	Create2(me ContractRef, data []byte, gas, price, salt, value *big.Int) ([]byte, common.Address, error)
 }
 
 // Vm is the basic interface for an implementation of the EVM.
@@ -110,8 +126,10 @@ type Database interface {
 	SetCode(common.Address, []byte)
 
 	AddRefund(*big.Int)
+	SubRefund(*big.Int)
 	GetRefund() *big.Int
 
+	GetCommittedState(common.Address, common.Hash) common.Hash
 	GetState(common.Address, common.Hash) common.Hash
 	SetState(common.Address, common.Hash, common.Hash)
 
@@ -122,6 +140,14 @@ type Database interface {
 	// Notably this should also return true for suicided accounts.
 	Exist(common.Address) bool
 	Empty(common.Address) bool
+
+	// PrepareAccessList resets the EIP-2929 access list and warms up the sender,
+	// destination and precompiles.
+	PrepareAccessList(sender common.Address, dst *common.Address, precompiles []common.Address)
+	AddressInAccessList(addr common.Address) bool
+	SlotInAccessList(addr common.Address, slot common.Hash) (addressOk bool, slotOk bool)
+	AddAddressToAccessList(addr common.Address)
+	AddSlotToAccessList(addr common.Address, slot common.Hash)
 }
 
 // Account represents a contract or basic ethereum account.
@@ -21,6 +21,7 @@ import (
 	"math/big"
 	"reflect"
 
+	"github.com/eth-classic/go-ethereum/common"
 	"github.com/eth-classic/go-ethereum/params"
 )
 
@@ -43,6 +44,7 @@ var (
 type GasTable struct {
 	ExtcodeSize *big.Int
 	ExtcodeCopy *big.Int
+	ExtcodeHash *big.Int
 	Balance     *big.Int
 	SLoad       *big.Int
 	Calls       *big.Int
@@ -136,6 +138,9 @@ var _baseCheck = map[OpCode]req{
 	XOR:            {2, GasFastestStep, 1},
 	NOT:            {1, GasFastestStep, 1},
 	BYTE:           {2, GasFastestStep, 1},
+	SHL:            {2, GasFastestStep, 1},
+	SHR:            {2, GasFastestStep, 1},
+	SAR:            {2, GasFastestStep, 1},
 	CALLDATALOAD:   {1, GasFastestStep, 1},
 	CALLDATACOPY:   {3, GasFastestStep, 1},
 	MLOAD:          {1, GasFastestStep, 1},
@@ -165,6 +170,8 @@ var _baseCheck = map[OpCode]req{
 	CALLDATASIZE:   {0, GasQuickStep, 1},
 	DIFFICULTY:     {0, GasQuickStep, 1},
 	GASLIMIT:       {0, GasQuickStep, 1},
+	CHAINID:        {0, GasQuickStep, 1},
+	SELFBALANCE:    {0, GasFastStep, 1},
 	POP:            {1, GasQuickStep, 0},
 	PC:             {0, GasQuickStep, 1},
 	MSIZE:          {0, GasQuickStep, 1},
@@ -174,10 +181,12 @@ var _baseCheck = map[OpCode]req{
 	BALANCE:        {1, new(big.Int), 1},
 	EXTCODESIZE:    {1, new(big.Int), 1},
 	EXTCODECOPY:    {4, new(big.Int), 0},
+	EXTCODEHASH:    {1, new(big.Int), 1},
 	SLOAD:          {1, big.NewInt(50), 1},
 	SSTORE:         {2, new(big.Int), 0},
 	SHA3:           {2, big.NewInt(30), 1},
 	CREATE:         {3, big.NewInt(32000), 1},
+	CREATE2:        {4, big.NewInt(32000), 1},
 	// Zero is calculated in the gasSwitch
 	CALL:           {7, new(big.Int), 1},
 	CALLCODE:       {7, new(big.Int), 1},
@@ -188,6 +197,161 @@ var _baseCheck = map[OpCode]req{
 	SUICIDE:        {1, new(big.Int), 0},
 	JUMPDEST:       {0, big.NewInt(1), 0},
 	RETURN:         {2, new(big.Int), 0},
+	PUSH0:          {0, GasQuickStep, 1},
 	PUSH1:          {0, GasFastestStep, 1},
 	DUP1:           {0, new(big.Int), 1},
 }
+
+var (
+	SstoreSentryGasEIP2200   = big.NewInt(2300)  // Minimum gas required to be present for an SSTORE call, not consumed
+	SstoreNoopGasEIP2200     = big.NewInt(800)   // Once per SSTORE operation if the value doesn't change.
+	SstoreDirtyGasEIP2200    = big.NewInt(800)   // Once per SSTORE operation if a dirty value is changed.
+	SstoreInitGasEIP2200     = big.NewInt(20000) // Once per SSTORE operation from clean zero to non-zero
+	SstoreInitRefundEIP2200  = big.NewInt(19200) // Once per SSTORE operation for resetting to the original zero value
+	SstoreCleanGasEIP2200    = big.NewInt(5000)  // Once per SSTORE operation from clean non-zero to something else
+	SstoreCleanRefundEIP2200 = big.NewInt(4200)  // Once per SSTORE operation for resetting to the original non-zero value
+	SstoreClearRefundEIP2200 = big.NewInt(15000) // Once per SSTORE operation for clearing an originally existing storage slot
+)
+
+// gasSStoreEIP2200 calculates the gas of an SSTORE using the net gas metering
+// rules of EIP-2200, adjusting the refund counter as a side effect. Only the
+// first write to a clean slot pays the full set or reset price; later writes
+// within the same transaction pay the dirty price and have their refunds
+// adjusted according to whether the slot was cleared, recreated or reset to
+// its original value. clearingRefund is the refund for clearing a slot, which
+// EIP-3529 reduces.
+func gasSStoreEIP2200(contract *Contract, statedb Database, key, value common.Hash, clearingRefund *big.Int) (*big.Int, error) {
+	if contract.Gas.Cmp(SstoreSentryGasEIP2200) <= 0 {
+		return nil, OutOfGasError
+	}
+	current := statedb.GetState(contract.Address(), key)
+	if current == value { // noop (1)
+		return SstoreNoopGasEIP2200, nil
+	}
+	original := statedb.GetCommittedState(contract.Address(), key)
+	if original == current {
+		if common.EmptyHash(original) { // create slot (2.1.1)
+			return SstoreInitGasEIP2200, nil
+		}
+		if common.EmptyHash(value) { // delete slot (2.1.2b)
+			statedb.AddRefund(clearingRefund)
+		}
+		return SstoreCleanGasEIP2200, nil // write existing slot (2.1.2)
+	}
+	if !common.EmptyHash(original) {
+		if common.EmptyHash(current) { // recreate slot (2.2.1.1)
+			statedb.SubRefund(clearingRefund)
+		} else if common.EmptyHash(value) { // delete slot (2.2.1.2)
+			statedb.AddRefund(clearingRefund)
+		}
+	}
+	if original == value {
+		if common.EmptyHash(original) { // reset to original inexistent slot (2.2.2.1)
+			statedb.AddRefund(SstoreInitRefundEIP2200)
+		} else { // reset to original existing slot (2.2.2.2)
+			statedb.AddRefund(SstoreCleanRefundEIP2200)
+		}
+	}
+	return SstoreDirtyGasEIP2200, nil // dirty update (2.2)
+}
+
+var (
+	ColdAccountAccessCostEIP2929 = big.NewInt(2600) // COLD_ACCOUNT_ACCESS_COST
+	ColdSloadCostEIP2929         = big.NewInt(2100) // COLD_SLOAD_COST
+	WarmStorageReadCostEIP2929   = big.NewInt(100)  // WARM_STORAGE_READ_COST
+
+	// The gas tables used alongside EIP-2929 charge the warm cost for account
+	// accesses, so a cold access is charged the difference on top.
+	coldAccountSurchargeEIP2929 = new(big.Int).Sub(ColdAccountAccessCostEIP2929, WarmStorageReadCostEIP2929)
+	coldSloadSurchargeEIP2929   = new(big.Int).Sub(ColdSloadCostEIP2929, WarmStorageReadCostEIP2929)
+
+	// InitCodeWordGasEIP3860 is the gas charged per word of init code (EIP-3860).
+	InitCodeWordGasEIP3860 = big.NewInt(2)
+
+	// SstoreClearsScheduleRefundEIP3529 is the refund for clearing a slot after
+	// EIP-3529: SSTORE_RESET_GAS - COLD_SLOAD_COST + ACCESS_LIST_STORAGE_KEY_COST.
+	SstoreClearsScheduleRefundEIP3529 = big.NewInt(4800)
+)
+
+// accountAccessGasEIP2929 warms up addr, returning the extra gas charged if it
+// was cold.
+func accountAccessGasEIP2929(statedb Database, addr common.Address) *big.Int {
+	if statedb.AddressInAccessList(addr) {
+		return new(big.Int)
+	}
+	statedb.AddAddressToAccessList(addr)
+	return coldAccountSurchargeEIP2929
+}
+
+// slotAccessGasEIP2929 warms up the storage slot, returning the extra gas
+// charged if it was cold.
+func slotAccessGasEIP2929(statedb Database, addr common.Address, slot common.Hash) *big.Int {
+	if _, slotOk := statedb.SlotInAccessList(addr, slot); slotOk {
+		return new(big.Int)
+	}
+	statedb.AddSlotToAccessList(addr, slot)
+	return coldSloadSurchargeEIP2929
+}
+
+// gasSStoreEIP2929 calculates the gas of an SSTORE using the EIP-2200 net gas
+// metering rules as amended by EIP-2929: a cold slot is charged COLD_SLOAD_COST
+// on top, and the SLOAD_GAS component of EIP-2200 becomes WARM_STORAGE_READ_COST.
+// The refund for clearing a slot is passed in since it is changed by EIP-3529.
+func gasSStoreEIP2929(contract *Contract, statedb Database, key, value common.Hash, clearingRefund *big.Int) (*big.Int, error) {
+	if contract.Gas.Cmp(SstoreSentryGasEIP2200) <= 0 {
+		return nil, OutOfGasError
+	}
+	var (
+		addr = contract.Address()
+		cost = new(big.Int)
+	)
+	if _, slotOk := statedb.SlotInAccessList(addr, key); !slotOk {
+		cost.Set(ColdSloadCostEIP2929)
+		statedb.AddSlotToAccessList(addr, key)
+	}
+	current := statedb.GetState(addr, key)
+	if current == value { // noop
+		return cost.Add(cost, WarmStorageReadCostEIP2929), nil
+	}
+	original := statedb.GetCommittedState(addr, key)
+	if original == current {
+		if common.EmptyHash(original) { // create slot
+			return cost.Add(cost, SstoreInitGasEIP2200), nil
+		}
+		if common.EmptyHash(value) { // delete slot
+			statedb.AddRefund(clearingRefund)
+		}
+		// write existing slot
+		return cost.Add(cost, new(big.Int).Sub(SstoreCleanGasEIP2200, ColdSloadCostEIP2929)), nil
+	}
+	if !common.EmptyHash(original) {
+		if common.EmptyHash(current) { // recreate slot
+			statedb.SubRefund(clearingRefund)
+		} else if common.EmptyHash(value) { // delete slot
+			statedb.AddRefund(clearingRefund)
+		}
+	}
+	if original == value {
+		if common.EmptyHash(original) { // reset to original inexistent slot
+			statedb.AddRefund(new(big.Int).Sub(SstoreInitGasEIP2200, WarmStorageReadCostEIP2929))
+		} else { // reset to original existing slot
+			refund := new(big.Int).Sub(SstoreCleanGasEIP2200, ColdSloadCostEIP2929)
+			statedb.AddRefund(refund.Sub(refund, WarmStorageReadCostEIP2929))
+		}
+	}
+	return cost.Add(cost, WarmStorageReadCostEIP2929), nil // dirty update
+}
+
+// MaxInitCodeSizeEIP3860 is the maximum size of contract creation code (EIP-3860).
+const MaxInitCodeSizeEIP3860 = 2 * 24576
+
+// initCodeGasEIP3860 rejects init code above the EIP-3860 size limit and adds
+// the per-word init code charge to gas.
+func initCodeGasEIP3860(size *big.Int, gas *big.Int) error {
+	if size.Cmp(big.NewInt(MaxInitCodeSizeEIP3860)) > 0 {
+		return ErrMaxInitCodeSize
+	}
+	words := toWordSize(size)
+	gas.Add(gas, words.Mul(words, InitCodeWordGasEIP3860))
+	return nil
+}
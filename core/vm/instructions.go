@@ -24,7 +24,10 @@ import (
 	"github.com/eth-classic/go-ethereum/crypto"
 )
 
-var callStipend = big.NewInt(2300) // Free gas given at beginning of call.
+var (
+	callStipend = big.NewInt(2300) // Free gas given at beginning of call.
+	big256      = big.NewInt(256)  // Shift amounts at or above this zero (or sign) fill the word.
+)
 
 type instrFn func(pc *uint64, env Environment, contract *Contract, memory *Memory, stack *stack) ([]byte, error)
 
@@ -250,6 +253,52 @@ func opByte(pc *uint64, env Environment, contract *Contract, memory *Memory, sta
 	}
 	return nil, nil
 }
+
+// opSHL implements Shift Left
+// The SHL instruction (shift left) pops 2 values from the stack, first arg1 and then arg2,
+// and pushes on the stack arg2 shifted to the left by arg1 number of bits.
+func opSHL(pc *uint64, env Environment, contract *Contract, memory *Memory, stack *stack) ([]byte, error) {
+	shift, value := stack.pop(), stack.pop()
+	if shift.Cmp(big256) >= 0 {
+		stack.push(new(big.Int))
+		return nil, nil
+	}
+	stack.push(U256(value.Lsh(value, uint(shift.Uint64()))))
+	return nil, nil
+}
+
+// opSHR implements Logical Shift Right
+// The SHR instruction (logical shift right) pops 2 values from the stack, first arg1 and then arg2,
+// and pushes on the stack arg2 shifted to the right by arg1 number of bits with zero fill.
+func opSHR(pc *uint64, env Environment, contract *Contract, memory *Memory, stack *stack) ([]byte, error) {
+	shift, value := stack.pop(), stack.pop()
+	if shift.Cmp(big256) >= 0 {
+		stack.push(new(big.Int))
+		return nil, nil
+	}
+	stack.push(value.Rsh(value, uint(shift.Uint64())))
+	return nil, nil
+}
+
+// opSAR implements Arithmetic Shift Right
+// The SAR instruction (arithmetic shift right) pops 2 values from the stack, first arg1 and then arg2,
+// and pushes on the stack arg2 shifted to the right by arg1 number of bits with sign extension.
+func opSAR(pc *uint64, env Environment, contract *Contract, memory *Memory, stack *stack) ([]byte, error) {
+	shift, value := stack.pop(), S256(stack.pop())
+	if shift.Cmp(big256) >= 0 {
+		if value.Sign() >= 0 {
+			stack.push(new(big.Int))
+		} else {
+			stack.push(U256(big.NewInt(-1)))
+		}
+		return nil, nil
+	}
+	// big.Int right shifts of negative values round towards negative infinity,
+	// which is exactly the sign extension required here.
+	stack.push(U256(value.Rsh(value, uint(shift.Uint64()))))
+	return nil, nil
+}
+
 func opAddmod(pc *uint64, env Environment, contract *Contract, memory *Memory, stack *stack) ([]byte, error) {
 	x, y, z := stack.pop(), stack.pop(), stack.pop()
 	if z.Sign() > 0 {
@@ -367,6 +416,19 @@ func opExtCodeCopy(pc *uint64, env Environment, contract *Contract, memory *Memo
 	return nil, nil
 }
 
+// opExtCodeHash returns the code hash of a specified account (EIP-1052).
+// Non-existent and empty accounts (EIP-161) yield zero, accounts without code
+// yield the hash of empty code, and contracts yield the hash of their code.
+func opExtCodeHash(pc *uint64, env Environment, contract *Contract, memory *Memory, stack *stack) ([]byte, error) {
+	addr := common.BigToAddress(stack.pop())
+	if env.Db().Empty(addr) {
+		stack.push(new(big.Int))
+	} else {
+		stack.push(env.Db().GetCodeHash(addr).Big())
+	}
+	return nil, nil
+}
+
 func opGasprice(pc *uint64, env Environment, contract *Contract, memory *Memory, stack *stack) ([]byte, error) {
 	stack.push(new(big.Int).Set(contract.Price))
 	return nil, nil
@@ -409,6 +471,25 @@ func opGasLimit(pc *uint64, env Environment, contract *Contract, memory *Memory,
 	return nil, nil
 }
 
+// opChainID implements CHAINID (EIP-1344), pushing the configured chain id.
+func opChainID(pc *uint64, env Environment, contract *Contract, memory *Memory, stack *stack) ([]byte, error) {
+	stack.push(new(big.Int).Set(env.RuleSet().GetChainID()))
+	return nil, nil
+}
+
+// opSelfBalance implements SELFBALANCE (EIP-1884), a cheaper BALANCE of the
+// executing contract.
+func opSelfBalance(pc *uint64, env Environment, contract *Contract, memory *Memory, stack *stack) ([]byte, error) {
+	stack.push(new(big.Int).Set(env.Db().GetBalance(contract.Address())))
+	return nil, nil
+}
+
+// opPush0 implements PUSH0 (EIP-3855), pushing the constant zero.
+func opPush0(pc *uint64, env Environment, contract *Contract, memory *Memory, stack *stack) ([]byte, error) {
+	stack.push(new(big.Int))
+	return nil, nil
+}
+
 func opPop(pc *uint64, env Environment, contract *Contract, memory *Memory, stack *stack) ([]byte, error) {
 	stack.pop()
 	return nil, nil
@@ -523,6 +604,33 @@ func opCreate(pc *uint64, env Environment, contract *Contract, memory *Memory, s
 	return nil, nil
 }
 
+func opCreate2(pc *uint64, env Environment, contract *Contract, memory *Memory, stack *stack) ([]byte, error) {
+	var (
+		value        = stack.pop()
+		offset, size = stack.pop(), stack.pop()
+		salt         = stack.pop()
+		input        = memory.Get(offset.Int64(), size.Int64())
+		gas          = new(big.Int).Set(contract.Gas)
+	)
+	if env.RuleSet().GasTable(env.BlockNumber()).CreateBySuicide != nil {
+		gas.Div(gas, n64)
+		gas = gas.Sub(contract.Gas, gas)
+	}
+
+	contract.UseGas(gas)
+	ret, addr, suberr := env.Create2(contract, input, gas, contract.Price, salt, value)
+	if suberr != nil {
+		stack.push(new(big.Int))
+	} else {
+		stack.push(addr.Big())
+	}
+
+	if suberr == ErrRevert {
+		return ret, nil
+	}
+	return nil, nil
+}
+
 func opCall(pc *uint64, env Environment, contract *Contract, memory *Memory, stack *stack) ([]byte, error) {
 	gas := stack.pop()
 	// pop gas and value of the stack.
@@ -66,6 +66,52 @@ func newJumpTable(ruleset RuleSet, blockNumber *big.Int) vmJumpTable {
 		}
 	}
 
+	if ruleset.IsAgharta(blockNumber) {
+		jumpTable[SHL] = jumpPtr{
+			fn:    opSHL,
+			valid: true,
+		}
+		jumpTable[SHR] = jumpPtr{
+			fn:    opSHR,
+			valid: true,
+		}
+		jumpTable[SAR] = jumpPtr{
+			fn:    opSAR,
+			valid: true,
+		}
+		jumpTable[EXTCODEHASH] = jumpPtr{
+			fn:    opExtCodeHash,
+			valid: true,
+		}
+		jumpTable[CREATE2] = jumpPtr{
+			fn:      opCreate2,
+			valid:   true,
+			writes:  true,
+			returns: true,
+		}
+	}
+
+	if ruleset.IsEIP1344(blockNumber) {
+		jumpTable[CHAINID] = jumpPtr{
+			fn:    opChainID,
+			valid: true,
+		}
+	}
+
+	if ruleset.IsEIP1884(blockNumber) {
+		jumpTable[SELFBALANCE] = jumpPtr{
+			fn:    opSelfBalance,
+			valid: true,
+		}
+	}
+
+	if ruleset.IsEIP3855(blockNumber) {
+		jumpTable[PUSH0] = jumpPtr{
+			fn:    opPush0,
+			valid: true,
+		}
+	}
+
 	return jumpTable
 }
 
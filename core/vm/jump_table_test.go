@@ -24,12 +24,41 @@ import (
 type ruleSet struct {
 	hs *big.Int
 	at *big.Int
+	ag *big.Int
+	ph *big.Int
+	sp *big.Int
 }
 
 func (r ruleSet) IsHomestead(n *big.Int) bool { return n.Cmp(r.hs) >= 0 }
 
 func (r ruleSet) IsAtlantis(n *big.Int) bool { return n.Cmp(r.at) >= 0 }
 
+func (r ruleSet) IsAgharta(n *big.Int) bool { return r.ag != nil && n.Cmp(r.ag) >= 0 }
+
+func (r ruleSet) IsEIP1344(n *big.Int) bool { return r.ph != nil && n.Cmp(r.ph) >= 0 }
+
+func (r ruleSet) IsEIP1884(n *big.Int) bool { return r.ph != nil && n.Cmp(r.ph) >= 0 }
+
+func (r ruleSet) IsEIP2028(n *big.Int) bool { return r.ph != nil && n.Cmp(r.ph) >= 0 }
+
+func (r ruleSet) IsEIP2200(n *big.Int) bool { return r.ph != nil && n.Cmp(r.ph) >= 0 }
+
+func (r ruleSet) IsEIP152(n *big.Int) bool { return r.ph != nil && n.Cmp(r.ph) >= 0 }
+
+func (r ruleSet) IsEIP2929(*big.Int) bool { return false }
+
+func (r ruleSet) IsEIP3529(*big.Int) bool { return false }
+
+func (r ruleSet) IsEIP3541(*big.Int) bool { return false }
+
+func (r ruleSet) IsEIP3651(n *big.Int) bool { return r.sp != nil && n.Cmp(r.sp) >= 0 }
+
+func (r ruleSet) IsEIP3855(n *big.Int) bool { return r.sp != nil && n.Cmp(r.sp) >= 0 }
+
+func (r ruleSet) IsEIP3860(n *big.Int) bool { return r.sp != nil && n.Cmp(r.sp) >= 0 }
+
+func (r ruleSet) GetChainID() *big.Int { return big.NewInt(61) }
+
 func (r ruleSet) GasTable(*big.Int) *GasTable {
 	return &GasTable{
 		ExtcodeSize: big.NewInt(20),
@@ -39,19 +68,72 @@ func (r ruleSet) GasTable(*big.Int) *GasTable {
 		Calls:       big.NewInt(40),
 		Suicide:     big.NewInt(0),
 		ExpByte:     big.NewInt(10),
+		ExtcodeHash: big.NewInt(400),
 	}
 }
 
 func TestInit(t *testing.T) {
-	jumpTable := newJumpTable(ruleSet{big.NewInt(1), big.NewInt(1)}, big.NewInt(0))
+	jumpTable := newJumpTable(ruleSet{big.NewInt(1), big.NewInt(1), nil, nil, nil}, big.NewInt(0))
 	if jumpTable[DELEGATECALL].valid {
 		t.Error("Expected DELEGATECALL not to be present")
 	}
 
 	for _, n := range []int64{1, 2, 100} {
-		jumpTable := newJumpTable(ruleSet{big.NewInt(1), big.NewInt(1)}, big.NewInt(n))
+		jumpTable := newJumpTable(ruleSet{big.NewInt(1), big.NewInt(1), nil, nil, nil}, big.NewInt(n))
 		if !jumpTable[DELEGATECALL].valid {
 			t.Error("Expected DELEGATECALL to be present for block", n)
 		}
 	}
 }
+
+func TestAghartaInstructions(t *testing.T) {
+	rs := ruleSet{big.NewInt(0), big.NewInt(0), big.NewInt(10), nil, nil}
+	ops := []OpCode{SHL, SHR, SAR, EXTCODEHASH, CREATE2}
+
+	jumpTable := newJumpTable(rs, big.NewInt(9))
+	for _, op := range ops {
+		if jumpTable[op].valid {
+			t.Errorf("Expected %v not to be present before Agharta", op)
+		}
+	}
+
+	jumpTable = newJumpTable(rs, big.NewInt(10))
+	for _, op := range ops {
+		if !jumpTable[op].valid {
+			t.Errorf("Expected %v to be present at Agharta", op)
+		}
+	}
+	if !jumpTable[CREATE2].writes {
+		t.Error("Expected CREATE2 to be write protected")
+	}
+}
+
+func TestPhoenixInstructions(t *testing.T) {
+	rs := ruleSet{big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(10), nil}
+	ops := []OpCode{CHAINID, SELFBALANCE}
+
+	jumpTable := newJumpTable(rs, big.NewInt(9))
+	for _, op := range ops {
+		if jumpTable[op].valid {
+			t.Errorf("Expected %v not to be present before Phoenix", op)
+		}
+	}
+
+	jumpTable = newJumpTable(rs, big.NewInt(10))
+	for _, op := range ops {
+		if !jumpTable[op].valid {
+			t.Errorf("Expected %v to be present at Phoenix", op)
+		}
+	}
+}
+
+func TestSpiralInstructions(t *testing.T) {
+	rs := ruleSet{big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(0), big.NewInt(10)}
+
+	if newJumpTable(rs, big.NewInt(9))[PUSH0].valid {
+		t.Error("Expected PUSH0 not to be present before Spiral")
+	}
+	if !newJumpTable(rs, big.NewInt(10))[PUSH0].valid {
+		t.Error("Expected PUSH0 to be present at Spiral")
+	}
+}
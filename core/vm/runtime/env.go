@@ -115,3 +115,7 @@ func (self *Env) StaticCall(me vm.ContractRef, addr common.Address, data []byte,
 func (self *Env) Create(caller vm.ContractRef, data []byte, gas, price, value *big.Int) ([]byte, common.Address, error) {
 	return core.Create(self, caller, data, gas, price, value)
 }
+
+func (self *Env) Create2(caller vm.ContractRef, data []byte, gas, price, salt, value *big.Int) ([]byte, common.Address, error) {
+	return core.Create2(self, caller, data, gas, price, salt, value)
+}
@@ -28,17 +28,32 @@ import (
 	"github.com/eth-classic/go-ethereum/ethdb"
 )
 
-// The default, always homestead, rule set for the vm env
+// The default, always homestead, rule set for the vm env. It enables the forks
+// up to Phoenix; Magneto and later features are left to custom rule sets.
 type ruleSet struct{}
 
 func (ruleSet) IsHomestead(*big.Int) bool { return true }
 func (ruleSet) IsAtlantis(*big.Int) bool  { return true }
+func (ruleSet) IsAgharta(*big.Int) bool   { return true }
+func (ruleSet) IsEIP1344(*big.Int) bool   { return true }
+func (ruleSet) IsEIP1884(*big.Int) bool   { return true }
+func (ruleSet) IsEIP2028(*big.Int) bool   { return true }
+func (ruleSet) IsEIP2200(*big.Int) bool   { return true }
+func (ruleSet) IsEIP152(*big.Int) bool    { return true }
+func (ruleSet) IsEIP2929(*big.Int) bool   { return false }
+func (ruleSet) IsEIP3529(*big.Int) bool   { return false }
+func (ruleSet) IsEIP3541(*big.Int) bool   { return false }
+func (ruleSet) IsEIP3651(*big.Int) bool   { return false }
+func (ruleSet) IsEIP3855(*big.Int) bool   { return false }
+func (ruleSet) IsEIP3860(*big.Int) bool   { return false }
+func (ruleSet) GetChainID() *big.Int      { return new(big.Int) }
 func (ruleSet) GasTable(*big.Int) *vm.GasTable {
 	return &vm.GasTable{
 		ExtcodeSize:     big.NewInt(700),
 		ExtcodeCopy:     big.NewInt(700),
-		Balance:         big.NewInt(400),
-		SLoad:           big.NewInt(200),
+		ExtcodeHash:     big.NewInt(700),
+		Balance:         big.NewInt(700),
+		SLoad:           big.NewInt(800),
 		Calls:           big.NewInt(700),
 		Suicide:         big.NewInt(5000),
 		ExpByte:         big.NewInt(10),
@@ -117,6 +117,251 @@ func TestCall(t *testing.T) {
 	}
 }
 
+// Test vectors from EIP-2200.
+func TestEIP2200(t *testing.T) {
+	tests := []struct {
+		original byte
+		code     string
+		used     int64
+		refund   int64
+	}{
+		{0, "60006000556000600055", 1612, 0},
+		{0, "60006000556001600055", 20812, 0},
+		{0, "60016000556000600055", 20812, 19200},
+		{0, "60016000556002600055", 20812, 0},
+		{0, "60016000556001600055", 20812, 0},
+		{1, "60006000556000600055", 5812, 15000},
+		{1, "60006000556001600055", 5812, 4200},
+		{1, "60006000556002600055", 5812, 0},
+		{1, "60026000556000600055", 5812, 15000},
+		{1, "60026000556003600055", 5812, 0},
+		{1, "60026000556001600055", 5812, 4200},
+		{1, "60016000556000600055", 5812, 15000},
+		{1, "60016000556002600055", 5812, 0},
+		{1, "60016000556001600055", 1612, 0},
+		{0, "600160005560006000556001600055", 40818, 19200},
+		{1, "600060005560016000556000600055", 10818, 19200},
+	}
+	address := common.StringToAddress("contract")
+	for i, tt := range tests {
+		db, _ := ethdb.NewMemDatabase()
+		statedb, _ := state.New(common.Hash{}, state.NewDatabase(db))
+		statedb.CreateAccount(address)
+		statedb.SetCode(address, common.Hex2Bytes(tt.code))
+		statedb.SetState(address, common.Hash{}, common.BytesToHash([]byte{tt.original}))
+		statedb.IntermediateRoot(false)
+
+		gas := big.NewInt(100000)
+		if _, err := Call(address, nil, &Config{State: statedb, GasLimit: gas}); err != nil {
+			t.Errorf("test %d: failed to execute: %v", i, err)
+			continue
+		}
+		if used := 100000 - gas.Int64(); used != tt.used {
+			t.Errorf("test %d: gas used mismatch: have %v, want %v", i, used, tt.used)
+		}
+		if refund := statedb.GetRefund(); refund.Cmp(big.NewInt(tt.refund)) != 0 {
+			t.Errorf("test %d: gas refund mismatch: have %v, want %v", i, refund, tt.refund)
+		}
+	}
+}
+
+// eip2929RuleSet enables EIP-2929 state access pricing on top of the default
+// rule set.
+type eip2929RuleSet struct{ ruleSet }
+
+func (eip2929RuleSet) IsEIP2929(*big.Int) bool { return true }
+func (eip2929RuleSet) GasTable(*big.Int) *vm.GasTable {
+	return &vm.GasTable{
+		ExtcodeSize:     big.NewInt(100),
+		ExtcodeCopy:     big.NewInt(100),
+		ExtcodeHash:     big.NewInt(100),
+		Balance:         big.NewInt(100),
+		SLoad:           big.NewInt(100),
+		Calls:           big.NewInt(100),
+		Suicide:         big.NewInt(5000),
+		ExpByte:         big.NewInt(50),
+		CreateBySuicide: big.NewInt(25000),
+	}
+}
+
+func TestEIP2929(t *testing.T) {
+	tests := []struct {
+		code string
+		used int64
+	}{
+		// EXTCODEHASH, EXTCODESIZE and BALANCE on warm precompiles, then
+		// cold accounts, then the same accounts warm, then origin and self.
+		{"60013f5060023b506003315060f13f5060f23b5060f3315060f23f5060f33b5060f1315032315030315000", 8653},
+		// Cold then warm SLOAD of the same slot.
+		{"6000545060005450", 2210},
+		// SSTORE to a cold slot, then SLOAD of the now warm slot.
+		{"6001600055600054", 22100 + 100 + 9},
+	}
+	var (
+		origin  = common.StringToAddress("origin")
+		address = common.StringToAddress("contract")
+		rules   = eip2929RuleSet{}
+	)
+	for i, tt := range tests {
+		db, _ := ethdb.NewMemDatabase()
+		statedb, _ := state.New(common.Hash{}, state.NewDatabase(db))
+		statedb.CreateAccount(address)
+		statedb.SetCode(address, common.Hex2Bytes(tt.code))
+		statedb.IntermediateRoot(false)
+		statedb.PrepareAccessList(origin, &address, vm.ActivePrecompiles(rules, new(big.Int)))
+
+		gas := big.NewInt(100000)
+		if _, err := Call(address, nil, &Config{State: statedb, GasLimit: gas, Origin: origin, RuleSet: rules}); err != nil {
+			t.Errorf("test %d: failed to execute: %v", i, err)
+			continue
+		}
+		if used := 100000 - gas.Int64(); used != tt.used {
+			t.Errorf("test %d: gas used mismatch: have %v, want %v", i, used, tt.used)
+		}
+	}
+}
+
+// mystiqueRuleSet additionally enables the EIP-3529 refund reduction and the
+// EIP-3541 code prefix rule.
+type mystiqueRuleSet struct{ eip2929RuleSet }
+
+func (mystiqueRuleSet) IsEIP3529(*big.Int) bool { return true }
+func (mystiqueRuleSet) IsEIP3541(*big.Int) bool { return true }
+
+// eip3529RuleSet enables the EIP-3529 refund reduction on top of the EIP-2200
+// net gas metering of the default rule set, without EIP-2929.
+type eip3529RuleSet struct{ ruleSet }
+
+func (eip3529RuleSet) IsEIP3529(*big.Int) bool { return true }
+
+func TestEIP3529(t *testing.T) {
+	tests := []struct {
+		rules    vm.RuleSet
+		original byte
+		code     string
+		refund   int64
+	}{
+		// Clearing a slot
+		{eip2929RuleSet{}, 1, "6000600055", 15000},
+		{mystiqueRuleSet{}, 1, "6000600055", 4800},
+		// Clearing then restoring a slot
+		{mystiqueRuleSet{}, 1, "60006000556001600055", 2800},
+		// Clearing a slot under EIP-2200 metering only
+		{ruleSet{}, 1, "6000600055", 15000},
+		{eip3529RuleSet{}, 1, "6000600055", 4800},
+		// Self-destructing
+		{eip2929RuleSet{}, 0, "33ff", 24000},
+		{mystiqueRuleSet{}, 0, "33ff", 0},
+	}
+	address := common.StringToAddress("contract")
+	for i, tt := range tests {
+		db, _ := ethdb.NewMemDatabase()
+		statedb, _ := state.New(common.Hash{}, state.NewDatabase(db))
+		statedb.CreateAccount(address)
+		statedb.SetCode(address, common.Hex2Bytes(tt.code))
+		statedb.SetState(address, common.Hash{}, common.BytesToHash([]byte{tt.original}))
+		statedb.IntermediateRoot(false)
+
+		if _, err := Call(address, nil, &Config{State: statedb, GasLimit: big.NewInt(100000), RuleSet: tt.rules}); err != nil {
+			t.Errorf("test %d: failed to execute: %v", i, err)
+			continue
+		}
+		if refund := statedb.GetRefund(); refund.Cmp(big.NewInt(tt.refund)) != 0 {
+			t.Errorf("test %d: gas refund mismatch: have %v, want %v", i, refund, tt.refund)
+		}
+	}
+}
+
+func TestEIP3541(t *testing.T) {
+	tests := []struct {
+		rules vm.RuleSet
+		code  string
+		err   error
+	}{
+		// Init code returning 0xef
+		{eip2929RuleSet{}, "60ef60005360016000f3", nil},
+		{mystiqueRuleSet{}, "60ef60005360016000f3", vm.ErrInvalidCode},
+		// Init code returning 0xfe
+		{mystiqueRuleSet{}, "60fe60005360016000f3", nil},
+	}
+	for i, tt := range tests {
+		db, _ := ethdb.NewMemDatabase()
+		statedb, _ := state.New(common.Hash{}, state.NewDatabase(db))
+		cfg := &Config{State: statedb, RuleSet: tt.rules}
+		setDefaults(cfg)
+
+		sender := statedb.CreateAccount(cfg.Origin)
+		_, addr, err := NewEnv(cfg, statedb).Create(sender, common.Hex2Bytes(tt.code), big.NewInt(100000), cfg.GasPrice, cfg.Value)
+		if err != tt.err {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, tt.err)
+		}
+		if size := statedb.GetCodeSize(addr); (tt.err == nil) != (size == 1) {
+			t.Errorf("test %d: unexpected code size %d", i, size)
+		}
+	}
+}
+
+// spiralRuleSet additionally enables the warm coinbase, PUSH0 and the EIP-3860
+// init code rules.
+type spiralRuleSet struct{ mystiqueRuleSet }
+
+func (spiralRuleSet) IsEIP3651(*big.Int) bool { return true }
+func (spiralRuleSet) IsEIP3855(*big.Int) bool { return true }
+func (spiralRuleSet) IsEIP3860(*big.Int) bool { return true }
+
+func TestPush0(t *testing.T) {
+	// PUSH0 PUSH1 0x2a ADD PUSH0 MSTORE PUSH1 32 PUSH0 RETURN
+	code := common.Hex2Bytes("5f602a015f5260205ff3")
+
+	ret, _, err := Execute(code, nil, &Config{RuleSet: spiralRuleSet{}})
+	if err != nil {
+		t.Fatal("didn't expect error", err)
+	}
+	if num := new(big.Int).SetBytes(ret); num.Cmp(big.NewInt(42)) != 0 {
+		t.Error("Expected 42, got", num)
+	}
+	if _, _, err := Execute(code, nil, &Config{RuleSet: mystiqueRuleSet{}}); err == nil {
+		t.Error("expected PUSH0 to be invalid before Spiral")
+	}
+}
+
+func TestEIP3860(t *testing.T) {
+	tests := []struct {
+		rules vm.RuleSet
+		size  int64
+		used  int64
+		err   error
+	}{
+		// CREATE of 64 zero bytes of init code: 3 pushes, memory expansion
+		// of two words and the CREATE base cost.
+		{mystiqueRuleSet{}, 64, 3*3 + 2*3 + 32000, nil},
+		{spiralRuleSet{}, 64, 3*3 + 2*3 + 32000 + 2*2, nil},
+		// Init code above the size limit
+		{mystiqueRuleSet{}, vm.MaxInitCodeSizeEIP3860 + 1, -1, nil},
+		{spiralRuleSet{}, vm.MaxInitCodeSizeEIP3860 + 1, 1000000, vm.ErrMaxInitCodeSize},
+	}
+	address := common.StringToAddress("contract")
+	for i, tt := range tests {
+		db, _ := ethdb.NewMemDatabase()
+		statedb, _ := state.New(common.Hash{}, state.NewDatabase(db))
+		statedb.CreateAccount(address)
+		// PUSH3 size PUSH1 0 PUSH1 0 CREATE STOP
+		code := append([]byte{byte(vm.PUSH3)}, common.LeftPadBytes(big.NewInt(tt.size).Bytes(), 3)...)
+		code = append(code, common.Hex2Bytes("60006000f000")...)
+		statedb.SetCode(address, code)
+		statedb.IntermediateRoot(false)
+
+		gas := big.NewInt(1000000)
+		_, err := Call(address, nil, &Config{State: statedb, GasLimit: gas, RuleSet: tt.rules})
+		if err != tt.err {
+			t.Errorf("test %d: error mismatch: have %v, want %v", i, err, tt.err)
+		}
+		if used := 1000000 - gas.Int64(); tt.used >= 0 && used != tt.used {
+			t.Errorf("test %d: gas used mismatch: have %v, want %v", i, used, tt.used)
+		}
+	}
+}
+
 func BenchmarkCall(b *testing.B) {
 	var definition = `[{"constant":true,"inputs":[],"name":"seller","outputs":[{"name":"","type":"address"}],"type":"function"},{"constant":false,"inputs":[],"name":"abort","outputs":[],"type":"function"},{"constant":true,"inputs":[],"name":"value","outputs":[{"name":"","type":"uint256"}],"type":"function"},{"constant":false,"inputs":[],"name":"refund","outputs":[],"type":"function"},{"constant":true,"inputs":[],"name":"buyer","outputs":[{"name":"","type":"address"}],"type":"function"},{"constant":false,"inputs":[],"name":"confirmReceived","outputs":[],"type":"function"},{"constant":true,"inputs":[],"name":"state","outputs":[{"name":"","type":"uint8"}],"type":"function"},{"constant":false,"inputs":[],"name":"confirmPurchase","outputs":[],"type":"function"},{"inputs":[],"type":"constructor"},{"anonymous":false,"inputs":[],"name":"Aborted","type":"event"},{"anonymous":false,"inputs":[],"name":"PurchaseConfirmed","type":"event"},{"anonymous":false,"inputs":[],"name":"ItemReceived","type":"event"},{"anonymous":false,"inputs":[],"name":"Refunded","type":"event"}]`
 
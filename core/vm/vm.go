@@ -32,6 +32,8 @@ var (
 	OutOfGasError          = errors.New("Out of gas")
 	CodeStoreOutOfGasError = errors.New("Contract creation code storage out of gas")
 	ErrRevert              = errors.New("Execution reverted")
+	ErrInvalidCode         = errors.New("Invalid code: must not begin with 0xef")
+	ErrMaxInitCodeSize     = errors.New("Max initcode size exceeded")
 )
 
 // VirtualMachine is an EVM interface
@@ -76,16 +78,9 @@ func (evm *EVM) Run(contract *Contract, input []byte, readOnly bool) (ret []byte
 	evm.env.SetReturnData(nil)
 
 	if contract.CodeAddr != nil {
-		if evm.env.RuleSet().IsAtlantis(evm.env.BlockNumber()) {
-			if p := PrecompiledAtlantis[contract.CodeAddr.Str()]; p != nil {
-				return evm.RunPrecompiled(p, input, contract)
-			}
-		} else {
-			if p := PrecompiledPreAtlantis[contract.CodeAddr.Str()]; p != nil {
-				return evm.RunPrecompiled(p, input, contract)
-			}
+		if p := Precompiles(evm.env.RuleSet(), evm.env.BlockNumber())[contract.CodeAddr.Str()]; p != nil {
+			return evm.RunPrecompiled(p, input, contract)
 		}
-
 	}
 
 	// Don't bother with the execution if there's no code.
@@ -185,6 +180,9 @@ func calculateGasAndSize(gasTable *GasTable, env Environment, contract *Contract
 		gas                 = new(big.Int)
 		newMemSize *big.Int = new(big.Int)
 		isAtlantis          = env.RuleSet().IsAtlantis(env.BlockNumber())
+		isEIP2929           = env.RuleSet().IsEIP2929(env.BlockNumber())
+		isEIP3529           = env.RuleSet().IsEIP3529(env.BlockNumber())
+		isEIP3860           = env.RuleSet().IsEIP3860(env.BlockNumber())
 	)
 	err := baseCheck(op, stack, gas)
 	if err != nil {
@@ -209,6 +207,10 @@ func calculateGasAndSize(gasTable *GasTable, env Environment, contract *Contract
 		// if suicide is not nil: homestead gas fork
 		if gasTable.CreateBySuicide != nil {
 			gas.Set(gasTable.Suicide)
+			if isEIP2929 && !env.Db().AddressInAccessList(address) {
+				env.Db().AddAddressToAccessList(address)
+				gas.Add(gas, ColdAccountAccessCostEIP2929)
+			}
 			if isAtlantis {
 				if env.Db().Empty(address) && env.Db().GetBalance(contract.Address()).Sign() != 0 {
 					gas.Add(gas, gasTable.CreateBySuicide)
@@ -218,15 +220,30 @@ func calculateGasAndSize(gasTable *GasTable, env Environment, contract *Contract
 			}
 		}
 
-		if !statedb.HasSuicided(contract.Address()) {
+		// EIP-3529 removes the refund for self-destructing
+		if !isEIP3529 && !statedb.HasSuicided(contract.Address()) {
 			statedb.AddRefund(big.NewInt(24000))
 		}
 	case EXTCODESIZE:
 		gas.Set(gasTable.ExtcodeSize)
+		if isEIP2929 {
+			gas.Add(gas, accountAccessGasEIP2929(statedb, common.BigToAddress(stack.back(0))))
+		}
+	case EXTCODEHASH:
+		gas.Set(gasTable.ExtcodeHash)
+		if isEIP2929 {
+			gas.Add(gas, accountAccessGasEIP2929(statedb, common.BigToAddress(stack.back(0))))
+		}
 	case BALANCE:
 		gas.Set(gasTable.Balance)
+		if isEIP2929 {
+			gas.Add(gas, accountAccessGasEIP2929(statedb, common.BigToAddress(stack.back(0))))
+		}
 	case SLOAD:
 		gas.Set(gasTable.SLoad)
+		if isEIP2929 {
+			gas.Add(gas, slotAccessGasEIP2929(statedb, contract.Address(), common.BigToHash(stack.back(0))))
+		}
 	case SWAP1, SWAP2, SWAP3, SWAP4, SWAP5, SWAP6, SWAP7, SWAP8, SWAP9, SWAP10, SWAP11, SWAP12, SWAP13, SWAP14, SWAP15, SWAP16:
 		n := int(op - SWAP1 + 2)
 		err := stack.require(n)
@@ -269,6 +286,29 @@ func calculateGasAndSize(gasTable *GasTable, env Environment, contract *Contract
 			return nil, nil, err
 		}
 
+		// EIP-3529 reduces the refund for clearing a slot under both net gas
+		// metering schemes
+		clearingRefund := SstoreClearRefundEIP2200
+		if isEIP3529 {
+			clearingRefund = SstoreClearsScheduleRefundEIP3529
+		}
+		if isEIP2929 {
+			g, err := gasSStoreEIP2929(contract, statedb, common.BigToHash(stack.back(0)), common.BigToHash(stack.back(1)), clearingRefund)
+			if err != nil {
+				return nil, nil, err
+			}
+			gas.Set(g)
+			break
+		}
+		if env.RuleSet().IsEIP2200(env.BlockNumber()) {
+			g, err := gasSStoreEIP2200(contract, statedb, common.BigToHash(stack.back(0)), common.BigToHash(stack.back(1)), clearingRefund)
+			if err != nil {
+				return nil, nil, err
+			}
+			gas.Set(g)
+			break
+		}
+
 		var g *big.Int
 		y, x := stack.back(1), stack.back(0)
 		val := statedb.GetState(contract.Address(), common.BigToHash(x))
@@ -324,6 +364,9 @@ func calculateGasAndSize(gasTable *GasTable, env Environment, contract *Contract
 		quadMemGas(mem, newMemSize, gas)
 	case EXTCODECOPY:
 		gas.Set(gasTable.ExtcodeCopy)
+		if isEIP2929 {
+			gas.Add(gas, accountAccessGasEIP2929(statedb, common.BigToAddress(stack.back(0))))
+		}
 
 		newMemSize = calcMemSize(stack.back(1), stack.back(3))
 
@@ -334,9 +377,32 @@ func calculateGasAndSize(gasTable *GasTable, env Environment, contract *Contract
 	case CREATE:
 		newMemSize = calcMemSize(stack.back(1), stack.back(2))
 
+		if isEIP3860 {
+			if err := initCodeGasEIP3860(stack.back(2), gas); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		quadMemGas(mem, newMemSize, gas)
+	case CREATE2:
+		newMemSize = calcMemSize(stack.back(1), stack.back(2))
+
+		if isEIP3860 {
+			if err := initCodeGasEIP3860(stack.back(2), gas); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		// the init code is hashed to derive the contract address
+		words := toWordSize(stack.back(2))
+		gas.Add(gas, words.Mul(words, big.NewInt(6)))
+
 		quadMemGas(mem, newMemSize, gas)
 	case CALL, CALLCODE:
 		gas.Set(gasTable.Calls)
+		if isEIP2929 {
+			gas.Add(gas, accountAccessGasEIP2929(statedb, common.BigToAddress(stack.back(1))))
+		}
 
 		if op == CALL {
 			address := common.BigToAddress(stack.back(1))
@@ -371,6 +437,9 @@ func calculateGasAndSize(gasTable *GasTable, env Environment, contract *Contract
 
 	case DELEGATECALL:
 		gas.Set(gasTable.Calls)
+		if isEIP2929 {
+			gas.Add(gas, accountAccessGasEIP2929(statedb, common.BigToAddress(stack.back(1))))
+		}
 
 		x := calcMemSize(stack.back(4), stack.back(5))
 		y := calcMemSize(stack.back(2), stack.back(3))
@@ -389,6 +458,9 @@ func calculateGasAndSize(gasTable *GasTable, env Environment, contract *Contract
 		gas.Add(gas, cg)
 	case STATICCALL:
 		gas.Set(gasTable.Calls)
+		if isEIP2929 {
+			gas.Add(gas, accountAccessGasEIP2929(statedb, common.BigToAddress(stack.back(1))))
+		}
 
 		x := calcMemSize(stack.back(4), stack.back(5))
 		y := calcMemSize(stack.back(2), stack.back(3))
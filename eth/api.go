@@ -31,7 +31,6 @@ import (
 	"sync"
 	"time"
 
-	"github.com/eth-classic/ethash"
 	"github.com/eth-classic/go-ethereum/accounts"
 	"github.com/eth-classic/go-ethereum/common"
 	"github.com/eth-classic/go-ethereum/common/compiler"
@@ -48,8 +47,10 @@ import (
 	ethMetrics "github.com/eth-classic/go-ethereum/metrics"
 	"github.com/eth-classic/go-ethereum/miner"
 	"github.com/eth-classic/go-ethereum/p2p"
+	"github.com/eth-classic/go-ethereum/pow/etchash"
 	"github.com/eth-classic/go-ethereum/rlp"
 	"github.com/eth-classic/go-ethereum/rpc"
+	"github.com/eth-classic/go-ethereum/trie"
 )
 
 const defaultGas = uint64(90000)
@@ -86,7 +87,7 @@ func stateAndBlockByNumber(m *miner.Miner, bc *core.BlockChain, blockNr rpc.Bloc
 	if block == nil {
 		return nil, nil, nil
 	}
-	stateDb, err := state.New(block.Root(), state.NewDatabase(chainDb))
+	stateDb, err := bc.StateAt(block.Root())
 	return stateDb, block, err
 }
 
@@ -308,7 +309,7 @@ func (s *PrivateMinerAPI) SetExtra(b hexutil.Bytes) bool {
 
 // MakeDAG creates the new DAG for the given block number
 func (s *PrivateMinerAPI) MakeDAG(blockNr rpc.BlockNumber) (bool, error) {
-	if err := ethash.MakeDAG(uint64(blockNr.Int64()), ""); err != nil {
+	if err := etchash.MakeDAG(uint64(blockNr.Int64()), "", s.e.chainConfig.ECIP1099Block()); err != nil {
 		return false, err
 	}
 	return true, nil
@@ -749,6 +750,77 @@ func (s *PublicBlockChainAPI) GetStorageAt(address common.Address, key string, b
 	return state.GetState(address, common.HexToHash(key)).Hex(), nil
 }
 
+// AccountResult is the result of eth_getProof, the Merkle proof of an account
+// and some of its storage slots as specified by EIP-1186.
+type AccountResult struct {
+	Address      common.Address  `json:"address"`
+	AccountProof []string        `json:"accountProof"`
+	Balance      *rpc.HexNumber  `json:"balance"`
+	CodeHash     common.Hash     `json:"codeHash"`
+	Nonce        *rpc.HexNumber  `json:"nonce"`
+	StorageHash  common.Hash     `json:"storageHash"`
+	StorageProof []StorageResult `json:"storageProof"`
+}
+
+// StorageResult is the Merkle proof of a single storage slot.
+type StorageResult struct {
+	Key   string         `json:"key"`
+	Value *rpc.HexNumber `json:"value"`
+	Proof []string       `json:"proof"`
+}
+
+// GetProof returns the Merkle proof of the given account and storage slots in
+// the state of the given block number, as specified by EIP-1186. Proofs of
+// absence are returned for missing accounts and slots.
+func (s *PublicBlockChainAPI) GetProof(address common.Address, storageKeys []string, blockNr rpc.BlockNumber) (*AccountResult, error) {
+	state, _, err := stateAndBlockByNumber(s.miner, s.bc, blockNr, s.chainDb)
+	if state == nil || err != nil {
+		return nil, err
+	}
+	storageTrie := state.StorageTrie(address)
+	storageHash := types.EmptyRootHash
+	if storageTrie != nil {
+		storageHash = storageTrie.Hash()
+	}
+	// Prove the requested slots, leaving the proofs empty for missing accounts
+	storageProof := make([]StorageResult, len(storageKeys))
+	for i, key := range storageKeys {
+		storageProof[i] = StorageResult{Key: key, Value: rpc.NewHexNumber(0), Proof: []string{}}
+		if storageTrie == nil {
+			continue
+		}
+		slot := common.HexToHash(key)
+		proof, err := state.GetStorageProof(address, slot)
+		if err != nil {
+			return nil, err
+		}
+		storageProof[i].Value = rpc.NewHexNumber(state.GetState(address, slot).Big())
+		storageProof[i].Proof = toHexSlice(proof)
+	}
+	accountProof, err := state.GetProof(address)
+	if err != nil {
+		return nil, err
+	}
+	return &AccountResult{
+		Address:      address,
+		AccountProof: toHexSlice(accountProof),
+		Balance:      rpc.NewHexNumber(state.GetBalance(address)),
+		CodeHash:     state.GetCodeHash(address),
+		Nonce:        rpc.NewHexNumber(state.GetNonce(address)),
+		StorageHash:  storageHash,
+		StorageProof: storageProof,
+	}, nil
+}
+
+// toHexSlice encodes a list of byte slices into hex strings.
+func toHexSlice(b [][]byte) []string {
+	r := make([]string, len(b))
+	for i := range b {
+		r[i] = common.ToHex(b[i])
+	}
+	return r
+}
+
 // callmsg is the message type used for call transactions.
 type callmsg struct {
 	from          *state.StateObject
@@ -756,6 +828,7 @@ type callmsg struct {
 	gas, gasPrice *big.Int
 	value         *big.Int
 	data          []byte
+	accessList    types.AccessList
 }
 
 // accessor boilerplate to implement core.Message
@@ -767,15 +840,17 @@ func (m callmsg) GasPrice() *big.Int                    { return m.gasPrice }
 func (m callmsg) Gas() *big.Int                         { return m.gas }
 func (m callmsg) Value() *big.Int                       { return m.value }
 func (m callmsg) Data() []byte                          { return m.data }
+func (m callmsg) AccessList() types.AccessList          { return m.accessList }
 
 // CallArgs represents the arguments for a call.
 type CallArgs struct {
-	From     common.Address  `json:"from"`
-	To       *common.Address `json:"to"`
-	Gas      *rpc.HexNumber  `json:"gas"`
-	GasPrice *rpc.HexNumber  `json:"gasPrice"`
-	Value    rpc.HexNumber   `json:"value"`
-	Data     string          `json:"data"`
+	From       common.Address    `json:"from"`
+	To         *common.Address   `json:"to"`
+	Gas        *rpc.HexNumber    `json:"gas"`
+	GasPrice   *rpc.HexNumber    `json:"gasPrice"`
+	Value      rpc.HexNumber     `json:"value"`
+	Data       string            `json:"data"`
+	AccessList *types.AccessList `json:"accessList"`
 }
 
 func (s *PublicBlockChainAPI) doCall(args CallArgs, blockNr rpc.BlockNumber) (string, *big.Int, error) {
@@ -809,6 +884,9 @@ func (s *PublicBlockChainAPI) doCall(args CallArgs, blockNr rpc.BlockNumber) (st
 		value:    args.Value.BigInt(),
 		data:     common.FromHex(args.Data),
 	}
+	if args.AccessList != nil {
+		msg.accessList = *args.AccessList
+	}
 	if msg.gas == nil {
 		msg.gas = big.NewInt(50000000)
 	}
@@ -873,7 +951,7 @@ func (s *PublicBlockChainAPI) rpcOutputBlock(b *types.Block, inclTx bool, fullTx
 		if fullTx {
 			formatTx = func(tx *types.Transaction) (interface{}, error) {
 				if tx.Protected() {
-					tx.SetSigner(types.NewChainIdSigner(s.bc.Config().GetChainID()))
+					tx.SetSigner(types.NewAccessListSigner(s.bc.Config().GetChainID()))
 				}
 				return newRPCTransaction(b, tx.Hash())
 			}
@@ -902,22 +980,24 @@ func (s *PublicBlockChainAPI) rpcOutputBlock(b *types.Block, inclTx bool, fullTx
 
 // RPCTransaction represents a transaction that will serialize to the RPC representation of a transaction
 type RPCTransaction struct {
-	BlockHash        common.Hash     `json:"blockHash"`
-	BlockNumber      *rpc.HexNumber  `json:"blockNumber"`
-	From             common.Address  `json:"from"`
-	Gas              *rpc.HexNumber  `json:"gas"`
-	GasPrice         *rpc.HexNumber  `json:"gasPrice"`
-	Hash             common.Hash     `json:"hash"`
-	Input            string          `json:"input"`
-	Nonce            *rpc.HexNumber  `json:"nonce"`
-	To               *common.Address `json:"to"`
-	TransactionIndex *rpc.HexNumber  `json:"transactionIndex"`
-	Value            *rpc.HexNumber  `json:"value"`
-	ReplayProtected  bool            `json:"replayProtected"`
-	ChainId          *big.Int        `json:"chainId,omitempty"`
-	V                *rpc.HexNumber  `json:"v"`
-	R                *rpc.HexNumber  `json:"r"`
-	S                *rpc.HexNumber  `json:"s"`
+	BlockHash        common.Hash       `json:"blockHash"`
+	BlockNumber      *rpc.HexNumber    `json:"blockNumber"`
+	From             common.Address    `json:"from"`
+	Gas              *rpc.HexNumber    `json:"gas"`
+	GasPrice         *rpc.HexNumber    `json:"gasPrice"`
+	Hash             common.Hash       `json:"hash"`
+	Input            string            `json:"input"`
+	Nonce            *rpc.HexNumber    `json:"nonce"`
+	To               *common.Address   `json:"to"`
+	TransactionIndex *rpc.HexNumber    `json:"transactionIndex"`
+	Value            *rpc.HexNumber    `json:"value"`
+	ReplayProtected  bool              `json:"replayProtected"`
+	ChainId          *big.Int          `json:"chainId,omitempty"`
+	Type             *rpc.HexNumber    `json:"type"`
+	AccessList       *types.AccessList `json:"accessList,omitempty"`
+	V                *rpc.HexNumber    `json:"v"`
+	R                *rpc.HexNumber    `json:"r"`
+	S                *rpc.HexNumber    `json:"s"`
 }
 
 // newRPCPendingTransaction returns a pending transaction that will serialize to the RPC representation
@@ -942,9 +1022,24 @@ func newRPCPendingTransaction(tx *types.Transaction) *RPCTransaction {
 		Value:           rpc.NewHexNumber(tx.Value()),
 		ReplayProtected: protected,
 		ChainId:         chainId,
+		Type:            rpc.NewHexNumber(tx.Type()),
+		AccessList:      rpcAccessList(tx),
 	}
 }
 
+// rpcAccessList returns the access list of a typed transaction, or nil for
+// legacy transactions so the field is omitted from the RPC representation.
+func rpcAccessList(tx *types.Transaction) *types.AccessList {
+	if tx.Type() == types.LegacyTxType {
+		return nil
+	}
+	al := tx.AccessList()
+	if al == nil {
+		al = types.AccessList{}
+	}
+	return &al
+}
+
 // newRPCTransaction returns a transaction that will serialize to the RPC representation.
 func newRPCTransactionFromBlockIndex(b *types.Block, txIndex int) (*RPCTransaction, error) {
 	if txIndex >= 0 && txIndex < len(b.Transactions()) {
@@ -953,7 +1048,7 @@ func newRPCTransactionFromBlockIndex(b *types.Block, txIndex int) (*RPCTransacti
 		var protected bool
 		var chainId *big.Int
 		if tx.Protected() {
-			signer = types.NewChainIdSigner(tx.ChainId())
+			signer = types.NewAccessListSigner(tx.ChainId())
 			protected = true
 			chainId = tx.ChainId()
 		}
@@ -975,6 +1070,8 @@ func newRPCTransactionFromBlockIndex(b *types.Block, txIndex int) (*RPCTransacti
 			Value:            rpc.NewHexNumber(tx.Value()),
 			ReplayProtected:  protected,
 			ChainId:          chainId,
+			Type:             rpc.NewHexNumber(tx.Type()),
+			AccessList:       rpcAccessList(tx),
 			V:                rpc.NewHexNumber(v),
 			R:                rpc.NewHexNumber(r),
 			S:                rpc.NewHexNumber(s),
@@ -1204,7 +1301,7 @@ func (s *PublicTransactionPoolAPI) GetTransactionReceipt(txHash common.Hash) (ma
 
 	var signer types.Signer = types.BasicSigner{}
 	if tx.Protected() {
-		signer = types.NewChainIdSigner(tx.ChainId())
+		signer = types.NewAccessListSigner(tx.ChainId())
 	}
 	from, _ := types.Sender(signer, tx)
 
@@ -1220,6 +1317,7 @@ func (s *PublicTransactionPoolAPI) GetTransactionReceipt(txHash common.Hash) (ma
 		"cumulativeGasUsed": rpc.NewHexNumber(receipt.CumulativeGasUsed),
 		"contractAddress":   nil,
 		"logs":              receipt.Logs,
+		"type":              rpc.NewHexNumber(receipt.Type),
 	}
 
 	if receipt.Logs == nil {
@@ -1335,7 +1433,7 @@ func (s *PublicTransactionPoolAPI) SendTransaction(args SendTxArgs) (common.Hash
 // The sender is responsible for signing the transaction and using the correct nonce.
 func (s *PublicTransactionPoolAPI) SendRawTransaction(encodedTx string) (string, error) {
 	tx := new(types.Transaction)
-	if err := rlp.DecodeBytes(common.FromHex(encodedTx), tx); err != nil {
+	if err := tx.UnmarshalBinary(common.FromHex(encodedTx)); err != nil {
 		return "", err
 	}
 
@@ -1496,7 +1594,7 @@ type SignTransactionResult struct {
 func newTx(t *types.Transaction) *Tx {
 	var signer types.Signer = types.BasicSigner{}
 	if t.Protected() {
-		signer = types.NewChainIdSigner(t.ChainId())
+		signer = types.NewAccessListSigner(t.ChainId())
 	}
 	from, _ := types.Sender(signer, t)
 	return &Tx{
@@ -1545,7 +1643,7 @@ func (s *PublicTransactionPoolAPI) SignTransaction(args SignTransactionArgs) (*S
 		return nil, err
 	}
 
-	data, err := rlp.EncodeToBytes(signedTx)
+	data, err := signedTx.MarshalBinary()
 	if err != nil {
 		return nil, err
 	}
@@ -1561,7 +1659,7 @@ func (s *PublicTransactionPoolAPI) PendingTransactions() []*RPCTransaction {
 	for _, tx := range pending {
 		var signer types.Signer = types.BasicSigner{}
 		if tx.Protected() {
-			signer = types.NewChainIdSigner(tx.ChainId())
+			signer = types.NewAccessListSigner(tx.ChainId())
 		}
 		from, _ := types.Sender(signer, tx)
 		if s.am.HasAddress(from) {
@@ -1604,7 +1702,7 @@ func (s *PublicTransactionPoolAPI) Resend(tx Tx, gasPrice, gasLimit *rpc.HexNumb
 	for _, p := range pending {
 		var signer types.Signer = types.BasicSigner{}
 		if p.Protected() {
-			signer = types.NewChainIdSigner(p.ChainId())
+			signer = types.NewAccessListSigner(p.ChainId())
 		}
 
 		if pFrom, err := types.Sender(signer, p); err == nil && pFrom == tx.From && signer.Hash(p) == signer.Hash(tx.tx) {
@@ -1875,6 +1973,131 @@ func (api *PublicDebugAPI) AccountExist(address common.Address, number uint64) (
 	return stateDb.Exist(address), nil
 }
 
+// rangeMaxResults is the maximum number of items returned by a single account or
+// storage range request.
+const rangeMaxResults = 1024
+
+// AccountRangeEntry is an account returned by AccountRange. The address is only
+// set if its preimage is known.
+type AccountRangeEntry struct {
+	Address  *common.Address `json:"address"`
+	Balance  *rpc.HexNumber  `json:"balance"`
+	Nonce    *rpc.HexNumber  `json:"nonce"`
+	Root     common.Hash     `json:"root"`
+	CodeHash common.Hash     `json:"codeHash"`
+}
+
+// AccountRangeResult is a page of accounts keyed by the hash of their address,
+// along with the hash to request the next page from (nil if there is none).
+type AccountRangeResult struct {
+	Accounts map[string]*AccountRangeEntry `json:"accounts"`
+	Next     *common.Hash                  `json:"next"`
+}
+
+// AccountRange returns at most maxResults accounts of the state at the given block,
+// in the order of the hash of their address starting from start.
+func (api *PublicDebugAPI) AccountRange(number uint64, start common.Hash, maxResults int) (*AccountRangeResult, error) {
+	block := api.eth.BlockChain().GetBlockByNumber(number)
+	if block == nil {
+		return nil, fmt.Errorf("block #%d not found", number)
+	}
+	tr, err := api.eth.BlockChain().StateCache().OpenTrie(block.Root())
+	if err != nil {
+		return nil, err
+	}
+	if maxResults <= 0 || maxResults > rangeMaxResults {
+		maxResults = rangeMaxResults
+	}
+	var (
+		preimages = core.PreimageTable(api.eth.ChainDb())
+		result    = &AccountRangeResult{Accounts: make(map[string]*AccountRangeEntry)}
+	)
+	it := trie.NewIterator(tr.NodeIterator(start[:]))
+	for it.Next() {
+		hash := common.BytesToHash(it.Key)
+		if len(result.Accounts) == maxResults {
+			result.Next = &hash
+			break
+		}
+		var data state.Account
+		if err := rlp.DecodeBytes(it.Value, &data); err != nil {
+			return nil, fmt.Errorf("invalid account %x: %v", hash, err)
+		}
+		entry := &AccountRangeEntry{
+			Balance:  rpc.NewHexNumber(data.Balance),
+			Nonce:    rpc.NewHexNumber(data.Nonce),
+			Root:     data.Root,
+			CodeHash: common.BytesToHash(data.CodeHash),
+		}
+		if preimage, err := preimages.Get(hash[:]); err == nil && len(preimage) == common.AddressLength {
+			addr := common.BytesToAddress(preimage)
+			entry.Address = &addr
+		}
+		result.Accounts[hash.Hex()] = entry
+	}
+	if it.Err != nil {
+		return nil, it.Err
+	}
+	return result, nil
+}
+
+// StorageRangeEntry is a storage slot returned by StorageRangeAt. The key is only
+// set if its preimage is known.
+type StorageRangeEntry struct {
+	Key   *common.Hash `json:"key"`
+	Value common.Hash  `json:"value"`
+}
+
+// StorageRangeResult is a page of storage slots keyed by the hash of their key,
+// along with the hash to request the next page from (nil if there is none).
+type StorageRangeResult struct {
+	Storage map[string]*StorageRangeEntry `json:"storage"`
+	Next    *common.Hash                  `json:"next"`
+}
+
+// StorageRangeAt returns at most maxResults storage slots of a contract, as found
+// right before executing the transaction at the given index of a block, in the
+// order of the hash of their key starting from start.
+func (api *PublicDebugAPI) StorageRangeAt(blockHash common.Hash, txIndex int, contract common.Address, start common.Hash, maxResults int) (*StorageRangeResult, error) {
+	_, vmenv, err := api.computeTxEnv(blockHash, txIndex)
+	if err != nil {
+		return nil, err
+	}
+	tr := vmenv.Db().(*state.StateDB).StorageTrie(contract)
+	if tr == nil {
+		return nil, fmt.Errorf("account %x doesn't exist", contract)
+	}
+	if maxResults <= 0 || maxResults > rangeMaxResults {
+		maxResults = rangeMaxResults
+	}
+	var (
+		preimages = core.PreimageTable(api.eth.ChainDb())
+		result    = &StorageRangeResult{Storage: make(map[string]*StorageRangeEntry)}
+	)
+	it := trie.NewIterator(tr.NodeIterator(start[:]))
+	for it.Next() {
+		hash := common.BytesToHash(it.Key)
+		if len(result.Storage) == maxResults {
+			result.Next = &hash
+			break
+		}
+		_, content, _, err := rlp.Split(it.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid storage slot %x: %v", hash, err)
+		}
+		entry := &StorageRangeEntry{Value: common.BytesToHash(content)}
+		if preimage, err := preimages.Get(hash[:]); err == nil && len(preimage) == common.HashLength {
+			key := common.BytesToHash(preimage)
+			entry.Key = &key
+		}
+		result.Storage[hash.Hex()] = entry
+	}
+	if it.Err != nil {
+		return nil, it.Err
+	}
+	return result, nil
+}
+
 // GetBlockRlp retrieves the RLP encoded for of a single block.
 func (api *PublicDebugAPI) GetBlockRlp(number uint64) (string, error) {
 	block := api.eth.BlockChain().GetBlockByNumber(number)
@@ -1903,7 +2126,7 @@ func (api *PublicDebugAPI) SeedHash(number uint64) (string, error) {
 	if block == nil {
 		return "", fmt.Errorf("block #%d not found", number)
 	}
-	hash, err := ethash.GetSeedHash(number)
+	hash, err := etchash.SeedHash(number, api.eth.chainConfig.ECIP1099Block())
 	if err != nil {
 		return "", err
 	}
@@ -1917,6 +2140,12 @@ func (api *PublicDebugAPI) SetHead(number uint64) (bool, error) {
 	return true, nil
 }
 
+// ChaindbCompact compacts the chain database while the node keeps running,
+// logging the progress. It returns once the compaction is done.
+func (api *PublicDebugAPI) ChaindbCompact() error {
+	return core.CompactDatabase(api.eth.ChainDb())
+}
+
 // Metrics return all available registered metrics for the client.
 // See https://github.com/eth-classic/go-ethereum/wiki/Metrics-and-Monitoring for prophetic documentation.
 func (api *PublicDebugAPI) Metrics(raw bool) (map[string]interface{}, error) {
@@ -2063,6 +2292,9 @@ func (s *PublicBlockChainAPI) TraceCall(args CallArgs, blockNr rpc.BlockNumber)
 		value:    args.Value.BigInt(),
 		data:     common.FromHex(args.Data),
 	}
+	if args.AccessList != nil {
+		msg.accessList = *args.AccessList
+	}
 	if msg.gas.Sign() == 0 {
 		msg.gas = big.NewInt(50000000)
 	}
@@ -2136,12 +2368,13 @@ func (s *PublicDebugAPI) computeTxEnv(blockHash common.Hash, txIndex int) (core.
 		}
 
 		msg := callmsg{
-			from:     from,
-			to:       tx.To(),
-			gas:      tx.Gas(),
-			gasPrice: tx.GasPrice(),
-			value:    tx.Value(),
-			data:     tx.Data(),
+			from:       from,
+			to:         tx.To(),
+			gas:        tx.Gas(),
+			gasPrice:   tx.GasPrice(),
+			value:      tx.Value(),
+			data:       tx.Data(),
+			accessList: tx.AccessList(),
 		}
 
 		vmenv := core.NewEnv(statedb, s.eth.chainConfig, s.eth.BlockChain(), msg, block.Header())
@@ -2159,6 +2392,100 @@ func (s *PublicDebugAPI) computeTxEnv(blockHash common.Hash, txIndex int) (core.
 	return nil, nil, fmt.Errorf("tx index %d out of range for block %x", txIndex, blockHash)
 }
 
+// StateDiffValue holds the value of a state item before and after a block or
+// transaction.
+type StateDiffValue struct {
+	Pre  interface{} `json:"pre"`
+	Post interface{} `json:"post"`
+}
+
+// StateDiffAccount holds the modified fields and storage slots of an account,
+// the unmodified ones are omitted.
+type StateDiffAccount struct {
+	Exists  *StateDiffValue            `json:"exists,omitempty"`
+	Balance *StateDiffValue            `json:"balance,omitempty"`
+	Nonce   *StateDiffValue            `json:"nonce,omitempty"`
+	Code    *StateDiffValue            `json:"code,omitempty"`
+	Storage map[string]*StateDiffValue `json:"storage,omitempty"`
+}
+
+// StateDiffBlock returns the accounts, balances, nonces, code and storage slots
+// modified by the given block, mining rewards included.
+func (api *PublicDebugAPI) StateDiffBlock(number uint64) (map[common.Address]*StateDiffAccount, error) {
+	block := api.eth.BlockChain().GetBlockByNumber(number)
+	if block == nil {
+		return nil, fmt.Errorf("block #%d not found", number)
+	}
+	diffs, rewards, err := api.processDiffs(block)
+	if err != nil {
+		return nil, err
+	}
+	return formatStateDiff(state.MergeDiffs(append(diffs, rewards)...)), nil
+}
+
+// StateDiffTransaction returns the accounts, balances, nonces, code and storage
+// slots modified by the given transaction.
+func (api *PublicDebugAPI) StateDiffTransaction(txHash common.Hash) (map[common.Address]*StateDiffAccount, error) {
+	tx, blockHash, _, txIndex := core.GetTransaction(api.eth.ChainDb(), txHash)
+	if tx == nil {
+		return nil, fmt.Errorf("tx '%x' not found", txHash)
+	}
+	block := api.eth.BlockChain().GetBlock(blockHash)
+	if block == nil {
+		return nil, fmt.Errorf("block %x not found", blockHash)
+	}
+	diffs, _, err := api.processDiffs(block)
+	if err != nil {
+		return nil, err
+	}
+	if txIndex >= uint64(len(diffs)) {
+		return nil, fmt.Errorf("tx index %d out of range for block %x", txIndex, blockHash)
+	}
+	return formatStateDiff(diffs[txIndex]), nil
+}
+
+// processDiffs reprocesses the block on top of its parent state, returning the
+// state changes of each transaction and of the rewards.
+func (api *PublicDebugAPI) processDiffs(block *types.Block) ([]state.Diff, state.Diff, error) {
+	parent := api.eth.BlockChain().GetBlock(block.ParentHash())
+	if parent == nil {
+		return nil, nil, fmt.Errorf("block parent %x not found", block.ParentHash())
+	}
+	statedb, err := api.eth.BlockChain().StateAt(parent.Root())
+	if err != nil {
+		return nil, nil, err
+	}
+	return core.NewStateProcessor(api.eth.chainConfig, api.eth.BlockChain()).ProcessDiffs(block, statedb)
+}
+
+// formatStateDiff converts a state diff into its RPC representation.
+func formatStateDiff(diff state.Diff) map[common.Address]*StateDiffAccount {
+	result := make(map[common.Address]*StateDiffAccount, len(diff))
+	for addr, acc := range diff {
+		res := new(StateDiffAccount)
+		if acc.Pre.Exists != acc.Post.Exists {
+			res.Exists = &StateDiffValue{acc.Pre.Exists, acc.Post.Exists}
+		}
+		if acc.Pre.Balance.Cmp(acc.Post.Balance) != 0 {
+			res.Balance = &StateDiffValue{rpc.NewHexNumber(acc.Pre.Balance), rpc.NewHexNumber(acc.Post.Balance)}
+		}
+		if acc.Pre.Nonce != acc.Post.Nonce {
+			res.Nonce = &StateDiffValue{rpc.NewHexNumber(acc.Pre.Nonce), rpc.NewHexNumber(acc.Post.Nonce)}
+		}
+		if !bytes.Equal(acc.Pre.Code, acc.Post.Code) {
+			res.Code = &StateDiffValue{"0x" + common.Bytes2Hex(acc.Pre.Code), "0x" + common.Bytes2Hex(acc.Post.Code)}
+		}
+		if len(acc.Storage) > 0 {
+			res.Storage = make(map[string]*StateDiffValue, len(acc.Storage))
+			for key, slot := range acc.Storage {
+				res.Storage[key.Hex()] = &StateDiffValue{slot.Pre, slot.Post}
+			}
+		}
+		result[addr] = res
+	}
+	return result
+}
+
 // PublicNetAPI offers network related RPC methods
 type PublicNetAPI struct {
 	net            *p2p.Server
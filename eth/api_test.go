@@ -0,0 +1,276 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package eth
+
+import (
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/eth-classic/go-ethereum/common"
+	"github.com/eth-classic/go-ethereum/core"
+	"github.com/eth-classic/go-ethereum/core/types"
+	"github.com/eth-classic/go-ethereum/crypto"
+	"github.com/eth-classic/go-ethereum/ethdb"
+	"github.com/eth-classic/go-ethereum/event"
+)
+
+// testAPIChainConfig activates replay protection and the EIP-2929 and EIP-2930
+// access lists from the genesis block.
+const testAPIChainConfig = `{
+	"forks": [{
+		"name": "Magneto",
+		"block": 0,
+		"features": [
+			{"id": "eip155", "options": {"chainID": 61}},
+			{"id": "eip2929", "options": {}},
+			{"id": "eip2930", "options": {}}
+		]
+	}]
+}`
+
+// newTestAPIBackend creates an Ethereum service around a chain of the given
+// number of blocks, funding testBank and generated by gen. The chain keeps the
+// recent states in its trie cache, like a --gcmode=full node.
+func newTestAPIBackend(t *testing.T, blocks int, gen func(int, *core.BlockGen)) *Ethereum {
+	config := new(core.ChainConfig)
+	if err := json.Unmarshal([]byte(testAPIChainConfig), config); err != nil {
+		t.Fatalf("failed to parse chain config: %v", err)
+	}
+	var (
+		funds    = core.GenesisAccount{Address: testBank.Address, Balance: big.NewInt(1000000000000000000)}
+		db, _    = ethdb.NewMemDatabase()
+		gendb, _ = ethdb.NewMemDatabase()
+	)
+	core.WriteGenesisBlockForTesting(db, funds)
+	chain, _ := core.GenerateChain(config, core.WriteGenesisBlockForTesting(gendb, funds), gendb, blocks, gen)
+
+	cacheConfig := &core.CacheConfig{TrieNodeLimit: 256 * 1024 * 1024, TrieTimeLimit: time.Hour}
+	blockchain, err := core.NewBlockChainWithCacheConfig(db, cacheConfig, config, new(core.FakePow), new(event.TypeMux))
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	if res := blockchain.InsertChain(chain); res.Error != nil {
+		t.Fatalf("failed to insert block %d: %v", res.Index, res.Error)
+	}
+	return &Ethereum{chainConfig: config, chainDb: db, blockchain: blockchain}
+}
+
+// Tests that transactions are replayed with their access list.
+func TestComputeTxEnvAccessList(t *testing.T) {
+	var (
+		to   = common.Address{0x01}
+		list = types.AccessList{{Address: common.Address{0x02}, StorageKeys: []common.Hash{{0x03}}}}
+	)
+	eth := newTestAPIBackend(t, 1, func(i int, block *core.BlockGen) {
+		tx := types.NewAccessListTransaction(big.NewInt(61), block.TxNonce(testBank.Address), &to, big.NewInt(1), big.NewInt(50000), new(big.Int), nil, list)
+		tx, err := tx.SignECDSA(testBankKey)
+		if err != nil {
+			t.Fatalf("failed to sign transaction: %v", err)
+		}
+		block.AddTx(tx)
+	})
+	defer eth.blockchain.Stop()
+
+	block := eth.blockchain.GetBlockByNumber(1)
+	api := NewPublicDebugAPI(eth)
+
+	msg, _, err := api.computeTxEnv(block.Hash(), 0)
+	if err != nil {
+		t.Fatalf("failed to compute transaction environment: %v", err)
+	}
+	if !reflect.DeepEqual(msg.AccessList(), list) {
+		t.Errorf("access list mismatch: have %v, want %v", msg.AccessList(), list)
+	}
+	// The intrinsic gas includes the access list: one address and one slot
+	res, err := api.TraceTransaction(block.Transactions()[0].Hash())
+	if err != nil {
+		t.Fatalf("failed to trace transaction: %v", err)
+	}
+	if want := big.NewInt(21000 + 2400 + 1900); res.Gas.Cmp(want) != 0 {
+		t.Errorf("gas mismatch: have %v, want %v", res.Gas, want)
+	}
+}
+
+// signTestTx signs a legacy transaction of the test bank for the test chain.
+func signTestTx(t *testing.T, block *core.BlockGen, to *common.Address, value *big.Int, gas int64, data []byte) *types.Transaction {
+	var tx *types.Transaction
+	if to == nil {
+		tx = types.NewContractCreation(block.TxNonce(testBank.Address), value, big.NewInt(gas), new(big.Int), data)
+	} else {
+		tx = types.NewTransaction(block.TxNonce(testBank.Address), *to, value, big.NewInt(gas), new(big.Int), data)
+	}
+	tx, err := tx.WithSigner(types.NewChainIdSigner(big.NewInt(61))).SignECDSA(testBankKey)
+	if err != nil {
+		t.Fatalf("failed to sign transaction: %v", err)
+	}
+	return tx
+}
+
+// Tests that the accounts of a recent state are returned in pages in the order
+// of their hash, with the addresses of the known preimages.
+func TestAccountRange(t *testing.T) {
+	recipients := make([]common.Address, 5)
+	for i := range recipients {
+		recipients[i] = common.Address{byte(i + 1)}
+	}
+	eth := newTestAPIBackend(t, 1, func(i int, block *core.BlockGen) {
+		for j := range recipients {
+			block.AddTx(signTestTx(t, block, &recipients[j], big.NewInt(1), 21000, nil))
+		}
+	})
+	defer eth.blockchain.Stop()
+
+	// Forget the preimage of the bank address
+	if err := core.PreimageTable(eth.chainDb).Delete(crypto.Keccak256(testBank.Address[:])); err != nil {
+		t.Fatalf("failed to delete preimage: %v", err)
+	}
+	api := NewPublicDebugAPI(eth)
+
+	// The bank, the coinbase and the recipients, three at a time
+	var (
+		start    common.Hash
+		hashes   []string
+		resolved = make(map[common.Address]bool)
+	)
+	for page := 0; ; page++ {
+		res, err := api.AccountRange(1, start, 3)
+		if err != nil {
+			t.Fatalf("page %d: failed to retrieve accounts: %v", page, err)
+		}
+		if len(res.Accounts) > 3 {
+			t.Fatalf("page %d: too many accounts: %d", page, len(res.Accounts))
+		}
+		var keys []string
+		for hash, entry := range res.Accounts {
+			keys = append(keys, hash)
+			if entry.Address != nil {
+				if crypto.Keccak256Hash(entry.Address[:]).Hex() != hash {
+					t.Errorf("page %d: address %x doesn't match hash %s", page, *entry.Address, hash)
+				}
+				resolved[*entry.Address] = true
+			}
+		}
+		sort.Strings(keys)
+		if len(hashes) > 0 && len(keys) > 0 && keys[0] <= hashes[len(hashes)-1] {
+			t.Errorf("page %d: accounts not after the previous page", page)
+		}
+		hashes = append(hashes, keys...)
+
+		if res.Next == nil {
+			break
+		}
+		if len(res.Accounts) != 3 {
+			t.Errorf("page %d: next key set for partial page of %d accounts", page, len(res.Accounts))
+		}
+		if res.Next.Hex() <= keys[len(keys)-1] {
+			t.Errorf("page %d: next key %x not after the page", page, *res.Next)
+		}
+		start = *res.Next
+	}
+	if len(hashes) != 2+len(recipients) {
+		t.Errorf("account count mismatch: have %d, want %d", len(hashes), 2+len(recipients))
+	}
+	for _, addr := range recipients {
+		if !resolved[addr] {
+			t.Errorf("address %x not resolved", addr)
+		}
+	}
+	if resolved[testBank.Address] {
+		t.Errorf("address without preimage resolved")
+	}
+	if _, err := api.AccountRange(2, common.Hash{}, 3); err == nil {
+		t.Errorf("missing block accepted")
+	}
+}
+
+// Tests that the storage of a contract is returned in pages as found before the
+// given transaction, with the keys of the known preimages.
+func TestStorageRangeAt(t *testing.T) {
+	// Init code storing the values 1-5 in the slots 1-5
+	var code []byte
+	for i := byte(1); i <= 5; i++ {
+		code = append(code, 0x60, i, 0x60, i, 0x55)
+	}
+	contract := crypto.CreateAddress(testBank.Address, 0)
+	eth := newTestAPIBackend(t, 2, func(i int, block *core.BlockGen) {
+		switch i {
+		case 0:
+			block.AddTx(signTestTx(t, block, nil, big.NewInt(1), 200000, code))
+		case 1:
+			// A call to the contract, the range is taken before it
+			block.AddTx(signTestTx(t, block, &contract, new(big.Int), 50000, nil))
+		}
+	})
+	defer eth.blockchain.Stop()
+
+	// Forget the preimage of the first slot key
+	if err := core.PreimageTable(eth.chainDb).Delete(crypto.Keccak256(common.Hash{31: 1}.Bytes())); err != nil {
+		t.Fatalf("failed to delete preimage: %v", err)
+	}
+	api := NewPublicDebugAPI(eth)
+	block := eth.blockchain.GetBlockByNumber(2)
+
+	var (
+		start common.Hash
+		slots = make(map[string]*StorageRangeEntry)
+		pages int
+	)
+	for {
+		res, err := api.StorageRangeAt(block.Hash(), 0, contract, start, 2)
+		if err != nil {
+			t.Fatalf("failed to retrieve storage: %v", err)
+		}
+		for hash, entry := range res.Storage {
+			slots[hash] = entry
+		}
+		pages++
+		if res.Next == nil {
+			break
+		}
+		start = *res.Next
+	}
+	if pages != 3 {
+		t.Errorf("page count mismatch: have %d, want 3", pages)
+	}
+	if len(slots) != 5 {
+		t.Fatalf("slot count mismatch: have %d, want 5", len(slots))
+	}
+	for i := byte(1); i <= 5; i++ {
+		key := common.Hash{31: i}
+		entry := slots[crypto.Keccak256Hash(key[:]).Hex()]
+		if entry == nil {
+			t.Errorf("slot %d missing", i)
+			continue
+		}
+		if entry.Value != (common.Hash{31: i}) {
+			t.Errorf("slot %d: value mismatch: have %x", i, entry.Value)
+		}
+		if (entry.Key == nil) != (i == 1) {
+			t.Errorf("slot %d: key resolution mismatch: have %v", i, entry.Key)
+		}
+		if entry.Key != nil && *entry.Key != key {
+			t.Errorf("slot %d: key mismatch: have %x", i, *entry.Key)
+		}
+	}
+	if _, err := api.StorageRangeAt(block.Hash(), 0, common.Address{0xff}, common.Hash{}, 2); err == nil {
+		t.Errorf("missing contract accepted")
+	}
+}
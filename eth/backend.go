@@ -18,7 +18,6 @@
 package eth
 
 import (
-	"bytes"
 	"errors"
 	"fmt"
 	"math/big"
@@ -34,6 +33,7 @@ import (
 	"github.com/eth-classic/go-ethereum/common/compiler"
 	"github.com/eth-classic/go-ethereum/common/httpclient"
 	"github.com/eth-classic/go-ethereum/common/registrar/ethreg"
+	"github.com/eth-classic/go-ethereum/consensus/clique"
 	"github.com/eth-classic/go-ethereum/core"
 	"github.com/eth-classic/go-ethereum/core/types"
 	"github.com/eth-classic/go-ethereum/eth/downloader"
@@ -45,16 +45,14 @@ import (
 	"github.com/eth-classic/go-ethereum/miner"
 	"github.com/eth-classic/go-ethereum/node"
 	"github.com/eth-classic/go-ethereum/p2p"
+	"github.com/eth-classic/go-ethereum/pow"
+	"github.com/eth-classic/go-ethereum/pow/etchash"
 	"github.com/eth-classic/go-ethereum/rlp"
 	"github.com/eth-classic/go-ethereum/rpc"
 )
 
 const (
-	epochLength    = 30000
-	ethashRevision = 23
-
 	autoDAGcheckInterval = 10 * time.Hour
-	autoDAGepochHeight   = epochLength / 2
 )
 
 type Config struct {
@@ -69,12 +67,19 @@ type Config struct {
 	SkipBcVersionCheck bool // e.g. blockchain export
 	DatabaseCache      int
 	DatabaseHandles    int
-
-	NatSpec   bool
-	DocRoot   string
-	AutoDAG   bool
-	PowTest   bool
-	PowShared bool
+	NoPruning          bool          // Whether to disable state trie garbage collection and flush every trie to disk
+	TrieCache          int           // Megabytes of memory allowed for caching state trie nodes
+	TrieTimeout        time.Duration // Time after which the cached state trie of a block is flushed to disk
+	Snapshot           bool          // Whether to maintain a flat state snapshot for fast account and storage reads
+	AncientThreshold   uint64        // Number of recent blocks kept out of the ancient store, 0 to not move any there
+
+	NatSpec     bool
+	DocRoot     string
+	AutoDAG     bool
+	PowTest     bool
+	PowShared   bool
+	Clique      bool // Seal blocks with the clique proof-of-authority engine of the chain configuration
+	DisableMESS bool // Never enforce MESS (ECBP-1100) artificial finality, even if the chain configuration activates it
 
 	AccountManager *accounts.Manager
 	Etherbase      common.Address
@@ -106,12 +111,14 @@ type Ethereum struct {
 	dappDb    ethdb.Database // Dapp database
 	indexesDb ethdb.Database // Indexes database (optional -- eg. add-tx indexes)
 
+	bloomIndexer *core.ChainIndexer // Bloom bits index of the chain for log filtering, nil if read-only
+
 	// Handlers
 	txPool          *core.TxPool
 	txMu            sync.Mutex
 	blockchain      *core.BlockChain
 	accountManager  *accounts.Manager
-	pow             *ethash.Ethash
+	pow             pow.PoW
 	protocolManager *ProtocolManager
 	SolcPath        string
 	solc            *compiler.Solidity
@@ -142,15 +149,22 @@ type Ethereum struct {
 
 func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 	// Open the chain database and perform any upgrades needed
-	chainDb, err := ctx.OpenDatabase("chaindata", config.DatabaseCache, config.DatabaseHandles)
+	chainDb, err := ctx.OpenDatabaseWithFreezer("chaindata", config.DatabaseCache, config.DatabaseHandles)
 	if err != nil {
 		return nil, err
 	}
-	if err := upgradeChainDatabase(chainDb); err != nil {
-		return nil, err
-	}
-	if err := addMipmapBloomBins(chainDb); err != nil {
-		return nil, err
+	// A read-only database is served as is, log filtering may miss older blocks
+	if ctx.ReadOnly() {
+		if pending := core.PendingMigrations(chainDb); len(pending) > 0 {
+			glog.V(logger.Warn).Infof("Serving read-only database at version %d, %d migrations pending", core.GetDatabaseVersion(chainDb), len(pending))
+		}
+	} else {
+		if _, err := core.MigrateDatabase(chainDb, false); err != nil {
+			return nil, err
+		}
+		if err := addMipmapBloomBins(chainDb); err != nil {
+			return nil, err
+		}
 	}
 
 	dappDb, err := ctx.OpenDatabase("dapp", config.DatabaseCache, config.DatabaseHandles)
@@ -168,7 +182,7 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 	}()))
 
 	// Load up any custom genesis block if requested
-	if config.Genesis != nil {
+	if config.Genesis != nil && !ctx.ReadOnly() {
 		_, err := core.WriteGenesisBlock(chainDb, config.Genesis)
 		if err != nil {
 			return nil, err
@@ -191,7 +205,9 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 		if bcVersion != config.BlockChainVersion && bcVersion != 0 {
 			return nil, fmt.Errorf("Blockchain DB version mismatch (%d / %d). Run geth upgradedb.\n", bcVersion, config.BlockChainVersion)
 		}
-		core.WriteBlockChainVersion(chainDb, config.BlockChainVersion)
+		if !ctx.ReadOnly() {
+			core.WriteBlockChainVersion(chainDb, config.BlockChainVersion)
+		}
 	}
 	glog.V(logger.Info).Infof("Blockchain DB Version: %d", config.BlockChainVersion)
 
@@ -207,7 +223,7 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 		NatSpec:                 config.NatSpec,
 		MinerThreads:            config.MinerThreads,
 		SolcPath:                config.SolcPath,
-		AutoDAG:                 config.AutoDAG,
+		AutoDAG:                 config.AutoDAG && !config.Clique, // clique chains have no DAG
 		PowTest:                 config.PowTest,
 		GpoMinGasPrice:          config.GpoMinGasPrice,
 		GpoMaxGasPrice:          config.GpoMaxGasPrice,
@@ -218,18 +234,26 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 		httpclient:              httpclient.New(config.DocRoot),
 	}
 	switch {
+	case config.Clique:
+		if config.ChainConfig.Clique == nil {
+			return nil, errors.New("clique consensus requires a clique chain configuration")
+		}
+		glog.V(logger.Info).Infof("Consensus: clique proof-of-authority")
+		eth.pow = clique.New(config.ChainConfig.Clique, chainDb)
 	case config.PowTest:
 		glog.V(logger.Info).Infof("Consensus: ethash used in test mode")
-		eth.pow, err = ethash.NewForTesting()
+		engine, err := ethash.NewForTesting()
 		if err != nil {
 			return nil, err
 		}
+		// the small test mode caches are not subject to ECIP-1099
+		eth.pow = etchash.New(engine, nil)
 	case config.PowShared:
 		glog.V(logger.Info).Infof("Consensus: ethash used in shared mode")
-		eth.pow = ethash.NewShared()
+		eth.pow = etchash.New(ethash.NewShared(), config.ChainConfig.ECIP1099Block())
 
 	default:
-		eth.pow = ethash.New()
+		eth.pow = etchash.New(ethash.New(), config.ChainConfig.ECIP1099Block())
 	}
 
 	// Initialize indexes db if enabled
@@ -253,6 +277,9 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 	// load the genesis block or write a new one if no genesis
 	// block is present in the database.
 	genesis := core.GetBlock(chainDb, core.GetCanonicalHash(chainDb, 0))
+	if genesis == nil && ctx.ReadOnly() {
+		return nil, errors.New("No chain found in the read-only data directory.")
+	}
 	if genesis == nil {
 		genesis, err = core.WriteGenesisBlock(chainDb, core.DefaultConfigMainnet.Genesis)
 		if err != nil {
@@ -280,13 +307,27 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 
 	eth.chainConfig = config.ChainConfig
 
-	eth.blockchain, err = core.NewBlockChain(chainDb, eth.chainConfig, eth.pow, eth.EventMux())
+	cacheConfig := &core.CacheConfig{
+		Disabled:      config.NoPruning,
+		TrieNodeLimit: common.StorageSize(config.TrieCache) * 1024 * 1024,
+		TrieTimeLimit: config.TrieTimeout,
+		Snapshot:      config.Snapshot,
+
+		AncientThreshold: config.AncientThreshold,
+		ReadOnly:         ctx.ReadOnly(),
+	}
+	eth.blockchain, err = core.NewBlockChainWithCacheConfig(chainDb, cacheConfig, eth.chainConfig, eth.pow, eth.EventMux())
 	if err != nil {
 		if err == core.ErrNoGenesis {
 			return nil, fmt.Errorf(`No chain found. Please initialise a new chain using the "init" subcommand.`)
 		}
 		return nil, err
 	}
+	// Index the bloom bits of the chain in the background for fast log filtering
+	if !ctx.ReadOnly() {
+		eth.bloomIndexer = core.NewBloomIndexer(chainDb)
+		eth.bloomIndexer.Start(eth.blockchain.CurrentHeader(), eth.EventMux())
+	}
 	// Configure enabled atxi for blockchain
 	if config.UseAddrTxIndex {
 		eth.blockchain.SetAtxi(&core.AtxiT{
@@ -302,6 +343,7 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 	if eth.protocolManager, err = NewProtocolManager(eth.chainConfig, config.SyncMode, uint64(config.NetworkId), eth.eventMux, eth.txPool, eth.pow, eth.blockchain, chainDb); err != nil {
 		return nil, err
 	}
+	eth.protocolManager.artificialFinality = !config.DisableMESS
 	eth.miner = miner.New(eth, eth.chainConfig, eth.EventMux(), eth.pow)
 	if err = eth.miner.SetGasPrice(config.GasPrice); err != nil {
 		return nil, err
@@ -313,7 +355,7 @@ func New(ctx *node.ServiceContext, config *Config) (*Ethereum, error) {
 // APIs returns the collection of RPC services the ethereum package offers.
 // NOTE, some of these services probably need to be moved to somewhere else.
 func (s *Ethereum) APIs() []rpc.API {
-	return []rpc.API{
+	apis := []rpc.API{
 		{
 			Namespace: "eth",
 			Version:   "1.0",
@@ -389,6 +431,10 @@ func (s *Ethereum) APIs() []rpc.API {
 			Public:    true,
 		},
 	}
+	if engine, ok := s.pow.(*clique.Clique); ok {
+		apis = append(apis, engine.APIs(s.blockchain)...)
+	}
+	return apis
 }
 
 func (s *Ethereum) ResetWithGenesisBlock(gb *types.Block) {
@@ -413,6 +459,15 @@ func (self *Ethereum) SetEtherbase(etherbase common.Address) {
 	self.miner.SetEtherbase(etherbase)
 }
 
+// authorizeSigner hands the etherbase account to the clique engine to sign blocks
+// with, if the chain is sealed by proof-of-authority. The account has to be
+// unlocked for sealing to succeed.
+func (s *Ethereum) authorizeSigner(eb common.Address) {
+	if engine, ok := s.pow.(*clique.Clique); ok {
+		engine.Authorize(eb, s.accountManager.Sign)
+	}
+}
+
 func (s *Ethereum) StopMining()         { s.miner.Stop() }
 func (s *Ethereum) IsMining() bool      { return s.miner.Mining() }
 func (s *Ethereum) Miner() *miner.Miner { return s.miner }
@@ -449,6 +504,9 @@ func (s *Ethereum) Start(srvr *p2p.Server) error {
 // Stop implements node.Service, terminating all internal goroutines used by the
 // Ethereum protocol.
 func (s *Ethereum) Stop() error {
+	if s.bloomIndexer != nil {
+		s.bloomIndexer.Close()
+	}
 	s.blockchain.Stop()
 	s.protocolManager.Stop()
 	s.txPool.Stop()
@@ -471,9 +529,10 @@ func (s *Ethereum) WaitForShutdown() {
 
 // StartAutoDAG() spawns a go routine that checks the DAG every autoDAGcheckInterval
 // by default that is 10 times per epoch
-// in epoch n, if we past autoDAGepochHeight within-epoch blocks,
-// it calls ethash.MakeDAG  to pregenerate the DAG for the next epoch n+1
-// if it does not exist yet as well as remove the DAG for epoch n-1
+// in epoch n, if we past half of the epoch length within-epoch blocks,
+// it calls etchash.MakeDAG to pregenerate the DAG for the next epoch n+1
+// if it does not exist yet as well as remove the DAG for epoch n-1.
+// The epoch length follows ECIP-1099 if the chain configuration schedules it.
 // the loop quits if autodagquit channel is closed, it can safely restart and
 // stop any number of times.
 // For any more sophisticated pattern of DAG generation, use CLI subcommand
@@ -484,7 +543,8 @@ func (self *Ethereum) StartAutoDAG() {
 	}
 	go func() {
 		glog.V(logger.Info).Infof("Automatic pregeneration of ethash DAG ON (ethash dir: %s)", ethash.DefaultDir)
-		var nextEpoch uint64
+		var nextEpochBlock uint64 // first block of the next epoch
+		ecip1099Block := self.chainConfig.ECIP1099Block()
 		timer := time.After(0)
 		self.autodagquit = make(chan bool)
 		for {
@@ -492,26 +552,27 @@ func (self *Ethereum) StartAutoDAG() {
 			case <-timer:
 				glog.V(logger.Info).Infof("checking DAG (ethash dir: %s)", ethash.DefaultDir)
 				currentBlock := self.BlockChain().CurrentBlock().NumberU64()
-				thisEpoch := currentBlock / epochLength
-				if nextEpoch <= thisEpoch {
-					if currentBlock%epochLength > autoDAGepochHeight {
-						if thisEpoch > 0 {
-							previousDag, previousDagFull := dagFiles(thisEpoch - 1)
+				epochLength := etchash.EpochLength(currentBlock, ecip1099Block)
+				thisEpochBlock := currentBlock - currentBlock%epochLength
+				if nextEpochBlock <= thisEpochBlock {
+					if currentBlock%epochLength > epochLength/2 {
+						if thisEpochBlock > 0 {
+							previousDag, previousDagFull := dagFiles(thisEpochBlock-1, ecip1099Block)
 							os.Remove(filepath.Join(ethash.DefaultDir, previousDag))
 							os.Remove(filepath.Join(ethash.DefaultDir, previousDagFull))
-							glog.V(logger.Info).Infof("removed DAG for epoch %d (%s)", thisEpoch-1, previousDag)
+							glog.V(logger.Info).Infof("removed DAG for epoch of block %d (%s)", thisEpochBlock-1, previousDag)
 						}
-						nextEpoch = thisEpoch + 1
-						dag, _ := dagFiles(nextEpoch)
+						nextEpochBlock = thisEpochBlock + epochLength
+						dag, _ := dagFiles(nextEpochBlock, ecip1099Block)
 						if _, err := os.Stat(dag); os.IsNotExist(err) {
-							glog.V(logger.Info).Infof("Pregenerating DAG for epoch %d (%s)", nextEpoch, dag)
-							err := ethash.MakeDAG(nextEpoch*epochLength, "") // "" -> ethash.DefaultDir
+							glog.V(logger.Info).Infof("Pregenerating DAG for epoch of block %d (%s)", nextEpochBlock, dag)
+							err := etchash.MakeDAG(nextEpochBlock, "", ecip1099Block) // "" -> ethash.DefaultDir
 							if err != nil {
-								glog.V(logger.Error).Infof("Error generating DAG for epoch %d (%s)", nextEpoch, dag)
+								glog.V(logger.Error).Infof("Error generating DAG for epoch of block %d (%s)", nextEpochBlock, dag)
 								return
 							}
 						} else {
-							glog.V(logger.Error).Infof("DAG for epoch %d (%s)", nextEpoch, dag)
+							glog.V(logger.Error).Infof("DAG for epoch of block %d (%s)", nextEpochBlock, dag)
 						}
 					}
 				}
@@ -553,73 +614,14 @@ func (self *Ethereum) SetSolc(solcPath string) (*compiler.Solidity, error) {
 	return self.Solc()
 }
 
-// dagFiles(epoch) returns the two alternative DAG filenames (not a path)
+// dagFiles(block, ecip1099Block) returns the two alternative DAG filenames (not a path)
+// of the epoch containing block
 // 1) <revision>-<hex(seedhash[8])> 2) full-R<revision>-<hex(seedhash[8])>
-func dagFiles(epoch uint64) (string, string) {
-	seedHash, _ := ethash.GetSeedHash(epoch * epochLength)
-	dag := fmt.Sprintf("full-R%d-%x", ethashRevision, seedHash[:8])
+func dagFiles(block uint64, ecip1099Block *big.Int) (string, string) {
+	dag, _ := etchash.DAGFileName(block, ecip1099Block)
 	return dag, "full-R" + dag
 }
 
-// upgradeChainDatabase ensures that the chain database stores block split into
-// separate header and body entries.
-func upgradeChainDatabase(db ethdb.Database) error {
-	// Short circuit if the head block is stored already as separate header and body
-	data, err := db.Get([]byte("LastBlock"))
-	if err != nil {
-		return nil
-	}
-	head := common.BytesToHash(data)
-
-	if block := core.GetBlockByHashOld(db, head); block == nil {
-		return nil
-	}
-	// At least some of the database is still the old format, upgrade (skip the head block!)
-	glog.V(logger.Info).Info("Old database detected, upgrading...")
-
-	if db, ok := db.(*ethdb.LDBDatabase); ok {
-		blockPrefix := []byte("block-hash-")
-		for it := db.NewIterator(); it.Next(); {
-			// Skip anything other than a combined block
-			if !bytes.HasPrefix(it.Key(), blockPrefix) {
-				continue
-			}
-			// Skip the head block (merge last to signal upgrade completion)
-			if bytes.HasSuffix(it.Key(), head.Bytes()) {
-				continue
-			}
-			// Load the block, split and serialize (order!)
-			block := core.GetBlockByHashOld(db, common.BytesToHash(bytes.TrimPrefix(it.Key(), blockPrefix)))
-
-			if err := core.WriteTd(db, block.Hash(), block.DeprecatedTd()); err != nil {
-				return err
-			}
-			if err := core.WriteBody(db, block.Hash(), block.Body()); err != nil {
-				return err
-			}
-			if err := core.WriteHeader(db, block.Header()); err != nil {
-				return err
-			}
-			if err := db.Delete(it.Key()); err != nil {
-				return err
-			}
-		}
-		// Lastly, upgrade the head block, disabling the upgrade mechanism
-		current := core.GetBlockByHashOld(db, head)
-
-		if err := core.WriteTd(db, current.Hash(), current.DeprecatedTd()); err != nil {
-			return err
-		}
-		if err := core.WriteBody(db, current.Hash(), current.Body()); err != nil {
-			return err
-		}
-		if err := core.WriteHeader(db, current.Header()); err != nil {
-			return err
-		}
-	}
-	return nil
-}
-
 func addMipmapBloomBins(db ethdb.Database) (err error) {
 	const mipmapVersion uint = 2
 
@@ -39,6 +39,7 @@ func (s *Ethereum) StartMining(threads int, gpus string) error {
 	if gpus != "" {
 		return errors.New("GPU mining disabled. " + disabledInfo)
 	}
+	s.authorizeSigner(eb)
 
 	// CPU mining
 	go s.miner.Start(eb, threads)
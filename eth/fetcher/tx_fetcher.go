@@ -0,0 +1,428 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package fetcher
+
+import (
+	"time"
+
+	"github.com/eth-classic/go-ethereum/common"
+	"github.com/eth-classic/go-ethereum/core/types"
+	"github.com/eth-classic/go-ethereum/logger"
+	"github.com/eth-classic/go-ethereum/logger/glog"
+)
+
+const (
+	MaxTransactionFetch = 256 // Amount of transactions to be fetched per retrieval request
+
+	txArriveTimeout = 500 * time.Millisecond // Time allowance before an announced transaction is explicitly requested
+	txFetchTimeout  = 5 * time.Second        // Maximum allotted time to return an explicitly requested transaction
+	maxTxAnnounces  = 4096                   // Maximum number of unique transactions a peer may have announced
+)
+
+// txRetrievalFn is a callback type for retrieving a transaction from the local
+// transaction pool.
+type txRetrievalFn func(common.Hash) *types.Transaction
+
+// txAdderFn is a callback type to deliver a batch of transactions to the pool.
+type txAdderFn func([]*types.Transaction)
+
+// txRequesterFn is a callback type for sending a pooled transaction retrieval
+// request to a given peer.
+type txRequesterFn func(peer string, hashes []common.Hash) error
+
+// txAnnounce is the notification of the availability of a batch of new
+// transactions in the network.
+type txAnnounce struct {
+	origin string        // Identifier of the peer originating the notification
+	hashes []common.Hash // Transaction hashes being announced
+}
+
+// txDelivery is the notification that a batch of transactions have been added
+// to the pool and should be forgotten by the fetcher.
+type txDelivery struct {
+	origin string        // Identifier of the peer delivering the transactions
+	hashes []common.Hash // Hashes of the delivered transactions
+	direct bool          // Whether this is a reply to a retrieval request
+}
+
+// txFilter is a query for the transactions requested from a peer, used to drop
+// the unsolicited ones from its replies.
+type txFilter struct {
+	origin    string                        // Identifier of the peer delivering the reply
+	requested chan map[common.Hash]struct{} // Channel to return the in-flight request's transactions on
+}
+
+// txRequest is an in-flight pooled transaction retrieval.
+type txRequest struct {
+	hashes []common.Hash // Transactions requested from the peer
+	time   time.Time     // Timestamp of the request
+}
+
+// TxFetcher is responsible for retrieving new transactions based on announcements.
+//
+// Announced transactions are not requested immediately, rather the fetcher waits
+// a short while so the transaction has a chance to arrive through a direct
+// broadcast. Afterwards it is requested from one of the announcing peers at a
+// time, falling back to the others if the retrieval fails or times out.
+type TxFetcher struct {
+	notify  chan *txAnnounce
+	cleanup chan *txDelivery
+	filter  chan *txFilter
+	drop    chan string
+	quit    chan struct{}
+
+	// Announce states
+	announces map[string]map[common.Hash]struct{} // Set of announced transactions, grouped by origin peer
+	announced map[common.Hash]map[string]struct{} // Set of download locations, grouped by transaction hash
+	waittime  map[common.Hash]time.Time           // Timestamps of the first announcement of a transaction
+
+	// Retrieval states
+	fetching map[common.Hash]string // Transactions currently being retrieved, mapped to the source peer
+	requests map[string]*txRequest  // In-flight transaction retrievals, grouped by peer
+
+	// Callbacks
+	getTx    txRetrievalFn // Retrieves a transaction from the local pool
+	addTxs   txAdderFn     // Injects a batch of transactions into the pool
+	fetchTxs txRequesterFn // Retrieves a set of transactions from a remote peer
+
+	// Testing hooks
+	fetchingHook func(string, []common.Hash) // Method to call upon starting a transaction retrieval
+}
+
+// NewTxFetcher creates a transaction fetcher to retrieve transactions based on
+// hash announcements.
+func NewTxFetcher(getTx txRetrievalFn, addTxs txAdderFn, fetchTxs txRequesterFn) *TxFetcher {
+	return &TxFetcher{
+		notify:    make(chan *txAnnounce),
+		cleanup:   make(chan *txDelivery),
+		filter:    make(chan *txFilter),
+		drop:      make(chan string),
+		quit:      make(chan struct{}),
+		announces: make(map[string]map[common.Hash]struct{}),
+		announced: make(map[common.Hash]map[string]struct{}),
+		waittime:  make(map[common.Hash]time.Time),
+		fetching:  make(map[common.Hash]string),
+		requests:  make(map[string]*txRequest),
+		getTx:     getTx,
+		addTxs:    addTxs,
+		fetchTxs:  fetchTxs,
+	}
+}
+
+// Start boots up the announcement based transaction retrieval, accepting and
+// processing hash notifications and deliveries until termination requested.
+func (f *TxFetcher) Start() {
+	go f.loop()
+}
+
+// Stop terminates the announcement based transaction retrieval, canceling all
+// pending operations.
+func (f *TxFetcher) Stop() {
+	close(f.quit)
+}
+
+// Notify announces the fetcher of the potential availability of a batch of new
+// transactions in the network.
+func (f *TxFetcher) Notify(peer string, hashes []common.Hash) error {
+	// Skip any transactions already known to the pool
+	unknown := make([]common.Hash, 0, len(hashes))
+	for _, hash := range hashes {
+		if f.getTx(hash) == nil {
+			unknown = append(unknown, hash)
+		}
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+	select {
+	case f.notify <- &txAnnounce{origin: peer, hashes: unknown}:
+		return nil
+	case <-f.quit:
+		return errTerminated
+	}
+}
+
+// Enqueue delivers a batch of transactions into the pool and marks them as
+// retrieved. If direct is set, the transactions are the reply to a request of
+// the fetcher: only the requested ones are accepted, and any transactions
+// missing from the reply will be retrieved from other peers instead.
+func (f *TxFetcher) Enqueue(peer string, txs []*types.Transaction, direct bool) error {
+	if direct {
+		filter := &txFilter{origin: peer, requested: make(chan map[common.Hash]struct{}, 1)}
+		select {
+		case f.filter <- filter:
+		case <-f.quit:
+			return errTerminated
+		}
+		requested := <-filter.requested
+
+		solicited := make([]*types.Transaction, 0, len(txs))
+		for _, tx := range txs {
+			if _, ok := requested[tx.Hash()]; ok {
+				solicited = append(solicited, tx)
+			}
+		}
+		if dropped := len(txs) - len(solicited); dropped > 0 {
+			glog.V(logger.Debug).Infof("Peer %s: dropped %d unrequested transactions", peer, dropped)
+		}
+		txs = solicited
+	}
+	if len(txs) > 0 {
+		f.addTxs(txs)
+	}
+	hashes := make([]common.Hash, len(txs))
+	for i, tx := range txs {
+		hashes[i] = tx.Hash()
+	}
+	select {
+	case f.cleanup <- &txDelivery{origin: peer, hashes: hashes, direct: direct}:
+		return nil
+	case <-f.quit:
+		return errTerminated
+	}
+}
+
+// Drop removes all the announcements and retrievals of a disconnected peer.
+func (f *TxFetcher) Drop(peer string) error {
+	select {
+	case f.drop <- peer:
+		return nil
+	case <-f.quit:
+		return errTerminated
+	}
+}
+
+// loop is the main transaction fetcher loop, checking and processing various
+// notification events.
+func (f *TxFetcher) loop() {
+	fetchTimer := time.NewTimer(0)
+
+	for {
+		// Wait for an outside event to occur
+		select {
+		case <-f.quit:
+			// Fetcher terminating, abort all operations
+			return
+
+		case notification := <-f.notify:
+			// A batch of transactions was announced, make sure the peer isn't DOSing us
+			announces := f.announces[notification.origin]
+			if announces == nil {
+				announces = make(map[common.Hash]struct{})
+				f.announces[notification.origin] = announces
+			}
+			// Timestamp the whole batch at once, so it matures (and is retrieved) together
+			now := time.Now()
+			for _, hash := range notification.hashes {
+				if _, ok := announces[hash]; ok {
+					continue
+				}
+				if len(announces) >= maxTxAnnounces {
+					glog.V(logger.Debug).Infof("Peer %s: exceeded outstanding transaction announces (%d)", notification.origin, maxTxAnnounces)
+					break
+				}
+				announces[hash] = struct{}{}
+				if f.announced[hash] == nil {
+					f.announced[hash] = make(map[string]struct{})
+					f.waittime[hash] = now
+				}
+				f.announced[hash][notification.origin] = struct{}{}
+			}
+			f.scheduleFetches(fetchTimer)
+
+		case <-fetchTimer.C:
+			// At least one transaction's wait or retrieval timer ran out
+			f.scheduleFetches(fetchTimer)
+
+		case delivery := <-f.cleanup:
+			// Transactions arrived, forget about them
+			for _, hash := range delivery.hashes {
+				f.forgetHash(hash)
+			}
+			// If this was the reply to a retrieval, everything not delivered is not
+			// available at the peer anymore
+			if delivery.direct {
+				f.forgetRequest(delivery.origin, delivery.hashes)
+			}
+			f.scheduleFetches(fetchTimer)
+
+		case filter := <-f.filter:
+			// A retrieval reply arrived, return what was asked of the peer
+			requested := make(map[common.Hash]struct{})
+			if request := f.requests[filter.origin]; request != nil {
+				for _, hash := range request.hashes {
+					requested[hash] = struct{}{}
+				}
+			}
+			filter.requested <- requested
+
+		case peer := <-f.drop:
+			// A peer was disconnected, reschedule its retrievals and drop its announces
+			if request := f.requests[peer]; request != nil {
+				for _, hash := range request.hashes {
+					if f.fetching[hash] == peer {
+						delete(f.fetching, hash)
+					}
+				}
+				delete(f.requests, peer)
+			}
+			for hash := range f.announces[peer] {
+				f.forgetAnnounce(peer, hash)
+			}
+			delete(f.announces, peer)
+			f.scheduleFetches(fetchTimer)
+		}
+	}
+}
+
+// scheduleFetches requests all the announced transactions past their arrival
+// allowance from the idle peers announcing them, and resets the fetch timer to
+// the next time something needs to be done.
+func (f *TxFetcher) scheduleFetches(timer *time.Timer) {
+	// Clean up any expired retrievals, the peer doesn't have (or won't give) the
+	// transactions so try the other announcers instead
+	for peer, request := range f.requests {
+		if time.Since(request.time) > txFetchTimeout {
+			glog.V(logger.Debug).Infof("Peer %s: transaction retrieval timed out, items=%d", peer, len(request.hashes))
+			f.forgetRequest(peer, nil)
+		}
+	}
+	for peer, announces := range f.announces {
+		// Only one request may be in flight to any given peer
+		if f.requests[peer] != nil {
+			continue
+		}
+		var hashes []common.Hash
+		for hash := range announces {
+			if _, ok := f.fetching[hash]; ok {
+				continue
+			}
+			if time.Since(f.waittime[hash]) < txArriveTimeout {
+				continue
+			}
+			// Don't bother retrieving transactions which arrived in the meantime
+			if f.getTx(hash) != nil {
+				f.forgetHash(hash)
+				continue
+			}
+			hashes = append(hashes, hash)
+			if len(hashes) == MaxTransactionFetch {
+				break
+			}
+		}
+		if len(hashes) == 0 {
+			continue
+		}
+		for _, hash := range hashes {
+			f.fetching[hash] = peer
+		}
+		f.requests[peer] = &txRequest{hashes: hashes, time: time.Now()}
+
+		glog.V(logger.Detail).Infof("Peer %s: fetching %d transactions", peer, len(hashes))
+		if f.fetchingHook != nil {
+			f.fetchingHook(peer, hashes)
+		}
+		go f.fetchTxs(peer, hashes)
+	}
+	f.rescheduleFetch(timer)
+}
+
+// rescheduleFetch resets the specified fetch timer to the next announce or
+// retrieval timeout.
+func (f *TxFetcher) rescheduleFetch(fetch *time.Timer) {
+	var (
+		next  time.Duration
+		found bool
+	)
+	for hash, waittime := range f.waittime {
+		if _, ok := f.fetching[hash]; ok {
+			continue
+		}
+		if left := txArriveTimeout - time.Since(waittime); !found || left < next {
+			next, found = left, true
+		}
+	}
+	for _, request := range f.requests {
+		if left := txFetchTimeout - time.Since(request.time); !found || left < next {
+			next, found = left, true
+		}
+	}
+	// Short circuit if nothing is waiting
+	if !found {
+		return
+	}
+	// Retrieving from busy peers only may still leave announces past their wait
+	// allowance, make sure to not spin on them
+	if next < gatherSlack {
+		next = gatherSlack
+	}
+	fetch.Reset(next)
+}
+
+// forgetRequest removes the in-flight retrieval of a peer, and drops the peer as
+// a source for all the requested transactions it did not deliver.
+func (f *TxFetcher) forgetRequest(peer string, delivered []common.Hash) {
+	request := f.requests[peer]
+	if request == nil {
+		return
+	}
+	delete(f.requests, peer)
+
+	arrived := make(map[common.Hash]struct{}, len(delivered))
+	for _, hash := range delivered {
+		arrived[hash] = struct{}{}
+	}
+	for _, hash := range request.hashes {
+		if _, ok := arrived[hash]; ok {
+			continue
+		}
+		if f.fetching[hash] == peer {
+			delete(f.fetching, hash)
+		}
+		if announces := f.announces[peer]; announces != nil {
+			delete(announces, hash)
+		}
+		f.forgetAnnounce(peer, hash)
+	}
+}
+
+// forgetAnnounce removes a peer as a download location of a transaction, and
+// forgets the transaction altogether if nobody else announced it.
+func (f *TxFetcher) forgetAnnounce(peer string, hash common.Hash) {
+	if sources := f.announced[hash]; sources != nil {
+		delete(sources, peer)
+		if len(sources) == 0 {
+			f.forgetHash(hash)
+		}
+	}
+}
+
+// forgetHash removes all traces of a transaction from the fetcher's internal
+// state.
+func (f *TxFetcher) forgetHash(hash common.Hash) {
+	for peer := range f.announced[hash] {
+		if announces := f.announces[peer]; announces != nil {
+			delete(announces, hash)
+			if len(announces) == 0 && f.requests[peer] == nil {
+				delete(f.announces, peer)
+			}
+		}
+	}
+	delete(f.announced, hash)
+	delete(f.waittime, hash)
+	delete(f.fetching, hash)
+}
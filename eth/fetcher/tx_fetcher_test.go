@@ -0,0 +1,277 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package fetcher
+
+import (
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/eth-classic/go-ethereum/common"
+	"github.com/eth-classic/go-ethereum/core/types"
+)
+
+// txFetcherTester is a test simulator for mocking out the transaction pool and
+// the remote peers.
+type txFetcherTester struct {
+	fetcher *TxFetcher
+
+	pool map[common.Hash]*types.Transaction // Transactions known to the simulated pool
+	lock sync.RWMutex
+
+	fetches chan *txFetch // Retrievals started by the fetcher
+}
+
+// txFetch is a transaction retrieval requested from a remote peer.
+type txFetch struct {
+	peer   string
+	hashes []common.Hash
+}
+
+// newTxTester creates a new transaction fetcher test mocker.
+func newTxTester() *txFetcherTester {
+	tester := &txFetcherTester{
+		pool:    make(map[common.Hash]*types.Transaction),
+		fetches: make(chan *txFetch, 16),
+	}
+	tester.fetcher = NewTxFetcher(tester.getTx, tester.addTxs, func(string, []common.Hash) error { return nil })
+	tester.fetcher.fetchingHook = func(peer string, hashes []common.Hash) {
+		tester.fetches <- &txFetch{peer: peer, hashes: hashes}
+	}
+	tester.fetcher.Start()
+	return tester
+}
+
+// getTx retrieves a transaction from the tester's pool.
+func (t *txFetcherTester) getTx(hash common.Hash) *types.Transaction {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	return t.pool[hash]
+}
+
+// addTxs injects a batch of transactions into the tester's pool.
+func (t *txFetcherTester) addTxs(txs []*types.Transaction) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	for _, tx := range txs {
+		t.pool[tx.Hash()] = tx
+	}
+}
+
+// newTestTx creates a distinct unsigned transaction for the fetcher to track.
+func newTestTx(nonce uint64) *types.Transaction {
+	return types.NewTransaction(nonce, common.Address{}, big.NewInt(0), big.NewInt(21000), big.NewInt(0), nil)
+}
+
+// verifyTxFetch checks that a retrieval of the given transactions was started
+// within the arrival allowance, returning the peer it was requested from.
+func verifyTxFetch(t *testing.T, fetches chan *txFetch, hashes ...common.Hash) string {
+	select {
+	case fetch := <-fetches:
+		if len(fetch.hashes) != len(hashes) {
+			t.Fatalf("fetched hash count mismatch: have %d, want %d", len(fetch.hashes), len(hashes))
+		}
+		want := make(map[common.Hash]bool)
+		for _, hash := range hashes {
+			want[hash] = true
+		}
+		for _, hash := range fetch.hashes {
+			if !want[hash] {
+				t.Fatalf("unexpected hash fetched: %x", hash)
+			}
+		}
+		return fetch.peer
+	case <-time.After(txArriveTimeout + time.Second):
+		t.Fatalf("transaction retrieval timeout")
+	}
+	return ""
+}
+
+// verifyNoTxFetch checks that no retrieval was started within the arrival
+// allowance.
+func verifyNoTxFetch(t *testing.T, fetches chan *txFetch) {
+	select {
+	case fetch := <-fetches:
+		t.Fatalf("unexpected transaction retrieval from %s: %v", fetch.peer, fetch.hashes)
+	case <-time.After(txArriveTimeout + 2*gatherSlack):
+	}
+}
+
+// Tests that announced transactions are retrieved after the arrival allowance,
+// and forgotten once delivered.
+func TestTxFetcherAnnounceRetrieval(t *testing.T) {
+	tester := newTxTester()
+	defer tester.fetcher.Stop()
+
+	txs := []*types.Transaction{newTestTx(0), newTestTx(1)}
+	start := time.Now()
+	tester.fetcher.Notify("A", []common.Hash{txs[0].Hash(), txs[1].Hash()})
+
+	if peer := verifyTxFetch(t, tester.fetches, txs[0].Hash(), txs[1].Hash()); peer != "A" {
+		t.Fatalf("retrieval peer mismatch: have %s, want A", peer)
+	}
+	if elapsed := time.Since(start); elapsed < txArriveTimeout {
+		t.Errorf("retrieval started before the arrival allowance: %v", elapsed)
+	}
+	tester.fetcher.Enqueue("A", txs, true)
+	if tester.getTx(txs[0].Hash()) == nil || tester.getTx(txs[1].Hash()) == nil {
+		t.Fatalf("delivered transactions not added to the pool")
+	}
+	verifyNoTxFetch(t, tester.fetches)
+}
+
+// Tests that transactions arriving through direct broadcasts within the arrival
+// allowance are not retrieved explicitly.
+func TestTxFetcherBroadcastBeforeRetrieval(t *testing.T) {
+	tester := newTxTester()
+	defer tester.fetcher.Stop()
+
+	tx := newTestTx(0)
+	tester.fetcher.Notify("A", []common.Hash{tx.Hash()})
+	tester.fetcher.Enqueue("B", []*types.Transaction{tx}, false)
+
+	verifyNoTxFetch(t, tester.fetches)
+}
+
+// Tests that announcements of transactions already known to the pool are ignored.
+func TestTxFetcherKnownAnnouncement(t *testing.T) {
+	tester := newTxTester()
+	defer tester.fetcher.Stop()
+
+	tx := newTestTx(0)
+	tester.addTxs([]*types.Transaction{tx})
+	tester.fetcher.Notify("A", []common.Hash{tx.Hash()})
+
+	verifyNoTxFetch(t, tester.fetches)
+}
+
+// Tests that transactions missing from a retrieval reply are requested from the
+// other announcing peers instead.
+func TestTxFetcherMissingFallback(t *testing.T) {
+	tester := newTxTester()
+	defer tester.fetcher.Stop()
+
+	tx := newTestTx(0)
+	tester.fetcher.Notify("A", []common.Hash{tx.Hash()})
+	tester.fetcher.Notify("B", []common.Hash{tx.Hash()})
+
+	first := verifyTxFetch(t, tester.fetches, tx.Hash())
+	tester.fetcher.Enqueue(first, nil, true)
+
+	if second := verifyTxFetch(t, tester.fetches, tx.Hash()); second == first {
+		t.Fatalf("transaction re-requested from the same peer %s", first)
+	}
+}
+
+// Tests that transactions not requested from a peer are dropped from its
+// retrieval replies.
+func TestTxFetcherUnrequestedDelivery(t *testing.T) {
+	tester := newTxTester()
+	defer tester.fetcher.Stop()
+
+	txs := []*types.Transaction{newTestTx(0), newTestTx(1), newTestTx(2)}
+
+	// A reply without any request in flight is dropped entirely
+	tester.fetcher.Enqueue("A", txs[2:], true)
+	if tester.getTx(txs[2].Hash()) != nil {
+		t.Fatalf("unsolicited transaction added to the pool")
+	}
+	// A reply to a request is stripped of the transactions not asked for
+	tester.fetcher.Notify("A", []common.Hash{txs[0].Hash()})
+	verifyTxFetch(t, tester.fetches, txs[0].Hash())
+
+	tester.fetcher.Enqueue("A", txs[:2], true)
+	if tester.getTx(txs[0].Hash()) == nil {
+		t.Fatalf("requested transaction not added to the pool")
+	}
+	if tester.getTx(txs[1].Hash()) != nil {
+		t.Fatalf("unrequested transaction added to the pool")
+	}
+	// Broadcasts are not retrieval replies, and are accepted as is
+	tester.fetcher.Enqueue("A", txs[1:], false)
+	if tester.getTx(txs[1].Hash()) == nil || tester.getTx(txs[2].Hash()) == nil {
+		t.Fatalf("broadcast transactions not added to the pool")
+	}
+}
+
+// Tests that the retrievals of a dropped peer are requested from the other
+// announcing peers instead.
+func TestTxFetcherDropFallback(t *testing.T) {
+	tester := newTxTester()
+	defer tester.fetcher.Stop()
+
+	tx := newTestTx(0)
+	tester.fetcher.Notify("A", []common.Hash{tx.Hash()})
+	tester.fetcher.Notify("B", []common.Hash{tx.Hash()})
+
+	first := verifyTxFetch(t, tester.fetches, tx.Hash())
+	tester.fetcher.Drop(first)
+
+	if second := verifyTxFetch(t, tester.fetches, tx.Hash()); second == first {
+		t.Fatalf("transaction re-requested from the dropped peer %s", first)
+	}
+	// With all sources gone, nothing should be retried
+	tester.fetcher.Enqueue("B", nil, true)
+	tester.fetcher.Enqueue("A", nil, true)
+	verifyNoTxFetch(t, tester.fetches)
+}
+
+// Tests that only a limited number of transactions are requested from a peer at
+// once, the rest being retrieved after the previous request completes.
+func TestTxFetcherRetrievalLimit(t *testing.T) {
+	tester := newTxTester()
+	defer tester.fetcher.Stop()
+
+	txs := make([]*types.Transaction, MaxTransactionFetch+1)
+	hashes := make([]common.Hash, len(txs))
+	for i := range txs {
+		txs[i] = newTestTx(uint64(i))
+		hashes[i] = txs[i].Hash()
+	}
+	tester.fetcher.Notify("A", hashes)
+
+	var fetch *txFetch
+	select {
+	case fetch = <-tester.fetches:
+	case <-time.After(txArriveTimeout + time.Second):
+		t.Fatalf("transaction retrieval timeout")
+	}
+	if len(fetch.hashes) != MaxTransactionFetch {
+		t.Fatalf("retrieval size mismatch: have %d, want %d", len(fetch.hashes), MaxTransactionFetch)
+	}
+	fetched := make(map[common.Hash]bool)
+	var delivered []*types.Transaction
+	for _, hash := range fetch.hashes {
+		fetched[hash] = true
+	}
+	for _, tx := range txs {
+		if fetched[tx.Hash()] {
+			delivered = append(delivered, tx)
+		}
+	}
+	verifyNoTxFetch(t, tester.fetches)
+	tester.fetcher.Enqueue("A", delivered, true)
+
+	for _, hash := range hashes {
+		if !fetched[hash] {
+			verifyTxFetch(t, tester.fetches, hash)
+		}
+	}
+}
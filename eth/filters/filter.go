@@ -21,7 +21,9 @@ import (
 	"time"
 
 	"github.com/eth-classic/go-ethereum/common"
+	"github.com/eth-classic/go-ethereum/common/bitutil"
 	"github.com/eth-classic/go-ethereum/core"
+	"github.com/eth-classic/go-ethereum/core/bloombits"
 	"github.com/eth-classic/go-ethereum/core/types"
 	"github.com/eth-classic/go-ethereum/core/vm"
 	"github.com/eth-classic/go-ethereum/ethdb"
@@ -70,7 +72,9 @@ func (self *Filter) SetTopics(topics [][]common.Hash) {
 	self.topics = topics
 }
 
-// Run filters logs with the current parameters set
+// Run filters logs with the current parameters set. The sections of the chain
+// covered by the bloom bits index are matched with a few database reads each,
+// the remaining blocks are scanned.
 func (self *Filter) Find() vm.Logs {
 	latestBlock := core.GetBlock(self.db, core.GetHeadBlockHash(self.db))
 	if latestBlock == nil {
@@ -85,13 +89,93 @@ func (self *Filter) Find() vm.Logs {
 		endBlockNo = latestBlock.NumberU64()
 	}
 
+	// without any criteria every block matches, nothing to gain from the index
+	matcher := bloombits.NewMatcher(core.BloomBitsBlocks, self.bloomFilters())
+	if matcher.Empty() {
+		return self.scan(beginBlockNo, endBlockNo)
+	}
+	var (
+		logs      vm.Logs
+		unindexed = beginBlockNo
+	)
+	for section := beginBlockNo / core.BloomBitsBlocks; section*core.BloomBitsBlocks <= endBlockNo; section++ {
+		matches := self.indexedBlocks(matcher, section)
+		if matches == nil {
+			continue
+		}
+		// scan the blocks since the last indexed section, then the candidates
+		first := section * core.BloomBitsBlocks
+		if unindexed < first {
+			logs = append(logs, self.scan(unindexed, first-1)...)
+		}
+		for i := uint64(0); i < core.BloomBitsBlocks; i++ {
+			if matches[i/8]&(1<<byte(7-i%8)) == 0 {
+				continue
+			}
+			if num := first + i; num >= beginBlockNo && num <= endBlockNo {
+				logs = append(logs, self.getLogs(num, num)...)
+			}
+		}
+		unindexed = first + core.BloomBitsBlocks
+	}
+	if unindexed <= endBlockNo {
+		logs = append(logs, self.scan(unindexed, endBlockNo)...)
+	}
+	return logs
+}
+
+// bloomFilters returns the address and topic criteria of the filter as the
+// alternatives of each matched position, nil for the wildcard topic.
+func (self *Filter) bloomFilters() [][][]byte {
+	var addresses [][]byte
+	for _, addr := range self.addresses {
+		addresses = append(addresses, addr.Bytes())
+	}
+	filters := [][][]byte{addresses}
+	for _, sub := range self.topics {
+		var topics [][]byte
+		for _, topic := range sub {
+			if (topic == common.Hash{}) {
+				topics = append(topics, nil)
+			} else {
+				topics = append(topics, topic.Bytes())
+			}
+		}
+		filters = append(filters, topics)
+	}
+	return filters
+}
+
+// indexedBlocks matches a section of the canonical chain using the bloom bits
+// index, returning the bitset of its blocks possibly holding matching logs. It
+// returns nil if the section isn't indexed.
+func (self *Filter) indexedBlocks(matcher *bloombits.Matcher, section uint64) []byte {
+	head := core.GetCanonicalHash(self.db, (section+1)*core.BloomBitsBlocks-1)
+	if (head == common.Hash{}) {
+		return nil
+	}
+	matches, err := matcher.Match(func(bit uint) ([]byte, error) {
+		data, err := core.GetBloomBits(self.db, bit, section, head)
+		if err != nil {
+			return nil, err
+		}
+		return bitutil.DecompressBytes(data, int(core.BloomBitsBlocks/8))
+	})
+	if err != nil {
+		return nil
+	}
+	return matches
+}
+
+// scan filters the logs of a range of blocks without the bloom bits index.
+func (self *Filter) scan(start, end uint64) vm.Logs {
 	// if no addresses are present we can't make use of fast search which
 	// uses the mipmap bloom filters to check for fast inclusion and uses
 	// higher range probability in order to ensure at least a false positive
 	if len(self.addresses) == 0 {
-		return self.getLogs(beginBlockNo, endBlockNo)
+		return self.getLogs(start, end)
 	}
-	return self.mipFind(beginBlockNo, endBlockNo, 0)
+	return self.mipFind(start, end, 0)
 }
 
 func (self *Filter) mipFind(start, end uint64, depth int) (logs vm.Logs) {
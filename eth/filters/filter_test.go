@@ -20,7 +20,9 @@ import (
 	"io/ioutil"
 	"math/big"
 	"os"
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/eth-classic/go-ethereum/common"
 	"github.com/eth-classic/go-ethereum/core"
@@ -28,6 +30,7 @@ import (
 	"github.com/eth-classic/go-ethereum/core/vm"
 	"github.com/eth-classic/go-ethereum/crypto"
 	"github.com/eth-classic/go-ethereum/ethdb"
+	"github.com/eth-classic/go-ethereum/event"
 	"github.com/eth-classic/go-ethereum/logger/glog"
 )
 
@@ -286,3 +289,86 @@ func TestFilters(t *testing.T) {
 		t.Error("expected 0 log, got", len(logs))
 	}
 }
+
+func TestFiltersIndexed(t *testing.T) {
+	db, _ := ethdb.NewMemDatabase()
+
+	var (
+		key1, _ = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+		addr    = crypto.PubkeyToAddress(key1.PublicKey)
+
+		hash1 = common.BytesToHash([]byte("topic1"))
+		hash2 = common.BytesToHash([]byte("topic2"))
+	)
+	// Create a chain with logs in its first section and its unindexed tail
+	logged := map[uint64]common.Hash{100: hash1, core.BloomBitsBlocks + 104: hash2}
+
+	var parent *types.Header
+	for i := uint64(0); i < core.BloomBitsBlocks+300; i++ {
+		header := &types.Header{Number: new(big.Int).SetUint64(i), Difficulty: big.NewInt(1)}
+		if parent != nil {
+			header.ParentHash = parent.Hash()
+		}
+		var receipts types.Receipts
+		if topic, ok := logged[i]; ok {
+			receipt := types.NewReceipt(nil, new(big.Int))
+			receipt.Logs = vm.Logs{&vm.Log{Address: addr, Topics: []common.Hash{topic}}}
+			receipt.Bloom = types.CreateBloom(types.Receipts{receipt})
+			receipts = types.Receipts{receipt}
+			header.Bloom = receipt.Bloom
+		}
+		block := types.NewBlockWithHeader(header)
+		core.WriteBlock(db, block)
+		core.WriteCanonicalHash(db, block.Hash(), i)
+		core.WriteBlockReceipts(db, block.Hash(), receipts)
+
+		// Only the unindexed tail is covered by the mipmap blooms
+		if i >= core.BloomBitsBlocks {
+			core.WriteMipmapBloom(db, i, receipts)
+		}
+		parent = header
+	}
+	core.WriteHeadBlockHash(db, parent.Hash())
+
+	indexer := core.NewBloomIndexer(db)
+	indexer.Start(parent, new(event.TypeMux))
+	defer indexer.Close()
+
+	for start := time.Now(); time.Since(start) < 5*time.Second; time.Sleep(10 * time.Millisecond) {
+		if sections, _ := indexer.Sections(); sections == 1 {
+			break
+		}
+	}
+	if sections, _ := indexer.Sections(); sections != 1 {
+		t.Fatalf("indexed sections mismatch: have %d, want 1", sections)
+	}
+	tests := []struct {
+		begin, end int64
+		addresses  []common.Address
+		topics     [][]common.Hash
+		want       []common.Hash
+	}{
+		{0, -1, []common.Address{addr}, nil, []common.Hash{hash1, hash2}},
+		{0, -1, nil, [][]common.Hash{{hash1}}, []common.Hash{hash1}},
+		{0, -1, nil, [][]common.Hash{{hash2}}, []common.Hash{hash2}},
+		{0, -1, []common.Address{addr}, [][]common.Hash{{common.Hash{}}}, []common.Hash{hash1, hash2}},
+		{101, int64(core.BloomBitsBlocks + 103), []common.Address{addr}, nil, nil},
+		{50, 150, []common.Address{addr}, [][]common.Hash{{hash1, hash2}}, []common.Hash{hash1}},
+		{0, -1, []common.Address{common.BytesToAddress([]byte("failmenow"))}, nil, nil},
+	}
+	for i, tt := range tests {
+		filter := New(db)
+		filter.SetBeginBlock(tt.begin)
+		filter.SetEndBlock(tt.end)
+		filter.SetAddresses(tt.addresses)
+		filter.SetTopics(tt.topics)
+
+		var have []common.Hash
+		for _, log := range filter.Find() {
+			have = append(have, log.Topics[0])
+		}
+		if !reflect.DeepEqual(have, tt.want) {
+			t.Errorf("test %d: logs mismatch: have %x, want %x", i, have, tt.want)
+		}
+	}
+}
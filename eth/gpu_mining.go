@@ -40,6 +40,7 @@ func (s *Ethereum) StartMining(threads int, gpus string) error {
 		glog.V(logger.Error).Infoln(err)
 		return err
 	}
+	s.authorizeSigner(eb)
 
 	// GPU mining
 	if gpus != "" {
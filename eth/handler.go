@@ -28,6 +28,7 @@ import (
 
 	"github.com/eth-classic/go-ethereum/common"
 	"github.com/eth-classic/go-ethereum/core"
+	"github.com/eth-classic/go-ethereum/core/forkid"
 	"github.com/eth-classic/go-ethereum/core/types"
 	"github.com/eth-classic/go-ethereum/eth/downloader"
 	"github.com/eth-classic/go-ethereum/eth/fetcher"
@@ -68,14 +69,19 @@ type ProtocolManager struct {
 	fastSync   uint32 // Flag whether fast sync is enabled (gets disabled if we already have blocks)
 	acceptsTxs uint32 // Flag whether we're considered synchronised (enables transaction processing)
 
+	// artificialFinality enables MESS reorg protection once synchronised
+	artificialFinality bool
+
 	txpool      txPool
 	blockchain  *core.BlockChain
 	chaindb     ethdb.Database
 	chainConfig *core.ChainConfig
+	forkFilter  forkid.Filter // Fork ID filter, constant across the lifetime of the node
 	maxPeers    int
 
 	downloader *downloader.Downloader
 	fetcher    *fetcher.Fetcher
+	txFetcher  *fetcher.TxFetcher
 	peers      *peerSet
 
 	SubProtocols []p2p.Protocol
@@ -106,6 +112,7 @@ func NewProtocolManager(config *core.ChainConfig, mode downloader.SyncMode, netw
 		blockchain:  blockchain,
 		chaindb:     chaindb,
 		chainConfig: config,
+		forkFilter:  forkid.NewFilter(blockchain),
 		peers:       newPeerSet(),
 		newPeerCh:   make(chan *peer),
 		noMorePeers: make(chan struct{}),
@@ -195,6 +202,15 @@ func NewProtocolManager(config *core.ChainConfig, mode downloader.SyncMode, netw
 	}
 	manager.fetcher = fetcher.New(mux, blockchain.GetBlock, validator, manager.BroadcastBlock, heighter, inserter, manager.removePeer)
 
+	fetchTxs := func(id string, hashes []common.Hash) error {
+		p := manager.peers.Peer(id)
+		if p == nil {
+			return errNotRegistered
+		}
+		return p.RequestTxs(hashes)
+	}
+	manager.txFetcher = fetcher.NewTxFetcher(txpool.GetTransaction, txpool.AddTransactions, fetchTxs)
+
 	return manager, nil
 }
 
@@ -213,6 +229,7 @@ func (pm *ProtocolManager) removePeer(id string) {
 
 	// Unregister the peer from the downloader and Ethereum peer set
 	pm.downloader.UnregisterPeer(id)
+	pm.txFetcher.Drop(id)
 	if err := pm.peers.Unregister(id); err != nil {
 		glog.V(logger.Error).Infoln("Removal failed:", err)
 	}
@@ -278,7 +295,8 @@ func (pm *ProtocolManager) handle(p *peer) error {
 
 	// Execute the Ethereum handshake
 	td, head, genesis := pm.blockchain.Status()
-	if err := p.Handshake(pm.networkId, td, head, genesis); err != nil {
+	forkID := forkid.NewID(pm.blockchain.Config(), genesis, pm.blockchain.CurrentHeader().Number.Uint64())
+	if err := p.Handshake(pm.networkId, td, head, genesis, forkID, pm.forkFilter); err != nil {
 		glog.V(logger.Debug).Infof("handler: %s ->handshakefailed err=%v", p, err)
 		return err
 	}
@@ -785,7 +803,86 @@ func (pm *ProtocolManager) handleMsg(p *peer) (err error) {
 			}
 			p.MarkTransaction(tx.Hash())
 		}
-		pm.txpool.AddTransactions(txs)
+		pm.txFetcher.Enqueue(p.id, txs, false)
+
+	case p.version >= eth65 && msg.Code == NewPooledTransactionHashesMsg:
+		// New transaction announcement arrived, make sure we have a valid and fresh chain to handle them
+		if atomic.LoadUint32(&pm.acceptsTxs) == 0 {
+			mlogWireDelegate(p, "receive", NewPooledTransactionHashesMsg, intSize, []common.Hash{}, errors.New("not synced"))
+			break
+		}
+		var hashes []common.Hash
+		if e := msg.Decode(&hashes); e != nil {
+			err = errResp(ErrDecode, "msg %v: %v", msg, e)
+			mlogWireDelegate(p, "receive", NewPooledTransactionHashesMsg, intSize, hashes, err)
+			return
+		}
+		mlogWireDelegate(p, "receive", NewPooledTransactionHashesMsg, intSize, hashes, err)
+		// Schedule all the unknown hashes for retrieval
+		for _, hash := range hashes {
+			p.MarkTransaction(hash)
+		}
+		pm.txFetcher.Notify(p.id, hashes)
+
+	case p.version >= eth65 && msg.Code == GetPooledTransactionsMsg:
+		// Decode the retrieval message
+		msgStream := rlp.NewStream(msg.Payload, uint64(msg.Size))
+		if _, err = msgStream.List(); err != nil {
+			mlogWireDelegate(p, "receive", GetPooledTransactionsMsg, intSize, []common.Hash{}, err)
+			return err
+		}
+		// Gather transactions until the fetch or network limits is reached
+		var (
+			hash   common.Hash
+			bytes  int
+			hashes []common.Hash
+			txs    []rlp.RawValue
+		)
+		for bytes < softResponseLimit && len(txs) < fetcher.MaxTransactionFetch {
+			// Retrieve the hash of the next transaction
+			if e := msgStream.Decode(&hash); e == rlp.EOL {
+				break
+			} else if e != nil {
+				err = errResp(ErrDecode, "msg %v: %v", msg, e)
+				mlogWireDelegate(p, "receive", GetPooledTransactionsMsg, intSize, hashes, err)
+				return
+			}
+			// Retrieve the requested transaction, skipping if unknown to us
+			tx := pm.txpool.GetTransaction(hash)
+			if tx == nil {
+				continue
+			}
+			if encoded, e := rlp.EncodeToBytes(tx); e == nil {
+				hashes = append(hashes, hash)
+				txs = append(txs, encoded)
+				bytes += len(encoded)
+			}
+		}
+		mlogWireDelegate(p, "receive", GetPooledTransactionsMsg, intSize, hashes, err)
+		return p.SendPooledTransactionsRLP(hashes, txs)
+
+	case p.version >= eth65 && msg.Code == PooledTransactionsMsg:
+		// Transactions arrived, make sure we have a valid and fresh chain to handle them
+		if atomic.LoadUint32(&pm.acceptsTxs) == 0 {
+			mlogWireDelegate(p, "receive", PooledTransactionsMsg, intSize, []*types.Transaction{}, errors.New("not synced"))
+			break
+		}
+		// Transactions can be processed, parse all of them and deliver to the pool
+		var txs []*types.Transaction
+		if e := msg.Decode(&txs); e != nil {
+			err = errResp(ErrDecode, "msg %v: %v", msg, e)
+			mlogWireDelegate(p, "receive", PooledTransactionsMsg, intSize, txs, err)
+			return
+		}
+		mlogWireDelegate(p, "receive", PooledTransactionsMsg, intSize, txs, err)
+		for i, tx := range txs {
+			// Validate and mark the remote transaction
+			if tx == nil {
+				return errResp(ErrDecode, "transaction %d is nil", i)
+			}
+			p.MarkTransaction(tx.Hash())
+		}
+		pm.txFetcher.Enqueue(p.id, txs, true)
 
 	default:
 		err = errResp(ErrInvalidMsgCode, "%v", msg.Code)
@@ -828,15 +925,27 @@ func (pm *ProtocolManager) BroadcastBlock(block *types.Block, propagate bool) {
 }
 
 // BroadcastTx will propagate a transaction to all peers which are not known to
-// already have the given transaction.
+// already have the given transaction. Peers speaking eth/65 or later only get
+// the full transaction in a square root sized subset, the rest is just announced
+// the hash and may retrieve the transaction on demand.
 func (pm *ProtocolManager) BroadcastTx(hash common.Hash, tx *types.Transaction) {
 	// Broadcast transaction to a batch of peers not knowing about it
-	peers := pm.peers.PeersWithoutTx(hash)
-	//FIXME include this again: peers = peers[:int(math.Sqrt(float64(len(peers))))]
-	for _, peer := range peers {
+	var legacy, announcing []*peer
+	for _, peer := range pm.peers.PeersWithoutTx(hash) {
+		if peer.version >= eth65 {
+			announcing = append(announcing, peer)
+		} else {
+			legacy = append(legacy, peer)
+		}
+	}
+	transfer := announcing[:int(math.Sqrt(float64(len(announcing))))]
+	for _, peer := range append(legacy, transfer...) {
 		peer.AsyncSendTransactions(types.Transactions{tx})
 	}
-	glog.V(logger.Detail).Infof("broadcast tx [%s] to %d peers", tx.Hash().Hex(), len(peers))
+	for _, peer := range announcing[len(transfer):] {
+		peer.AsyncSendPooledTransactionHashes([]common.Hash{hash})
+	}
+	glog.V(logger.Detail).Infof("broadcast tx [%s] to %d peers, announced to %d peers", tx.Hash().Hex(), len(legacy)+len(transfer), len(announcing)-len(transfer))
 }
 
 // Mined broadcast loop
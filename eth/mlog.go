@@ -36,6 +36,12 @@ var mlogLinesWire = []*logger.MLogT{
 	mlogWireReceiveGetReceipts,
 	mlogWireSendReceipts,
 	mlogWireReceiveReceipts,
+	mlogWireSendNewPooledTransactionHashes,
+	mlogWireReceiveNewPooledTransactionHashes,
+	mlogWireSendGetPooledTransactions,
+	mlogWireReceiveGetPooledTransactions,
+	mlogWireSendPooledTransactions,
+	mlogWireReceivePooledTransactions,
 	mlogWireReceiveInvalid,
 }
 
@@ -371,6 +377,51 @@ func mlogWireDelegate(p *peer, direction string, msgCode uint64, size int, data
 			}
 		}
 
+	case NewPooledTransactionHashesMsg, GetPooledTransactionsMsg:
+		if payload, ok := data.([]common.Hash); ok {
+			details = append(details, len(payload))
+			if len(payload) > 0 {
+				details = append(details, payload[0].Hex())
+			} else {
+				details = append(details, common.Hash{}.Hex())
+			}
+		} else if err != nil {
+			details = append(details, 0, common.Hash{}.Hex())
+		} else {
+			glog.Fatal("cant cast: ", ProtocolMessageStringer(uint(msgCode)), direction)
+		}
+		switch {
+		case msgCode == NewPooledTransactionHashesMsg && direction == "send":
+			line = mlogWireSendNewPooledTransactionHashes
+		case msgCode == NewPooledTransactionHashesMsg:
+			line = mlogWireReceiveNewPooledTransactionHashes
+		case direction == "send":
+			line = mlogWireSendGetPooledTransactions
+		default:
+			line = mlogWireReceiveGetPooledTransactions
+		}
+
+	case PooledTransactionsMsg:
+		if direction == "send" {
+			line = mlogWireSendPooledTransactions
+			if payload, ok := data.([]rlp.RawValue); ok {
+				details = append(details, len(payload))
+			} else if err != nil {
+				details = append(details, 0)
+			} else {
+				glog.Fatal("cant cast: PooledTransactionsMsg", direction)
+			}
+		} else {
+			line = mlogWireReceivePooledTransactions
+			if payload, ok := data.([]*types.Transaction); ok {
+				details = append(details, len(payload))
+			} else if err != nil {
+				details = append(details, 0)
+			} else {
+				glog.Fatal("cant cast: PooledTransactionsMsg", direction)
+			}
+		}
+
 	default:
 		line = mlogWireReceiveInvalid
 	}
@@ -665,6 +716,70 @@ var mlogWireReceiveReceipts = &logger.MLogT{
 	}...),
 }
 
+var mlogWireSendNewPooledTransactionHashes = &logger.MLogT{
+	Description: "Called once for each outgoing NewPooledTransactionHashesMsg message.",
+	Receiver:    "WIRE",
+	Verb:        "SEND",
+	Subject:     strings.ToUpper(ProtocolMessageStringer(NewPooledTransactionHashesMsg)),
+	Details: append(mlogWireCommonDetails, []logger.MLogDetailT{
+		{Owner: "MSG", Key: "LEN_ITEMS", Value: "INT"},
+		{Owner: "MSG", Key: "FIRST_HASH", Value: "STRING"},
+	}...),
+}
+
+var mlogWireReceiveNewPooledTransactionHashes = &logger.MLogT{
+	Description: "Called once for each incoming NewPooledTransactionHashesMsg message.",
+	Receiver:    "WIRE",
+	Verb:        "RECEIVE",
+	Subject:     strings.ToUpper(ProtocolMessageStringer(NewPooledTransactionHashesMsg)),
+	Details: append(mlogWireCommonDetails, []logger.MLogDetailT{
+		{Owner: "MSG", Key: "LEN_ITEMS", Value: "INT"},
+		{Owner: "MSG", Key: "FIRST_HASH", Value: "STRING"},
+	}...),
+}
+
+var mlogWireSendGetPooledTransactions = &logger.MLogT{
+	Description: "Called once for each outgoing GetPooledTransactionsMsg message.",
+	Receiver:    "WIRE",
+	Verb:        "SEND",
+	Subject:     strings.ToUpper(ProtocolMessageStringer(GetPooledTransactionsMsg)),
+	Details: append(mlogWireCommonDetails, []logger.MLogDetailT{
+		{Owner: "MSG", Key: "LEN_ITEMS", Value: "INT"},
+		{Owner: "MSG", Key: "FIRST_HASH", Value: "STRING"},
+	}...),
+}
+
+var mlogWireReceiveGetPooledTransactions = &logger.MLogT{
+	Description: "Called once for each incoming GetPooledTransactionsMsg message.",
+	Receiver:    "WIRE",
+	Verb:        "RECEIVE",
+	Subject:     strings.ToUpper(ProtocolMessageStringer(GetPooledTransactionsMsg)),
+	Details: append(mlogWireCommonDetails, []logger.MLogDetailT{
+		{Owner: "MSG", Key: "LEN_ITEMS", Value: "INT"},
+		{Owner: "MSG", Key: "FIRST_HASH", Value: "STRING"},
+	}...),
+}
+
+var mlogWireSendPooledTransactions = &logger.MLogT{
+	Description: "Called once for each outgoing PooledTransactionsMsg message.",
+	Receiver:    "WIRE",
+	Verb:        "SEND",
+	Subject:     strings.ToUpper(ProtocolMessageStringer(PooledTransactionsMsg)),
+	Details: append(mlogWireCommonDetails, []logger.MLogDetailT{
+		{Owner: "MSG", Key: "LEN_ITEMS", Value: "INT"},
+	}...),
+}
+
+var mlogWireReceivePooledTransactions = &logger.MLogT{
+	Description: "Called once for each incoming PooledTransactionsMsg message.",
+	Receiver:    "WIRE",
+	Verb:        "RECEIVE",
+	Subject:     strings.ToUpper(ProtocolMessageStringer(PooledTransactionsMsg)),
+	Details: append(mlogWireCommonDetails, []logger.MLogDetailT{
+		{Owner: "MSG", Key: "LEN_ITEMS", Value: "INT"},
+	}...),
+}
+
 var mlogWireReceiveInvalid = &logger.MLogT{
 	Description: "Called once for each incoming wire message that is invalid.",
 	Receiver:    "WIRE",
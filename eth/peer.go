@@ -24,6 +24,7 @@ import (
 	"time"
 
 	"github.com/eth-classic/go-ethereum/common"
+	"github.com/eth-classic/go-ethereum/core/forkid"
 	"github.com/eth-classic/go-ethereum/core/types"
 	"github.com/eth-classic/go-ethereum/logger"
 	"github.com/eth-classic/go-ethereum/logger/glog"
@@ -47,6 +48,11 @@ const (
 	// contain a single transaction, or thousands.
 	maxQueuedTxs = 128
 
+	// maxQueuedTxAnns is the maximum number of transaction announcements to queue up
+	// before dropping broadcasts. Similarly to transaction lists, an announcement
+	// may carry a single hash or thousands.
+	maxQueuedTxAnns = 128
+
 	// maxQueuedProps is the maximum number of block propagations to queue up before
 	// dropping broadcasts. There's not much point in queueing stale blocks, so a few
 	// that might cover uncles should be enough.
@@ -90,26 +96,28 @@ type peer struct {
 	knownTxs    *set.Set // Set of transaction hashes known to be known by this peer
 	knownBlocks *set.Set // Set of block hashes known to be known by this peer
 
-	queuedTxs   chan []*types.Transaction // Queue of transactions to broadcast to the peer
-	queuedProps chan *propEvent           // Queue of blocks to broadcast to the peer
-	queuedAnns  chan *types.Block         // Queue of blocks to announce to the peer
-	term        chan struct{}             // Termination channel to stop the broadcaster
+	queuedTxs    chan []*types.Transaction // Queue of transactions to broadcast to the peer
+	queuedTxAnns chan []common.Hash        // Queue of transaction hashes to announce to the peer
+	queuedProps  chan *propEvent           // Queue of blocks to broadcast to the peer
+	queuedAnns   chan *types.Block         // Queue of blocks to announce to the peer
+	term         chan struct{}             // Termination channel to stop the broadcaster
 }
 
 func newPeer(version int, p *p2p.Peer, rw p2p.MsgReadWriter) *peer {
 	id := p.ID()
 
 	return &peer{
-		Peer:        p,
-		rw:          rw,
-		version:     version,
-		id:          fmt.Sprintf("%x", id[:8]),
-		knownTxs:    set.New(),
-		knownBlocks: set.New(),
-		queuedTxs:   make(chan []*types.Transaction, maxQueuedTxs),
-		queuedProps: make(chan *propEvent, maxQueuedProps),
-		queuedAnns:  make(chan *types.Block, maxQueuedAnns),
-		term:        make(chan struct{}),
+		Peer:         p,
+		rw:           rw,
+		version:      version,
+		id:           fmt.Sprintf("%x", id[:8]),
+		knownTxs:     set.New(),
+		knownBlocks:  set.New(),
+		queuedTxs:    make(chan []*types.Transaction, maxQueuedTxs),
+		queuedTxAnns: make(chan []common.Hash, maxQueuedTxAnns),
+		queuedProps:  make(chan *propEvent, maxQueuedProps),
+		queuedAnns:   make(chan *types.Block, maxQueuedAnns),
+		term:         make(chan struct{}),
 	}
 }
 
@@ -125,6 +133,12 @@ func (p *peer) broadcast() {
 			}
 			glog.V(logger.Detail).Infoln("Broadcast transactions", "count", len(txs))
 
+		case hashes := <-p.queuedTxAnns:
+			if err := p.SendPooledTransactionHashes(hashes); err != nil {
+				return
+			}
+			glog.V(logger.Detail).Infoln("Announced transactions", "count", len(hashes))
+
 		case prop := <-p.queuedProps:
 			if err := p.SendNewBlock(prop.block, prop.td); err != nil {
 				return
@@ -222,6 +236,42 @@ func (p *peer) AsyncSendTransactions(txs []*types.Transaction) {
 	}
 }
 
+// SendPooledTransactionHashes announces the availability of a batch of
+// transactions through a hash notification (eth/65).
+func (p *peer) SendPooledTransactionHashes(hashes []common.Hash) error {
+	for _, hash := range hashes {
+		p.knownTxs.Add(hash)
+	}
+	s, e := p2p.Send(p.rw, NewPooledTransactionHashesMsg, hashes)
+	mlogWireDelegate(p, "send", NewPooledTransactionHashesMsg, s, hashes, nil)
+	return e
+}
+
+// AsyncSendPooledTransactionHashes queues a batch of transaction hashes for
+// announcement to a remote peer. If the peer's announce queue is full, the event
+// is silently dropped.
+func (p *peer) AsyncSendPooledTransactionHashes(hashes []common.Hash) {
+	select {
+	case p.queuedTxAnns <- hashes:
+		for _, hash := range hashes {
+			p.knownTxs.Add(hash)
+		}
+	default:
+		glog.V(logger.Debug).Infoln("Dropping transaction announcement", "count", len(hashes))
+	}
+}
+
+// SendPooledTransactionsRLP sends the requested pooled transactions to the peer
+// from an already RLP encoded format (eth/65).
+func (p *peer) SendPooledTransactionsRLP(hashes []common.Hash, txs []rlp.RawValue) error {
+	for _, hash := range hashes {
+		p.knownTxs.Add(hash)
+	}
+	s, e := p2p.Send(p.rw, PooledTransactionsMsg, txs)
+	mlogWireDelegate(p, "send", PooledTransactionsMsg, s, txs, nil)
+	return e
+}
+
 // SendNewBlockHashes announces the availability of a number of blocks through
 // a hash notification.
 func (p *peer) SendNewBlockHashes(hashes []common.Hash, numbers []uint64) error {
@@ -355,6 +405,14 @@ func (p *peer) RequestNodeData(hashes []common.Hash) error {
 	return e
 }
 
+// RequestTxs fetches a batch of pooled transactions from a remote node (eth/65).
+func (p *peer) RequestTxs(hashes []common.Hash) error {
+	glog.V(logger.Debug).Infof("fetching from: %v req=pooledtxs n=%d first=%s", p, len(hashes), hashes[0].Hex())
+	s, e := p2p.Send(p.rw, GetPooledTransactionsMsg, hashes)
+	mlogWireDelegate(p, "send", GetPooledTransactionsMsg, s, hashes, nil)
+	return e
+}
+
 // RequestReceipts fetches a batch of transaction receipts from a remote node.
 func (p *peer) RequestReceipts(hashes []common.Hash) error {
 	glog.V(logger.Debug).Infof("fetching from: %v req=receipts n=%d first=%s", p, len(hashes), hashes[0].Hex())
@@ -364,8 +422,9 @@ func (p *peer) RequestReceipts(hashes []common.Hash) error {
 }
 
 // Handshake executes the eth protocol handshake, negotiating version number,
-// network IDs, difficulties, head and genesis blocks.
-func (p *peer) Handshake(network uint64, td *big.Int, head common.Hash, genesis common.Hash) error {
+// network IDs, difficulties, head and genesis blocks. Starting with eth/64 the
+// fork identifiers are exchanged too, and peers rejected by forkFilter dropped.
+func (p *peer) Handshake(network uint64, td *big.Int, head common.Hash, genesis common.Hash, forkID forkid.ID, forkFilter forkid.Filter) error {
 	// Send out own handshake in a new thread
 	sendErrc := make(chan error, 1)
 	recErrc := make(chan error, 1)
@@ -381,15 +440,26 @@ func (p *peer) Handshake(network uint64, td *big.Int, head common.Hash, genesis
 		GenesisBlock:    genesis,
 	}
 
+	var msg interface{} = d
+	if p.version >= eth64 {
+		msg = &statusData64{
+			ProtocolVersion: d.ProtocolVersion,
+			NetworkId:       d.NetworkId,
+			TD:              d.TD,
+			CurrentBlock:    d.CurrentBlock,
+			GenesisBlock:    d.GenesisBlock,
+			ForkID:          forkID,
+		}
+	}
 	go func() {
 		var e error
-		sendSize, e = p2p.Send(p.rw, StatusMsg, d)
+		sendSize, e = p2p.Send(p.rw, StatusMsg, msg)
 		sendErrc <- e
 	}()
 	go func() {
 		var e error
 		var s uint32
-		s, e = p.readStatusReturnSize(network, &status, genesis)
+		s, e = p.readStatusReturnSize(network, &status, genesis, forkFilter)
 		recSize = int(s)
 		recErrc <- e
 	}()
@@ -420,7 +490,9 @@ func (p *peer) Handshake(network uint64, td *big.Int, head common.Hash, genesis
 	return nil
 }
 
-func (p *peer) readStatusReturnSize(network uint64, status *statusData, genesis common.Hash) (size uint32, err error) {
+// readStatusReturnSize reads and validates the status message of the remote peer.
+// For eth/64 peers the announced fork ID is validated by forkFilter as well.
+func (p *peer) readStatusReturnSize(network uint64, status *statusData, genesis common.Hash, forkFilter forkid.Filter) (size uint32, err error) {
 	msg, err := p.rw.ReadMsg()
 	if err != nil {
 		return msg.Size, err
@@ -432,7 +504,21 @@ func (p *peer) readStatusReturnSize(network uint64, status *statusData, genesis
 		return msg.Size, errResp(ErrMsgTooLarge, "%v > %v", msg.Size, ProtocolMaxMsgSize)
 	}
 	// Decode the handshake and make sure everything matches
-	if err := msg.Decode(&status); err != nil {
+	var forkID *forkid.ID
+	if p.version >= eth64 {
+		var status64 statusData64
+		if err := msg.Decode(&status64); err != nil {
+			return msg.Size, errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		*status = statusData{
+			ProtocolVersion: status64.ProtocolVersion,
+			NetworkId:       status64.NetworkId,
+			TD:              status64.TD,
+			CurrentBlock:    status64.CurrentBlock,
+			GenesisBlock:    status64.GenesisBlock,
+		}
+		forkID = &status64.ForkID
+	} else if err := msg.Decode(&status); err != nil {
 		return msg.Size, errResp(ErrDecode, "msg %v: %v", msg, err)
 	}
 	if status.GenesisBlock != genesis {
@@ -444,11 +530,16 @@ func (p *peer) readStatusReturnSize(network uint64, status *statusData, genesis
 	if int(status.ProtocolVersion) != p.version {
 		return msg.Size, errResp(ErrProtocolVersionMismatch, "%d (!= %d)", status.ProtocolVersion, p.version)
 	}
+	if forkID != nil {
+		if err := forkFilter(*forkID); err != nil {
+			return msg.Size, errResp(ErrForkIDRejected, "%v", err)
+		}
+	}
 	return msg.Size, nil
 }
 
-func (p *peer) readStatus(network uint64, status *statusData, genesis common.Hash) (err error) {
-	_, err = p.readStatusReturnSize(network, status, genesis)
+func (p *peer) readStatus(network uint64, status *statusData, genesis common.Hash, forkFilter forkid.Filter) (err error) {
+	_, err = p.readStatusReturnSize(network, status, genesis, forkFilter)
 	return
 }
 
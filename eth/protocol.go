@@ -22,6 +22,7 @@ import (
 	"math/big"
 
 	"github.com/eth-classic/go-ethereum/common"
+	"github.com/eth-classic/go-ethereum/core/forkid"
 	"github.com/eth-classic/go-ethereum/core/types"
 	"github.com/eth-classic/go-ethereum/rlp"
 )
@@ -30,16 +31,18 @@ import (
 const (
 	eth62 = 62
 	eth63 = 63
+	eth64 = 64
+	eth65 = 65
 )
 
 // Official short name of the protocol used during capability negotiation.
 var ProtocolName = "eth"
 
 // Supported versions of the eth protocol (first is primary).
-var ProtocolVersions = []uint{eth63, eth62}
+var ProtocolVersions = []uint{eth65, eth64, eth63, eth62}
 
 // Number of implemented message corresponding to different protocol versions.
-var ProtocolLengths = []uint64{17, 8}
+var ProtocolLengths = []uint64{17, 17, 17, 8}
 
 const (
 	NetworkId          = 1
@@ -63,6 +66,11 @@ const (
 	NodeDataMsg    = 0x0e
 	GetReceiptsMsg = 0x0f
 	ReceiptsMsg    = 0x10
+
+	// Protocol messages belonging to eth/65
+	NewPooledTransactionHashesMsg = 0x08
+	GetPooledTransactionsMsg      = 0x09
+	PooledTransactionsMsg         = 0x0a
 )
 
 func ProtocolMessageStringer(m uint) string {
@@ -83,6 +91,12 @@ func ProtocolMessageStringer(m uint) string {
 		return "BlockBodies"
 	case NewBlockMsg:
 		return "NewBlock"
+	case NewPooledTransactionHashesMsg:
+		return "NewPooledTransactionHashes"
+	case GetPooledTransactionsMsg:
+		return "GetPooledTransactions"
+	case PooledTransactionsMsg:
+		return "PooledTransactions"
 	case GetNodeDataMsg:
 		return "GetNodeData"
 	case NodeDataMsg:
@@ -108,6 +122,7 @@ const (
 	ErrNoStatusMsg
 	ErrExtraStatusMsg
 	ErrSuspendedPeer
+	ErrForkIDRejected
 )
 
 func (e errCode) String() string {
@@ -125,6 +140,7 @@ var errorToString = map[int]string{
 	ErrNoStatusMsg:             "No status message",
 	ErrExtraStatusMsg:          "Extra status message",
 	ErrSuspendedPeer:           "Suspended peer",
+	ErrForkIDRejected:          "Fork ID rejected",
 }
 
 type txPool interface {
@@ -134,9 +150,13 @@ type txPool interface {
 	// GetTransactions should return pending transactions.
 	// The slice should be modifiable by the caller.
 	GetTransactions() types.Transactions
+
+	// GetTransaction should return the pooled transaction with the given hash,
+	// or nil if the pool doesn't know about it.
+	GetTransaction(hash common.Hash) *types.Transaction
 }
 
-// statusData is the network packet for the status message.
+// statusData is the network packet for the status message of eth/62 and eth/63.
 type statusData struct {
 	ProtocolVersion uint32
 	NetworkId       uint32
@@ -145,6 +165,17 @@ type statusData struct {
 	GenesisBlock    common.Hash
 }
 
+// statusData64 is the network packet for the status message of eth/64 and later,
+// extending the legacy status with the EIP-2124 fork identifier.
+type statusData64 struct {
+	ProtocolVersion uint32
+	NetworkId       uint32
+	TD              *big.Int
+	CurrentBlock    common.Hash
+	GenesisBlock    common.Hash
+	ForkID          forkid.ID
+}
+
 // newBlockData is the network packet for the block propagation message.
 type newBlockData struct {
 	Block *types.Block
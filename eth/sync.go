@@ -43,12 +43,22 @@ type txsync struct {
 	txs []*types.Transaction
 }
 
-// syncTransactions starts sending all currently pending transactions to the given peer.
+// syncTransactions starts sending all currently pending transactions to the given
+// peer. Peers speaking eth/65 or later are only announced the hashes, retrieving
+// the transactions they don't yet know about on demand.
 func (pm *ProtocolManager) syncTransactions(p *peer) {
 	txs := pm.txpool.GetTransactions()
 	if len(txs) == 0 {
 		return
 	}
+	if p.version >= eth65 {
+		hashes := make([]common.Hash, len(txs))
+		for i, tx := range txs {
+			hashes[i] = tx.Hash()
+		}
+		p.AsyncSendPooledTransactionHashes(hashes)
+		return
+	}
 	select {
 	case pm.txsyncCh <- &txsync{p, txs}:
 	case <-pm.quitSync:
@@ -132,6 +142,8 @@ func (pm *ProtocolManager) syncer() {
 	// Start and ensure cleanup of sync mechanisms
 	pm.fetcher.Start()
 	defer pm.fetcher.Stop()
+	pm.txFetcher.Start()
+	defer pm.txFetcher.Stop()
 	defer pm.downloader.Terminate()
 
 	// Wait for different events to fire synchronisation operations
@@ -206,6 +218,10 @@ func (pm *ProtocolManager) synchronise(peer *peer) {
 		glog.D(logger.Info).Infoln("Fast sync complete, auto disabling")
 	}
 	atomic.StoreUint32(&pm.acceptsTxs, 1) // Mark initial sync done
+	// Artificial finality only protects a chain we're confident is the canonical one
+	if pm.artificialFinality && !pm.blockchain.IsArtificialFinalityEnabled() {
+		pm.blockchain.EnableArtificialFinality(true)
+	}
 	if head := pm.blockchain.CurrentBlock(); head.NumberU64() > 0 {
 		// We've completed a sync cycle, notify all peers of new state. This path is
 		// essential in star-topology networks where a gateway node needs to notify
@@ -17,6 +17,7 @@
 package ethdb
 
 import (
+	"os"
 	"path/filepath"
 
 	"strconv"
@@ -57,8 +58,10 @@ func SetHandleRatio(db string, ratio float64) {
 }
 
 type LDBDatabase struct {
-	file string
-	db   *leveldb.DB
+	file     string
+	db       *leveldb.DB
+	ancients *Freezer // Ancient store of the immutable chain data, nil if none
+	readonly bool     // Whether the database was opened read-only, failing all writes
 
 	quitLock sync.Mutex      // Mutex protecting the quit channel access
 	quitChan chan chan error // Quit channel to stop the metrics collection before closing the database
@@ -66,6 +69,17 @@ type LDBDatabase struct {
 
 // NewLDBDatabase returns a LevelDB wrapped object.
 func NewLDBDatabase(file string, cache int, handles int) (*LDBDatabase, error) {
+	return openLDBDatabase(file, cache, handles, false)
+}
+
+// NewReadOnlyLDBDatabase returns a LevelDB wrapped object of an existing
+// database opened read-only, which other read-only processes may open too.
+// Every write fails with ErrReadOnly.
+func NewReadOnlyLDBDatabase(file string, cache int, handles int) (*LDBDatabase, error) {
+	return openLDBDatabase(file, cache, handles, true)
+}
+
+func openLDBDatabase(file string, cache int, handles int, readonly bool) (*LDBDatabase, error) {
 	// Calculate the cache and file descriptor allowance for this particular database
 	cache = int(float64(cache) * cacheRatio[filepath.Base(file)])
 	if cache < 16 {
@@ -78,14 +92,16 @@ func NewLDBDatabase(file string, cache int, handles int) (*LDBDatabase, error) {
 	glog.V(logger.Info).Infof("Allotted %dMB cache and %d file handles to %s", cache, handles, file)
 	glog.D(logger.Warn).Infof("Allotted %s cache and %s file handles to %s", logger.ColorGreen(strconv.Itoa(cache)+"MB"), logger.ColorGreen(strconv.Itoa(handles)), logger.ColorGreen(file))
 
-	// Open the db and recover any potential corruptions
+	// Open the db and recover any potential corruptions, unless read-only
 	db, err := leveldb.OpenFile(file, &opt.Options{
 		OpenFilesCacheCapacity: handles,
 		BlockCacheCapacity:     cache / 2 * opt.MiB,
 		WriteBuffer:            cache / 4 * opt.MiB, // Two of these are used internally
 		Filter:                 filter.NewBloomFilter(10),
+		ReadOnly:               readonly,
+		ErrorIfMissing:         readonly,
 	})
-	if _, corrupted := err.(*errors.ErrCorrupted); corrupted {
+	if _, corrupted := err.(*errors.ErrCorrupted); corrupted && !readonly {
 		db, err = leveldb.RecoverFile(file, nil)
 	}
 	// (Re)check for errors and abort if opening of the db failed
@@ -93,11 +109,55 @@ func NewLDBDatabase(file string, cache int, handles int) (*LDBDatabase, error) {
 		return nil, err
 	}
 	return &LDBDatabase{
-		file: file,
-		db:   db,
+		file:     file,
+		db:       db,
+		readonly: readonly,
 	}, nil
 }
 
+// NewLDBDatabaseWithFreezer returns a LevelDB wrapped object along with the
+// ancient store in the given directory, through which the database serves the
+// immutable chain data moved out of LevelDB.
+func NewLDBDatabaseWithFreezer(file string, ancient string, cache int, handles int) (*LDBDatabase, error) {
+	db, err := NewLDBDatabase(file, cache, handles)
+	if err != nil {
+		return nil, err
+	}
+	if db.ancients, err = NewFreezer(ancient); err != nil {
+		db.Close()
+		return nil, err
+	}
+	glog.V(logger.Info).Infof("Opened ancient store at %s with %d blocks", ancient, db.ancients.Ancients())
+	return db, nil
+}
+
+// NewReadOnlyLDBDatabaseWithFreezer is the read-only NewLDBDatabaseWithFreezer.
+// The ancient store is left out if the directory doesn't exist, as databases
+// created before it was introduced don't have one.
+func NewReadOnlyLDBDatabaseWithFreezer(file string, ancient string, cache int, handles int) (*LDBDatabase, error) {
+	db, err := NewReadOnlyLDBDatabase(file, cache, handles)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(ancient); os.IsNotExist(err) {
+		return db, nil
+	}
+	if db.ancients, err = NewReadOnlyFreezer(ancient); err != nil {
+		db.Close()
+		return nil, err
+	}
+	glog.V(logger.Info).Infof("Opened read-only ancient store at %s with %d blocks", ancient, db.ancients.Ancients())
+	return db, nil
+}
+
+// AncientStore returns the ancient store of the database, nil if it has none.
+func (db *LDBDatabase) AncientStore() AncientStore {
+	if db.ancients == nil {
+		return nil
+	}
+	return db.ancients
+}
+
 // Path returns the path to the database directory.
 func (db *LDBDatabase) Path() string {
 	return db.file
@@ -105,6 +165,9 @@ func (db *LDBDatabase) Path() string {
 
 // Put puts the given key / value to the queue
 func (self *LDBDatabase) Put(key []byte, value []byte) error {
+	if self.readonly {
+		return ErrReadOnly
+	}
 	return self.db.Put(key, value, nil)
 }
 
@@ -124,6 +187,9 @@ func (db *LDBDatabase) Has(key []byte) (bool, error) {
 
 // Delete deletes the key from the queue and database
 func (self *LDBDatabase) Delete(key []byte) error {
+	if self.readonly {
+		return ErrReadOnly
+	}
 	// Execute the actual operation
 	return self.db.Delete(key, nil)
 }
@@ -140,10 +206,36 @@ func NewBytesPrefix(prefix []byte) *ldbutil.Range {
 	return ldbutil.BytesPrefix(prefix)
 }
 
+// NewIteratorWithPrefix creates an iterator over the entries whose key starts
+// with the given prefix.
+func (db *LDBDatabase) NewIteratorWithPrefix(prefix []byte) Iterator {
+	return db.db.NewIterator(ldbutil.BytesPrefix(prefix), nil)
+}
+
+// NewIteratorWithRange creates an iterator over the entries whose key is within
+// [start, limit), a nil limit meaning no upper bound.
+func (db *LDBDatabase) NewIteratorWithRange(start, limit []byte) Iterator {
+	return db.db.NewIterator(&ldbutil.Range{Start: start, Limit: limit}, nil)
+}
+
+// Compact compacts the entries whose key is within [start, limit), nil bounds
+// meaning the start or end of the key space.
+func (db *LDBDatabase) Compact(start, limit []byte) error {
+	if db.readonly {
+		return ErrReadOnly
+	}
+	return db.db.CompactRange(ldbutil.Range{Start: start, Limit: limit})
+}
+
 func (self *LDBDatabase) Close() {
 	if err := self.db.Close(); err != nil {
 		glog.Errorf("eth: DB %s: %s", self.file, err)
 	}
+	if self.ancients != nil {
+		if err := self.ancients.Close(); err != nil {
+			glog.Errorf("eth: ancient store %s: %s", self.ancients.Path(), err)
+		}
+	}
 }
 
 func (self *LDBDatabase) LDB() *leveldb.DB {
@@ -153,13 +245,14 @@ func (self *LDBDatabase) LDB() *leveldb.DB {
 // TODO: remove this stuff and expose leveldb directly
 
 func (db *LDBDatabase) NewBatch() Batch {
-	return &ldbBatch{db: db.db, b: new(leveldb.Batch)}
+	return &ldbBatch{db: db.db, b: new(leveldb.Batch), readonly: db.readonly}
 }
 
 type ldbBatch struct {
-	db   *leveldb.DB
-	b    *leveldb.Batch
-	size int
+	db       *leveldb.DB
+	b        *leveldb.Batch
+	size     int
+	readonly bool
 }
 
 func (b *ldbBatch) Put(key, value []byte) error {
@@ -168,10 +261,24 @@ func (b *ldbBatch) Put(key, value []byte) error {
 	return nil
 }
 
+func (b *ldbBatch) Delete(key []byte) error {
+	b.b.Delete(key)
+	b.size += len(key)
+	return nil
+}
+
 func (b *ldbBatch) Write() error {
+	if b.readonly {
+		return ErrReadOnly
+	}
 	return b.db.Write(b.b, nil)
 }
 
+func (b *ldbBatch) Reset() {
+	b.b.Reset()
+	b.size = 0
+}
+
 func (b *ldbBatch) ValueSize() int {
 	return b.size
 }
@@ -206,10 +313,53 @@ func (dt *table) Delete(key []byte) error {
 	return dt.db.Delete(append([]byte(dt.prefix), key...))
 }
 
+func (dt *table) NewIteratorWithPrefix(prefix []byte) Iterator {
+	return &tableIterator{dt.db.NewIteratorWithPrefix(append([]byte(dt.prefix), prefix...)), len(dt.prefix)}
+}
+
+func (dt *table) NewIteratorWithRange(start, limit []byte) Iterator {
+	// A nil limit is bounded by the end of the table
+	if limit == nil {
+		limit = ldbutil.BytesPrefix([]byte(dt.prefix)).Limit
+	} else {
+		limit = append([]byte(dt.prefix), limit...)
+	}
+	return &tableIterator{dt.db.NewIteratorWithRange(append([]byte(dt.prefix), start...), limit), len(dt.prefix)}
+}
+
+func (dt *table) Compact(start, limit []byte) error {
+	if limit == nil {
+		limit = ldbutil.BytesPrefix([]byte(dt.prefix)).Limit
+	} else {
+		limit = append([]byte(dt.prefix), limit...)
+	}
+	return dt.db.Compact(append([]byte(dt.prefix), start...), limit)
+}
+
 func (dt *table) Close() {
 	// Do nothing; don't close the underlying DB.
 }
 
+// tableIterator is an iterator over the entries of a table, stripping the keys
+// of the table prefix.
+type tableIterator struct {
+	it     Iterator
+	prefix int
+}
+
+func (it *tableIterator) Next() bool    { return it.it.Next() }
+func (it *tableIterator) Error() error  { return it.it.Error() }
+func (it *tableIterator) Value() []byte { return it.it.Value() }
+func (it *tableIterator) Release()      { it.it.Release() }
+
+func (it *tableIterator) Key() []byte {
+	key := it.it.Key()
+	if key == nil {
+		return nil
+	}
+	return key[it.prefix:]
+}
+
 type tableBatch struct {
 	batch  Batch
 	prefix string
@@ -228,10 +378,18 @@ func (tb *tableBatch) Put(key, value []byte) error {
 	return tb.batch.Put(append([]byte(tb.prefix), key...), value)
 }
 
+func (tb *tableBatch) Delete(key []byte) error {
+	return tb.batch.Delete(append([]byte(tb.prefix), key...))
+}
+
 func (tb *tableBatch) Write() error {
 	return tb.batch.Write()
 }
 
+func (tb *tableBatch) Reset() {
+	tb.batch.Reset()
+}
+
 func (tb *tableBatch) ValueSize() int {
 	return tb.batch.ValueSize()
 }
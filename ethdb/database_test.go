@@ -0,0 +1,268 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethdb
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testDatabases runs fn against every database backend, along with a table on
+// top of each sharing its key space with unrelated entries.
+func testDatabases(t *testing.T, fn func(t *testing.T, db Database)) {
+	dir, err := ioutil.TempDir("", "ethdb-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ldb, err := NewLDBDatabase(dir, 0, 0)
+	if err != nil {
+		t.Fatalf("failed to open leveldb database: %v", err)
+	}
+	defer ldb.Close()
+
+	mdb, _ := NewMemDatabase()
+
+	for name, db := range map[string]Database{"leveldb": ldb, "memory": mdb} {
+		fn(t, db)
+		if t.Failed() {
+			t.Fatalf("%s database failed", name)
+		}
+		// Surround the table with entries sorting right before and after it
+		for _, key := range []string{"tabla", "tablf", "tabld"} {
+			db.Put([]byte(key), []byte(key))
+		}
+		fn(t, NewTable(db, "table"))
+		if t.Failed() {
+			t.Fatalf("%s table failed", name)
+		}
+	}
+}
+
+// iterate collects the keys and values of an iterator as a flat list.
+func iterate(t *testing.T, it Iterator) []string {
+	defer it.Release()
+
+	var entries []string
+	for it.Next() {
+		entries = append(entries, string(it.Key())+"="+string(it.Value()))
+	}
+	if err := it.Error(); err != nil {
+		t.Errorf("iteration failed: %v", err)
+	}
+	return entries
+}
+
+func TestIterators(t *testing.T) {
+	testDatabases(t, func(t *testing.T, db Database) {
+		for _, key := range []string{"b2", "a1", "c", "b1", "a2", "b"} {
+			if err := db.Put([]byte(key), []byte(strings.ToUpper(key))); err != nil {
+				t.Fatalf("failed to put %s: %v", key, err)
+			}
+		}
+		tests := []struct {
+			it   Iterator
+			want []string
+		}{
+			{db.NewIteratorWithPrefix(nil), []string{"a1=A1", "a2=A2", "b=B", "b1=B1", "b2=B2", "c=C"}},
+			{db.NewIteratorWithPrefix([]byte("b")), []string{"b=B", "b1=B1", "b2=B2"}},
+			{db.NewIteratorWithPrefix([]byte("d")), nil},
+			{db.NewIteratorWithRange([]byte("a2"), []byte("b2")), []string{"a2=A2", "b=B", "b1=B1"}},
+			{db.NewIteratorWithRange([]byte("b1"), nil), []string{"b1=B1", "b2=B2", "c=C"}},
+			{db.NewIteratorWithRange(nil, []byte("b")), []string{"a1=A1", "a2=A2"}},
+		}
+		for i, tt := range tests {
+			if have := iterate(t, tt.it); !reflect.DeepEqual(have, tt.want) {
+				t.Errorf("test %d: entries mismatch: have %v, want %v", i, have, tt.want)
+			}
+		}
+		if err := db.Compact(nil, nil); err != nil {
+			t.Errorf("failed to compact: %v", err)
+		}
+		if have := iterate(t, db.NewIteratorWithPrefix([]byte("a"))); !reflect.DeepEqual(have, []string{"a1=A1", "a2=A2"}) {
+			t.Errorf("entries mismatch after compaction: have %v", have)
+		}
+		// Clean up for the next run, deleting the entries while iterating
+		it := db.NewIteratorWithPrefix(nil)
+		for it.Next() {
+			if len(it.Key()) <= 2 {
+				db.Delete(it.Key())
+			}
+		}
+		it.Release()
+	})
+}
+
+func TestBatchDelete(t *testing.T) {
+	testDatabases(t, func(t *testing.T, db Database) {
+		db.Put([]byte("k1"), []byte("v1"))
+		db.Put([]byte("k2"), []byte("v2"))
+
+		batch := db.NewBatch()
+		batch.Delete([]byte("k1"))
+		batch.Put([]byte("k3"), []byte("v3"))
+		if batch.ValueSize() == 0 {
+			t.Errorf("batch size not accounting for the writes")
+		}
+		// Nothing is written before the batch is
+		if ok, _ := db.Has([]byte("k1")); !ok {
+			t.Errorf("entry deleted before writing the batch")
+		}
+		if err := batch.Write(); err != nil {
+			t.Fatalf("failed to write batch: %v", err)
+		}
+		if have := iterate(t, db.NewIteratorWithPrefix([]byte("k"))); !reflect.DeepEqual(have, []string{"k2=v2", "k3=v3"}) {
+			t.Errorf("entries mismatch after batch: have %v", have)
+		}
+		// A reset batch drops its writes and can be reused
+		batch.Reset()
+		batch.Delete([]byte("k2"))
+		batch.Reset()
+		if batch.ValueSize() != 0 {
+			t.Errorf("reset batch size mismatch: have %d, want 0", batch.ValueSize())
+		}
+		batch.Delete([]byte("k3"))
+		if err := batch.Write(); err != nil {
+			t.Fatalf("failed to write reset batch: %v", err)
+		}
+		if have := iterate(t, db.NewIteratorWithPrefix([]byte("k"))); !reflect.DeepEqual(have, []string{"k2=v2"}) {
+			t.Errorf("entries mismatch after reset batch: have %v", have)
+		}
+		db.Delete([]byte("k2"))
+	})
+}
+
+func TestTableBatchDelete(t *testing.T) {
+	db, _ := NewMemDatabase()
+	db.Put([]byte("tablekey"), []byte("in"))
+	db.Put([]byte("key"), []byte("out"))
+
+	batch := NewTableBatch(db, "table")
+	batch.Delete([]byte("key"))
+	if err := batch.Write(); err != nil {
+		t.Fatalf("failed to write batch: %v", err)
+	}
+	if ok, _ := db.Has([]byte("tablekey")); ok {
+		t.Errorf("table entry not deleted")
+	}
+	if ok, _ := db.Has([]byte("key")); !ok {
+		t.Errorf("entry outside the table deleted")
+	}
+}
+
+func TestReadOnly(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ethdb-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var (
+		file    = filepath.Join(dir, "chaindata")
+		ancient = filepath.Join(file, "ancient")
+	)
+	// A missing database isn't created
+	if _, err := NewReadOnlyLDBDatabaseWithFreezer(file, ancient, 0, 0); err == nil {
+		t.Fatalf("read-only database created")
+	}
+	// Write some data, leaving a partial ancient item behind
+	db, err := NewLDBDatabaseWithFreezer(file, ancient, 0, 0)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	db.Put([]byte("key"), []byte("value"))
+	for number := uint64(0); number < 2; number++ {
+		item := []byte{byte(number)}
+		if err := db.ancients.AppendAncient(number, item, item, item, item, item); err != nil {
+			t.Fatalf("failed to append ancient #%d: %v", number, err)
+		}
+	}
+	db.ancients.tables[AncientHashes].append([]byte{0xff})
+	db.Close()
+
+	// Reopen it read-only twice, both serving the data and failing all writes
+	for i := 0; i < 2; i++ {
+		rodb, err := NewReadOnlyLDBDatabaseWithFreezer(file, ancient, 0, 0)
+		if err != nil {
+			t.Fatalf("failed to open read-only database %d: %v", i, err)
+		}
+		defer rodb.Close()
+
+		if value, err := rodb.Get([]byte("key")); err != nil || string(value) != "value" {
+			t.Errorf("read-only value mismatch: have %q, %v, want %q", value, err, "value")
+		}
+		store := rodb.AncientStore()
+		if n := store.Ancients(); n != 2 {
+			t.Errorf("read-only ancient count mismatch: have %d, want 2", n)
+		}
+		if blob, err := store.Ancient(AncientHashes, 1); err != nil || !bytes.Equal(blob, []byte{1}) {
+			t.Errorf("read-only ancient mismatch: have %x, %v, want 01", blob, err)
+		}
+		batch := rodb.NewBatch()
+		batch.Put([]byte("key"), []byte("other"))
+
+		for name, err := range map[string]error{
+			"put":      rodb.Put([]byte("key"), []byte("other")),
+			"delete":   rodb.Delete([]byte("key")),
+			"batch":    batch.Write(),
+			"compact":  rodb.Compact(nil, nil),
+			"append":   store.AppendAncient(2, nil, nil, nil, nil, nil),
+			"truncate": store.TruncateAncients(0),
+		} {
+			if err != ErrReadOnly {
+				t.Errorf("read-only %s error mismatch: have %v, want %v", name, err, ErrReadOnly)
+			}
+		}
+	}
+	// The partial ancient item was left alone
+	if stat, err := os.Stat(filepath.Join(ancient, AncientHashes+".idx")); err != nil || stat.Size() != 3*8 {
+		t.Errorf("partial ancient item discarded by read-only freezer: %v", err)
+	}
+}
+
+func TestReadOnlyWithoutFreezer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ethdb-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := NewLDBDatabase(dir, 0, 0)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	db.Close()
+
+	rodb, err := NewReadOnlyLDBDatabaseWithFreezer(dir, filepath.Join(dir, "ancient"), 0, 0)
+	if err != nil {
+		t.Fatalf("failed to open read-only database: %v", err)
+	}
+	defer rodb.Close()
+
+	if store := rodb.AncientStore(); store != nil {
+		t.Errorf("read-only database has an ancient store")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "ancient")); !os.IsNotExist(err) {
+		t.Errorf("read-only database created the ancient store: %v", err)
+	}
+}
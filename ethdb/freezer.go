@@ -0,0 +1,391 @@
+// Copyright 2020 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package ethdb
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Kinds of the chain data kept by the ancient store, one table each.
+const (
+	AncientHashes   = "hashes"   // Canonical block hashes
+	AncientHeaders  = "headers"  // Block headers in RLP encoding
+	AncientBodies   = "bodies"   // Block bodies in RLP encoding
+	AncientReceipts = "receipts" // Block receipts in their RLP storage encoding
+	AncientTds      = "diffs"    // Block total difficulties in RLP encoding
+)
+
+// ancientKinds are the tables of the ancient store, in the order items are appended.
+var ancientKinds = []string{AncientHashes, AncientHeaders, AncientBodies, AncientReceipts, AncientTds}
+
+// AncientKinds returns the kinds of data kept by the ancient store.
+func AncientKinds() []string {
+	return append([]string{}, ancientKinds...)
+}
+
+var (
+	errUnknownAncient  = errors.New("unknown ancient kind")
+	errAncientNotFound = errors.New("ancient item not found")
+	errAncientOrder    = errors.New("ancient items appended out of order")
+)
+
+// Freezer is an AncientStore keeping each kind of data in a flat data file,
+// along with an index file holding the end offset of every item.
+type Freezer struct {
+	dir      string
+	tables   map[string]*freezerTable
+	items    uint64 // Number of blocks stored in every table
+	readonly bool   // Whether the freezer was opened read-only, failing all writes
+
+	lock sync.RWMutex
+}
+
+// NewFreezer opens the freezer in the given directory, creating it if missing.
+// Items partially appended when the process last stopped are discarded.
+func NewFreezer(dir string) (*Freezer, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	return openFreezer(dir, false)
+}
+
+// NewReadOnlyFreezer opens an existing freezer read-only. Items partially
+// appended when the process last stopped are left alone, but not served.
+// Every write fails with ErrReadOnly.
+func NewReadOnlyFreezer(dir string) (*Freezer, error) {
+	return openFreezer(dir, true)
+}
+
+func openFreezer(dir string, readonly bool) (*Freezer, error) {
+	f := &Freezer{dir: dir, tables: make(map[string]*freezerTable), readonly: readonly}
+	for i, kind := range ancientKinds {
+		table, err := openFreezerTable(dir, kind, readonly)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		f.tables[kind] = table
+		if i == 0 || table.items < f.items {
+			f.items = table.items
+		}
+	}
+	// Tables appended to before a crash may be ahead of the others, cut them back
+	if readonly {
+		return f, nil
+	}
+	if err := f.truncate(f.items); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+// Path returns the directory of the freezer.
+func (f *Freezer) Path() string {
+	return f.dir
+}
+
+// Ancients returns the number of blocks in the freezer.
+func (f *Freezer) Ancients() uint64 {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	return f.items
+}
+
+// Ancient retrieves the data of the given kind of a frozen block.
+func (f *Freezer) Ancient(kind string, number uint64) ([]byte, error) {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	table, ok := f.tables[kind]
+	if !ok {
+		return nil, errUnknownAncient
+	}
+	if number >= f.items {
+		return nil, errAncientNotFound
+	}
+	return table.retrieve(number)
+}
+
+// AncientSize returns the size of the data and index files of the given kind.
+func (f *Freezer) AncientSize(kind string) (uint64, error) {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	table, ok := f.tables[kind]
+	if !ok {
+		return 0, errUnknownAncient
+	}
+	return table.size + table.items*8, nil
+}
+
+// AppendAncient stores the data of the block following the frozen ones. If any
+// table fails to store its item, the others are rolled back.
+func (f *Freezer) AppendAncient(number uint64, hash, header, body, receipts, td []byte) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.readonly {
+		return ErrReadOnly
+	}
+	if number != f.items {
+		return errAncientOrder
+	}
+	blobs := map[string][]byte{
+		AncientHashes:   hash,
+		AncientHeaders:  header,
+		AncientBodies:   body,
+		AncientReceipts: receipts,
+		AncientTds:      td,
+	}
+	for _, kind := range ancientKinds {
+		if err := f.tables[kind].append(blobs[kind]); err != nil {
+			if rerr := f.truncate(f.items); rerr != nil {
+				return fmt.Errorf("%v, rollback failed: %v", err, rerr)
+			}
+			return err
+		}
+	}
+	f.items++
+	return nil
+}
+
+// TruncateAncients discards the blocks above the given number of blocks.
+func (f *Freezer) TruncateAncients(items uint64) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.readonly {
+		return ErrReadOnly
+	}
+	if items >= f.items {
+		return nil
+	}
+	if err := f.truncate(items); err != nil {
+		return err
+	}
+	f.items = items
+	return nil
+}
+
+// truncate cuts every table back to the given number of items.
+func (f *Freezer) truncate(items uint64) error {
+	for _, table := range f.tables {
+		if err := table.truncate(items); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Sync flushes the appended data of every table to disk.
+func (f *Freezer) Sync() error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if f.readonly {
+		return nil
+	}
+	for _, table := range f.tables {
+		if err := table.sync(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close syncs and closes the files of every table.
+func (f *Freezer) Close() error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	var errs []error
+	for _, table := range f.tables {
+		if err := table.close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%v", errs)
+	}
+	return nil
+}
+
+// freezerTable is a single kind of data of the freezer. The data file holds the
+// items back to back, the index file the 8 byte big endian end offset of each.
+type freezerTable struct {
+	data     *os.File
+	index    *os.File
+	items    uint64 // Number of items in the table
+	size     uint64 // Size of the data file
+	readonly bool   // Whether the files were opened read-only
+}
+
+// openFreezerTable opens the files of a table, dropping the trailing items
+// whose data or index entry wasn't fully written. Read-only tables ignore them
+// instead.
+func openFreezerTable(dir, name string, readonly bool) (*freezerTable, error) {
+	flag := os.O_RDWR | os.O_CREATE
+	if readonly {
+		flag = os.O_RDONLY
+	}
+	data, err := os.OpenFile(filepath.Join(dir, name+".dat"), flag, 0644)
+	if err != nil {
+		return nil, err
+	}
+	index, err := os.OpenFile(filepath.Join(dir, name+".idx"), flag, 0644)
+	if err != nil {
+		data.Close()
+		return nil, err
+	}
+	t := &freezerTable{data: data, index: index, readonly: readonly}
+
+	dataStat, err := data.Stat()
+	if err != nil {
+		t.close()
+		return nil, err
+	}
+	indexStat, err := index.Stat()
+	if err != nil {
+		t.close()
+		return nil, err
+	}
+	for t.items = uint64(indexStat.Size()) / 8; t.items > 0; t.items-- {
+		end, err := t.offset(t.items - 1)
+		if err != nil {
+			t.close()
+			return nil, err
+		}
+		if end <= uint64(dataStat.Size()) {
+			break
+		}
+	}
+	if readonly {
+		if t.items > 0 {
+			if t.size, err = t.offset(t.items - 1); err != nil {
+				t.close()
+				return nil, err
+			}
+		}
+		return t, nil
+	}
+	if err := t.truncate(t.items); err != nil {
+		t.close()
+		return nil, err
+	}
+	return t, nil
+}
+
+// offset returns the end offset of an item in the data file.
+func (t *freezerTable) offset(item uint64) (uint64, error) {
+	var buf [8]byte
+	if _, err := t.index.ReadAt(buf[:], int64(item*8)); err != nil {
+		return 0, err
+	}
+	return binary.BigEndian.Uint64(buf[:]), nil
+}
+
+// retrieve reads an item from the data file.
+func (t *freezerTable) retrieve(item uint64) ([]byte, error) {
+	var start uint64
+	if item > 0 {
+		var err error
+		if start, err = t.offset(item - 1); err != nil {
+			return nil, err
+		}
+	}
+	end, err := t.offset(item)
+	if err != nil {
+		return nil, err
+	}
+	if end < start {
+		return nil, fmt.Errorf("corrupted ancient index: item %d ends at %d before its start %d", item, end, start)
+	}
+	blob := make([]byte, end-start)
+	if _, err := t.data.ReadAt(blob, int64(start)); err != nil {
+		return nil, err
+	}
+	return blob, nil
+}
+
+// append writes an item after the last one, data first so an interrupted
+// append leaves no index entry pointing past the data.
+func (t *freezerTable) append(blob []byte) error {
+	if _, err := t.data.WriteAt(blob, int64(t.size)); err != nil {
+		return err
+	}
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], t.size+uint64(len(blob)))
+	if _, err := t.index.WriteAt(buf[:], int64(t.items*8)); err != nil {
+		return err
+	}
+	t.items++
+	t.size += uint64(len(blob))
+	return nil
+}
+
+// truncate discards the items above the given number of items.
+func (t *freezerTable) truncate(items uint64) error {
+	var size uint64
+	if items > 0 {
+		var err error
+		if size, err = t.offset(items - 1); err != nil {
+			return err
+		}
+	}
+	if err := t.index.Truncate(int64(items * 8)); err != nil {
+		return err
+	}
+	if err := t.data.Truncate(int64(size)); err != nil {
+		return err
+	}
+	t.items, t.size = items, size
+	return nil
+}
+
+// sync flushes the files of the table to disk.
+func (t *freezerTable) sync() error {
+	if err := t.data.Sync(); err != nil {
+		return err
+	}
+	return t.index.Sync()
+}
+
+// close syncs and closes the files of the table.
+func (t *freezerTable) close() error {
+	var errs []error
+	for _, file := range []*os.File{t.data, t.index} {
+		if !t.readonly {
+			if err := file.Sync(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+		if err := file.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%v", errs)
+	}
+	return nil
+}
@@ -16,6 +16,11 @@
 
 package ethdb
 
+import "errors"
+
+// ErrReadOnly is returned by every write to a database opened read-only.
+var ErrReadOnly = errors.New("database is read-only")
+
 // Code using batches should try to add this much data to the batch.
 // The value was determined empirically.
 const IdealBatchSize = 100 * 1024
@@ -25,17 +30,129 @@ type Putter interface {
 	Put(key []byte, value []byte) error
 }
 
+// Deleter wraps the database delete operation supported by both batches and regular databases.
+type Deleter interface {
+	Delete(key []byte) error
+}
+
+// Iterator iterates over the entries of a database in ascending key order. The
+// key and value returned must not be modified, and are only valid until the
+// next call to Next. An iterator must be released once it's no longer used.
+type Iterator interface {
+	Next() bool
+	Error() error
+	Key() []byte
+	Value() []byte
+	Release()
+}
+
+// Iteratee wraps the creation of iterators over the key space of a database.
+type Iteratee interface {
+	// NewIteratorWithPrefix creates an iterator over the entries whose key
+	// starts with the given prefix.
+	NewIteratorWithPrefix(prefix []byte) Iterator
+
+	// NewIteratorWithRange creates an iterator over the entries whose key is
+	// within [start, limit), a nil limit meaning no upper bound.
+	NewIteratorWithRange(start, limit []byte) Iterator
+}
+
+// Compacter wraps the compaction of the key space of a database.
+type Compacter interface {
+	// Compact compacts the entries whose key is within [start, limit), nil
+	// bounds meaning the start or end of the key space.
+	Compact(start, limit []byte) error
+}
+
 type Database interface {
 	Putter
+	Deleter
+	Iteratee
+	Compacter
 	Get(key []byte) ([]byte, error)
 	Has(key []byte) (bool, error)
-	Delete(key []byte) error
 	Close()
 	NewBatch() Batch
 }
 
 type Batch interface {
 	Putter
+	Deleter
 	ValueSize() int // amount of data in the batch
 	Write() error
+	Reset() // drops the writes of the batch, so it can be reused
+}
+
+// AncientStore is an append-only store of immutable canonical chain data,
+// indexed by block number.
+type AncientStore interface {
+	// Ancients returns the number of blocks in the store, all blocks below
+	// that number being present.
+	Ancients() uint64
+
+	// Ancient retrieves the data of the given kind of a stored block.
+	Ancient(kind string, number uint64) ([]byte, error)
+
+	// AncientSize returns the disk size of the data of the given kind.
+	AncientSize(kind string) (uint64, error)
+
+	// AppendAncient stores the data of the block following the stored ones.
+	AppendAncient(number uint64, hash, header, body, receipts, td []byte) error
+
+	// TruncateAncients discards the blocks above the given number of blocks.
+	TruncateAncients(items uint64) error
+
+	// Sync flushes the appended data to disk.
+	Sync() error
+}
+
+// AncientDatabase is a database keeping the immutable part of the chain in an
+// ancient store.
+type AncientDatabase interface {
+	Database
+	AncientStore() AncientStore // Ancient store of the database, nil if there's none
+}
+
+// Batcher wraps the batch creation of a database.
+type Batcher interface {
+	NewBatch() Batch
+}
+
+// BatchWriter is a Putter collecting the writes into batches of a database,
+// each written out once it holds IdealBatchSize worth of data. Flush must be
+// called to write out the last batch.
+type BatchWriter struct {
+	db      Batcher
+	batch   Batch
+	pending int // Number of writes in the current batch
+}
+
+// NewBatchWriter creates a writer batching the writes to db.
+func NewBatchWriter(db Batcher) *BatchWriter {
+	return &BatchWriter{db: db, batch: db.NewBatch()}
+}
+
+// Put adds a write to the current batch, writing it out if it grew large enough.
+func (w *BatchWriter) Put(key, value []byte) error {
+	if err := w.batch.Put(key, value); err != nil {
+		return err
+	}
+	w.pending++
+	if w.batch.ValueSize() >= IdealBatchSize {
+		return w.Flush()
+	}
+	return nil
+}
+
+// Flush writes out the current batch.
+func (w *BatchWriter) Flush() error {
+	if w.pending == 0 {
+		return nil
+	}
+	if err := w.batch.Write(); err != nil {
+		return err
+	}
+	w.batch.Reset()
+	w.pending = 0
+	return nil
 }
@@ -18,9 +18,11 @@ package ethdb
 
 import (
 	"errors"
+	"sort"
 	"sync"
 
 	"github.com/eth-classic/go-ethereum/common"
+	"github.com/syndtr/goleveldb/leveldb/util"
 )
 
 /*
@@ -99,11 +101,84 @@ func (db *MemDatabase) Delete(key []byte) error {
 
 func (db *MemDatabase) Close() {}
 
+// NewIteratorWithPrefix creates an iterator over the entries whose key starts
+// with the given prefix.
+func (db *MemDatabase) NewIteratorWithPrefix(prefix []byte) Iterator {
+	r := util.BytesPrefix(prefix)
+	return db.NewIteratorWithRange(r.Start, r.Limit)
+}
+
+// NewIteratorWithRange creates an iterator over the entries whose key is within
+// [start, limit), a nil limit meaning no upper bound. The iterator works on a
+// copy of the entries, so it isn't affected by later writes.
+func (db *MemDatabase) NewIteratorWithRange(start, limit []byte) Iterator {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	var keys []string
+	for key := range db.db {
+		if key >= string(start) && (limit == nil || key < string(limit)) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	it := &memIterator{index: -1, keys: make([][]byte, len(keys)), values: make([][]byte, len(keys))}
+	for i, key := range keys {
+		it.keys[i], it.values[i] = []byte(key), common.CopyBytes(db.db[key])
+	}
+	return it
+}
+
+// Compact does nothing, there's nothing to compact in memory.
+func (db *MemDatabase) Compact(start, limit []byte) error {
+	return nil
+}
+
+// memIterator is an iterator over a sorted copy of memory database entries.
+type memIterator struct {
+	index  int
+	keys   [][]byte
+	values [][]byte
+}
+
+func (it *memIterator) Next() bool {
+	if it.index < len(it.keys) {
+		it.index++
+	}
+	return it.index < len(it.keys)
+}
+
+func (it *memIterator) Error() error {
+	return nil
+}
+
+func (it *memIterator) Key() []byte {
+	if it.index < 0 || it.index >= len(it.keys) {
+		return nil
+	}
+	return it.keys[it.index]
+}
+
+func (it *memIterator) Value() []byte {
+	if it.index < 0 || it.index >= len(it.keys) {
+		return nil
+	}
+	return it.values[it.index]
+}
+
+func (it *memIterator) Release() {
+	it.index, it.keys, it.values = -1, nil, nil
+}
+
 func (db *MemDatabase) NewBatch() Batch {
 	return &memBatch{db: db}
 }
 
-type kv struct{ k, v []byte }
+type kv struct {
+	k, v []byte
+	del  bool
+}
 type memBatch struct {
 	db     *MemDatabase
 	writes []kv
@@ -111,21 +186,36 @@ type memBatch struct {
 }
 
 func (b *memBatch) Put(key, value []byte) error {
-	b.writes = append(b.writes, kv{common.CopyBytes(key), common.CopyBytes(value)})
+	b.writes = append(b.writes, kv{common.CopyBytes(key), common.CopyBytes(value), false})
 	b.size += len(value)
 	return nil
 }
 
+func (b *memBatch) Delete(key []byte) error {
+	b.writes = append(b.writes, kv{common.CopyBytes(key), nil, true})
+	b.size += len(key)
+	return nil
+}
+
 func (b *memBatch) Write() error {
 	b.db.lock.Lock()
 	defer b.db.lock.Unlock()
 
 	for _, kv := range b.writes {
+		if kv.del {
+			delete(b.db.db, string(kv.k))
+			continue
+		}
 		b.db.db[string(kv.k)] = kv.v
 	}
 	return nil
 }
 
+func (b *memBatch) Reset() {
+	b.writes = b.writes[:0]
+	b.size = 0
+}
+
 func (b *memBatch) ValueSize() int {
 	return b.size
 }
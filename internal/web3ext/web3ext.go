@@ -19,6 +19,7 @@ package web3ext
 
 var Modules = map[string]string{
 	"admin":    Admin_JS,
+	"clique":   Clique_JS,
 	"debug":    Debug_JS,
 	"eth":      Eth_JS,
 	"miner":    Miner_JS,
@@ -150,6 +151,56 @@ web3._extend({
 });
 `
 
+const Clique_JS = `
+web3._extend({
+	property: 'clique',
+	methods:
+	[
+		new web3._extend.Method({
+			name: 'getSnapshot',
+			call: 'clique_getSnapshot',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputDefaultBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getSnapshotAtHash',
+			call: 'clique_getSnapshotAtHash',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'getSigners',
+			call: 'clique_getSigners',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputDefaultBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getSignersAtHash',
+			call: 'clique_getSignersAtHash',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'propose',
+			call: 'clique_propose',
+			params: 2,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, null]
+		}),
+		new web3._extend.Method({
+			name: 'discard',
+			call: 'clique_discard',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter]
+		})
+	],
+	properties:
+	[
+		new web3._extend.Property({
+			name: 'proposals',
+			getter: 'clique_proposals'
+		})
+	]
+});
+`
+
 const Geth_JS = `
 web3._extend({
 	property: 'geth',
@@ -203,6 +254,11 @@ web3._extend({
 			call: 'debug_setHead',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'chaindbCompact',
+			call: 'debug_chaindbCompact',
+			params: 0
+		}),
 		new web3._extend.Method({
 			name: 'seedHash',
 			call: 'debug_seedHash',
@@ -236,6 +292,26 @@ web3._extend({
 			call: 'debug_traceTransaction',
 			params: 1
 		}),
+		new web3._extend.Method({
+			name: 'stateDiffBlock',
+			call: 'debug_stateDiffBlock',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'stateDiffTransaction',
+			call: 'debug_stateDiffTransaction',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'accountRange',
+			call: 'debug_accountRange',
+			params: 3
+		}),
+		new web3._extend.Method({
+			name: 'storageRangeAt',
+			call: 'debug_storageRangeAt',
+			params: 5
+		}),
 		new web3._extend.Method({
 			name: 'accountExist',
 			call: 'debug_accountExist',
@@ -285,6 +361,12 @@ web3._extend({
 			name: 'chainId',
 			call: 'eth_chainId',
 			params: 0
+		}),
+		new web3._extend.Method({
+			name: 'getProof',
+			call: 'eth_getProof',
+			params: 3,
+			inputFormatter: [web3._extend.formatters.inputAddressFormatter, null, web3._extend.formatters.inputDefaultBlockNumberFormatter]
 		})
 	],
 	properties:
@@ -22,6 +22,7 @@ import (
 	"sync/atomic"
 
 	"github.com/eth-classic/go-ethereum/common"
+	"github.com/eth-classic/go-ethereum/core"
 	"github.com/eth-classic/go-ethereum/logger"
 	"github.com/eth-classic/go-ethereum/logger/glog"
 	"github.com/eth-classic/go-ethereum/pow"
@@ -37,14 +38,16 @@ type CpuAgent struct {
 
 	index int
 	pow   pow.PoW
+	chain core.ChainReader
 
 	isMining int32 // isMining indicates whether the agent is currently mining
 }
 
-func NewCpuAgent(index int, pow pow.PoW) *CpuAgent {
+func NewCpuAgent(index int, pow pow.PoW, chain core.ChainReader) *CpuAgent {
 	miner := &CpuAgent{
 		pow:   pow,
 		index: index,
+		chain: chain,
 	}
 
 	return miner
@@ -117,6 +120,19 @@ done:
 func (self *CpuAgent) mine(work *Work, stop <-chan struct{}) {
 	glog.V(logger.Debug).Infof("(re)started agent[%d]. mining...\n", self.index)
 
+	// Seal with the consensus engine if there is one
+	if engine, ok := self.pow.(core.Engine); ok {
+		block, err := engine.Seal(self.chain, work.Block, stop)
+		if err != nil {
+			glog.V(logger.Warn).Infof("Block sealing failed: %v", err)
+		}
+		if block != nil {
+			self.returnCh <- &Result{work, block}
+		} else {
+			self.returnCh <- nil
+		}
+		return
+	}
 	// Mine
 	nonce, mixDigest := self.pow.Search(work.Block, stop, self.index)
 	if nonce != 0 {
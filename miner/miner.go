@@ -121,8 +121,12 @@ func (self *Miner) Start(coinbase common.Address, threads int) {
 
 	atomic.StoreInt32(&self.mining, 1)
 
+	// Consensus engines seal blocks by signing them, more threads won't help
+	if _, ok := self.pow.(core.Engine); ok && threads > 1 {
+		threads = 1
+	}
 	for i := 0; i < threads; i++ {
-		self.worker.register(NewCpuAgent(i, self.pow))
+		self.worker.register(NewCpuAgent(i, self.pow, self.eth.BlockChain()))
 	}
 
 	mlogMinerStart.AssignDetails(
@@ -267,7 +267,10 @@ func (self *worker) wait() {
 				}
 				go self.mux.Post(core.NewMinedBlockEvent{Block: block})
 			} else {
-				work.state.CommitTo(self.chainDb, work.config.IsAtlantis(block.Number()))
+				if err := self.chain.WriteBlockState(block, work.state); err != nil {
+					glog.V(logger.Error).Infoln("error writing mined block state", err)
+					continue
+				}
 				parent := self.chain.GetBlock(block.ParentHash())
 				if parent == nil {
 					glog.V(logger.Error).Infoln("Invalid block found during mining")
@@ -275,7 +278,13 @@ func (self *worker) wait() {
 				}
 
 				auxValidator := self.eth.BlockChain().AuxValidator()
-				if err := core.ValidateHeader(self.config, auxValidator, block.Header(), parent.Header(), true, false); err != nil && err != core.BlockFutureErr {
+				var err error
+				if _, ok := auxValidator.(core.Engine); ok {
+					err = self.proc.ValidateHeader(block.Header(), parent.Header(), true)
+				} else {
+					err = core.ValidateHeader(self.config, auxValidator, block.Header(), parent.Header(), true, false)
+				}
+				if err != nil && err != core.BlockFutureErr {
 					glog.V(logger.Error).Infoln("Invalid header on mined block:", err)
 					continue
 				}
@@ -366,9 +375,15 @@ func (self *worker) makeCurrent(parent *types.Block, header *types.Header) error
 	if err != nil {
 		return err
 	}
+	// Senders are recovered with the EIP-155 signer even before the fork, access
+	// list transactions are only accepted once EIP-2930 is active
+	var signer types.Signer = types.NewChainIdSigner(self.config.GetChainID())
+	if self.config.IsEIP2930(header.Number) {
+		signer = types.NewAccessListSigner(self.config.GetChainID())
+	}
 	work := &Work{
 		config:    self.config,
-		signer:    types.NewChainIdSigner(self.config.GetChainID()),
+		signer:    signer,
 		state:     state,
 		ancestors: set.New(),
 		family:    set.New(),
@@ -480,6 +495,13 @@ func (self *worker) commitNewWork() {
 		Extra:      HeaderExtra,
 		Time:       big.NewInt(tstamp),
 	}
+	engine, _ := self.chain.AuxValidator().(core.Engine)
+	if engine != nil {
+		if err := engine.Prepare(self.chain, header); err != nil {
+			glog.V(logger.Error).Infoln("Failed to prepare header for mining:", err)
+			return
+		}
+	}
 	previous := self.current
 	// Could potentially happen if starting to mine in an odd state.
 	err := self.makeCurrent(parent, header)
@@ -535,7 +557,8 @@ func (self *worker) commitNewWork() {
 		badUncles []common.Hash
 	)
 	for hash, uncle := range self.possibleUncles {
-		if len(uncles) == 2 {
+		// Consensus engines don't reward uncles, so none are included
+		if len(uncles) == 2 || engine != nil {
 			break
 		}
 		if err := self.commitUncle(work, uncle.Header()); err != nil {
@@ -555,7 +578,11 @@ func (self *worker) commitNewWork() {
 
 	if atomic.LoadInt32(&self.mining) == 1 {
 		// commit state root after all state transitions.
-		core.AccumulateRewards(work.config, work.state, header, uncles)
+		if engine != nil {
+			engine.Finalize(self.chain, header, work.state, uncles)
+		} else {
+			core.AccumulateRewards(work.config, work.state, header, uncles)
+		}
 		header.Root = work.state.IntermediateRoot(self.config.IsAtlantis(header.Number))
 	}
 
@@ -612,6 +639,10 @@ func (env *Work) commitTransactions(mux *event.TypeMux, transactions types.Trans
 
 	var coalescedLogs vm.Logs
 	for _, tx := range transactions {
+		// Typed transactions can't be included before EIP-2930
+		if tx.Type() != types.LegacyTxType && !env.config.IsEIP2930(env.header.Number) {
+			continue
+		}
 		// Error may be ignored here. The error has already been checked
 		// during transaction acceptance is the transaction pool.
 		// We use the eip155 signer regardless of the current hf.
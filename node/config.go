@@ -59,6 +59,11 @@ type Config struct {
 	// in memory.
 	DataDir string
 
+	// ReadOnly opens the databases of the data directory read-only, for analysis
+	// of a copy of another node's data directory. Every database write fails,
+	// and the node stays off the network, neither listening nor dialing.
+	ReadOnly bool
+
 	// IPCPath is the requested location to place the IPC endpoint. If the path is
 	// a simple file name, it is placed inside the chaindata directory (or on the root
 	// pipe path on Windows), whereas if it's a resolvable path name (absolute or
@@ -224,8 +229,8 @@ func (c *Config) NodeKey() *ecdsa.PrivateKey {
 	if c.fs == nil {
 		c.fs = &fs{afero.NewOsFs()}
 	}
-	// Generate ephemeral key if no datadir is being used
-	if c.DataDir == "" {
+	// Generate ephemeral key if no datadir is being used or it may not be written to
+	if c.DataDir == "" || c.ReadOnly {
 		key, err := crypto.GenerateKey()
 		if err != nil {
 			glog.Fatalf("Failed to generate ephemeral node key: %v", err)
@@ -46,6 +46,7 @@ var (
 // be registered.
 type Node struct {
 	datadir  string         // Path to the currently used data directory
+	readonly bool           // Whether the databases of the data directory are opened read-only
 	eventmux *event.TypeMux // Event multiplexer used between the services of a stack
 
 	serverConfig p2p.Config
@@ -98,23 +99,35 @@ func New(conf *Config) (*Node, error) {
 	if conf.DataDir != "" {
 		nodeDbPath = filepath.Join(conf.DataDir, datadirNodeDatabase)
 	}
+	serverConfig := p2p.Config{
+		PrivateKey:      conf.NodeKey(),
+		Name:            conf.Name,
+		Discovery:       !conf.NoDiscovery,
+		BootstrapNodes:  conf.BootstrapNodes,
+		StaticNodes:     conf.StaticNodes(),
+		TrustedNodes:    conf.TrusterNodes(),
+		NodeDatabase:    nodeDbPath,
+		ListenAddr:      conf.ListenAddr,
+		NAT:             conf.NAT,
+		Dialer:          conf.Dialer,
+		NoDial:          conf.NoDial,
+		MaxPeers:        conf.MaxPeers,
+		MaxPendingPeers: conf.MaxPendingPeers,
+	}
+	// A read-only node serves what its data directory holds, without syncing
+	if conf.ReadOnly {
+		serverConfig.Discovery = false
+		serverConfig.StaticNodes = nil
+		serverConfig.NodeDatabase = ""
+		serverConfig.ListenAddr = ""
+		serverConfig.NAT = nil
+		serverConfig.NoDial = true
+		serverConfig.MaxPeers = 0
+	}
 	return &Node{
-		datadir: conf.DataDir,
-		serverConfig: p2p.Config{
-			PrivateKey:      conf.NodeKey(),
-			Name:            conf.Name,
-			Discovery:       !conf.NoDiscovery,
-			BootstrapNodes:  conf.BootstrapNodes,
-			StaticNodes:     conf.StaticNodes(),
-			TrustedNodes:    conf.TrusterNodes(),
-			NodeDatabase:    nodeDbPath,
-			ListenAddr:      conf.ListenAddr,
-			NAT:             conf.NAT,
-			Dialer:          conf.Dialer,
-			NoDial:          conf.NoDial,
-			MaxPeers:        conf.MaxPeers,
-			MaxPendingPeers: conf.MaxPendingPeers,
-		},
+		datadir:       conf.DataDir,
+		readonly:      conf.ReadOnly,
+		serverConfig:  serverConfig,
 		serviceFuncs:  []ServiceConstructor{},
 		ipcEndpoint:   conf.IPCEndpoint(),
 		httpHost:      conf.HTTPHost,
@@ -160,6 +173,7 @@ func (n *Node) Start() error {
 		// Create a new context for the particular service
 		ctx := &ServiceContext{
 			datadir:  n.datadir,
+			readonly: n.readonly,
 			services: make(map[reflect.Type]Service),
 			EventMux: n.eventmux,
 		}
@@ -31,20 +31,45 @@ import (
 // as well as utility methods to operate on the service environment.
 type ServiceContext struct {
 	datadir  string                   // Data directory for protocol persistence
+	readonly bool                     // Whether the databases are opened read-only
 	services map[reflect.Type]Service // Index of the already constructed services
 	EventMux *event.TypeMux           // Event multiplexer used for decoupled notifications
 }
 
 // OpenDatabase opens an existing database with the given name (or creates one
 // if no previous can be found) from within the node's data directory. If the
-// node is an ephemeral one, a memory database is returned.
+// node is an ephemeral one, a memory database is returned. If the node is a
+// read-only one, the database must exist and is opened read-only.
 func (ctx *ServiceContext) OpenDatabase(name string, cache int, handles int) (ethdb.Database, error) {
 	if ctx.datadir == "" {
 		return ethdb.NewMemDatabase()
 	}
+	if ctx.readonly {
+		return ethdb.NewReadOnlyLDBDatabase(filepath.Join(ctx.datadir, name), cache, handles)
+	}
 	return ethdb.NewLDBDatabase(filepath.Join(ctx.datadir, name), cache, handles)
 }
 
+// OpenDatabaseWithFreezer opens a database like OpenDatabase, along with the
+// ancient store kept in its "ancient" subdirectory. If the node is an ephemeral
+// one, a memory database without ancient store is returned.
+func (ctx *ServiceContext) OpenDatabaseWithFreezer(name string, cache int, handles int) (ethdb.Database, error) {
+	if ctx.datadir == "" {
+		return ethdb.NewMemDatabase()
+	}
+	dir := filepath.Join(ctx.datadir, name)
+	if ctx.readonly {
+		return ethdb.NewReadOnlyLDBDatabaseWithFreezer(dir, filepath.Join(dir, "ancient"), cache, handles)
+	}
+	return ethdb.NewLDBDatabaseWithFreezer(dir, filepath.Join(dir, "ancient"), cache, handles)
+}
+
+// ReadOnly reports whether the databases of the node are opened read-only, in
+// which case the services shouldn't attempt any writes.
+func (ctx *ServiceContext) ReadOnly() bool {
+	return ctx.readonly
+}
+
 // Service retrieves a currently running service registered of a specific type.
 func (ctx *ServiceContext) Service(service interface{}) error {
 	element := reflect.ValueOf(service).Elem()
@@ -0,0 +1,659 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// Package etchash implements ECIP-1099 on top of the ethash proof-of-work.
+//
+// ECIP-1099 doubles the ethash epoch length from 30000 to 60000 blocks starting
+// at a configured activation block. Cache and dataset sizes are derived from the
+// new (60000 block) epoch number, while the seed hash is that of the classic
+// epoch 2*epoch, so an ECIP-1099 epoch is identified by the same seed as the
+// classic epoch it starts at. Blocks before the activation block are handled by
+// the ethash library unchanged, which has its epoch length built in, so the
+// hashimoto algorithm is implemented here for the later ones.
+package etchash
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"math"
+	"math/big"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/eth-classic/ethash"
+	"github.com/eth-classic/go-ethereum/common"
+	"github.com/eth-classic/go-ethereum/crypto"
+	"github.com/eth-classic/go-ethereum/crypto/sha3"
+	"github.com/eth-classic/go-ethereum/logger"
+	"github.com/eth-classic/go-ethereum/logger/glog"
+	"github.com/eth-classic/go-ethereum/pow"
+)
+
+const (
+	epochLengthDefault  = 30000 // Blocks per epoch before ECIP-1099
+	epochLengthECIP1099 = 60000 // Blocks per epoch after ECIP-1099
+
+	revision     = 23 // Data structure version, part of the DAG file name
+	cachesInMem  = 2  // Number of verification caches to keep in memory
+	dagChunkRows = 1 << 14
+
+	datasetInitBytes   = 1 << 30 // Bytes in dataset at genesis
+	datasetGrowthBytes = 1 << 23 // Dataset growth per epoch
+	cacheInitBytes     = 1 << 24 // Bytes in cache at genesis
+	cacheGrowthBytes   = 1 << 17 // Cache growth per epoch
+	mixBytes           = 128     // Width of mix
+	hashBytes          = 64      // Hash length in bytes
+	hashWords          = 16      // Number of 32 bit ints in a hash
+	datasetParents     = 256     // Number of parents of each dataset element
+	cacheRounds        = 3       // Number of rounds in cache production
+	loopAccesses       = 64      // Number of accesses in hashimoto loop
+)
+
+// dumpMagic is the little endian uint64 written at the start of a DAG file,
+// matching the file format of the ethash library.
+const dumpMagic = uint64(0xfee1deadbaddcafe)
+
+// errDAGStopped is returned if a DAG generation is aborted.
+var errDAGStopped = errors.New("DAG generation stopped")
+
+// maxUint256 is a big integer representing 2^256.
+var maxUint256 = new(big.Int).Exp(big.NewInt(2), big.NewInt(256), big.NewInt(0))
+
+// IsECIP1099 returns whether the block is mined with the ECIP-1099 epoch length.
+// A nil activation block disables ECIP-1099.
+func IsECIP1099(block uint64, ecip1099Block *big.Int) bool {
+	return ecip1099Block != nil && new(big.Int).SetUint64(block).Cmp(ecip1099Block) >= 0
+}
+
+// EpochLength returns the ethash epoch length in effect at the given block.
+func EpochLength(block uint64, ecip1099Block *big.Int) uint64 {
+	if IsECIP1099(block, ecip1099Block) {
+		return epochLengthECIP1099
+	}
+	return epochLengthDefault
+}
+
+// SeedHash returns the seed hash of the epoch the given block belongs to.
+func SeedHash(block uint64, ecip1099Block *big.Int) ([]byte, error) {
+	if !IsECIP1099(block, ecip1099Block) {
+		return ethash.GetSeedHash(block)
+	}
+	return seedHash(block / epochLengthECIP1099 * 2), nil
+}
+
+// DAGFileName returns the name (not a path) of the DAG file for the epoch the
+// given block belongs to. ECIP-1099 DAGs are named apart from the classic ones,
+// since they share their seed hash with a classic epoch of a different size.
+func DAGFileName(block uint64, ecip1099Block *big.Int) (string, error) {
+	seed, err := SeedHash(block, ecip1099Block)
+	if err != nil {
+		return "", err
+	}
+	if len(seed) < 8 {
+		return "", fmt.Errorf("invalid seed hash for block %d", block)
+	}
+	if IsECIP1099(block, ecip1099Block) {
+		return dagFileName(block / epochLengthECIP1099), nil
+	}
+	return fmt.Sprintf("full-R%d-%x", revision, seed[:8]), nil
+}
+
+// dagFileName returns the name of the DAG file of an ECIP-1099 epoch.
+func dagFileName(epoch uint64) string {
+	seed := seedHash(epoch * 2)
+	return fmt.Sprintf("full-R%d-ecip1099-%x", revision, seed[:8])
+}
+
+// MakeDAG generates the DAG for the epoch the given block belongs to and writes
+// it to dir. An empty dir stands for ethash.DefaultDir.
+func MakeDAG(block uint64, dir string, ecip1099Block *big.Int) error {
+	if !IsECIP1099(block, ecip1099Block) {
+		return ethash.MakeDAG(block, dir)
+	}
+	if dir == "" {
+		dir = ethash.DefaultDir
+	}
+	return makeDAG(block/epochLengthECIP1099, dir, nil)
+}
+
+// makeDAG generates the DAG of an ECIP-1099 epoch and writes it to dir. The
+// generation is aborted with errDAGStopped if stop is closed.
+func makeDAG(epoch uint64, dir string, stop <-chan struct{}) error {
+	select {
+	case <-stop:
+		return errDAGStopped
+	default:
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return err
+	}
+	name := dagFileName(epoch)
+	glog.V(logger.Info).Infof("Generating ECIP-1099 DAG for epoch %d (%s)", epoch, name)
+
+	cache := make([]uint32, calcCacheSize(epoch)/4)
+	generateCache(cache, seedHash(epoch*2))
+
+	return writeDAG(filepath.Join(dir, name), cache, calcDatasetSize(epoch), stop)
+}
+
+// writeDAG generates the dataset of the given size chunk by chunk, writing it
+// to a temporary file that is moved into place once complete. The generation
+// is aborted with errDAGStopped if stop is closed.
+func writeDAG(path string, cache []uint32, size uint64, stop <-chan struct{}) error {
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+
+	var magic [8]byte
+	binary.LittleEndian.PutUint64(magic[:], dumpMagic)
+	w.Write(magic[:])
+
+	chunk := make([]byte, dagChunkRows*hashBytes)
+	for offset := uint64(0); offset < size; offset += uint64(len(chunk)) {
+		data := chunk
+		if rest := size - offset; rest < uint64(len(data)) {
+			data = data[:rest]
+		}
+		select {
+		case <-stop:
+			f.Close()
+			os.Remove(tmp)
+			return errDAGStopped
+		default:
+		}
+		generateDataset(data, uint32(offset/hashBytes), cache)
+		if _, err := w.Write(data); err != nil {
+			f.Close()
+			os.Remove(tmp)
+			return err
+		}
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// openDAG memory maps a DAG file holding a dataset of the given size, returning
+// the whole mapped file, header included.
+func openDAG(path string, size uint64) ([]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+	if uint64(info.Size()) != 8+size {
+		return nil, fmt.Errorf("invalid DAG file size %d, want %d", info.Size(), 8+size)
+	}
+	mem, err := mmapFile(f, int(info.Size()))
+	if err != nil {
+		return nil, err
+	}
+	if binary.LittleEndian.Uint64(mem) != dumpMagic {
+		munmapFile(mem)
+		return nil, errors.New("invalid DAG file magic")
+	}
+	return mem, nil
+}
+
+// cache is a verification cache of a single ECIP-1099 epoch, generated lazily
+// on first use.
+type cache struct {
+	epoch uint64
+	size  uint64 // Size of the dataset of this epoch
+	once  sync.Once
+	cache []uint32
+}
+
+func (c *cache) generate() {
+	c.once.Do(func() {
+		glog.V(logger.Debug).Infof("Generating ECIP-1099 verification cache for epoch %d", c.epoch)
+		c.size = calcDatasetSize(c.epoch)
+		c.cache = make([]uint32, calcCacheSize(c.epoch)/4)
+		generateCache(c.cache, seedHash(c.epoch*2))
+	})
+}
+
+// dataset is the mining dataset of a single ECIP-1099 epoch, memory mapped from
+// its DAG file on first use, which is generated first if missing or invalid.
+type dataset struct {
+	epoch   uint64
+	lock    sync.Mutex
+	mmap    []byte // Memory mapped DAG file, nil until generated
+	dataset []byte // Dataset within the mapped file
+}
+
+// generate maps the DAG file of the epoch from dir, generating it first if
+// needed. A generation aborted through stop is started over by the next call.
+func (d *dataset) generate(dir string, stop <-chan struct{}) error {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if d.mmap != nil {
+		return nil
+	}
+	path := filepath.Join(dir, dagFileName(d.epoch))
+	size := calcDatasetSize(d.epoch)
+
+	mem, err := openDAG(path, size)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			glog.V(logger.Warn).Warnf("Regenerating ECIP-1099 DAG %s: %v", path, err)
+		}
+		if err := makeDAG(d.epoch, dir, stop); err != nil {
+			return err
+		}
+		if mem, err = openDAG(path, size); err != nil {
+			return err
+		}
+	}
+	d.mmap, d.dataset = mem, mem[8:]
+	return nil
+}
+
+// release unmaps the DAG file of the dataset once it's no longer used.
+func (d *dataset) release() {
+	if d.mmap != nil {
+		munmapFile(d.mmap)
+		d.mmap, d.dataset = nil, nil
+	}
+}
+
+// Etchash is a proof-of-work following ECIP-1099. Blocks before the activation
+// block are verified and mined by the wrapped ethash engine. Later blocks are
+// verified using a verification cache, and mined on the full dataset memory
+// mapped from the DAG files in ethash.DefaultDir.
+type Etchash struct {
+	*ethash.Ethash
+	ecip1099Block *big.Int
+
+	lock   sync.Mutex
+	caches map[uint64]*cache
+	full   *dataset // Mining dataset of the epoch last mined on
+
+	hashrate int64 // Hashes per second of the running search, accessed atomically
+}
+
+// New wraps an ethash engine, switching to the ECIP-1099 epoch length from
+// ecip1099Block on. A nil ecip1099Block leaves the engine unchanged.
+func New(engine *ethash.Ethash, ecip1099Block *big.Int) *Etchash {
+	return &Etchash{
+		Ethash:        engine,
+		ecip1099Block: ecip1099Block,
+		caches:        make(map[uint64]*cache),
+	}
+}
+
+// cache returns the verification cache of the given epoch, evicting the oldest
+// one if too many are kept in memory.
+func (e *Etchash) cache(epoch uint64) *cache {
+	e.lock.Lock()
+	c, ok := e.caches[epoch]
+	if !ok {
+		if len(e.caches) >= cachesInMem {
+			oldest := uint64(math.MaxUint64)
+			for ep := range e.caches {
+				if ep < oldest {
+					oldest = ep
+				}
+			}
+			delete(e.caches, oldest)
+		}
+		c = &cache{epoch: epoch}
+		e.caches[epoch] = c
+	}
+	e.lock.Unlock()
+
+	c.generate()
+	return c
+}
+
+// dataset returns the mining dataset of the given epoch, dropping the one of the
+// previous epoch. Its DAG file is unmapped once the last search using it ended.
+func (e *Etchash) dataset(epoch uint64) *dataset {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+
+	if e.full == nil || e.full.epoch != epoch {
+		e.full = &dataset{epoch: epoch}
+		runtime.SetFinalizer(e.full, (*dataset).release)
+	}
+	return e.full
+}
+
+// Verify checks whether the block's nonce and mix digest satisfy its difficulty.
+func (e *Etchash) Verify(block pow.Block) bool {
+	if !IsECIP1099(block.NumberU64(), e.ecip1099Block) {
+		return e.Ethash.Verify(block)
+	}
+	difficulty := block.Difficulty()
+	if difficulty.Sign() <= 0 {
+		return false
+	}
+	c := e.cache(block.NumberU64() / epochLengthECIP1099)
+	digest, result := hashimotoLight(c.size, c.cache, block.HashNoNonce().Bytes(), block.Nonce())
+	if !bytes.Equal(block.MixDigest().Bytes(), digest) {
+		return false
+	}
+	target := new(big.Int).Div(maxUint256, difficulty)
+	return new(big.Int).SetBytes(result).Cmp(target) <= 0
+}
+
+// Search looks for a nonce satisfying the block's difficulty until stop is
+// closed. ECIP-1099 blocks are searched on the full dataset, which is mapped
+// from (or generated into) the DAG file of their epoch first.
+func (e *Etchash) Search(block pow.Block, stop <-chan struct{}, index int) (uint64, []byte) {
+	if !IsECIP1099(block.NumberU64(), e.ecip1099Block) {
+		return e.Ethash.Search(block, stop, index)
+	}
+	d := e.dataset(block.NumberU64() / epochLengthECIP1099)
+	if err := d.generate(ethash.DefaultDir, stop); err != nil {
+		if err != errDAGStopped {
+			glog.V(logger.Error).Errorf("ECIP-1099 DAG of epoch %d unavailable: %v", d.epoch, err)
+		}
+		return 0, nil
+	}
+	defer atomic.StoreInt64(&e.hashrate, 0)
+
+	var (
+		target = new(big.Int).Div(maxUint256, block.Difficulty())
+		nonce  = uint64(rand.New(rand.NewSource(time.Now().UnixNano())).Int63())
+	)
+	nonce, digest := search(d.dataset, block.HashNoNonce().Bytes(), target, nonce, stop, &e.hashrate)
+
+	// Keep the DAG file mapped until the search is done
+	runtime.KeepAlive(d)
+	return nonce, digest
+}
+
+// search runs hashimoto over the full dataset from the given nonce on, until a
+// result meets the target or stop is closed, storing the hash rate in hashrate.
+func search(dataset []byte, hash []byte, target *big.Int, nonce uint64, stop <-chan struct{}, hashrate *int64) (uint64, []byte) {
+	var (
+		start  = time.Now()
+		hashes int64
+	)
+	for {
+		select {
+		case <-stop:
+			return 0, nil
+		default:
+		}
+		digest, result := hashimotoFull(dataset, hash, nonce)
+		if new(big.Int).SetBytes(result).Cmp(target) <= 0 {
+			return nonce, digest
+		}
+		hashes++
+		if hashes%64 == 0 {
+			atomic.StoreInt64(hashrate, int64(float64(hashes)/time.Since(start).Seconds()))
+		}
+		nonce++
+	}
+}
+
+// GetHashrate returns the hash rate of the running search.
+func (e *Etchash) GetHashrate() int64 {
+	return e.Ethash.GetHashrate() + atomic.LoadInt64(&e.hashrate)
+}
+
+// calcCacheSize calculates the size of the verification cache for the given
+// epoch, i.e. the largest prime number of hash rows below the linear bound.
+func calcCacheSize(epoch uint64) uint64 {
+	size := cacheInitBytes + cacheGrowthBytes*epoch - hashBytes
+	for !new(big.Int).SetUint64(size / hashBytes).ProbablyPrime(1) { // Always accurate for n < 2^64
+		size -= 2 * hashBytes
+	}
+	return size
+}
+
+// calcDatasetSize calculates the size of the mining dataset for the given
+// epoch, i.e. the largest prime number of mix rows below the linear bound.
+func calcDatasetSize(epoch uint64) uint64 {
+	size := datasetInitBytes + datasetGrowthBytes*epoch - mixBytes
+	for !new(big.Int).SetUint64(size / mixBytes).ProbablyPrime(1) { // Always accurate for n < 2^64
+		size -= 2 * mixBytes
+	}
+	return size
+}
+
+// hasher is a repetitive hasher allowing the same hash data structures to be
+// reused between hash runs instead of requiring new ones to be created.
+type hasher func(dest []byte, data []byte)
+
+// makeHasher creates a repetitive hasher, writing the digest of data into the
+// beginning of dest.
+func makeHasher(h hash.Hash) hasher {
+	return func(dest []byte, data []byte) {
+		h.Reset()
+		h.Write(data)
+		h.Sum(dest[:0])
+	}
+}
+
+// seedHash is the seed to use for generating a verification cache and the
+// mining dataset of the given (classic, 30000 block long) epoch.
+func seedHash(epoch uint64) []byte {
+	seed := make([]byte, 32)
+	keccak256 := makeHasher(sha3.NewKeccak256())
+	for i := uint64(0); i < epoch; i++ {
+		keccak256(seed, seed)
+	}
+	return seed
+}
+
+// generateCache creates a verification cache of a given size for an input seed.
+// The cache production process involves first sequentially filling up the
+// memory, then performing three passes of Sergio Demian Lerner's RandMemoHash
+// algorithm from Strict Memory Hard Hashing Functions (2014).
+func generateCache(dest []uint32, seed []byte) {
+	size := uint64(len(dest) * 4)
+	rows := int(size) / hashBytes
+	cache := make([]byte, size)
+
+	keccak512 := makeHasher(sha3.NewKeccak512())
+
+	// Sequentially produce the initial dataset
+	keccak512(cache, seed)
+	for offset := uint64(hashBytes); offset < size; offset += hashBytes {
+		keccak512(cache[offset:], cache[offset-hashBytes:offset])
+	}
+	// Use a low-round version of randmemohash
+	temp := make([]byte, hashBytes)
+
+	for i := 0; i < cacheRounds; i++ {
+		for j := 0; j < rows; j++ {
+			var (
+				srcOff = ((j - 1 + rows) % rows) * hashBytes
+				dstOff = j * hashBytes
+				xorOff = (binary.LittleEndian.Uint32(cache[dstOff:]) % uint32(rows)) * hashBytes
+			)
+			for k := 0; k < hashBytes; k++ {
+				temp[k] = cache[srcOff+k] ^ cache[int(xorOff)+k]
+			}
+			keccak512(cache[dstOff:], temp)
+		}
+	}
+	for i := range dest {
+		dest[i] = binary.LittleEndian.Uint32(cache[i*4:])
+	}
+}
+
+// fnv is an algorithm inspired by the FNV hash, which in some cases is used as
+// a non-associative substitute for XOR. Note that we multiply the prime with
+// the full 32-bit input, in contrast with the FNV-1 spec which multiplies the
+// prime with one byte (octet) in turn.
+func fnv(a, b uint32) uint32 {
+	return a*0x01000193 ^ b
+}
+
+// fnvHash mixes in data into mix using the ethash fnv method.
+func fnvHash(mix []uint32, data []uint32) {
+	for i := 0; i < len(mix); i++ {
+		mix[i] = mix[i]*0x01000193 ^ data[i]
+	}
+}
+
+// generateDatasetItem combines data from 256 pseudorandomly selected cache nodes,
+// and hashes that to compute a single dataset node.
+func generateDatasetItem(cache []uint32, index uint32, keccak512 hasher) []byte {
+	// Calculate the number of theoretical rows (we use one buffer nonetheless)
+	rows := uint32(len(cache) / hashWords)
+
+	// Initialize the mix
+	mix := make([]byte, hashBytes)
+
+	binary.LittleEndian.PutUint32(mix, cache[(index%rows)*hashWords]^index)
+	for i := 1; i < hashWords; i++ {
+		binary.LittleEndian.PutUint32(mix[i*4:], cache[(index%rows)*hashWords+uint32(i)])
+	}
+	keccak512(mix, mix)
+
+	// Convert the mix to uint32s to avoid constant bit shifting
+	intMix := make([]uint32, hashWords)
+	for i := 0; i < len(intMix); i++ {
+		intMix[i] = binary.LittleEndian.Uint32(mix[i*4:])
+	}
+	// fnv it with a lot of random cache nodes based on index
+	for i := uint32(0); i < datasetParents; i++ {
+		parent := fnv(index^i, intMix[i%16]) % rows
+		fnvHash(intMix, cache[parent*hashWords:])
+	}
+	// Flatten the uint32 mix into a binary one and return
+	for i, val := range intMix {
+		binary.LittleEndian.PutUint32(mix[i*4:], val)
+	}
+	keccak512(mix, mix)
+	return mix
+}
+
+// generateDataset fills dest with the dataset items starting at item offset
+// start, spreading the work over all available CPUs.
+func generateDataset(dest []byte, start uint32, cache []uint32) {
+	threads := runtime.NumCPU()
+	items := uint32(len(dest) / hashBytes)
+
+	var pend sync.WaitGroup
+	pend.Add(threads)
+	for i := 0; i < threads; i++ {
+		go func(id uint32) {
+			defer pend.Done()
+
+			keccak512 := makeHasher(sha3.NewKeccak512())
+			for index := id; index < items; index += uint32(threads) {
+				copy(dest[index*hashBytes:], generateDatasetItem(cache, start+index, keccak512))
+			}
+		}(uint32(i))
+	}
+	pend.Wait()
+}
+
+// hashimoto aggregates data from the full dataset in order to produce our final
+// value for a particular header hash and nonce.
+func hashimoto(hash []byte, nonce uint64, size uint64, lookup func(index uint32) []uint32) ([]byte, []byte) {
+	// Calculate the number of theoretical rows (we use one buffer nonetheless)
+	rows := uint32(size / mixBytes)
+
+	// Combine header+nonce into a 64 byte seed
+	seed := make([]byte, 40)
+	copy(seed, hash)
+	binary.LittleEndian.PutUint64(seed[32:], nonce)
+
+	seed = crypto.Keccak512(seed)
+	seedHead := binary.LittleEndian.Uint32(seed)
+
+	// Start the mix with replicated seed
+	mix := make([]uint32, mixBytes/4)
+	for i := 0; i < len(mix); i++ {
+		mix[i] = binary.LittleEndian.Uint32(seed[i%16*4:])
+	}
+	// Mix in random dataset nodes
+	temp := make([]uint32, len(mix))
+
+	for i := 0; i < loopAccesses; i++ {
+		parent := fnv(uint32(i)^seedHead, mix[i%len(mix)]) % rows
+		for j := uint32(0); j < mixBytes/hashBytes; j++ {
+			copy(temp[j*hashWords:], lookup(2*parent+j))
+		}
+		fnvHash(mix, temp)
+	}
+	// Compress mix
+	for i := 0; i < len(mix); i += 4 {
+		mix[i/4] = fnv(fnv(fnv(mix[i], mix[i+1]), mix[i+2]), mix[i+3])
+	}
+	mix = mix[:len(mix)/4]
+
+	digest := make([]byte, common.HashLength)
+	for i, val := range mix {
+		binary.LittleEndian.PutUint32(digest[i*4:], val)
+	}
+	return digest, crypto.Keccak256(append(seed, digest...))
+}
+
+// hashimotoLight aggregates data from the full dataset (using only a small
+// in-memory cache) in order to produce our final value for a particular header
+// hash and nonce.
+func hashimotoLight(size uint64, cache []uint32, hash []byte, nonce uint64) ([]byte, []byte) {
+	keccak512 := makeHasher(sha3.NewKeccak512())
+
+	lookup := func(index uint32) []uint32 {
+		rawData := generateDatasetItem(cache, index, keccak512)
+
+		data := make([]uint32, len(rawData)/4)
+		for i := 0; i < len(data); i++ {
+			data[i] = binary.LittleEndian.Uint32(rawData[i*4:])
+		}
+		return data
+	}
+	return hashimoto(hash, nonce, size, lookup)
+}
+
+// hashimotoFull aggregates data from the full dataset (using the full in-memory
+// dataset) in order to produce our final value for a particular header hash and
+// nonce.
+func hashimotoFull(dataset []byte, hash []byte, nonce uint64) ([]byte, []byte) {
+	lookup := func(index uint32) []uint32 {
+		offset := index * hashBytes
+		data := make([]uint32, hashWords)
+		for i := 0; i < len(data); i++ {
+			data[i] = binary.LittleEndian.Uint32(dataset[offset+uint32(i*4):])
+		}
+		return data
+	}
+	return hashimoto(hash, nonce, uint64(len(dataset)), lookup)
+}
@@ -0,0 +1,267 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package etchash
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/eth-classic/go-ethereum/common"
+	"github.com/eth-classic/go-ethereum/crypto"
+)
+
+// Tests that the cache and dataset sizes are calculated correctly.
+func TestSizeCalculations(t *testing.T) {
+	tests := []struct {
+		epoch   uint64
+		cache   uint64
+		dataset uint64
+	}{
+		{0, 16776896, 1073739904},
+		{1, 16907456, 1082130304},
+	}
+	for i, tt := range tests {
+		if size := calcCacheSize(tt.epoch); size != tt.cache {
+			t.Errorf("test %d: cache size mismatch: have %d, want %d", i, size, tt.cache)
+		}
+		if size := calcDatasetSize(tt.epoch); size != tt.dataset {
+			t.Errorf("test %d: dataset size mismatch: have %d, want %d", i, size, tt.dataset)
+		}
+	}
+}
+
+// Tests that seed hashes are chained keccak256 hashes of the zero hash.
+func TestSeedHash(t *testing.T) {
+	if seed := seedHash(0); !bytes.Equal(seed, make([]byte, 32)) {
+		t.Errorf("epoch 0 seed mismatch: have %x, want zero", seed)
+	}
+	want := make([]byte, 32)
+	for i := 0; i < 390; i++ {
+		want = crypto.Keccak256(want)
+	}
+	if seed := seedHash(390); !bytes.Equal(seed, want) {
+		t.Errorf("epoch 390 seed mismatch: have %x, want %x", seed, want)
+	}
+}
+
+// Tests that ECIP-1099 epochs start from the seed of the classic epoch at the
+// same block, with the epoch length doubled from the activation block on.
+func TestECIP1099Epochs(t *testing.T) {
+	activation := big.NewInt(11700000)
+
+	if l := EpochLength(11699999, activation); l != 30000 {
+		t.Errorf("epoch length before activation: have %d, want 30000", l)
+	}
+	if l := EpochLength(11700000, activation); l != 60000 {
+		t.Errorf("epoch length at activation: have %d, want 60000", l)
+	}
+	if l := EpochLength(11700000, nil); l != 30000 {
+		t.Errorf("epoch length without activation: have %d, want 30000", l)
+	}
+	tests := []struct {
+		block uint64
+		epoch uint64 // classic epoch providing the seed
+	}{
+		{11700000, 390},
+		{11759999, 390},
+		{11760000, 392},
+	}
+	for i, tt := range tests {
+		seed, err := SeedHash(tt.block, activation)
+		if err != nil {
+			t.Fatalf("test %d: seed hash failed: %v", i, err)
+		}
+		if want := seedHash(tt.epoch); !bytes.Equal(seed, want) {
+			t.Errorf("test %d: seed mismatch: have %x, want %x", i, seed, want)
+		}
+	}
+	name, err := DAGFileName(11700000, activation)
+	if err != nil {
+		t.Fatalf("DAG file name failed: %v", err)
+	}
+	if want := "full-R23-ecip1099-" + common.Bytes2Hex(seedHash(390)[:8]); name != want {
+		t.Errorf("DAG file name mismatch: have %s, want %s", name, want)
+	}
+}
+
+// Tests that light and full hashimoto agree with the reference implementation.
+func TestHashimoto(t *testing.T) {
+	// Create the verification cache and mining dataset
+	cache := make([]uint32, 1024/4)
+	generateCache(cache, make([]byte, 32))
+
+	dataset := make([]byte, 32*1024)
+	generateDataset(dataset, 0, cache)
+
+	// Create a block to verify
+	hash := common.FromHex("0xc9149cc0386e689d789a1c2f3d5d169a61a6218ed30e74414dc736e442ef3d1f")
+	nonce := uint64(0)
+
+	wantDigest := common.FromHex("0xe4073cffaef931d37117cefd9afd27ea0f1cad6a981dd2605c4a1ac97c519800")
+	wantResult := common.FromHex("0xd3539235ee2e6f8db665c0a72169f55b7f6c605712330b778ec3944f0eb5a557")
+
+	digest, result := hashimotoLight(32*1024, cache, hash, nonce)
+	if !bytes.Equal(digest, wantDigest) {
+		t.Errorf("light hashimoto digest mismatch: have %x, want %x", digest, wantDigest)
+	}
+	if !bytes.Equal(result, wantResult) {
+		t.Errorf("light hashimoto result mismatch: have %x, want %x", result, wantResult)
+	}
+	digest, result = hashimotoFull(dataset, hash, nonce)
+	if !bytes.Equal(digest, wantDigest) {
+		t.Errorf("full hashimoto digest mismatch: have %x, want %x", digest, wantDigest)
+	}
+	if !bytes.Equal(result, wantResult) {
+		t.Errorf("full hashimoto result mismatch: have %x, want %x", result, wantResult)
+	}
+}
+
+// Tests that DAG files are mapped back into the dataset they were generated
+// from, and rejected if truncated.
+func TestOpenDAG(t *testing.T) {
+	dir, err := ioutil.TempDir("", "etchash-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cache := make([]uint32, 1024/4)
+	generateCache(cache, make([]byte, 32))
+
+	want := make([]byte, 32*1024)
+	generateDataset(want, 0, cache)
+
+	path := filepath.Join(dir, "dag")
+	if err := writeDAG(path, cache, uint64(len(want)), nil); err != nil {
+		t.Fatalf("failed to write DAG: %v", err)
+	}
+	mem, err := openDAG(path, uint64(len(want)))
+	if err != nil {
+		t.Fatalf("failed to open DAG: %v", err)
+	}
+	defer munmapFile(mem)
+
+	if !bytes.Equal(mem[8:], want) {
+		t.Errorf("dataset mismatch")
+	}
+	if _, err := openDAG(path, uint64(len(want))+mixBytes); err == nil {
+		t.Errorf("truncated DAG opened")
+	}
+}
+
+// Tests that an aborted DAG generation leaves no files behind and is started
+// over by the next attempt.
+func TestStopDAG(t *testing.T) {
+	dir, err := ioutil.TempDir("", "etchash-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cache := make([]uint32, 1024/4)
+	generateCache(cache, make([]byte, 32))
+
+	stop := make(chan struct{})
+	close(stop)
+
+	path := filepath.Join(dir, "dag")
+	if err := writeDAG(path, cache, 32*1024, stop); err != errDAGStopped {
+		t.Fatalf("stopped DAG write error mismatch: have %v, want %v", err, errDAGStopped)
+	}
+	d := &dataset{epoch: 0}
+	if err := d.generate(dir, stop); err != errDAGStopped {
+		t.Fatalf("stopped DAG generation error mismatch: have %v, want %v", err, errDAGStopped)
+	}
+	if files, _ := ioutil.ReadDir(dir); len(files) != 0 {
+		t.Errorf("stopped DAG generation left %d files behind", len(files))
+	}
+	if d.dataset != nil {
+		t.Errorf("stopped DAG generation produced a dataset")
+	}
+	// Place a (sparse) DAG file and ensure the dataset picks it up
+	f, err := os.Create(filepath.Join(dir, dagFileName(0)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := make([]byte, 8)
+	binary.LittleEndian.PutUint64(header, dumpMagic)
+	f.Write(header)
+	f.Truncate(int64(8 + calcDatasetSize(0)))
+	f.Close()
+
+	if err := d.generate(dir, stop); err != nil {
+		t.Fatalf("failed to open DAG after stopped generation: %v", err)
+	}
+	defer d.release()
+
+	if uint64(len(d.dataset)) != calcDatasetSize(0) {
+		t.Errorf("dataset size mismatch: have %d, want %d", len(d.dataset), calcDatasetSize(0))
+	}
+}
+
+// Tests that nonces found on the full dataset pass verification with the cache.
+func TestSearch(t *testing.T) {
+	cache := make([]uint32, 1024/4)
+	generateCache(cache, make([]byte, 32))
+
+	dataset := make([]byte, 32*1024)
+	generateDataset(dataset, 0, cache)
+
+	var (
+		hash     = common.FromHex("0xc9149cc0386e689d789a1c2f3d5d169a61a6218ed30e74414dc736e442ef3d1f")
+		target   = new(big.Int).Div(maxUint256, big.NewInt(100))
+		hashrate int64
+	)
+	nonce, digest := search(dataset, hash, target, 0, make(chan struct{}), &hashrate)
+
+	wantDigest, result := hashimotoLight(uint64(len(dataset)), cache, hash, nonce)
+	if !bytes.Equal(digest, wantDigest) {
+		t.Errorf("digest mismatch: have %x, want %x", digest, wantDigest)
+	}
+	if new(big.Int).SetBytes(result).Cmp(target) > 0 {
+		t.Errorf("result %x above target %x", result, target)
+	}
+	// A closed stop channel aborts the search
+	stop := make(chan struct{})
+	close(stop)
+	if nonce, digest := search(dataset, hash, new(big.Int), 0, stop, &hashrate); nonce != 0 || digest != nil {
+		t.Errorf("aborted search returned nonce %d", nonce)
+	}
+}
+
+// Tests that no more than cachesInMem verification caches are kept, even when
+// requested for older and older epochs.
+func TestCacheEviction(t *testing.T) {
+	e := New(nil, big.NewInt(0))
+	for epoch := uint64(cachesInMem); ; epoch-- {
+		e.cache(epoch)
+		if len(e.caches) > cachesInMem {
+			t.Fatalf("epoch %d: too many caches: have %d, want at most %d", epoch, len(e.caches), cachesInMem)
+		}
+		if e.caches[epoch] == nil {
+			t.Fatalf("epoch %d: requested cache evicted", epoch)
+		}
+		if epoch == 0 {
+			break
+		}
+	}
+}
@@ -0,0 +1,50 @@
+// Copyright 2017 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+// +build windows
+
+package etchash
+
+import (
+	"os"
+	"reflect"
+	"syscall"
+	"unsafe"
+)
+
+// mmapFile maps the first size bytes of a file read-only into memory.
+func mmapFile(f *os.File, size int) ([]byte, error) {
+	handle, err := syscall.CreateFileMapping(syscall.Handle(f.Fd()), nil, syscall.PAGE_READONLY, 0, 0, nil)
+	if err != nil {
+		return nil, os.NewSyscallError("CreateFileMapping", err)
+	}
+	// The view keeps the mapping alive, the handle is not needed past this
+	defer syscall.CloseHandle(handle)
+
+	addr, err := syscall.MapViewOfFile(handle, syscall.FILE_MAP_READ, 0, 0, uintptr(size))
+	if err != nil {
+		return nil, os.NewSyscallError("MapViewOfFile", err)
+	}
+	var mem []byte
+	header := (*reflect.SliceHeader)(unsafe.Pointer(&mem))
+	header.Data, header.Len, header.Cap = addr, size, size
+	return mem, nil
+}
+
+// munmapFile unmaps a file mapped by mmapFile.
+func munmapFile(mem []byte) error {
+	return syscall.UnmapViewOfFile(uintptr(unsafe.Pointer(&mem[0])))
+}
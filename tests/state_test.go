@@ -709,7 +709,6 @@ func TestAllETH(t *testing.T) {
 	unsupportedDirs := map[string]bool{
 		"stZeroKnowledge":  true,
 		"stZeroKnowledge2": true,
-		"stCreate2":        true,
 	}
 
 	for _, dn := range dirNames {
@@ -728,7 +727,6 @@ func TestAllETH(t *testing.T) {
 func runETHTests(t *testing.T, fileNames []string, skipTests map[string]string) {
 	unsupportedForkConfigs := map[string]bool{
 		"Constantinople":               true,
-		"ConstantinopleFix":            true,
 		"EIP158":                       true,
 		"FrontierToHomesteadAt5":       true,
 		"HomesteadToEIP150At5":         true,
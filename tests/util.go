@@ -155,6 +155,11 @@ type RuleSet struct {
 	DiehardBlock             *big.Int
 	ExplosionBlock           *big.Int
 	AtlantisBlock            *big.Int
+	AghartaBlock             *big.Int
+	PhoenixBlock             *big.Int
+	MagnetoBlock             *big.Int
+	MystiqueBlock            *big.Int
+	SpiralBlock              *big.Int
 }
 
 // StateTest object that matches the General State Test json file
@@ -203,11 +208,73 @@ func (r RuleSet) IsAtlantis(n *big.Int) bool {
 	return r.AtlantisBlock != nil && n.Cmp(r.AtlantisBlock) >= 0
 }
 
+func (r RuleSet) IsAgharta(n *big.Int) bool {
+	return r.AghartaBlock != nil && n.Cmp(r.AghartaBlock) >= 0
+}
+
+func (r RuleSet) IsPhoenix(n *big.Int) bool {
+	return r.PhoenixBlock != nil && n.Cmp(r.PhoenixBlock) >= 0
+}
+
+func (r RuleSet) IsMagneto(n *big.Int) bool {
+	return r.MagnetoBlock != nil && n.Cmp(r.MagnetoBlock) >= 0
+}
+
+func (r RuleSet) IsMystique(n *big.Int) bool {
+	return r.MystiqueBlock != nil && n.Cmp(r.MystiqueBlock) >= 0
+}
+
+func (r RuleSet) IsSpiral(n *big.Int) bool {
+	return r.SpiralBlock != nil && n.Cmp(r.SpiralBlock) >= 0
+}
+
+func (r RuleSet) IsEIP1344(n *big.Int) bool { return r.IsPhoenix(n) }
+func (r RuleSet) IsEIP1884(n *big.Int) bool { return r.IsPhoenix(n) }
+func (r RuleSet) IsEIP2028(n *big.Int) bool { return r.IsPhoenix(n) }
+func (r RuleSet) IsEIP2200(n *big.Int) bool { return r.IsPhoenix(n) }
+func (r RuleSet) IsEIP152(n *big.Int) bool  { return r.IsPhoenix(n) }
+func (r RuleSet) IsEIP2929(n *big.Int) bool { return r.IsMagneto(n) }
+func (r RuleSet) IsEIP3529(n *big.Int) bool { return r.IsMystique(n) }
+func (r RuleSet) IsEIP3541(n *big.Int) bool { return r.IsMystique(n) }
+func (r RuleSet) IsEIP3651(n *big.Int) bool { return r.IsSpiral(n) }
+func (r RuleSet) IsEIP3855(n *big.Int) bool { return r.IsSpiral(n) }
+func (r RuleSet) IsEIP3860(n *big.Int) bool { return r.IsSpiral(n) }
+
+// GetChainID returns the chain id used by the ethereum/tests fixtures.
+func (r RuleSet) GetChainID() *big.Int { return big.NewInt(1) }
+
 func (r RuleSet) GasTable(num *big.Int) *vm.GasTable {
+	if num != nil && r.IsMagneto(num) {
+		return &vm.GasTable{
+			ExtcodeSize:     big.NewInt(100),
+			ExtcodeCopy:     big.NewInt(100),
+			ExtcodeHash:     big.NewInt(100),
+			Balance:         big.NewInt(100),
+			SLoad:           big.NewInt(100),
+			Calls:           big.NewInt(100),
+			Suicide:         big.NewInt(5000),
+			ExpByte:         big.NewInt(50),
+			CreateBySuicide: big.NewInt(25000),
+		}
+	}
+	if num != nil && r.IsPhoenix(num) {
+		return &vm.GasTable{
+			ExtcodeSize:     big.NewInt(700),
+			ExtcodeCopy:     big.NewInt(700),
+			ExtcodeHash:     big.NewInt(700),
+			Balance:         big.NewInt(700),
+			SLoad:           big.NewInt(800),
+			Calls:           big.NewInt(700),
+			Suicide:         big.NewInt(5000),
+			ExpByte:         big.NewInt(50),
+			CreateBySuicide: big.NewInt(25000),
+		}
+	}
 	if r.HomesteadGasRepriceBlock == nil || num == nil || num.Cmp(r.HomesteadGasRepriceBlock) < 0 {
 		return &vm.GasTable{
 			ExtcodeSize:     big.NewInt(20),
 			ExtcodeCopy:     big.NewInt(20),
+			ExtcodeHash:     big.NewInt(400),
 			Balance:         big.NewInt(20),
 			SLoad:           big.NewInt(50),
 			Calls:           big.NewInt(40),
@@ -220,6 +287,7 @@ func (r RuleSet) GasTable(num *big.Int) *vm.GasTable {
 		return &vm.GasTable{
 			ExtcodeSize:     big.NewInt(700),
 			ExtcodeCopy:     big.NewInt(700),
+			ExtcodeHash:     big.NewInt(400),
 			Balance:         big.NewInt(400),
 			SLoad:           big.NewInt(200),
 			Calls:           big.NewInt(700),
@@ -232,6 +300,7 @@ func (r RuleSet) GasTable(num *big.Int) *vm.GasTable {
 	return &vm.GasTable{
 		ExtcodeSize:     big.NewInt(700),
 		ExtcodeCopy:     big.NewInt(700),
+		ExtcodeHash:     big.NewInt(400),
 		Balance:         big.NewInt(400),
 		SLoad:           big.NewInt(200),
 		Calls:           big.NewInt(700),
@@ -397,6 +466,20 @@ func (self *Env) Create(caller vm.ContractRef, data []byte, gas, price, value *b
 	}
 }
 
+func (self *Env) Create2(caller vm.ContractRef, data []byte, gas, price, salt, value *big.Int) ([]byte, common.Address, error) {
+	if self.vmTest {
+		caller.ReturnGas(gas, price)
+
+		var s [32]byte
+		copy(s[:], common.BigToHash(salt).Bytes())
+		obj := self.state.GetOrNewStateObject(crypto.CreateAddress2(caller.Address(), s, crypto.Keccak256(data)))
+
+		return nil, obj.Address(), nil
+	} else {
+		return core.Create2(self, caller, data, gas, price, salt, value)
+	}
+}
+
 type Message struct {
 	from              common.Address
 	to                *common.Address
@@ -418,3 +501,4 @@ func (self Message) Gas() *big.Int                         { return self.gas }
 func (self Message) Value() *big.Int                       { return self.value }
 func (self Message) Nonce() uint64                         { return self.nonce }
 func (self Message) Data() []byte                          { return self.data }
+func (self Message) AccessList() types.AccessList          { return nil }
@@ -43,14 +43,18 @@ var calculatorPool = sync.Pool{
 type hasher struct {
 	cachegen   uint16
 	cachelimit uint16
-	threaded   bool
+	parallel   bool // Whether to hash the children of the topmost full node concurrently
 	mu         sync.Mutex
 }
 
-func newHasher(cachegen, cachelimit uint16) *hasher {
+// newHasher creates a hasher, hashing the subtries of the topmost full node on
+// separate goroutines if parallel is set. That only pays off for tries with
+// many modified nodes, small ones are faster to hash on a single goroutine.
+func newHasher(cachegen, cachelimit uint16, parallel bool) *hasher {
 	h := &hasher{
 		cachegen:   cachegen,
 		cachelimit: cachelimit,
+		parallel:   parallel,
 	}
 	return h
 }
@@ -162,10 +166,10 @@ func (h *hasher) hashChildren(original node, db DatabaseWriter) (node, node, err
 				h.mu.Unlock()
 			}
 		}
-		// If we're not running in threaded mode yet, span a goroutine for each child
-		if !h.threaded {
-			// Disable further threading
-			h.threaded = true
+		// If this is the topmost full node of a large trie, span a goroutine for each child
+		if h.parallel {
+			// Disable further threading, the subtries are hashed sequentially
+			h.parallel = false
 
 			// Hash all the children concurrently
 			var wg sync.WaitGroup
@@ -174,9 +178,6 @@ func (h *hasher) hashChildren(original node, db DatabaseWriter) (node, node, err
 				go hashChild(i, &wg)
 			}
 			wg.Wait()
-
-			// Reenable threading for subsequent hash calls
-			h.threaded = false
 		} else {
 			for i := 0; i < 16; i++ {
 				hashChild(i, nil)
@@ -0,0 +1,435 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package trie
+
+import (
+	"sync"
+	"time"
+
+	"github.com/eth-classic/go-ethereum/common"
+	"github.com/eth-classic/go-ethereum/ethdb"
+	"github.com/eth-classic/go-ethereum/logger"
+	"github.com/eth-classic/go-ethereum/logger/glog"
+)
+
+// cachedNodeSize is the approximate memory overhead of a cached node on top of
+// its blob, used to keep the size accounting of the cache honest.
+const cachedNodeSize = 3 * common.HashLength
+
+// LeafResolver is a callback type invoked when a node inserted into a NodeCache
+// contains leaf values. It returns the hashes of any further database entries
+// the leaf references (e.g. the storage trie root and code of an account), which
+// will be kept alive for as long as the node itself is.
+type LeafResolver func(leaf []byte) []common.Hash
+
+// cachedNode is a trie node (or any other blob, such as contract code) held in
+// memory by a NodeCache until it is either garbage collected or flushed to disk.
+type cachedNode struct {
+	blob     []byte              // Encoded node, copied from the committing trie
+	parents  int                 // Number of live nodes referencing this one
+	children map[common.Hash]int // Nodes referenced by this one, with reference counts
+	prev     common.Hash         // Previous node in the insertion order flush list
+	next     common.Hash         // Next node in the insertion order flush list
+}
+
+// NodeCache is an intermediate write layer between the trie data structures and
+// the disk database. Committed trie nodes are aggregated in memory and reference
+// counted, so nodes of state tries that are no longer needed can be discarded
+// without ever touching the disk. Nodes are only flushed when a trie is explicitly
+// committed or the cache grows above its allowance.
+//
+// NodeCache implements the Database interface, so tries can be both committed
+// into and opened from it. Reads not served from memory fall back to disk.
+type NodeCache struct {
+	diskdb ethdb.Database // Persistent storage for matured trie nodes
+	leaves LeafResolver   // Resolver for references embedded in leaf values
+
+	nodes  map[common.Hash]*cachedNode // Data and references relationships of the nodes
+	oldest common.Hash                 // Oldest tracked node, flush-list head
+	newest common.Hash                 // Newest tracked node, flush-list tail
+
+	size common.StorageSize // Storage size of the cached nodes
+
+	gcnodes uint64             // Nodes garbage collected since last commit
+	gcsize  common.StorageSize // Data storage garbage collected since last commit
+	gctime  time.Duration      // Time spent on garbage collection since last commit
+
+	flushnodes uint64             // Nodes flushed since last commit
+	flushsize  common.StorageSize // Data storage flushed since last commit
+	flushtime  time.Duration      // Time spent on data flushing since last commit
+
+	lock sync.RWMutex
+}
+
+// NewNodeCache creates a new trie node cache on top of a persistent database.
+// The optional leaf resolver is used to track references held by leaf values.
+func NewNodeCache(diskdb ethdb.Database, leaves LeafResolver) *NodeCache {
+	return &NodeCache{
+		diskdb: diskdb,
+		leaves: leaves,
+		nodes: map[common.Hash]*cachedNode{
+			{}: {children: make(map[common.Hash]int)},
+		},
+	}
+}
+
+// DiskDB retrieves the persistent storage backing the cache.
+func (c *NodeCache) DiskDB() ethdb.Database {
+	return c.diskdb
+}
+
+// Get retrieves a node from memory, or from the disk database if not cached.
+func (c *NodeCache) Get(key []byte) ([]byte, error) {
+	c.lock.RLock()
+	node := c.nodes[common.BytesToHash(key)]
+	c.lock.RUnlock()
+
+	if node != nil && len(key) == common.HashLength {
+		return node.blob, nil
+	}
+	return c.diskdb.Get(key)
+}
+
+// Has reports whether a node is available either in memory or on disk.
+func (c *NodeCache) Has(key []byte) (bool, error) {
+	c.lock.RLock()
+	_, ok := c.nodes[common.BytesToHash(key)]
+	c.lock.RUnlock()
+
+	if ok && len(key) == common.HashLength {
+		return true, nil
+	}
+	return c.diskdb.Has(key)
+}
+
+// Put inserts a node into the cache. The node starts out unreferenced; it is
+// kept alive by the nodes referencing it, or by an explicit Reference.
+//
+// Keys which are not hashes are passed through to the disk database directly.
+func (c *NodeCache) Put(key []byte, blob []byte) error {
+	if len(key) != common.HashLength {
+		return c.diskdb.Put(key, blob)
+	}
+	hash := common.BytesToHash(key)
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if _, ok := c.nodes[hash]; ok {
+		return nil
+	}
+	entry := &cachedNode{
+		blob: common.CopyBytes(blob),
+		prev: c.newest,
+	}
+	// Anything that doesn't decode as a trie node (e.g. contract code) is simply
+	// cached as a blob without any children.
+	if n, err := decodeNode(key, entry.blob, 0); err == nil {
+		c.gatherChildren(n, entry)
+	}
+	for child, refs := range entry.children {
+		c.nodes[child].parents += refs
+	}
+	c.nodes[hash] = entry
+
+	// Append the node to the flush list
+	if c.oldest == (common.Hash{}) {
+		c.oldest, c.newest = hash, hash
+	} else {
+		c.nodes[c.newest].next, c.newest = hash, hash
+	}
+	c.size += common.StorageSize(cachedNodeSize + len(entry.blob))
+	return nil
+}
+
+// gatherChildren collects the hashes of all cached nodes and leaf references
+// directly referenced by n, descending into embedded nodes. Children not in the
+// cache are already persisted, so they need no tracking.
+func (c *NodeCache) gatherChildren(n node, entry *cachedNode) {
+	switch n := n.(type) {
+	case *shortNode:
+		c.gatherChildren(n.Val, entry)
+	case *fullNode:
+		for _, child := range n.Children {
+			c.gatherChildren(child, entry)
+		}
+	case hashNode:
+		c.addChild(entry, common.BytesToHash(n))
+	case valueNode:
+		if c.leaves != nil {
+			for _, hash := range c.leaves(n) {
+				c.addChild(entry, hash)
+			}
+		}
+	}
+}
+
+// addChild records a reference from the node to a child, if the child is cached.
+func (c *NodeCache) addChild(n *cachedNode, hash common.Hash) {
+	if _, ok := c.nodes[hash]; !ok || hash == (common.Hash{}) {
+		return
+	}
+	if n.children == nil {
+		n.children = make(map[common.Hash]int)
+	}
+	n.children[hash]++
+}
+
+// Reference adds a new reference from a parent node to a child node. Referencing
+// a child from the empty hash pins it in memory until it is dereferenced.
+func (c *NodeCache) Reference(child common.Hash, parent common.Hash) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.reference(child, parent)
+}
+
+// reference is the private locked version of Reference.
+func (c *NodeCache) reference(child common.Hash, parent common.Hash) {
+	// If the node does not exist, it's a node pulled from disk, skip
+	node, ok := c.nodes[child]
+	if !ok {
+		return
+	}
+	owner := c.nodes[parent]
+	if owner == nil {
+		return
+	}
+	// If the reference already exists, only duplicate for roots
+	if _, ok := owner.children[child]; ok && parent != (common.Hash{}) {
+		return
+	}
+	node.parents++
+	if owner.children == nil {
+		owner.children = make(map[common.Hash]int)
+	}
+	owner.children[child]++
+}
+
+// Dereference removes an existing pin of a root node, garbage collecting all
+// the nodes that are no longer referenced by anything else.
+func (c *NodeCache) Dereference(root common.Hash) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	nodes, storage, start := len(c.nodes), c.size, time.Now()
+	c.dereference(root, common.Hash{})
+
+	c.gcnodes += uint64(nodes - len(c.nodes))
+	c.gcsize += storage - c.size
+	c.gctime += time.Since(start)
+
+	glog.V(logger.Debug).Infof("Dereferenced trie from memory database: nodes=%d size=%v time=%v gcnodes=%d gcsize=%v gctime=%v livenodes=%d livesize=%v",
+		nodes-len(c.nodes), storage-c.size, time.Since(start), c.gcnodes, c.gcsize, c.gctime, len(c.nodes), c.size)
+}
+
+// dereference is the private locked version of Dereference.
+func (c *NodeCache) dereference(child common.Hash, parent common.Hash) {
+	// Dereference the parent-child
+	if owner := c.nodes[parent]; owner != nil && owner.children[child] > 0 {
+		owner.children[child]--
+		if owner.children[child] == 0 {
+			delete(owner.children, child)
+		}
+	}
+	// If the child does not exist, it's a previously committed node
+	node, ok := c.nodes[child]
+	if !ok {
+		return
+	}
+	// If there are no more references to the child, delete it and cascade
+	if node.parents > 0 {
+		node.parents--
+	}
+	if node.parents == 0 {
+		c.unlink(child, node)
+		for hash, refs := range node.children {
+			for i := 0; i < refs; i++ {
+				c.dereference(hash, child)
+			}
+		}
+		delete(c.nodes, child)
+		c.size -= common.StorageSize(cachedNodeSize + len(node.blob))
+	}
+}
+
+// unlink removes a node from the flush list.
+func (c *NodeCache) unlink(hash common.Hash, node *cachedNode) {
+	switch hash {
+	case c.oldest:
+		c.oldest = node.next
+		if next := c.nodes[node.next]; next != nil && node.next != (common.Hash{}) {
+			next.prev = common.Hash{}
+		}
+	case c.newest:
+		c.newest = node.prev
+		if prev := c.nodes[node.prev]; prev != nil && node.prev != (common.Hash{}) {
+			prev.next = common.Hash{}
+		}
+	default:
+		c.nodes[node.prev].next = node.next
+		c.nodes[node.next].prev = node.prev
+	}
+	if c.oldest == (common.Hash{}) {
+		c.newest = common.Hash{}
+	}
+}
+
+// Cap iteratively flushes old but still referenced trie nodes until the total
+// memory usage goes below the given threshold. Nodes are flushed in insertion
+// order, so children always reach the disk before their parents.
+func (c *NodeCache) Cap(limit common.StorageSize) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	nodes, storage, start := len(c.nodes), c.size, time.Now()
+	batch := c.diskdb.NewBatch()
+
+	oldest := c.oldest
+	for size := c.size; size > limit && oldest != (common.Hash{}); {
+		node := c.nodes[oldest]
+		if err := batch.Put(oldest[:], node.blob); err != nil {
+			return err
+		}
+		// If we exceeded the ideal batch size, commit and reset
+		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				glog.V(logger.Error).Errorf("Failed to write flush list to disk: %v", err)
+				return err
+			}
+			batch = c.diskdb.NewBatch()
+		}
+		size -= common.StorageSize(cachedNodeSize + len(node.blob))
+		oldest = node.next
+	}
+	// Flush out any remainder data from the last batch
+	if err := batch.Write(); err != nil {
+		glog.V(logger.Error).Errorf("Failed to write flush list to disk: %v", err)
+		return err
+	}
+	// Write successful, clear out the flushed data
+	for c.oldest != oldest {
+		node := c.nodes[c.oldest]
+		delete(c.nodes, c.oldest)
+		c.oldest = node.next
+		c.size -= common.StorageSize(cachedNodeSize + len(node.blob))
+	}
+	if c.oldest == (common.Hash{}) {
+		c.newest = common.Hash{}
+	} else {
+		c.nodes[c.oldest].prev = common.Hash{}
+	}
+	c.flushnodes += uint64(nodes - len(c.nodes))
+	c.flushsize += storage - c.size
+	c.flushtime += time.Since(start)
+
+	glog.V(logger.Debug).Infof("Persisted nodes from memory database: nodes=%d size=%v time=%v flushnodes=%d flushsize=%v flushtime=%v livenodes=%d livesize=%v",
+		nodes-len(c.nodes), storage-c.size, time.Since(start), c.flushnodes, c.flushsize, c.flushtime, len(c.nodes), c.size)
+
+	return nil
+}
+
+// Commit writes a cached trie and everything it references out to disk, then
+// drops all of those nodes from memory.
+func (c *NodeCache) Commit(root common.Hash) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	nodes, storage, start := len(c.nodes), c.size, time.Now()
+
+	batch, err := c.commit(root, c.diskdb.NewBatch())
+	if err == nil {
+		err = batch.Write()
+	}
+	if err != nil {
+		glog.V(logger.Error).Errorf("Failed to write trie to disk: %v", err)
+		return err
+	}
+	// Write successful, clear out the flushed data
+	c.uncache(root)
+
+	glog.V(logger.Info).Infof("Persisted trie from memory database: nodes=%d size=%v time=%v gcnodes=%d gcsize=%v gctime=%v livenodes=%d livesize=%v",
+		nodes-len(c.nodes), storage-c.size, time.Since(start), c.gcnodes, c.gcsize, c.gctime, len(c.nodes), c.size)
+
+	// Reset the garbage collection statistics
+	c.gcnodes, c.gcsize, c.gctime = 0, 0, 0
+	c.flushnodes, c.flushsize, c.flushtime = 0, 0, 0
+
+	return nil
+}
+
+// commit is the private locked version of Commit, returning the batch to
+// continue writing into.
+func (c *NodeCache) commit(hash common.Hash, batch ethdb.Batch) (ethdb.Batch, error) {
+	// If the node does not exist, it's a previously committed node
+	node, ok := c.nodes[hash]
+	if !ok || hash == (common.Hash{}) {
+		return batch, nil
+	}
+	var err error
+	for child := range node.children {
+		if batch, err = c.commit(child, batch); err != nil {
+			return batch, err
+		}
+	}
+	if err := batch.Put(hash[:], node.blob); err != nil {
+		return batch, err
+	}
+	// If we've reached an optimal batch size, commit and start over
+	if batch.ValueSize() >= ethdb.IdealBatchSize {
+		if err := batch.Write(); err != nil {
+			return batch, err
+		}
+		batch = c.diskdb.NewBatch()
+	}
+	return batch, nil
+}
+
+// uncache is the post-processing step of a commit operation where the already
+// persisted trie is removed from the cache. The reason behind the two-phase
+// commit is to ensure consistent data availability while moving from memory
+// to disk.
+func (c *NodeCache) uncache(hash common.Hash) {
+	// If the node does not exist, we're done on this path
+	node, ok := c.nodes[hash]
+	if !ok || hash == (common.Hash{}) {
+		return
+	}
+	c.unlink(hash, node)
+	for child := range node.children {
+		c.uncache(child)
+	}
+	delete(c.nodes, hash)
+	c.size -= common.StorageSize(cachedNodeSize + len(node.blob))
+}
+
+// Size returns the current storage size of the memory cache in front of the
+// persistent database layer.
+func (c *NodeCache) Size() common.StorageSize {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return c.size
+}
+
+// Nodes retrieves the number of trie nodes currently held in memory.
+func (c *NodeCache) Nodes() int {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	return len(c.nodes) - 1 // Don't count the metaroot
+}
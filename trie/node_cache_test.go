@@ -0,0 +1,200 @@
+// Copyright 2018 The go-ethereum Authors
+// This file is part of the go-ethereum library.
+//
+// The go-ethereum library is free software: you can redistribute it and/or modify
+// it under the terms of the GNU Lesser General Public License as published by
+// the Free Software Foundation, either version 3 of the License, or
+// (at your option) any later version.
+//
+// The go-ethereum library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the go-ethereum library. If not, see <http://www.gnu.org/licenses/>.
+
+package trie
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/eth-classic/go-ethereum/common"
+	"github.com/eth-classic/go-ethereum/ethdb"
+)
+
+// makeCachedTrie commits a trie with the given number of entries (and a value
+// prefix making it distinct) into the node cache, returning its root.
+func makeCachedTrie(t *testing.T, cache *NodeCache, entries int, prefix string) common.Hash {
+	trie, _ := New(common.Hash{}, cache)
+	for i := 0; i < entries; i++ {
+		key := []byte(fmt.Sprintf("key-%04d", i))
+		trie.Update(key, []byte(fmt.Sprintf("%s-value-%04d-%s", prefix, i, bytes.Repeat([]byte{'x'}, 32))))
+	}
+	root, err := trie.CommitTo(cache)
+	if err != nil {
+		t.Fatalf("failed to commit trie: %v", err)
+	}
+	return root
+}
+
+// checkCachedTrie verifies that all entries of a trie created by makeCachedTrie
+// are retrievable from the given database.
+func checkCachedTrie(t *testing.T, db Database, root common.Hash, entries int, prefix string) {
+	trie, err := New(root, db)
+	if err != nil {
+		t.Fatalf("failed to open trie %x: %v", root, err)
+	}
+	for i := 0; i < entries; i++ {
+		key := []byte(fmt.Sprintf("key-%04d", i))
+		want := []byte(fmt.Sprintf("%s-value-%04d-%s", prefix, i, bytes.Repeat([]byte{'x'}, 32)))
+		if have, err := trie.TryGet(key); err != nil || !bytes.Equal(have, want) {
+			t.Fatalf("entry %d mismatch: have %q, err %v; want %q", i, have, err, want)
+		}
+	}
+}
+
+// Tests that committed tries are held in memory only and garbage collected
+// completely once dereferenced.
+func TestNodeCacheDereference(t *testing.T) {
+	diskdb, _ := ethdb.NewMemDatabase()
+	cache := NewNodeCache(diskdb, nil)
+
+	root := makeCachedTrie(t, cache, 100, "a")
+	cache.Reference(root, common.Hash{})
+
+	if len(diskdb.Keys()) != 0 {
+		t.Fatalf("trie nodes leaked to disk: %d", len(diskdb.Keys()))
+	}
+	if cache.Nodes() == 0 || cache.Size() == 0 {
+		t.Fatalf("trie nodes not cached: nodes %d, size %v", cache.Nodes(), cache.Size())
+	}
+	checkCachedTrie(t, cache, root, 100, "a")
+
+	cache.Dereference(root)
+	if nodes, size := cache.Nodes(), cache.Size(); nodes != 0 || size != 0 {
+		t.Fatalf("dereferenced trie not collected: nodes %d, size %v", nodes, size)
+	}
+	if len(diskdb.Keys()) != 0 {
+		t.Fatalf("trie nodes leaked to disk: %d", len(diskdb.Keys()))
+	}
+}
+
+// Tests that nodes shared between tries survive the garbage collection of one
+// of them.
+func TestNodeCacheSharedNodes(t *testing.T) {
+	diskdb, _ := ethdb.NewMemDatabase()
+	cache := NewNodeCache(diskdb, nil)
+
+	first := makeCachedTrie(t, cache, 100, "a")
+	cache.Reference(first, common.Hash{})
+
+	trie, _ := New(first, cache)
+	trie.Update([]byte("key-0000"), []byte("modified"))
+	second, err := trie.CommitTo(cache)
+	if err != nil {
+		t.Fatalf("failed to commit trie: %v", err)
+	}
+	cache.Reference(second, common.Hash{})
+
+	cache.Dereference(first)
+	if cache.Nodes() == 0 {
+		t.Fatalf("shared nodes collected")
+	}
+	trie, err = New(second, cache)
+	if err != nil {
+		t.Fatalf("failed to open trie: %v", err)
+	}
+	for i := 1; i < 100; i++ {
+		if _, err := trie.TryGet([]byte(fmt.Sprintf("key-%04d", i))); err != nil {
+			t.Fatalf("entry %d missing: %v", i, err)
+		}
+	}
+	cache.Dereference(second)
+	if nodes := cache.Nodes(); nodes != 0 {
+		t.Fatalf("dereferenced tries not collected: nodes %d", nodes)
+	}
+}
+
+// Tests that committing a trie flushes it to disk and releases it from memory.
+func TestNodeCacheCommit(t *testing.T) {
+	diskdb, _ := ethdb.NewMemDatabase()
+	cache := NewNodeCache(diskdb, nil)
+
+	root := makeCachedTrie(t, cache, 100, "a")
+	cache.Reference(root, common.Hash{})
+
+	if err := cache.Commit(root); err != nil {
+		t.Fatalf("failed to commit trie: %v", err)
+	}
+	if nodes, size := cache.Nodes(), cache.Size(); nodes != 0 || size != 0 {
+		t.Fatalf("committed trie not released: nodes %d, size %v", nodes, size)
+	}
+	checkCachedTrie(t, diskdb, root, 100, "a")
+
+	// Dereferencing a committed trie must not touch the persisted data
+	cache.Dereference(root)
+	checkCachedTrie(t, diskdb, root, 100, "a")
+}
+
+// Tests that capping the cache flushes the oldest nodes first, keeping every
+// trie accessible.
+func TestNodeCacheCap(t *testing.T) {
+	diskdb, _ := ethdb.NewMemDatabase()
+	cache := NewNodeCache(diskdb, nil)
+
+	first := makeCachedTrie(t, cache, 100, "a")
+	cache.Reference(first, common.Hash{})
+	second := makeCachedTrie(t, cache, 100, "b")
+	cache.Reference(second, common.Hash{})
+
+	limit := cache.Size() / 2
+	if err := cache.Cap(limit); err != nil {
+		t.Fatalf("failed to cap cache: %v", err)
+	}
+	if size := cache.Size(); size > limit {
+		t.Fatalf("cache size above limit: have %v, limit %v", size, limit)
+	}
+	if len(diskdb.Keys()) == 0 {
+		t.Fatalf("no nodes flushed to disk")
+	}
+	checkCachedTrie(t, cache, first, 100, "a")
+	checkCachedTrie(t, cache, second, 100, "b")
+
+	// The first trie is fully flushed, so it must be readable from disk alone
+	checkCachedTrie(t, diskdb, first, 100, "a")
+
+	cache.Dereference(first)
+	cache.Dereference(second)
+	if nodes := cache.Nodes(); nodes != 0 {
+		t.Fatalf("dereferenced tries not collected: nodes %d", nodes)
+	}
+}
+
+// Tests that leaf references resolved by the leaf resolver keep the referenced
+// data alive together with the trie.
+func TestNodeCacheLeafReferences(t *testing.T) {
+	diskdb, _ := ethdb.NewMemDatabase()
+
+	blob := []byte("referenced data blob which is not a trie node")
+	ref := common.BytesToHash(bytes.Repeat([]byte{0x01}, 32))
+	cache := NewNodeCache(diskdb, func(leaf []byte) []common.Hash {
+		return []common.Hash{ref}
+	})
+	cache.Put(ref[:], blob)
+
+	root := makeCachedTrie(t, cache, 10, "a")
+	cache.Reference(root, common.Hash{})
+
+	if have, err := cache.Get(ref[:]); err != nil || !bytes.Equal(have, blob) {
+		t.Fatalf("referenced blob mismatch: have %q, err %v", have, err)
+	}
+	if err := cache.Commit(root); err != nil {
+		t.Fatalf("failed to commit trie: %v", err)
+	}
+	if have, err := diskdb.Get(ref[:]); err != nil || !bytes.Equal(have, blob) {
+		t.Fatalf("referenced blob not persisted: have %q, err %v", have, err)
+	}
+}
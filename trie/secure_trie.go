@@ -197,7 +197,7 @@ func (t *SecureTrie) secKey(key []byte) []byte {
 // The caller must not hold onto the return value because it will become
 // invalid on the next call to hashKey or secKey.
 func (t *SecureTrie) hashKey(key []byte) []byte {
-	h := newHasher(0, 0)
+	h := newHasher(0, 0, false)
 	calculator := h.newCalculator()
 	calculator.sha.Write(key)
 	buf := calculator.sha.Sum(t.hashKeyBuf[:0])
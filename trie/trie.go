@@ -23,6 +23,7 @@ import (
 
 	"github.com/eth-classic/go-ethereum/common"
 	"github.com/eth-classic/go-ethereum/crypto"
+	"github.com/eth-classic/go-ethereum/ethdb"
 	"github.com/eth-classic/go-ethereum/logger/glog"
 	"github.com/rcrowley/go-metrics"
 )
@@ -35,6 +36,10 @@ var (
 	emptyState = crypto.Keccak256Hash(nil)
 )
 
+// parallelHashThreshold is the number of updates since the last hashing above
+// which the trie is hashed on multiple goroutines.
+const parallelHashThreshold = 100
+
 var (
 	cacheMissCounter   = metrics.NewRegisteredCounter("trie/cachemiss", nil)
 	cacheUnloadCounter = metrics.NewRegisteredCounter("trie/cacheunload", nil)
@@ -94,6 +99,10 @@ type Trie struct {
 	// new nodes are tagged with the current generation and unloaded
 	// when their generation is older than than cachegen-cachelimit.
 	cachegen, cachelimit uint16
+
+	// Number of updates and deletions since the last hashing, telling whether
+	// hashing is worth parallelising.
+	unhashed int
 }
 
 // SetCacheLimit sets the number of 'cache generations' to keep.
@@ -215,6 +224,7 @@ func (t *Trie) Update(key, value []byte) {
 //
 // If a node was not found in the database, a MissingNodeError is returned.
 func (t *Trie) TryUpdate(key, value []byte) error {
+	t.unhashed++
 	k := keybytesToHex(key)
 	if len(value) != 0 {
 		_, n, err := t.insert(t.root, nil, k, valueNode(value))
@@ -311,6 +321,7 @@ func (t *Trie) Delete(key []byte) {
 // TryDelete removes any existing value for key from the trie.
 // If a node was not found in the database, a MissingNodeError is returned.
 func (t *Trie) TryDelete(key []byte) error {
+	t.unhashed++
 	k := keybytesToHex(key)
 	_, n, err := t.delete(t.root, nil, k)
 	if err != nil {
@@ -488,7 +499,16 @@ func (t *Trie) Commit() (root common.Hash, err error) {
 // the changes made to db are written back to the trie's attached
 // database before using the trie.
 func (t *Trie) CommitTo(db DatabaseWriter) (root common.Hash, err error) {
+	// Write the nodes through batches if the database supports them
+	var batch *ethdb.BatchWriter
+	if bdb, ok := db.(ethdb.Batcher); ok {
+		batch = ethdb.NewBatchWriter(bdb)
+		db = batch
+	}
 	hash, cached, err := t.hashRoot(db)
+	if err == nil && batch != nil {
+		err = batch.Flush()
+	}
 	if err != nil {
 		return (common.Hash{}), err
 	}
@@ -501,6 +521,7 @@ func (t *Trie) hashRoot(db DatabaseWriter) (node, node, error) {
 	if t.root == nil {
 		return hashNode(emptyRoot.Bytes()), nil, nil
 	}
-	h := newHasher(t.cachegen, t.cachelimit)
+	h := newHasher(t.cachegen, t.cachelimit, t.unhashed >= parallelHashThreshold)
+	t.unhashed = 0
 	return h.hash(t.root, db, true)
 }
@@ -554,17 +554,70 @@ func benchUpdate(b *testing.B, e binary.ByteOrder) *Trie {
 // the first one will be NOOP. As such, we'll use b.N as the number of account to
 // insert into the trie before measuring the hashing.
 func BenchmarkHash(b *testing.B) {
+	// Create a realistic account trie to hash
+	addresses, accounts := makeAccounts(b.N)
+
+	// Insert the accounts into the trie and hash it
+	trie := newEmpty()
+	for i := 0; i < len(addresses); i++ {
+		trie.Update(crypto.Keccak256(addresses[i][:]), accounts[i])
+	}
+	b.ResetTimer()
+	b.ReportAllocs()
+	trie.Hash()
+}
+
+// Benchmarks hashing and committing tries of fixed sizes, filled from scratch
+// on every round. Tries below parallelHashThreshold are hashed sequentially.
+func BenchmarkHashFixedSize(b *testing.B) {
+	for _, size := range []int{10, 100, 1000, 10000, 100000} {
+		addresses, accounts := makeAccounts(size)
+		b.Run(fmt.Sprintf("%d", size), func(b *testing.B) {
+			benchmarkHashFixedSize(b, addresses, accounts, false)
+		})
+	}
+}
+
+func BenchmarkCommitFixedSize(b *testing.B) {
+	for _, size := range []int{10, 100, 1000, 10000, 100000} {
+		addresses, accounts := makeAccounts(size)
+		b.Run(fmt.Sprintf("%d", size), func(b *testing.B) {
+			benchmarkHashFixedSize(b, addresses, accounts, true)
+		})
+	}
+}
+
+func benchmarkHashFixedSize(b *testing.B, addresses [][20]byte, accounts [][]byte, commit bool) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		b.StopTimer()
+		trie := newEmpty()
+		for j := 0; j < len(addresses); j++ {
+			trie.Update(crypto.Keccak256(addresses[j][:]), accounts[j])
+		}
+		db, _ := ethdb.NewMemDatabase()
+		b.StartTimer()
+
+		if commit {
+			trie.CommitTo(db)
+		} else {
+			trie.Hash()
+		}
+	}
+}
+
+// makeAccounts generates deterministic random addresses and account blobs.
+func makeAccounts(size int) (addresses [][20]byte, accounts [][]byte) {
 	// Make the random benchmark deterministic
 	random := rand.New(rand.NewSource(0))
 
-	// Create a realistic account trie to hash
-	addresses := make([][20]byte, b.N)
+	addresses = make([][20]byte, size)
 	for i := 0; i < len(addresses); i++ {
 		for j := 0; j < len(addresses[i]); j++ {
 			addresses[i][j] = byte(random.Intn(256))
 		}
 	}
-	accounts := make([][]byte, len(addresses))
+	accounts = make([][]byte, len(addresses))
 	for i := 0; i < len(accounts); i++ {
 		var (
 			nonce   = uint64(random.Int63())
@@ -574,14 +627,49 @@ func BenchmarkHash(b *testing.B) {
 		)
 		accounts[i], _ = rlp.EncodeToBytes([]interface{}{nonce, balance, root, code})
 	}
-	// Insert the accounts into the trie and hash it
-	trie := newEmpty()
+	return addresses, accounts
+}
+
+// Tests that hashing a trie on multiple goroutines yields the same nodes as on
+// a single one.
+func TestParallelHash(t *testing.T) {
+	addresses, accounts := makeAccounts(2 * parallelHashThreshold)
+
+	// Fill one trie in a single go, and another hashing it below the threshold
+	parallel, sequential := newEmpty(), newEmpty()
 	for i := 0; i < len(addresses); i++ {
-		trie.Update(crypto.Keccak256(addresses[i][:]), accounts[i])
+		parallel.Update(crypto.Keccak256(addresses[i][:]), accounts[i])
+	}
+	for i := 0; i < len(addresses); i++ {
+		sequential.Update(crypto.Keccak256(addresses[i][:]), accounts[i])
+		if i%(parallelHashThreshold/2) == 0 {
+			sequential.Hash()
+		}
+	}
+	if parallel.unhashed < parallelHashThreshold || sequential.unhashed >= parallelHashThreshold {
+		t.Fatalf("hashing modes not exercised: parallel %d, sequential %d unhashed", parallel.unhashed, sequential.unhashed)
+	}
+	pdb, _ := ethdb.NewMemDatabase()
+	sdb, _ := ethdb.NewMemDatabase()
+	proot, err := parallel.CommitTo(pdb)
+	if err != nil {
+		t.Fatalf("parallel commit failed: %v", err)
+	}
+	sroot, err := sequential.CommitTo(sdb)
+	if err != nil {
+		t.Fatalf("sequential commit failed: %v", err)
+	}
+	if proot != sroot {
+		t.Fatalf("root mismatch: parallel %x, sequential %x", proot, sroot)
+	}
+	if len(pdb.Keys()) != len(sdb.Keys()) {
+		t.Errorf("stored node count mismatch: parallel %d, sequential %d", len(pdb.Keys()), len(sdb.Keys()))
+	}
+	for _, key := range sdb.Keys() {
+		if blob, _ := pdb.Get(key); blob == nil {
+			t.Errorf("node %x missing from parallel commit", key)
+		}
 	}
-	b.ResetTimer()
-	b.ReportAllocs()
-	trie.Hash()
 }
 
 func tempDB() (string, Database) {